@@ -5,7 +5,7 @@ import type { CreateProjectRequest, ProjectSummary, DimensionStat } from '../../
 import { DOOR_BITMASK_LABELS } from '../../types/project';
 
 const STAGE_TYPES = ['start', 'teaching', 'building', 'pressure', 'peak', 'release', 'boss'] as const;
-const SHAPE_TYPES = ['full', 'bridge', 'platform'] as const;
+const SHAPE_TYPES = ['full', 'bridge', 'platform', 'cave'] as const;
 
 // All valid door bitmasks (at least 1 door open)
 const ALL_DOOR_MASKS = Array.from({ length: 15 }, (_, i) => i + 1);
@@ -16,6 +16,7 @@ const emptyForm = (): CreateProjectRequest => ({
   shape_pct_full: 65,
   shape_pct_bridge: 10,
   shape_pct_platform: 25,
+  shape_pct_cave: 0,
   door_distribution: {
     '3': 3, '5': 10, '6': 3, '7': 3,
     '9': 3, '10': 22, '11': 3,
@@ -37,6 +38,7 @@ const projectToForm = (p: ProjectSummary): CreateProjectRequest => ({
   shape_pct_full: p.shape_pct_full,
   shape_pct_bridge: p.shape_pct_bridge,
   shape_pct_platform: p.shape_pct_platform,
+  shape_pct_cave: p.shape_pct_cave,
   door_distribution: { ...p.door_distribution },
   stage_pct_start: p.stage_pct_start,
   stage_pct_teaching: p.stage_pct_teaching,
@@ -67,7 +69,7 @@ export const ProjectsPage: React.FC<Props> = ({ onBack, onEditTemplate }) => {
     const errors: string[] = [];
     if (!form.name.trim()) errors.push('Name is required');
     if (form.total_rooms <= 0) errors.push('Total rooms must be positive');
-    const shapeSum = form.shape_pct_full + form.shape_pct_bridge + form.shape_pct_platform;
+    const shapeSum = form.shape_pct_full + form.shape_pct_bridge + form.shape_pct_platform + form.shape_pct_cave;
     if (shapeSum !== 100) errors.push(`Shape percentages must sum to 100 (got ${shapeSum})`);
     const stageSum = form.stage_pct_start + form.stage_pct_teaching + form.stage_pct_building +
       form.stage_pct_pressure + form.stage_pct_peak + form.stage_pct_release + form.stage_pct_boss;
@@ -237,7 +239,7 @@ const ProjectList: React.FC<{
             <div style={{ fontSize: 12, color: '#666', marginTop: 2 }}>
               {p.template_count} / {p.total_rooms} rooms
               <span style={{ margin: '0 6px' }}>|</span>
-              F:{p.shape_pct_full}% B:{p.shape_pct_bridge}% P:{p.shape_pct_platform}%
+              F:{p.shape_pct_full}% B:{p.shape_pct_bridge}% P:{p.shape_pct_platform}% C:{p.shape_pct_cave}%
             </div>
           </div>
           <div style={{ display: 'flex', gap: 6 }} onClick={e => e.stopPropagation()}>
@@ -355,7 +357,7 @@ const ProjectFormModal: React.FC<{
   onSubmit: () => void;
   onClose: () => void;
 }> = ({ form, editingId, formErrors, loading, onFieldChange, onDoorChange, onSubmit, onClose }) => {
-  const shapeSum = form.shape_pct_full + form.shape_pct_bridge + form.shape_pct_platform;
+  const shapeSum = form.shape_pct_full + form.shape_pct_bridge + form.shape_pct_platform + form.shape_pct_cave;
   const stageSum = form.stage_pct_start + form.stage_pct_teaching + form.stage_pct_building +
     form.stage_pct_pressure + form.stage_pct_peak + form.stage_pct_release + form.stage_pct_boss;
   const doorSum = Object.values(form.door_distribution).reduce((a, b) => a + b, 0);
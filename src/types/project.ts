@@ -5,6 +5,7 @@ export interface Project {
   shape_pct_full: number;
   shape_pct_bridge: number;
   shape_pct_platform: number;
+  shape_pct_cave: number;
   door_distribution: Record<string, number>;
   stage_pct_start: number;
   stage_pct_teaching: number;
@@ -27,6 +28,7 @@ export interface CreateProjectRequest {
   shape_pct_full: number;
   shape_pct_bridge: number;
   shape_pct_platform: number;
+  shape_pct_cave: number;
   door_distribution: Record<string, number>;
   stage_pct_start: number;
   stage_pct_teaching: number;
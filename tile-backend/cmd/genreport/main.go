@@ -0,0 +1,104 @@
+// Command genreport generates many rooms per shape × door mask × stage and
+// reports quality statistics, or diffs two reports to catch regressions.
+//
+//	genreport [-n 20] [-seed 1] [-width 20] [-height 12] [-shapes a,b] [-stages a,b] [-out report.json]
+//	genreport -diff old.json new.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"tile-backend/internal/generate"
+	"tile-backend/internal/genreport"
+)
+
+func main() {
+	defaults := genreport.DefaultOptions()
+	runs := flag.Int("n", defaults.Runs, "generations per shape/door mask/stage combination")
+	seed := flag.Int64("seed", defaults.Seed, "seed of the first run; run i uses seed+i")
+	width := flag.Int("width", defaults.Width, "room width")
+	height := flag.Int("height", defaults.Height, "room height")
+	shapes := flag.String("shapes", "", "comma-separated shapes to run (default all)")
+	stages := flag.String("stages", "", "comma-separated stage types to run (default all)")
+	timeout := flag.Duration("timeout", 0, "deadline per generation (default none)")
+	stageConfig := flag.String("stage-config", os.Getenv("STAGE_CONFIG_FILE"), "stage config file (default built-in stages)")
+	out := flag.String("out", "genreport.json", "where to write the JSON report")
+	diff := flag.Bool("diff", false, "compare two JSON reports (old, new) instead of generating")
+	flag.Parse()
+
+	if *diff {
+		if flag.NArg() != 2 {
+			log.Fatalf("usage: genreport -diff old.json new.json")
+		}
+		os.Exit(runDiff(flag.Arg(0), flag.Arg(1)))
+	}
+
+	if *stageConfig != "" {
+		if _, err := generate.LoadStageConfigFile(*stageConfig); err != nil {
+			log.Fatalf("Failed to load stage config: %v", err)
+		}
+	}
+	opts := genreport.Options{
+		Runs:    *runs,
+		Seed:    *seed,
+		Width:   *width,
+		Height:  *height,
+		Shapes:  splitList(*shapes),
+		Stages:  splitList(*stages),
+		Timeout: *timeout,
+	}
+	report, err := genreport.Run(context.Background(), opts)
+	if err != nil {
+		log.Fatalf("Report failed: %v", err)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode report: %v", err)
+	}
+	if err := os.WriteFile(*out, data, 0o644); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+	if err := genreport.WriteSummary(os.Stdout, report); err != nil {
+		log.Fatalf("Failed to write summary: %v", err)
+	}
+	fmt.Printf("\nReport written to %s\n", *out)
+}
+
+// runDiff prints the differences between two reports and returns the exit
+// code: 1 when there are regressions, so scripts can gate on it
+func runDiff(oldPath, newPath string) int {
+	old, err := genreport.ReadReport(oldPath)
+	if err != nil {
+		log.Fatalf("Failed to read report: %v", err)
+	}
+	cur, err := genreport.ReadReport(newPath)
+	if err != nil {
+		log.Fatalf("Failed to read report: %v", err)
+	}
+	d := genreport.Compare(old, cur, genreport.DefaultThresholds())
+	if err := genreport.WriteDiff(os.Stdout, d); err != nil {
+		log.Fatalf("Failed to write diff: %v", err)
+	}
+	if d.Regressions() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -11,6 +11,7 @@ import (
 	"syscall"
 	"time"
 
+	"tile-backend/internal/generate"
 	httpHandler "tile-backend/internal/http"
 	"tile-backend/internal/store"
 
@@ -23,6 +24,8 @@ type Config struct {
 	Port               int
 	LogLevel           string
 	CORSAllowedOrigins []string
+	StageConfigFile    string        // optional; built-in stage configs are used when empty
+	GenerateTimeout    time.Duration // deadline for one generate or auto-fill request
 }
 
 func main() {
@@ -33,6 +36,16 @@ func main() {
 	logger := initLogger(config.LogLevel)
 	defer logger.Sync()
 
+	// Load stage configs; SIGHUP reloads the file
+	if config.StageConfigFile != "" {
+		n, err := generate.LoadStageConfigFile(config.StageConfigFile)
+		if err != nil {
+			logger.Fatal("Failed to load stage config", zap.String("path", config.StageConfigFile), zap.Error(err))
+		}
+		logger.Info("Stage config loaded", zap.String("path", config.StageConfigFile), zap.Int("stages", n))
+		go watchStageConfig(config.StageConfigFile, logger)
+	}
+
 	// Initialize database
 	db, err := initDatabase(config.DatabaseURL, logger)
 	if err != nil {
@@ -45,7 +58,7 @@ func main() {
 	projectStore := store.NewPostgreSQLProjectStore(db)
 
 	// Setup router
-	router := httpHandler.SetupRouter(templateStore, projectStore, logger, config.CORSAllowedOrigins)
+	router := httpHandler.SetupRouter(templateStore, projectStore, logger, config.CORSAllowedOrigins, config.GenerateTimeout)
 
 	// Setup HTTP server
 	server := &http.Server{
@@ -84,9 +97,12 @@ func main() {
 // loadConfig loads configuration from environment variables
 func loadConfig() *Config {
 	config := &Config{
-		DatabaseURL: getEnv("DATABASE_URL", "postgres://liuli@192.168.0.151:5432/postgres?sslmode=disable"),
-		Port:        getEnvInt("PORT", 8090),
-		LogLevel:    getEnv("LOG_LEVEL", "info"),
+		DatabaseURL:     getEnv("DATABASE_URL", "postgres://liuli@192.168.0.151:5432/postgres?sslmode=disable"),
+		Port:            getEnvInt("PORT", 8090),
+		LogLevel:        getEnv("LOG_LEVEL", "info"),
+		StageConfigFile: getEnv("STAGE_CONFIG_FILE", ""),
+		// Below the server's WriteTimeout so the timeout response still reaches the client
+		GenerateTimeout: time.Duration(getEnvInt("GENERATE_TIMEOUT_SECONDS", 10)) * time.Second,
 	}
 
 	// Parse CORS origins
@@ -101,6 +117,21 @@ func loadConfig() *Config {
 	return config
 }
 
+// watchStageConfig reloads the stage config file on every SIGHUP. A file that
+// fails to load or validate is logged and the previous configs stay live.
+func watchStageConfig(path string, logger *zap.Logger) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		n, err := generate.LoadStageConfigFile(path)
+		if err != nil {
+			logger.Error("Failed to reload stage config, keeping previous config", zap.String("path", path), zap.Error(err))
+			continue
+		}
+		logger.Info("Stage config reloaded", zap.String("path", path), zap.Int("stages", n))
+	}
+}
+
 // initLogger initializes the zap logger
 func initLogger(level string) *zap.Logger {
 	var zapLevel zap.AtomicLevel
@@ -2,6 +2,7 @@ package generate
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strconv"
@@ -12,14 +13,23 @@ import (
 
 // workItem represents a single room to generate during auto-fill
 type workItem struct {
-	shape     string // "all", "bridge", "platform"
+	shape     string // "full", "bridge", "platform", "cave"
 	doorMask  int    // bitmask 0-15
 	stageType string
 }
 
 // stageShapeCompat returns whether a stage type is compatible with a room shape.
-// Shape uses DB values: "full", "bridge", "platform".
+// Shape uses DB values: "full", "bridge", "platform", "cave". The shape must be
+// registered, and both its own stage list and the stage's allowed room types
+// must admit it.
 func stageShapeCompat(stageType, dbShape string) bool {
+	g, ok := generatorForRoomType(dbShape)
+	if !ok {
+		return false
+	}
+	if !g.Info().allowsStage(stageType) {
+		return false
+	}
 	cfg := GetStageConfig(stageType)
 	if cfg == nil {
 		return true // unknown stage, allow anything
@@ -69,21 +79,14 @@ func bitmaskToDoors(mask int) []DoorPosition {
 	return doors
 }
 
-// dbShapeToGenShape maps DB room_type to the roomShape value used in payloads.
-// DB stores "full", payload uses "all".
-func dbShapeToGenShape(dbShape string) string {
-	if dbShape == "full" {
-		return "all"
-	}
-	return dbShape
-}
-
 // TemplateCreator is the subset of store.TemplateStore needed by AutoFill.
 type TemplateCreator interface {
 	Create(ctx context.Context, template model.Template) (*model.Template, error)
 }
 
-// AutoFill generates rooms to fill project deficits and saves them.
+// AutoFill generates rooms to fill project deficits and saves them. Once ctx is
+// canceled or its deadline passes it stops, returning the rooms saved so far
+// with an error wrapping a *StepError.
 func AutoFill(ctx context.Context, project *model.Project, stats *model.ProjectStats, templateStore TemplateCreator) (*model.AutoFillResult, error) {
 	items := buildWorkItems(project, stats)
 
@@ -98,7 +101,12 @@ func AutoFill(ctx context.Context, project *model.Project, stats *model.ProjectS
 			StageType: item.stageType,
 		}
 
-		payload, err := generateRoom(item)
+		payload, err := generateRoom(ctx, item, project.StageOverrides)
+		var stepErr *StepError
+		if errors.As(err, &stepErr) {
+			// Rooms saved so far stay in the project; the rest would be cut short too
+			return result, fmt.Errorf("auto-fill stopped after %d of %d rooms: %w", result.TotalGenerated, len(items), stepErr)
+		}
 		if err != nil {
 			ri.Error = err.Error()
 			result.TotalFailed++
@@ -116,6 +124,9 @@ func AutoFill(ctx context.Context, project *model.Project, stats *model.ProjectS
 			Payload:   *payload,
 			ProjectID: &project.ID,
 		}
+		if width, ok := MinCorridorWidth(payload, tmpl.Width, tmpl.Height); ok {
+			tmpl.MinCorridorWidth = &width
+		}
 
 		saved, err := templateStore.Create(ctx, tmpl)
 		if err != nil {
@@ -200,8 +211,8 @@ func buildWorkItems(project *model.Project, stats *model.ProjectStats) []workIte
 			}
 			if bestShape == "" {
 				// No compatible shape with deficit, pick any compatible shape
-				for _, sh := range []string{"full", "bridge", "platform"} {
-					if stageShapeCompat(stage, sh) {
+				for _, g := range Generators() {
+					if sh := g.Info().RoomType; stageShapeCompat(stage, sh) {
 						bestShape = sh
 						break
 					}
@@ -257,60 +268,33 @@ func buildWorkItems(project *model.Project, stats *model.ProjectStats) []workIte
 	return items
 }
 
-// generateRoom calls the appropriate generator for a work item.
-func generateRoom(item workItem) (*model.TemplatePayload, error) {
+// generateRoom calls the registered generator for a work item, applying the
+// project's stage overrides.
+func generateRoom(ctx context.Context, item workItem, overrides model.StageOverrides) (*model.TemplatePayload, error) {
 	doors := bitmaskToDoors(item.doorMask)
 	if len(doors) == 0 {
 		return nil, fmt.Errorf("door bitmask %d has no doors", item.doorMask)
 	}
 
-	// Default dimensions
-	width, height := 20, 12
-
-	switch item.shape {
-	case "full":
-		req := FullRoomGenerateRequest{
-			Width:        width,
-			Height:       height,
-			Doors:        doors,
-			StageType:    item.stageType,
-			RoomCategory: "normal",
-		}
-		resp, err := GenerateFullRoom(req)
-		if err != nil {
-			return nil, err
-		}
-		return &resp.Payload, nil
-
-	case "bridge":
-		req := BridgeGenerateRequest{
-			Width:        width,
-			Height:       height,
-			Doors:        doors,
-			StageType:    item.stageType,
-			RoomCategory: "normal",
-		}
-		resp, err := GenerateBridgeRoom(req)
-		if err != nil {
-			return nil, err
-		}
-		return &resp.Payload, nil
-
-	case "platform":
-		req := PlatformGenerateRequest{
-			Width:        width,
-			Height:       height,
-			Doors:        doors,
-			StageType:    item.stageType,
-			RoomCategory: "normal",
-		}
-		resp, err := GeneratePlatformRoom(req)
-		if err != nil {
-			return nil, err
-		}
-		return &resp.Payload, nil
-
-	default:
+	g, ok := generatorForRoomType(item.shape)
+	if !ok {
 		return nil, fmt.Errorf("unknown shape: %s", item.shape)
 	}
+
+	// Default dimensions
+	params := RoomParams{
+		Width:     20,
+		Height:    12,
+		Doors:     doors,
+		StageType: item.stageType,
+	}
+	result, err := g.Generate(ctx, g.RequestFor(params), GenerateOptions{StageOverrides: overrides})
+	if err != nil {
+		return nil, err
+	}
+	// Rooms that still break an invariant are not saved
+	if err := result.validationError(); err != nil {
+		return nil, err
+	}
+	return result.Payload, nil
 }
@@ -0,0 +1,31 @@
+package generate
+
+import (
+	"context"
+	"testing"
+
+	"tile-backend/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoFill_SetsMinCorridorWidth(t *testing.T) {
+	deficit := func(key string) map[string]model.DimensionStat {
+		return map[string]model.DimensionStat{key: {Required: 1, Deficit: 1}}
+	}
+	stats := &model.ProjectStats{TotalRooms: 1, Shape: deficit("full"), Door: deficit("10"), Stage: deficit("teaching")}
+	creator := &countingCreator{}
+
+	result, err := AutoFill(context.Background(), &model.Project{Name: "corridor"}, stats, creator)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.TotalGenerated)
+	require.Len(t, creator.templates, 1)
+
+	// Saved templates carry the corridor width like ones created over the API
+	tmpl := creator.templates[0]
+	width, ok := MinCorridorWidth(&tmpl.Payload, tmpl.Width, tmpl.Height)
+	require.True(t, ok)
+	require.NotNil(t, tmpl.MinCorridorWidth)
+	assert.Equal(t, width, *tmpl.MinCorridorWidth)
+}
@@ -0,0 +1,252 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+	"tile-backend/internal/model"
+)
+
+const (
+	batchMaxCount   = 50 // maximum variants per batch request
+	batchMaxWorkers = 8  // upper bound on concurrent generator runs
+)
+
+// Batch sort keys
+const (
+	BatchSortOverall        = "overall"        // DifficultyScore.Overall
+	BatchSortWalkableRatio  = "walkableRatio"  // ground cells / total cells
+	BatchSortTargetDistance = "targetDistance" // |Overall - targetDifficulty|, always ascending
+)
+
+// BatchGenerateRequest represents a request for multiple variants of one generate request
+type BatchGenerateRequest struct {
+	Shape            string               `json:"shape"`            // Registered generator to run: bridge, platform, fullroom, cave, ...
+	Request          json.RawMessage      `json:"request"`          // Generate request for that shape; its seed (optional) seeds the batch
+	Count            int                  `json:"count"`            // Number of variants to generate (1-50)
+	SortBy           string               `json:"sortBy"`           // overall, walkableRatio, targetDistance (optional, default: overall)
+	Descending       bool                 `json:"descending"`       // Sort highest first (optional, ignored for targetDistance)
+	TargetDifficulty *float64             `json:"targetDifficulty"` // Target overall difficulty 0-1 (required for targetDistance)
+	StageOverrides   model.StageOverrides `json:"-"`                // Project stage overrides applied to every variant (set from project_id, not read from the body)
+}
+
+// BatchGenerateResponse holds the ranked variants and any per-variant failures
+type BatchGenerateResponse struct {
+	Shape     string         `json:"shape"`
+	SortBy    string         `json:"sortBy"`
+	Requested int            `json:"requested"`
+	Variants  []BatchVariant `json:"variants"`
+	Failures  []BatchFailure `json:"failures,omitempty"`
+}
+
+// BatchVariant is one successfully generated room within a batch
+type BatchVariant struct {
+	Index         int                   `json:"index"` // Position in generation order (seed = base seed + index)
+	Seed          int64                 `json:"seed"`
+	Payload       model.TemplatePayload `json:"payload"`
+	Difficulty    *DifficultyScore      `json:"difficulty,omitempty"`
+	WalkableRatio float64               `json:"walkableRatio"`
+	SortValue     float64               `json:"sortValue"`
+}
+
+// BatchFailure records a variant that could not be generated
+type BatchFailure struct {
+	Index int    `json:"index"`
+	Seed  int64  `json:"seed"`
+	Error string `json:"error"`
+}
+
+// batchRunner generates a single variant with the given seed
+type batchRunner func(seed int64) (*model.TemplatePayload, *DifficultyScore, error)
+
+// GenerateBatch runs one generate request count times on a bounded worker pool
+// and returns the variants ranked by the requested key. Once ctx is canceled or
+// its deadline passes, the remaining variants stop and the batch fails with the
+// *StepError of the first variant that was cut short.
+func GenerateBatch(ctx context.Context, req BatchGenerateRequest) (*BatchGenerateResponse, error) {
+	if req.Count < 1 || req.Count > batchMaxCount {
+		return nil, fmt.Errorf("count must be between 1 and %d", batchMaxCount)
+	}
+
+	sortBy := req.SortBy
+	if sortBy == "" {
+		sortBy = BatchSortOverall
+	}
+	switch sortBy {
+	case BatchSortOverall, BatchSortWalkableRatio:
+	case BatchSortTargetDistance:
+		if req.TargetDifficulty == nil {
+			return nil, fmt.Errorf("targetDifficulty is required when sortBy is %s", BatchSortTargetDistance)
+		}
+		if *req.TargetDifficulty < 0 || *req.TargetDifficulty > 1 {
+			return nil, fmt.Errorf("targetDifficulty must be between 0 and 1")
+		}
+	default:
+		return nil, fmt.Errorf("invalid sortBy: %s (allowed: %s, %s, %s)",
+			sortBy, BatchSortOverall, BatchSortWalkableRatio, BatchSortTargetDistance)
+	}
+
+	run, baseSeed, err := newBatchRunner(ctx, req.Shape, req.Request, req.StageOverrides)
+	if err != nil {
+		return nil, err
+	}
+
+	// Variant i always uses baseSeed+i so a seeded batch is reproducible
+	_, base := newRequestRand(baseSeed)
+
+	variants := make([]*BatchVariant, req.Count)
+	failures := make([]*BatchFailure, req.Count)
+	errs := make([]error, req.Count)
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > batchMaxWorkers {
+		workers = batchMaxWorkers
+	}
+	if workers > req.Count {
+		workers = req.Count
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				seed := base + int64(i)
+				payload, difficulty, err := runBatchVariant(run, seed)
+				if err != nil {
+					failures[i] = &BatchFailure{Index: i, Seed: seed, Error: err.Error()}
+					errs[i] = err
+					continue
+				}
+				variants[i] = &BatchVariant{
+					Index:         i,
+					Seed:          seed,
+					Payload:       *payload,
+					Difficulty:    difficulty,
+					WalkableRatio: model.CalculateWalkableRatio(payload.Ground, payload.Meta.Width, payload.Meta.Height),
+				}
+			}
+		}()
+	}
+	for i := 0; i < req.Count; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// A variant cut short by ctx fails the batch; the rest would be cut short too
+	for _, err := range errs {
+		var stepErr *StepError
+		if errors.As(err, &stepErr) {
+			return nil, fmt.Errorf("batch stopped with %d of %d variants generated: %w", countVariants(variants), req.Count, stepErr)
+		}
+	}
+
+	resp := &BatchGenerateResponse{
+		Shape:     req.Shape,
+		SortBy:    sortBy,
+		Requested: req.Count,
+		Variants:  []BatchVariant{},
+	}
+	for i := 0; i < req.Count; i++ {
+		if v := variants[i]; v != nil {
+			v.SortValue = batchSortValue(v, sortBy, req.TargetDifficulty)
+			resp.Variants = append(resp.Variants, *v)
+		}
+		if f := failures[i]; f != nil {
+			resp.Failures = append(resp.Failures, *f)
+		}
+	}
+
+	descending := req.Descending && sortBy != BatchSortTargetDistance
+	sort.SliceStable(resp.Variants, func(a, b int) bool {
+		va, vb := resp.Variants[a].SortValue, resp.Variants[b].SortValue
+		if descending {
+			return va > vb
+		}
+		return va < vb
+	})
+
+	return resp, nil
+}
+
+// newBatchRunner decodes the shape-specific request and returns a runner for it
+// together with the request's own seed (nil when unset). Every run applies the
+// stage overrides.
+func newBatchRunner(ctx context.Context, shape string, raw json.RawMessage, overrides model.StageOverrides) (batchRunner, *int64, error) {
+	if len(raw) == 0 {
+		return nil, nil, fmt.Errorf("request is required")
+	}
+
+	g, ok := LookupGenerator(shape)
+	if !ok {
+		return nil, nil, unknownShapeError(shape)
+	}
+	req := g.NewRequest()
+	if err := json.Unmarshal(raw, req); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s request: %w", shape, err)
+	}
+	var seeded struct {
+		Seed *int64 `json:"seed"`
+	}
+	if err := json.Unmarshal(raw, &seeded); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s request: %w", shape, err)
+	}
+
+	return func(seed int64) (*model.TemplatePayload, *DifficultyScore, error) {
+		result, err := g.Generate(ctx, req, GenerateOptions{Seed: &seed, StageOverrides: overrides})
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := result.validationError(); err != nil {
+			return nil, nil, err
+		}
+		return result.Payload, result.Difficulty, nil
+	}, seeded.Seed, nil
+}
+
+// runBatchVariant runs one variant, converting a generator panic into a failure
+// so a single bad variant cannot take down the whole batch.
+func runBatchVariant(run batchRunner, seed int64) (payload *model.TemplatePayload, difficulty *DifficultyScore, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			payload, difficulty = nil, nil
+			err = fmt.Errorf("generator panic: %v", r)
+		}
+	}()
+	return run(seed)
+}
+
+// countVariants counts the variants that were generated
+func countVariants(variants []*BatchVariant) int {
+	n := 0
+	for _, v := range variants {
+		if v != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// batchSortValue returns the value a variant is ranked by
+func batchSortValue(v *BatchVariant, sortBy string, target *float64) float64 {
+	overall := 0.0
+	if v.Difficulty != nil {
+		overall = v.Difficulty.Overall
+	}
+	switch sortBy {
+	case BatchSortWalkableRatio:
+		return v.WalkableRatio
+	case BatchSortTargetDistance:
+		return math.Abs(overall - *target)
+	default:
+		return overall
+	}
+}
@@ -0,0 +1,118 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateBatch_SortedByOverall(t *testing.T) {
+	resp, err := GenerateBatch(context.Background(), BatchGenerateRequest{
+		Shape:   "fullroom",
+		Request: json.RawMessage(`{"width":20,"height":12,"doors":["left","right"],"stageType":"pressure","seed":42}`),
+		Count:   8,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, BatchSortOverall, resp.SortBy)
+	assert.Len(t, resp.Variants, 8)
+	assert.Empty(t, resp.Failures)
+
+	seen := make(map[int]bool)
+	for i, v := range resp.Variants {
+		assert.Equal(t, int64(42+v.Index), v.Seed)
+		assert.False(t, seen[v.Index], "duplicate variant index %d", v.Index)
+		seen[v.Index] = true
+		assert.Equal(t, v.Difficulty.Overall, v.SortValue)
+		if i > 0 {
+			assert.LessOrEqual(t, resp.Variants[i-1].SortValue, v.SortValue)
+		}
+	}
+}
+
+func TestGenerateBatch_Reproducible(t *testing.T) {
+	req := BatchGenerateRequest{
+		Shape:      "bridge",
+		Request:    json.RawMessage(`{"width":20,"height":12,"doors":["top","bottom"],"staticCount":2,"seed":7}`),
+		Count:      6,
+		SortBy:     BatchSortWalkableRatio,
+		Descending: true,
+	}
+
+	first, err := GenerateBatch(context.Background(), req)
+	require.NoError(t, err)
+	second, err := GenerateBatch(context.Background(), req)
+	require.NoError(t, err)
+
+	a, err := json.Marshal(first)
+	require.NoError(t, err)
+	b, err := json.Marshal(second)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(a), string(b))
+
+	for i := 1; i < len(first.Variants); i++ {
+		assert.GreaterOrEqual(t, first.Variants[i-1].WalkableRatio, first.Variants[i].WalkableRatio)
+	}
+}
+
+func TestGenerateBatch_TargetDistance(t *testing.T) {
+	target := 0.5
+	resp, err := GenerateBatch(context.Background(), BatchGenerateRequest{
+		Shape:            "platform",
+		Request:          json.RawMessage(`{"width":20,"height":12,"doors":["top","right","bottom","left"],"stageType":"building"}`),
+		Count:            5,
+		SortBy:           BatchSortTargetDistance,
+		Descending:       true, // ignored: closest first
+		TargetDifficulty: &target,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Variants)
+
+	for i := 1; i < len(resp.Variants); i++ {
+		assert.LessOrEqual(t, resp.Variants[i-1].SortValue, resp.Variants[i].SortValue)
+	}
+}
+
+func TestGenerateBatch_PerVariantFailures(t *testing.T) {
+	// Bridge generation needs 2 doors, so every variant fails but the batch itself succeeds
+	resp, err := GenerateBatch(context.Background(), BatchGenerateRequest{
+		Shape:   "bridge",
+		Request: json.RawMessage(`{"width":20,"height":12,"doors":["top"]}`),
+		Count:   3,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Variants)
+	require.Len(t, resp.Failures, 3)
+	for i, f := range resp.Failures {
+		assert.Equal(t, i, f.Index)
+		assert.Contains(t, f.Error, "at least 2 doors")
+	}
+}
+
+func TestGenerateBatch_InvalidInput(t *testing.T) {
+	valid := json.RawMessage(`{"width":20,"height":12,"doors":["left","right"]}`)
+	badTarget := 1.5
+
+	tests := []struct {
+		name string
+		req  BatchGenerateRequest
+	}{
+		{"zero count", BatchGenerateRequest{Shape: "fullroom", Request: valid, Count: 0}},
+		{"count too large", BatchGenerateRequest{Shape: "fullroom", Request: valid, Count: batchMaxCount + 1}},
+		{"unknown shape", BatchGenerateRequest{Shape: "tunnel", Request: valid, Count: 2}},
+		{"missing request", BatchGenerateRequest{Shape: "fullroom", Count: 2}},
+		{"bad sort key", BatchGenerateRequest{Shape: "fullroom", Request: valid, Count: 2, SortBy: "fun"}},
+		{"target missing", BatchGenerateRequest{Shape: "fullroom", Request: valid, Count: 2, SortBy: BatchSortTargetDistance}},
+		{"target out of range", BatchGenerateRequest{Shape: "fullroom", Request: valid, Count: 2, SortBy: BatchSortTargetDistance, TargetDifficulty: &badTarget}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := GenerateBatch(context.Background(), tt.req)
+			assert.Error(t, err)
+		})
+	}
+}
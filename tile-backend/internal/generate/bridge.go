@@ -1,34 +1,96 @@
 package generate
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"tile-backend/internal/model"
 )
 
-// GenerateBridgeRoom generates a bridge-type room template
-func GenerateBridgeRoom(req BridgeGenerateRequest) (*BridgeGenerateResponse, error) {
+// bridgeMinDoors is the fewest doors a bridge can span
+const bridgeMinDoors = 2
+
+// bridgeGenerator is the registry entry for bridge rooms
+var bridgeGenerator = &shapeGenerator[BridgeGenerateRequest, BridgeGenerateResponse]{
+	info: ShapeInfo{
+		Name:         "bridge",
+		RoomType:     "bridge",
+		PayloadShape: "bridge",
+		Doors:        allDoorSides,
+		MinDoors:     bridgeMinDoors,
+		MinWidth:     4,
+		MinHeight:    4,
+	},
+	generate: GenerateBridgeRoom,
+	setOptions: func(req *BridgeGenerateRequest, opts GenerateOptions) {
+		opts.apply(&req.Seed, &req.StageOverrides, &req.Trace)
+	},
+	fromParams: func(p RoomParams) BridgeGenerateRequest {
+		return BridgeGenerateRequest{Width: p.Width, Height: p.Height, Doors: p.Doors, StageType: p.StageType, RoomCategory: "normal"}
+	},
+	result: func(resp *BridgeGenerateResponse) (*model.TemplatePayload, *DifficultyScore, *ValidationDebugInfo) {
+		return &resp.Payload, resp.Difficulty, resp.DebugInfo.Validation
+	},
+}
+
+// GenerateBridgeRoom generates a bridge-type room template (see generateSampled).
+func GenerateBridgeRoom(ctx context.Context, req BridgeGenerateRequest) (*BridgeGenerateResponse, error) {
+	return generateSampled(ctx, req, generateBridgeRoom)
+}
+
+func (r *BridgeGenerateRequest) sampling() sampledRequest {
+	return sampledRequest{target: &r.DifficultyTarget, seed: &r.Seed, maxValidationAttempts: r.MaxValidationAttempts}
+}
+
+func (r *BridgeGenerateResponse) sampling() sampledResponse {
+	return sampledResponse{payload: &r.Payload, difficulty: r.Difficulty, seed: &r.Seed, target: &r.DifficultyTarget, validation: &r.DebugInfo.Validation}
+}
+
+// generateBridgeRoom generates one room from the request seed, without self-validation
+func generateBridgeRoom(ctx context.Context, req BridgeGenerateRequest) (*BridgeGenerateResponse, error) {
 	// Validate input
 	if req.Width < 4 || req.Width > 200 || req.Height < 4 || req.Height > 200 {
 		return nil, fmt.Errorf("invalid dimensions: width and height must be between 4 and 200")
 	}
-	if len(req.Doors) < 2 {
-		return nil, fmt.Errorf("at least 2 doors are required for bridge generation")
-	}
 
 	if err := ValidateRoomCategory(req.RoomCategory); err != nil {
 		return nil, err
 	}
+	if err := req.ConstraintMasks.Validate(req.Width, req.Height); err != nil {
+		return nil, err
+	}
+	if err := ValidateSymmetry(req.Symmetry); err != nil {
+		return nil, err
+	}
+	if err := req.Symmetry.checkMasks(&req.ConstraintMasks, req.Width, req.Height); err != nil {
+		return nil, err
+	}
 
-	// Validate doors are unique
-	doorSet := make(map[DoorPosition]bool)
-	for _, door := range req.Doors {
-		if doorSet[door] {
-			return nil, fmt.Errorf("duplicate door position: %s", door)
+	// Resolve door openings; descriptors replace the side list when given
+	doorPositions, sides, descriptors, err := resolveDoors(req.Doors, req.DoorDescriptors, req.Width, req.Height)
+	if err != nil {
+		return nil, err
+	}
+	if len(doorPositions) < bridgeMinDoors {
+		return nil, fmt.Errorf("at least %d doors are required for bridge generation", bridgeMinDoors)
+	}
+
+	// Mirror the doors so they match the symmetric room
+	addedDoors := 0
+	if req.Symmetry.enabled() {
+		requested := descriptors
+		if doorPositions, sides, descriptors, err = symmetricDoors(req.Symmetry, descriptors, req.Width, req.Height); err != nil {
+			return nil, err
 		}
-		doorSet[door] = true
+		addedDoors = countAddedDoors(requested, descriptors)
 	}
 
+	// All randomness for this request flows from a single seeded source
+	rng, seed := newRequestRand(req.Seed)
+	masks := &req.ConstraintMasks
+	trace := newTrace(req.Trace)
+	steps := newStepTracker(ctx, "bridge")
+
 	// Initialize debug info
 	debugInfo := &GenerateDebugInfo{}
 
@@ -38,79 +100,141 @@ func GenerateBridgeRoom(req BridgeGenerateRequest) (*BridgeGenerateResponse, err
 		ground[y] = make([]int, req.Width)
 	}
 
-	// Get door center positions
-	doorPositions := getDoorCenterPositions(req.Width, req.Height, req.Doors)
-
 	// Step 1: Connect all doors
 	groundDebug := &GroundDebugInfo{}
-	connectDoorsWithDebug(ground, doorPositions, req.Width, req.Height, groundDebug)
+	connectDoorsWithDebug(rng, ground, doorPositions, req.Width, req.Height, groundDebug)
+	trace.snapshot("connect doors", map[string][][]int{"ground": ground})
 
 	// Step 2: Draw small platforms
-	drawPlatformsWithDebug(ground, req.Width, req.Height, req.Doors, doorPositions, groundDebug)
+	drawPlatformsWithDebug(rng, ground, req.Width, req.Height, sides, doorPositions, groundDebug)
+	trace.snapshot("platforms", map[string][][]int{"ground": ground})
 
 	// Step 2.5: Draw floating islands in void areas (50% probability per island)
-	drawFloatingIslandsWithDebug(ground, req.Width, req.Height, groundDebug)
+	drawFloatingIslandsWithDebug(rng, ground, masks, req.Width, req.Height, groundDebug)
+	trace.snapshot("floating islands", map[string][][]int{"ground": ground})
+
+	// Every cell of every door opening is walkable
+	openDoorCells(ground, doorPositions, req.Width, req.Height)
 
 	// Step 2.6: Repair any disconnected ground fragments left by platform/island drawing.
 	// All ground cells must form a single 4-connected region before other layers are built.
 	ensureGroundConnectivity(ground, req.Width, req.Height)
+	trace.snapshot("connectivity repair", map[string][][]int{"ground": ground})
+
+	// Step 2.7: Apply designer forceGround / forceVoid masks
+	constraintReasons := applyGroundConstraints(ground, masks, doorPositions, req.Width, req.Height, trace)
+	if !masks.isEmpty() {
+		trace.snapshot("constraints", map[string][][]int{"ground": ground})
+	}
+
+	// Symmetry: mirror the ground so every later layer is placed on a symmetric room
+	if req.Symmetry.enabled() {
+		debugInfo.Symmetry = symmetrizeGround(req.Symmetry, ground, masks, constraintReasons, req.Width, req.Height)
+		debugInfo.Symmetry.AddedDoors = addedDoors
+		trace.snapshot("symmetric ground", map[string][][]int{"ground": ground})
+	}
 
 	debugInfo.Ground = groundDebug
+	if err := steps.done("ground"); err != nil {
+		return nil, err
+	}
 
 	// Create empty layers for other layers
 	emptyLayer := createEmptyLayer(req.Width, req.Height)
 
 	// Build door states
-	doorStates := &model.DoorStates{
-		Top:    boolToInt(doorSet[DoorTop]),
-		Right:  boolToInt(doorSet[DoorRight]),
-		Bottom: boolToInt(doorSet[DoorBottom]),
-		Left:   boolToInt(doorSet[DoorLeft]),
-	}
+	doorStates := model.DoorStatesFromDescriptors(descriptors)
 
 	// Step 3: Generate soft edge layer if requested
 	softEdgeLayer := copyLayer(emptyLayer)
 	if req.SoftEdgeCount > 0 {
-		softEdgeDebug := generateSoftEdgeLayerWithDebug(softEdgeLayer, ground, doorPositions, req.Width, req.Height, req.SoftEdgeCount)
+		softEdgeDebug := generateSoftEdgeLayerWithDebug(rng, softEdgeLayer, ground, doorPositions, req.Width, req.Height, req.SoftEdgeCount)
 		debugInfo.SoftEdge = softEdgeDebug
+		trace.snapshot("soft edge", map[string][][]int{"softEdge": softEdgeLayer})
 	} else {
 		debugInfo.SoftEdge = &SoftEdgeDebugInfo{
 			Skipped:    true,
 			SkipReason: "softEdgeCount is 0 or not specified",
 		}
 	}
+	if err := steps.done("soft edge"); err != nil {
+		return nil, err
+	}
 
 	// Step 3.5: Generate bridge layer to connect floating islands
 	bridgeLayer := copyLayer(emptyLayer)
 	bridgeLayerDebug := generateBridgeLayerWithDebug(bridgeLayer, ground, softEdgeLayer, req.Width, req.Height)
 	debugInfo.BridgeLayer = bridgeLayerDebug
+	trace.snapshot("bridge", map[string][][]int{"bridge": bridgeLayer})
+	if err := steps.done("bridge"); err != nil {
+		return nil, err
+	}
 
 	// Step 3.6: Generate rail layer if enabled
 	railLayer := copyLayer(emptyLayer)
 	if req.RailEnabled {
-		railDebug := GenerateRailLayer(railLayer, ground, bridgeLayer, req.Width, req.Height)
+		railDebug := GenerateRailLayer(rng, railLayer, ground, bridgeLayer, req.Width, req.Height)
 		debugInfo.Rail = railDebug
+		trace.snapshot("rail", map[string][][]int{"rail": railLayer})
 	} else {
 		debugInfo.Rail = &RailDebugInfo{
 			Skipped:    true,
 			SkipReason: "railEnabled is false or not specified",
 		}
 	}
+	if err := steps.done("rail"); err != nil {
+		return nil, err
+	}
+
+	// Step 3.7: Generate pipeline layer if enabled
+	pipelineLayer := copyLayer(emptyLayer)
+	if req.PipelineEnabled {
+		debugInfo.Pipeline = GeneratePipelineLayer(rng, pipelineLayer, ground, bridgeLayer, railLayer, doorPositions, req.Width, req.Height, req.PipelineCount)
+		trace.snapshot("pipeline", map[string][][]int{"pipeline": pipelineLayer})
+	} else {
+		debugInfo.Pipeline = &PipelineDebugInfo{
+			Skipped:    true,
+			SkipReason: "pipelineEnabled is false or not specified",
+		}
+	}
+	if err := steps.done("pipeline"); err != nil {
+		return nil, err
+	}
+	// Step 3.8: Generate hazard layer if requested
+	hazardLayer := copyLayer(emptyLayer)
+	if req.HazardCount > 0 {
+		debugInfo.Hazard = GenerateHazardLayer(ctx, rng, hazardLayer, ground, bridgeLayer, railLayer, pipelineLayer, doorPositions, req.Width, req.Height, req.HazardCount)
+		trace.snapshot("hazard", map[string][][]int{"hazard": hazardLayer})
+	} else {
+		debugInfo.Hazard = &HazardDebugInfo{Skipped: true, SkipReason: "hazardCount is 0 or not specified"}
+	}
+	if err := steps.done("hazard"); err != nil {
+		return nil, err
+	}
+
+	// Statics and enemies keep off the pipeline and hazards
+	placementMasks := masks.withBlocked(pipelineLayer, hazardLayer)
+
+	// Statics and enemies are placed on grids, read back as layers once placement is done
+	lc := newLayerContext(ground, softEdgeLayer, bridgeLayer, railLayer, doorPositions, req.Width, req.Height)
 
 	// Step 4: Generate static layer if requested
-	staticLayer := copyLayer(emptyLayer)
 	if req.StaticCount > 0 {
-		staticDebug := generateStaticLayerWithDebugAndRail(staticLayer, ground, softEdgeLayer, bridgeLayer, railLayer, doorPositions, req.Width, req.Height, req.StaticCount)
+		staticDebug := generateStaticLayerWithDebugAndRail(ctx, rng, lc, placementMasks, req.StaticCount)
 		debugInfo.Static = staticDebug
+		trace.snapshotGrids("static", map[string]*Grid{"static": lc.Static})
 	} else {
 		debugInfo.Static = &StaticDebugInfo{
 			Skipped:    true,
 			SkipReason: "staticCount is 0 or not specified",
 		}
 	}
+	if err := steps.done("static"); err != nil {
+		return nil, err
+	}
 
 	// Apply stage rules
-	stageResult, stageErr := ValidateAndApplyStage(req.StageType, "bridge", req.Doors, ground, req.Width, req.Height)
+	stageResult, stageErr := ValidateAndApplyStage(rng, req.StageType, "bridge", req.StageOverrides, sides, ground, req.Width, req.Height)
 	if stageErr != nil {
 		return nil, stageErr
 	}
@@ -122,25 +246,27 @@ func GenerateBridgeRoom(req BridgeGenerateRequest) (*BridgeGenerateResponse, err
 	}
 
 	// Main path computation
-	mainPathData, mainPathDebug := ComputeMainPath(ground, bridgeLayer, doorPositions, req.Width, req.Height)
+	mainPathData, mainPathDebug := ComputeMainPath(ground, bridgeLayer, hazardLayer, doorPositions, req.Width, req.Height)
+	lc.MainPath = mainPathData
 	debugInfo.MainPath = mainPathDebug
+	if err := steps.done("main path"); err != nil {
+		return nil, err
+	}
 
 	// Step 5: Generate zoner layer if requested
-	zonerLayer := copyLayer(emptyLayer)
 	if req.ZonerCount > 0 {
-		zonerDebug := GenerateZonerLayer(zonerLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, doorPositions, mainPathData, req.Width, req.Height, req.ZonerCount)
+		zonerDebug := GenerateZonerLayer(ctx, rng, lc, placementMasks, req.ZonerCount)
 		debugInfo.Zoner = zonerDebug
 	} else {
 		debugInfo.Zoner = &EnemyLayerDebugInfo{Skipped: true, SkipReason: "zonerCount is 0 or not specified"}
 	}
 
 	// Step 6: Generate chaser layer if requested
-	chaserLayer := copyLayer(emptyLayer)
 	if req.ChaserCount > 0 {
-		chaserDebug := GenerateChaserLayer(chaserLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, doorPositions, mainPathData, req.Width, req.Height, req.ChaserCount)
+		chaserDebug := GenerateChaserLayer(ctx, rng, lc, placementMasks, req.ChaserCount)
 		// Relaxed fallback: if strict pass still can't fill target, drop spacing constraint.
 		if remaining := req.ChaserCount - chaserDebug.PlacedCount; remaining > 0 {
-			GenerateChaserLayerRelaxed(chaserLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, doorPositions, mainPathData, req.Width, req.Height, remaining)
+			GenerateChaserLayerRelaxed(ctx, rng, lc, placementMasks, remaining)
 		}
 		debugInfo.Chaser = chaserDebug
 	} else {
@@ -148,12 +274,11 @@ func GenerateBridgeRoom(req BridgeGenerateRequest) (*BridgeGenerateResponse, err
 	}
 
 	// Step 6.5: Generate DPS layer if requested
-	dpsLayer := copyLayer(emptyLayer)
 	if req.DPSCount > 0 {
-		dpsDebug := GenerateDPSLayer(dpsLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, chaserLayer, doorPositions, mainPathData, req.Width, req.Height, req.DPSCount)
+		dpsDebug := GenerateDPSLayer(ctx, rng, lc, placementMasks, req.DPSCount)
 		// Relaxed fallback: if strict pass still can't fill target, drop spacing constraint.
 		if remaining := req.DPSCount - dpsDebug.PlacedCount; remaining > 0 {
-			GenerateDPSLayerRelaxed(dpsLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, chaserLayer, doorPositions, mainPathData, req.Width, req.Height, remaining)
+			GenerateDPSLayerRelaxed(ctx, rng, lc, placementMasks, remaining)
 		}
 		debugInfo.DPS = dpsDebug
 	} else {
@@ -161,14 +286,52 @@ func GenerateBridgeRoom(req BridgeGenerateRequest) (*BridgeGenerateResponse, err
 	}
 
 	// Step 7: Generate mob air layer if requested
-	mobAirLayer := copyLayer(emptyLayer)
 	if req.MobAirCount > 0 {
-		mobAirDebug := GenerateMobAirLayerNew(mobAirLayer, ground, softEdgeLayer, bridgeLayer, staticLayer, zonerLayer, chaserLayer, dpsLayer, doorPositions, req.Width, req.Height, req.MobAirCount)
+		mobAirDebug := GenerateMobAirLayerNew(ctx, lc, placementMasks, req.MobAirCount)
 		debugInfo.MobAir = mobAirDebug
 	} else {
 		debugInfo.MobAir = &MobAirDebugInfo{Skipped: true, SkipReason: "mobAirCount is 0 or not specified"}
 	}
 
+	staticLayer := lc.Static.Layer()
+	zonerLayer := lc.Zoner.Layer()
+	chaserLayer := lc.Chaser.Layer()
+	dpsLayer := lc.DPS.Layer()
+	mobAirLayer := lc.MobAir.Layer()
+
+	// Each enemy type only writes its own layer, so one frame per type shows its placement
+	trace.snapshot("zoner", map[string][][]int{"zoner": zonerLayer})
+	trace.snapshot("chaser", map[string][][]int{"chaser": chaserLayer})
+	trace.snapshot("dps", map[string][][]int{"dps": dpsLayer})
+	trace.snapshot("mobAir", map[string][][]int{"mobAir": mobAirLayer})
+	if err := steps.done("enemies"); err != nil {
+		return nil, err
+	}
+
+	// Step 8: Generate pickup layer if requested
+	pickupLayer := copyLayer(emptyLayer)
+	if req.PickupCount > 0 {
+		debugInfo.Pickup = GeneratePickupLayer(rng, pickupLayer, ground, bridgeLayer, railLayer, pipelineLayer, hazardLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, doorPositions, mainPathData, req.Width, req.Height, req.PickupCount)
+		trace.snapshot("pickup", map[string][][]int{"pickup": pickupLayer})
+	} else {
+		debugInfo.Pickup = &PickupDebugInfo{Skipped: true, SkipReason: "pickupCount is 0 or not specified"}
+	}
+	if err := steps.done("pickup"); err != nil {
+		return nil, err
+	}
+
+	// Symmetry: copy the source region of every layer onto the rest of the room,
+	// then recompute the main path over the mirrored bridges
+	if req.Symmetry.enabled() {
+		debugInfo.Symmetry.MirroredCells = mirrorLayers(req.Symmetry, req.Width, req.Height, softEdgeLayer, bridgeLayer, railLayer, pipelineLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, pickupLayer, hazardLayer)
+		debugInfo.Symmetry.RemovedCells += dropCuttingStatics(req.Symmetry, staticLayer, ground, doorPositions, req.Width, req.Height)
+		if n := dropCuttingHazards(hazardLayer, ground, bridgeLayer, doorPositions, req.Width, req.Height); n > 0 {
+			debugInfo.Symmetry.RemovedCells += n
+			debugInfo.Symmetry.HazardDropped = true
+		}
+		mainPathData, debugInfo.MainPath = ComputeMainPath(ground, bridgeLayer, hazardLayer, doorPositions, req.Width, req.Height)
+	}
+
 	// Build main path layer for output
 	mainPathLayer := copyLayer(emptyLayer)
 	if mainPathData != nil {
@@ -193,21 +356,22 @@ func GenerateBridgeRoom(req BridgeGenerateRequest) (*BridgeGenerateResponse, err
 	}
 	stageType := &stageStr
 	payload := model.TemplatePayload{
-		Ground:       ground,
-		SoftEdge:     softEdgeLayer,
-		Bridge:       bridgeLayer,
-		Rail:         railLayer,
-		Static:       staticLayer,
-		Chaser:       chaserLayer,
-		Zoner:        zonerLayer,
-		DPS:          dpsLayer,
-		MobAir:       mobAirLayer,
-		MainPath:     mainPathLayer,
-		Doors:        doorStates,
-		StageType:    stageType,
-		RoomShape:    &roomShape,
-		RoomCategory: &roomCategory,
-		OpenDoors:    model.ComputeOpenDoors(doorStates),
+		Ground:          ground,
+		SoftEdge:        softEdgeLayer,
+		Bridge:          bridgeLayer,
+		Rail:            railLayer,
+		Static:          staticLayer,
+		Chaser:          chaserLayer,
+		Zoner:           zonerLayer,
+		DPS:             dpsLayer,
+		MobAir:          mobAirLayer,
+		MainPath:        mainPathLayer,
+		Doors:           doorStates,
+		DoorDescriptors: descriptors,
+		StageType:       stageType,
+		RoomShape:       &roomShape,
+		RoomCategory:    &roomCategory,
+		OpenDoors:       model.ComputeOpenDoors(doorStates),
 		Meta: model.TemplateMeta{
 			Name:    fmt.Sprintf("bridge-%dx%d", req.Width, req.Height),
 			Version: 1,
@@ -216,61 +380,73 @@ func GenerateBridgeRoom(req BridgeGenerateRequest) (*BridgeGenerateResponse, err
 		},
 	}
 
-	difficulty := ComputeDifficulty(ground, softEdgeLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, mainPathData, req.Width, req.Height)
+	if req.PipelineEnabled {
+		payload.Pipeline = pipelineLayer
+	}
+	if req.HazardCount > 0 {
+		payload.Hazard = hazardLayer
+	}
+	if req.PickupCount > 0 {
+		payload.Pickup = pickupLayer
+	}
+	payload.SetLineSegments()
+
+	// Validate the mirrored room, clearing mirrored cells that break a rule
+	if req.Symmetry.enabled() {
+		if err := finishSymmetricPayload(req.Symmetry, &payload, debugInfo.Symmetry); err != nil {
+			return nil, err
+		}
+	}
+
+	// Patrol routes are traced around the final chaser cells
+	payload.ChaserPatrols, debugInfo.Patrol = GenerateChaserPatrols(ctx, rng, req.Symmetry, chaserLayer, ground, staticLayer, hazardLayer, doorPositions, req.Width, req.Height)
+	if err := steps.done("patrols"); err != nil {
+		return nil, err
+	}
+
+	// Spawn waves are assigned to the final enemy cells
+	payload.Waves, debugInfo.Waves = assignWaves(stageResult.PlacementHints, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, req.Width, req.Height)
+	trace.snapshotPayload("final", &payload)
+
+	difficulty := ComputeDifficulty(ground, softEdgeLayer, hazardLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, mainPathData, req.Width, req.Height)
+
+	// Report designer masks that could not be honored
+	if !masks.isEmpty() {
+		debugInfo.Constraints = checkConstraints(masks, constraintReasons, ground, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer)
+	}
 
-	return &BridgeGenerateResponse{Payload: payload, DebugInfo: debugInfo, Difficulty: difficulty}, nil
+	return &BridgeGenerateResponse{Payload: payload, DebugInfo: debugInfo, Difficulty: difficulty, Seed: seed, Trace: trace.Frames()}, nil
 }
 
 // connectDoors connects all doors using random brushes with straight or L-shaped paths
-func connectDoors(ground [][]int, doorPositions map[DoorPosition]Point, width, height int) {
-	connectDoorsWithDebug(ground, doorPositions, width, height, nil)
+func connectDoors(rng *rand.Rand, ground [][]int, doorPositions []DoorSite, width, height int) {
+	connectDoorsWithDebug(rng, ground, doorPositions, width, height, nil)
 }
 
 // connectDoorsWithDebug connects all doors and records debug info
-func connectDoorsWithDebug(ground [][]int, doorPositions map[DoorPosition]Point, width, height int, debug *GroundDebugInfo) {
-	doors := make([]DoorPosition, 0, len(doorPositions))
-	for door := range doorPositions {
-		doors = append(doors, door)
+func connectDoorsWithDebug(rng *rand.Rand, ground [][]int, doorPositions []DoorSite, width, height int, debug *GroundDebugInfo) {
+	if len(doorPositions) == 0 {
+		return
 	}
 
-	// Connect doors pairwise until all are connected
-	connected := make(map[DoorPosition]bool)
-	connected[doors[0]] = true
-
-	for len(connected) < len(doors) {
-		// Find an unconnected door and connect it to a connected door
-		for _, door := range doors {
-			if connected[door] {
-				continue
-			}
-
-			// Find a connected door to connect to
-			var targetDoor DoorPosition
-			for d := range connected {
-				targetDoor = d
-				break
-			}
-
-			// Connect the two doors
-			from := doorPositions[targetDoor]
-			to := doorPositions[door]
-			connInfo := connectTwoPointsWithDebug(ground, from, to, width, height, string(targetDoor), string(door))
-			if debug != nil {
-				debug.DoorConnections = append(debug.DoorConnections, connInfo)
-			}
-			connected[door] = true
+	// Connect every other door to the first one; doors are already in canonical order
+	target := doorPositions[0]
+	for _, door := range doorPositions[1:] {
+		connInfo := connectTwoPointsWithDebug(rng, ground, target.Point, door.Point, width, height, string(target.Side), string(door.Side))
+		if debug != nil {
+			debug.DoorConnections = append(debug.DoorConnections, connInfo)
 		}
 	}
 }
 
 // connectTwoPoints connects two points with a straight line or L-shaped path through center
-func connectTwoPoints(ground [][]int, from, to Point, width, height int) {
-	connectTwoPointsWithDebug(ground, from, to, width, height, "", "")
+func connectTwoPoints(rng *rand.Rand, ground [][]int, from, to Point, width, height int) {
+	connectTwoPointsWithDebug(rng, ground, from, to, width, height, "", "")
 }
 
 // connectTwoPointsWithDebug connects two points and returns debug info
-func connectTwoPointsWithDebug(ground [][]int, from, to Point, width, height int, fromName, toName string) DoorConnectionInfo {
-	brush := connectionBrushes[rand.Intn(len(connectionBrushes))]
+func connectTwoPointsWithDebug(rng *rand.Rand, ground [][]int, from, to Point, width, height int, fromName, toName string) DoorConnectionInfo {
+	brush := connectionBrushes[rng.Intn(len(connectionBrushes))]
 
 	// Calculate center point
 	centerX, centerY := width/2, height/2
@@ -296,21 +472,21 @@ func connectTwoPointsWithDebug(ground [][]int, from, to Point, width, height int
 }
 
 // drawPlatforms draws small platforms according to the probability-based strategy
-func drawPlatforms(ground [][]int, width, height int, doors []DoorPosition, doorPositions map[DoorPosition]Point) {
-	drawPlatformsWithDebug(ground, width, height, doors, doorPositions, nil)
+func drawPlatforms(rng *rand.Rand, ground [][]int, width, height int, doors []DoorPosition, doorPositions []DoorSite) {
+	drawPlatformsWithDebug(rng, ground, width, height, doors, doorPositions, nil)
 }
 
 // drawPlatformsWithDebug draws platforms and records debug info
-func drawPlatformsWithDebug(ground [][]int, width, height int, doors []DoorPosition, doorPositions map[DoorPosition]Point, debug *GroundDebugInfo) {
+func drawPlatformsWithDebug(rng *rand.Rand, ground [][]int, width, height int, doors []DoorPosition, doorPositions []DoorSite, debug *GroundDebugInfo) {
 	// Determine number of draws (1-3)
-	drawCount := rand.Intn(3) + 1
+	drawCount := rng.Intn(3) + 1
 
 	// Build strategies with weights
 	strategies := buildStrategies(width, height, doors, doorPositions)
 
 	for i := 0; i < drawCount && len(strategies) > 0; i++ {
 		// Select strategy by weight
-		selectedIdx := selectByWeight(strategies)
+		selectedIdx := selectByWeight(rng, strategies)
 		if selectedIdx < 0 {
 			break
 		}
@@ -318,7 +494,7 @@ func drawPlatformsWithDebug(ground [][]int, width, height int, doors []DoorPosit
 		strategy := strategies[selectedIdx]
 
 		// Draw on all points in the strategy with mirroring
-		brush := platformBrushes[rand.Intn(len(platformBrushes))]
+		brush := platformBrushes[rng.Intn(len(platformBrushes))]
 		for _, point := range strategy.Points {
 			applyBrushWithMirror(ground, point.X, point.Y, brush, width, height, strategy.Mirror)
 		}
@@ -350,7 +526,7 @@ func drawPlatformsWithDebug(ground [][]int, width, height int, doors []DoorPosit
 }
 
 // buildStrategies builds the platform placement strategies with weights
-func buildStrategies(width, height int, doors []DoorPosition, doorPositions map[DoorPosition]Point) []Strategy {
+func buildStrategies(width, height int, doors []DoorPosition, doorPositions []DoorSite) []Strategy {
 	strategies := []Strategy{}
 	centerX, centerY := width/2, height/2
 
@@ -363,15 +539,15 @@ func buildStrategies(width, height int, doors []DoorPosition, doorPositions map[
 	})
 
 	// Check for horizontal (left-right) connection
-	_, hasLeft := doorPositions[DoorLeft]
-	_, hasRight := doorPositions[DoorRight]
+	leftDoor, hasLeft := firstDoorOnSide(doorPositions, DoorLeft)
+	rightDoor, hasRight := firstDoorOnSide(doorPositions, DoorRight)
 	if hasLeft && hasRight {
 		// Left and right door positions: weight 10
 		// Points on X-axis (horizontal center), mirror top-bottom
 		strategies = append(strategies, Strategy{
 			Name:   "left_right_doors",
 			Weight: 10,
-			Points: []Point{doorPositions[DoorLeft], doorPositions[DoorRight]},
+			Points: []Point{leftDoor.Point, rightDoor.Point},
 			Mirror: MirrorX,
 		})
 		// Midpoints between center and doors: weight 10
@@ -380,23 +556,23 @@ func buildStrategies(width, height int, doors []DoorPosition, doorPositions map[
 			Name:   "left_right_midpoints",
 			Weight: 10,
 			Points: []Point{
-				{X: (centerX + doorPositions[DoorLeft].X) / 2, Y: centerY},
-				{X: (centerX + doorPositions[DoorRight].X) / 2, Y: centerY},
+				{X: (centerX + leftDoor.X) / 2, Y: centerY},
+				{X: (centerX + rightDoor.X) / 2, Y: centerY},
 			},
 			Mirror: MirrorX,
 		})
 	}
 
 	// Check for vertical (top-bottom) connection
-	_, hasTop := doorPositions[DoorTop]
-	_, hasBottom := doorPositions[DoorBottom]
+	topDoor, hasTop := firstDoorOnSide(doorPositions, DoorTop)
+	bottomDoor, hasBottom := firstDoorOnSide(doorPositions, DoorBottom)
 	if hasTop && hasBottom {
 		// Top and bottom door positions: weight 10
 		// Points on Y-axis (vertical center), mirror left-right
 		strategies = append(strategies, Strategy{
 			Name:   "top_bottom_doors",
 			Weight: 10,
-			Points: []Point{doorPositions[DoorTop], doorPositions[DoorBottom]},
+			Points: []Point{topDoor.Point, bottomDoor.Point},
 			Mirror: MirrorY,
 		})
 		// Midpoints between center and doors: weight 10
@@ -405,18 +581,15 @@ func buildStrategies(width, height int, doors []DoorPosition, doorPositions map[
 			Name:   "top_bottom_midpoints",
 			Weight: 10,
 			Points: []Point{
-				{X: centerX, Y: (centerY + doorPositions[DoorTop].Y) / 2},
-				{X: centerX, Y: (centerY + doorPositions[DoorBottom].Y) / 2},
+				{X: centerX, Y: (centerY + topDoor.Y) / 2},
+				{X: centerX, Y: (centerY + bottomDoor.Y) / 2},
 			},
 			Mirror: MirrorY,
 		})
 	}
 
 	// All connected doors: weight 10 (no mirror, points already cover all positions)
-	allDoorPoints := make([]Point, 0, len(doorPositions))
-	for _, pos := range doorPositions {
-		allDoorPoints = append(allDoorPoints, pos)
-	}
+	allDoorPoints := doorCenters(doorPositions)
 	if len(allDoorPoints) > 0 {
 		strategies = append(strategies, Strategy{
 			Name:   "all_doors",
@@ -428,7 +601,7 @@ func buildStrategies(width, height int, doors []DoorPosition, doorPositions map[
 
 	// Midpoints between center and all doors: weight 10 (no mirror, points already cover all positions)
 	midpoints := make([]Point, 0, len(doorPositions))
-	for _, pos := range doorPositions {
+	for _, pos := range doorCenters(doorPositions) {
 		midpoints = append(midpoints, Point{
 			X: (centerX + pos.X) / 2,
 			Y: (centerY + pos.Y) / 2,
@@ -529,7 +702,7 @@ func findMaxRectangle(ground [][]int, visited [][]bool, startX, startY, width, h
 }
 
 // drawFloatingIslandsWithDebug draws floating islands in void areas with 50% probability per attempt
-func drawFloatingIslandsWithDebug(ground [][]int, width, height int, debug *GroundDebugInfo) {
+func drawFloatingIslandsWithDebug(rng *rand.Rand, ground [][]int, masks *ConstraintMasks, width, height int, debug *GroundDebugInfo) {
 	// Step 1: Find all empty areas >= 4x4
 	emptyAreas := findEmptyAreas(ground, width, height)
 	if len(emptyAreas) == 0 {
@@ -543,14 +716,14 @@ func drawFloatingIslandsWithDebug(ground [][]int, width, height int, debug *Grou
 	}
 
 	// Shuffle the empty areas
-	rand.Shuffle(len(emptyAreas), func(i, j int) {
+	rng.Shuffle(len(emptyAreas), func(i, j int) {
 		emptyAreas[i], emptyAreas[j] = emptyAreas[j], emptyAreas[i]
 	})
 
 	// Step 2-4: Loop with 50% probability
 	for len(emptyAreas) > 0 {
 		// Step 2: 50% chance to continue
-		if rand.Float64() >= 0.8 {
+		if rng.Float64() >= 0.8 {
 			if debug != nil {
 				debug.FloatingIslands = append(debug.FloatingIslands, FloatingIslandInfo{
 					Skipped:    true,
@@ -565,7 +738,7 @@ func drawFloatingIslandsWithDebug(ground [][]int, width, height int, debug *Grou
 		emptyAreas = emptyAreas[1:]
 
 		// Try to place a floating island in this area
-		placed := tryPlaceFloatingIsland(ground, area, width, height, debug)
+		placed := tryPlaceFloatingIsland(rng, ground, area, masks, width, height, debug)
 		if !placed && debug != nil {
 			debug.FloatingIslands = append(debug.FloatingIslands, FloatingIslandInfo{
 				FromArea:   fmt.Sprintf("(%d,%d) %dx%d", area.X, area.Y, area.Width, area.Height),
@@ -577,7 +750,7 @@ func drawFloatingIslandsWithDebug(ground [][]int, width, height int, debug *Grou
 }
 
 // tryPlaceFloatingIsland attempts to place a floating island in the given empty area
-func tryPlaceFloatingIsland(ground [][]int, area EmptyArea, gridWidth, gridHeight int, debug *GroundDebugInfo) bool {
+func tryPlaceFloatingIsland(rng *rand.Rand, ground [][]int, area EmptyArea, masks *ConstraintMasks, gridWidth, gridHeight int, debug *GroundDebugInfo) bool {
 	// Collect all valid (position, size) combinations
 	// The margin can extend outside the empty area (to grid edge or other void cells)
 	// So we try all sizes that fit within the area and let isValidIslandPosition check margins
@@ -595,7 +768,7 @@ func tryPlaceFloatingIsland(ground [][]int, area EmptyArea, gridWidth, gridHeigh
 			// Find valid positions for this size
 			for y := area.Y; y <= area.Y+area.Height-islandHeight; y++ {
 				for x := area.X; x <= area.X+area.Width-islandWidth; x++ {
-					if isValidIslandPosition(ground, x, y, islandWidth, islandHeight, gridWidth, gridHeight) {
+					if isValidIslandPosition(ground, x, y, islandWidth, islandHeight, masks, gridWidth, gridHeight) {
 						validPlacements = append(validPlacements, placement{x, y, islandWidth, islandHeight})
 					}
 				}
@@ -608,7 +781,7 @@ func tryPlaceFloatingIsland(ground [][]int, area EmptyArea, gridWidth, gridHeigh
 	}
 
 	// Pick a random valid placement
-	p := validPlacements[rand.Intn(len(validPlacements))]
+	p := validPlacements[rng.Intn(len(validPlacements))]
 
 	// Draw the island
 	for dy := 0; dy < p.h; dy++ {
@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"context"
 	"fmt"
 	"testing"
 )
@@ -49,7 +50,7 @@ func TestBridgeBlockAlwaysHasOneFullEdgeOnGround(t *testing.T) {
 			Doors:  doorSet,
 		}
 
-		resp, err := GenerateBridgeRoom(req)
+		resp, err := GenerateBridgeRoom(context.Background(), req)
 		if err != nil {
 			t.Fatalf("trial %d: unexpected error: %v", trial, err)
 		}
@@ -165,7 +166,7 @@ func TestBridgeRoomBridgeTilesAreStrictlyOriented(t *testing.T) {
 			StaticCount:   3,
 		}
 
-		resp, err := GenerateBridgeRoom(req)
+		resp, err := GenerateBridgeRoom(context.Background(), req)
 		if err != nil {
 			continue
 		}
@@ -255,7 +256,7 @@ func TestBridgeGroundAlwaysConnected(t *testing.T) {
 			RailEnabled:   trial%2 == 0,
 		}
 
-		resp, err := GenerateBridgeRoom(req)
+		resp, err := GenerateBridgeRoom(context.Background(), req)
 		if err != nil {
 			continue
 		}
@@ -352,7 +353,7 @@ func TestBridgeIslandsHaveAdjacentBridgeTiles(t *testing.T) {
 			StaticCount:   5,
 		}
 
-		resp, err := GenerateBridgeRoom(req)
+		resp, err := GenerateBridgeRoom(context.Background(), req)
 		if err != nil {
 			continue
 		}
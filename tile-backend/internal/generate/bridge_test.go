@@ -1,6 +1,8 @@
 package generate
 
 import (
+	"context"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -109,7 +111,7 @@ func TestGenerateBridgeRoom_ValidInput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resp, err := GenerateBridgeRoom(tt.req)
+			resp, err := GenerateBridgeRoom(context.Background(), tt.req)
 			require.NoError(t, err)
 			require.NotNil(t, resp)
 
@@ -204,7 +206,7 @@ func TestGenerateBridgeRoom_InvalidInput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resp, err := GenerateBridgeRoom(tt.req)
+			resp, err := GenerateBridgeRoom(context.Background(), tt.req)
 			assert.Nil(t, resp)
 			require.Error(t, err)
 			assert.Contains(t, err.Error(), tt.expectedErr)
@@ -220,7 +222,7 @@ func TestGenerateBridgeRoom_DoorsConnected(t *testing.T) {
 		Doors:  []DoorPosition{DoorTop, DoorBottom, DoorLeft, DoorRight},
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Use BFS to verify all doors are connected
@@ -282,7 +284,7 @@ func TestGenerateBridgeRoom_GroundHasWalkableTiles(t *testing.T) {
 		Doors:  []DoorPosition{DoorTop, DoorBottom},
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Count walkable tiles
@@ -304,11 +306,11 @@ func TestGenerateBridgeRoom_GroundHasWalkableTiles(t *testing.T) {
 }
 
 func TestBuildStrategies(t *testing.T) {
-	doorPositions := map[DoorPosition]Point{
-		DoorTop:    {X: 10, Y: 0},
-		DoorBottom: {X: 10, Y: 19},
-		DoorLeft:   {X: 0, Y: 10},
-		DoorRight:  {X: 19, Y: 10},
+	doorPositions := []DoorSite{
+		doorSiteAt(DoorTop, 10, 0),
+		doorSiteAt(DoorRight, 19, 10),
+		doorSiteAt(DoorBottom, 10, 19),
+		doorSiteAt(DoorLeft, 0, 10),
 	}
 
 	strategies := buildStrategies(20, 20, []DoorPosition{DoorTop, DoorBottom, DoorLeft, DoorRight}, doorPositions)
@@ -337,12 +339,12 @@ func TestSelectByWeight(t *testing.T) {
 
 	// With weight 100 vs 0, should always select first
 	for i := 0; i < 10; i++ {
-		idx := selectByWeight(strategies)
+		idx := selectByWeight(rand.New(rand.NewSource(int64(i))), strategies)
 		assert.Equal(t, 0, idx)
 	}
 
 	// Empty strategies should return -1
-	idx := selectByWeight([]Strategy{})
+	idx := selectByWeight(rand.New(rand.NewSource(1)), []Strategy{})
 	assert.Equal(t, -1, idx)
 }
 
@@ -463,7 +465,7 @@ func TestGenerateBridgeRoom_WithSoftEdgeCount(t *testing.T) {
 		SoftEdgeCount: 3,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Count soft edge cells
@@ -498,7 +500,7 @@ func TestGenerateBridgeRoom_SoftEdgeDistanceFromDoors(t *testing.T) {
 		SoftEdgeCount: 5,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Get door positions
@@ -509,7 +511,7 @@ func TestGenerateBridgeRoom_SoftEdgeDistanceFromDoors(t *testing.T) {
 		for x := 0; x < req.Width; x++ {
 			if resp.Payload.SoftEdge[y][x] == 1 {
 				for _, doorPos := range doorPositions {
-					dist := manhattanDistance(Point{X: x, Y: y}, doorPos)
+					dist := manhattanDistance(Point{X: x, Y: y}, doorPos.Point)
 					assert.GreaterOrEqual(t, dist, softEdgeMinDoorDistance,
 						"soft edge at (%d,%d) should be at least %d cells from door at (%d,%d)",
 						x, y, softEdgeMinDoorDistance, doorPos.X, doorPos.Y)
@@ -527,7 +529,7 @@ func TestGenerateBridgeRoom_SoftEdgeShape(t *testing.T) {
 		SoftEdgeCount: 5,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify soft edges form 1×N or N×1 shapes
@@ -547,7 +549,7 @@ func TestGenerateBridgeRoom_ZeroSoftEdgeCount(t *testing.T) {
 		SoftEdgeCount: 0,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify soft edge layer is all zeros
@@ -573,7 +575,7 @@ func TestFindHorizontalConcave(t *testing.T) {
 		{1, 1, 1, 1, 1, 1, 1, 1},
 	}
 	softEdgeLayer := createEmptyLayer(8, 4)
-	doorPositions := map[DoorPosition]Point{}
+	doorPositions := []DoorSite{}
 
 	// Should find a horizontal concave notch at (1,1) to (4,1)
 	// The notch has: ground below (row 2), void above (row 0), ground on left (x=0) and right (x=5)
@@ -601,7 +603,7 @@ func TestFindVerticalConcave(t *testing.T) {
 		{0, 1, 1, 1, 1}, // ground closes the bottom
 	}
 	softEdgeLayer := createEmptyLayer(5, 5)
-	doorPositions := map[DoorPosition]Point{}
+	doorPositions := []DoorSite{}
 
 	// Should find a vertical concave notch at (1,1) to (1,3)
 	// The notch has: ground on right (x=2), void on left (x=0), ground at top (y=0) and bottom (y=4)
@@ -623,7 +625,7 @@ func TestGenerateBridgeRoom_WithStaticCount(t *testing.T) {
 		StaticCount: 3,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Count static placements (each static is 2x2, so count cells with static=1)
@@ -650,7 +652,7 @@ func TestGenerateBridgeRoom_StaticOnGround(t *testing.T) {
 		StaticCount: 5,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify all static cells are on ground
@@ -671,7 +673,7 @@ func TestGenerateBridgeRoom_StaticsDoNotTouch(t *testing.T) {
 		StaticCount: 5,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Find all static positions (top-left corners of 2x2 statics)
@@ -717,7 +719,7 @@ func TestGenerateBridgeRoom_DoorsConnectedWithStatics(t *testing.T) {
 		StaticCount: 5,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Create walkable map (ground=1 and static=0)
@@ -759,8 +761,7 @@ func TestIsValidStaticPosition(t *testing.T) {
 	ground := createEmptyLayer(width, height)
 	softEdge := createEmptyLayer(width, height)
 	bridge := createEmptyLayer(width, height)
-	staticLayer := createEmptyLayer(width, height)
-	forbiddenCells := make(map[Point]bool)
+	blocked := NewGrid(width, height)
 
 	// Fill ground in center area
 	for y := 3; y < 7; y++ {
@@ -768,37 +769,38 @@ func TestIsValidStaticPosition(t *testing.T) {
 			ground[y][x] = 1
 		}
 	}
+	lc := newLayerContext(ground, softEdge, bridge, nil, nil, width, height)
 
 	// Valid position in center
-	assert.True(t, isValidStaticPosition(Point{X: 4, Y: 4}, ground, softEdge, bridge, staticLayer, forbiddenCells, width, height))
+	assert.True(t, isValidStaticPosition(Point{X: 4, Y: 4}, lc, blocked))
 
 	// Invalid - no ground
-	assert.False(t, isValidStaticPosition(Point{X: 0, Y: 0}, ground, softEdge, bridge, staticLayer, forbiddenCells, width, height))
+	assert.False(t, isValidStaticPosition(Point{X: 0, Y: 0}, lc, blocked))
 
 	// Invalid - out of bounds
-	assert.False(t, isValidStaticPosition(Point{X: 9, Y: 9}, ground, softEdge, bridge, staticLayer, forbiddenCells, width, height))
+	assert.False(t, isValidStaticPosition(Point{X: 9, Y: 9}, lc, blocked))
 
 	// Invalid - forbidden cell
-	forbiddenCells[Point{X: 4, Y: 4}] = true
-	assert.False(t, isValidStaticPosition(Point{X: 4, Y: 4}, ground, softEdge, bridge, staticLayer, forbiddenCells, width, height))
+	blocked.Set(4, 4)
+	assert.False(t, isValidStaticPosition(Point{X: 4, Y: 4}, lc, blocked))
 }
 
 func TestTouchesExistingStatic(t *testing.T) {
 	width, height := 10, 10
-	staticLayer := createEmptyLayer(width, height)
+	staticLayer := NewGrid(width, height)
 
 	// Place a static at (5,5)
-	placeStatic(staticLayer, Point{X: 5, Y: 5})
+	staticLayer.SetRect(5, 5, staticSize, staticSize)
 
 	// Positions that touch
-	assert.True(t, touchesExistingStatic(Point{X: 3, Y: 5}, staticLayer, width, height), "diagonal should touch")
-	assert.True(t, touchesExistingStatic(Point{X: 7, Y: 5}, staticLayer, width, height), "adjacent right should touch")
-	assert.True(t, touchesExistingStatic(Point{X: 5, Y: 7}, staticLayer, width, height), "adjacent below should touch")
-	assert.True(t, touchesExistingStatic(Point{X: 5, Y: 3}, staticLayer, width, height), "adjacent above should touch")
+	assert.True(t, touchesExistingStatic(Point{X: 3, Y: 5}, staticLayer), "diagonal should touch")
+	assert.True(t, touchesExistingStatic(Point{X: 7, Y: 5}, staticLayer), "adjacent right should touch")
+	assert.True(t, touchesExistingStatic(Point{X: 5, Y: 7}, staticLayer), "adjacent below should touch")
+	assert.True(t, touchesExistingStatic(Point{X: 5, Y: 3}, staticLayer), "adjacent above should touch")
 
 	// Positions that don't touch (with gap)
-	assert.False(t, touchesExistingStatic(Point{X: 0, Y: 0}, staticLayer, width, height), "far away should not touch")
-	assert.False(t, touchesExistingStatic(Point{X: 8, Y: 5}, staticLayer, width, height), "one gap right should not touch")
+	assert.False(t, touchesExistingStatic(Point{X: 0, Y: 0}, staticLayer), "far away should not touch")
+	assert.False(t, touchesExistingStatic(Point{X: 8, Y: 5}, staticLayer), "one gap right should not touch")
 }
 
 func TestWouldTouch(t *testing.T) {
@@ -841,8 +843,9 @@ func TestDistanceFromEdge(t *testing.T) {
 }
 
 func TestPlaceStatic(t *testing.T) {
-	staticLayer := createEmptyLayer(10, 10)
-	placeStatic(staticLayer, Point{X: 3, Y: 4})
+	lc := newLayerContext(nil, nil, nil, nil, nil, 10, 10)
+	placeStatic(lc, Point{X: 3, Y: 4})
+	staticLayer := lc.Static.Layer()
 
 	// Verify 2x2 is filled
 	assert.Equal(t, 1, staticLayer[4][3])
@@ -853,6 +856,7 @@ func TestPlaceStatic(t *testing.T) {
 	// Verify surrounding cells are not filled
 	assert.Equal(t, 0, staticLayer[3][3])
 	assert.Equal(t, 0, staticLayer[6][3])
+	assert.Equal(t, 4, lc.Static.Count())
 }
 
 func TestFilterTouchingPositions(t *testing.T) {
@@ -886,8 +890,8 @@ func TestFilterTouchingPositions(t *testing.T) {
 }
 
 func TestGetDoorForbiddenCells(t *testing.T) {
-	doorPositions := map[DoorPosition]Point{
-		DoorTop: {X: 10, Y: 0},
+	doorPositions := []DoorSite{
+		doorSiteAt(DoorTop, 10, 0),
 	}
 
 	forbidden := getDoorForbiddenCells(doorPositions, 20, 20)
@@ -915,7 +919,7 @@ func TestGetDoorForbiddenCells(t *testing.T) {
 	assert.False(t, forbidden[Point{X: 0, Y: 10}])
 }
 
-func TestCheckConnectivityAfterPlacement(t *testing.T) {
+func TestDoorConnectivity_AllowsBlock(t *testing.T) {
 	width, height := 10, 10
 	ground := createEmptyLayer(width, height)
 	staticLayer := createEmptyLayer(width, height)
@@ -926,17 +930,19 @@ func TestCheckConnectivityAfterPlacement(t *testing.T) {
 		ground[y][6] = 1
 	}
 
-	doorPositions := map[DoorPosition]Point{
-		DoorTop:    {X: 5, Y: 0},
-		DoorBottom: {X: 5, Y: 9},
+	doorPositions := []DoorSite{
+		doorSiteAt(DoorTop, 5, 0),
+		doorSiteAt(DoorBottom, 5, 9),
 	}
 
+	doors := newDoorConnectivity(GridFromLayer(ground, width, height), GridFromLayer(staticLayer, width, height), doorPositions)
+
 	// Placing static that doesn't block path should be OK
-	assert.True(t, checkConnectivityAfterPlacement(ground, staticLayer, doorPositions, Point{X: 0, Y: 0}, width, height))
+	assert.True(t, doors.allowsBlock(Point{X: 0, Y: 0}, staticSize, staticSize))
 
 	// Placing static that blocks path should fail
 	// (blocking middle of the path)
-	assert.False(t, checkConnectivityAfterPlacement(ground, staticLayer, doorPositions, Point{X: 5, Y: 4}, width, height))
+	assert.False(t, doors.allowsBlock(Point{X: 5, Y: 4}, staticSize, staticSize))
 }
 
 func TestGenerateBridgeRoom_ZeroStaticCount(t *testing.T) {
@@ -947,7 +953,7 @@ func TestGenerateBridgeRoom_ZeroStaticCount(t *testing.T) {
 		StaticCount: 0, // Explicitly zero
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify static layer is all zeros
@@ -968,7 +974,7 @@ func TestGenerateBridgeRoom_WithTurretCount(t *testing.T) {
 		ChaserCount: 4,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Count turret placements
@@ -994,7 +1000,7 @@ func TestGenerateBridgeRoom_TurretOnGround(t *testing.T) {
 		ChaserCount: 5,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify all turret cells are on ground
@@ -1016,7 +1022,7 @@ func TestGenerateBridgeRoom_TurretNotOnStatic(t *testing.T) {
 		ChaserCount: 4,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify turrets don't overlap with statics
@@ -1037,7 +1043,7 @@ func TestGenerateBridgeRoom_ChaserNotInDoorForbiddenZone(t *testing.T) {
 		ChaserCount: 4,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	doorPositions := getDoorCenterPositions(req.Width, req.Height, req.Doors)
@@ -1062,7 +1068,7 @@ func TestGenerateBridgeRoom_ChaserOnGround(t *testing.T) {
 		ChaserCount: 6,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify all chasers are on ground
@@ -1085,7 +1091,7 @@ func TestGenerateBridgeRoom_DoorsConnectedWithTurrets(t *testing.T) {
 		ChaserCount: 4,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Create walkable map
@@ -1139,19 +1145,19 @@ func TestIsValidTurretPosition(t *testing.T) {
 		}
 	}
 
-	doorPositions := map[DoorPosition]Point{
-		DoorTop:    {X: 10, Y: 0},
-		DoorBottom: {X: 10, Y: 19},
+	doorPositions := []DoorSite{
+		doorSiteAt(DoorTop, 10, 0),
+		doorSiteAt(DoorBottom, 10, 19),
 	}
 
 	// Valid position far from doors
-	assert.True(t, isValidTurretPosition(Point{X: 10, Y: 10}, ground, softEdge, bridge, staticLayer, turretLayer, doorPositions, width, height))
+	assert.True(t, isValidTurretPosition(Point{X: 10, Y: 10}, ground, softEdge, bridge, staticLayer, turretLayer, doorPositions, nil, width, height))
 
 	// Invalid - no ground
-	assert.False(t, isValidTurretPosition(Point{X: 0, Y: 0}, ground, softEdge, bridge, staticLayer, turretLayer, doorPositions, width, height))
+	assert.False(t, isValidTurretPosition(Point{X: 0, Y: 0}, ground, softEdge, bridge, staticLayer, turretLayer, doorPositions, nil, width, height))
 
 	// Invalid - too close to door (within 4 cells)
-	assert.False(t, isValidTurretPosition(Point{X: 10, Y: 3}, ground, softEdge, bridge, staticLayer, turretLayer, doorPositions, width, height))
+	assert.False(t, isValidTurretPosition(Point{X: 10, Y: 3}, ground, softEdge, bridge, staticLayer, turretLayer, doorPositions, nil, width, height))
 }
 
 func TestManhattanDistance(t *testing.T) {
@@ -1188,7 +1194,7 @@ func TestGenerateBridgeRoom_ZeroTurretCount(t *testing.T) {
 		ChaserCount: 0, // Explicitly zero
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify turret layer is all zeros
@@ -1211,7 +1217,7 @@ func TestGenerateBridgeRoom_WithMobGroundCount(t *testing.T) {
 		ZonerCount: 5,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Count mob ground cells
@@ -1237,7 +1243,7 @@ func TestGenerateBridgeRoom_MobGroundOnGround(t *testing.T) {
 		ZonerCount: 3,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify all mob ground cells are on ground
@@ -1261,7 +1267,7 @@ func TestGenerateBridgeRoom_MobGroundNotOnOtherLayers(t *testing.T) {
 		ZonerCount:  5,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify mob ground doesn't overlap with static or turret
@@ -1285,7 +1291,7 @@ func TestGenerateBridgeRoom_MobGroundDistanceFromDoors(t *testing.T) {
 		ZonerCount: 4,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Get door positions
@@ -1296,7 +1302,7 @@ func TestGenerateBridgeRoom_MobGroundDistanceFromDoors(t *testing.T) {
 		for x := 0; x < req.Width; x++ {
 			if resp.Payload.Zoner[y][x] == 1 {
 				for _, doorPos := range doorPositions {
-					dist := manhattanDistance(Point{X: x, Y: y}, doorPos)
+					dist := manhattanDistance(Point{X: x, Y: y}, doorPos.Point)
 					assert.GreaterOrEqual(t, dist, mobGroundMinDoorDistance,
 						"mob ground at (%d,%d) should be at least %d cells from door at (%d,%d)",
 						x, y, mobGroundMinDoorDistance, doorPos.X, doorPos.Y)
@@ -1314,7 +1320,7 @@ func TestGenerateBridgeRoom_MobGroundDoNotTouch(t *testing.T) {
 		ZonerCount: 6,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Find all mob ground positions
@@ -1387,7 +1393,7 @@ func TestGenerateBridgeRoom_ZeroMobGroundCount(t *testing.T) {
 		ZonerCount: 0,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify mob ground layer is all zeros
@@ -1514,7 +1520,7 @@ func TestGenerateBridgeRoom_WithMobAirCount(t *testing.T) {
 		MobAirCount: 6,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Count mob air cells
@@ -1540,7 +1546,7 @@ func TestGenerateBridgeRoom_MobAirNoGroundRequirement(t *testing.T) {
 		MobAirCount: 4,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify mob air can be placed (no ground requirement for flying mobs)
@@ -1566,7 +1572,7 @@ func TestGenerateBridgeRoom_MobAirNotOnOtherLayers(t *testing.T) {
 		MobAirCount: 5,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify mob air doesn't overlap with other layers
@@ -1592,7 +1598,7 @@ func TestGenerateBridgeRoom_MobAirDistanceFromDoors(t *testing.T) {
 		MobAirCount: 5,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Get door positions
@@ -1603,7 +1609,7 @@ func TestGenerateBridgeRoom_MobAirDistanceFromDoors(t *testing.T) {
 		for x := 0; x < req.Width; x++ {
 			if resp.Payload.MobAir[y][x] == 1 {
 				for _, doorPos := range doorPositions {
-					dist := manhattanDistance(Point{X: x, Y: y}, doorPos)
+					dist := manhattanDistance(Point{X: x, Y: y}, doorPos.Point)
 					assert.GreaterOrEqual(t, dist, mobAirMinDoorDistance,
 						"mob air at (%d,%d) should be at least %d cells from door at (%d,%d)",
 						x, y, mobAirMinDoorDistance, doorPos.X, doorPos.Y)
@@ -1621,7 +1627,7 @@ func TestGenerateBridgeRoom_MobAirDoNotTouch(t *testing.T) {
 		MobAirCount: 8,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Find all mob air positions
@@ -1656,7 +1662,7 @@ func TestGenerateBridgeRoom_MobAirDistanceFromEdges(t *testing.T) {
 		MobAirCount: 6,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify mob air is at least 2 cells away from all edges
@@ -1685,7 +1691,7 @@ func TestGenerateBridgeRoom_ZeroMobAirCount(t *testing.T) {
 		MobAirCount: 0,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Verify mob air layer is all zeros
@@ -1767,7 +1773,7 @@ func TestGenerateBridgeRoom_DebugInfoPopulated(t *testing.T) {
 		MobAirCount: 4,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 	require.NotNil(t, resp.DebugInfo, "DebugInfo should not be nil")
 
@@ -1834,7 +1840,7 @@ func TestGenerateBridgeRoom_DebugInfoWithZeroCounts(t *testing.T) {
 		MobAirCount:   0,
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 	require.NotNil(t, resp.DebugInfo, "DebugInfo should not be nil")
 
@@ -1966,7 +1972,7 @@ func TestIsValidIslandPosition(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := isValidIslandPosition(ground, tt.x, tt.y, tt.islandWidth, tt.islandHeight, 15, 15)
+			result := isValidIslandPosition(ground, tt.x, tt.y, tt.islandWidth, tt.islandHeight, nil, 15, 15)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -1992,7 +1998,7 @@ func TestDrawFloatingIslandsWithDebug(t *testing.T) {
 	}
 
 	debug := &GroundDebugInfo{}
-	drawFloatingIslandsWithDebug(ground, 30, 30, debug)
+	drawFloatingIslandsWithDebug(rand.New(rand.NewSource(1)), ground, nil, 30, 30, debug)
 
 	// Check debug info is populated
 	t.Logf("Floating islands debug info: %+v", debug.FloatingIslands)
@@ -2021,7 +2027,7 @@ func TestFloatingIslandMinDistance(t *testing.T) {
 		}
 
 		debug := &GroundDebugInfo{}
-		drawFloatingIslandsWithDebug(testGround, 20, 20, debug)
+		drawFloatingIslandsWithDebug(rand.New(rand.NewSource(int64(i))), testGround, nil, 20, 20, debug)
 
 		// Check that any placed islands maintain min distance of 2 from original ground
 		for y := 0; y < 20; y++ {
@@ -2050,7 +2056,7 @@ func TestGenerateBridgeRoom_FloatingIslandsInDebugInfo(t *testing.T) {
 		Doors:  []DoorPosition{DoorTop, DoorBottom},
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 	require.NotNil(t, resp.DebugInfo)
 	require.NotNil(t, resp.DebugInfo.Ground)
@@ -2215,7 +2221,7 @@ func TestGenerateBridgeRoom_BridgeLayerDebugInfo(t *testing.T) {
 		Doors:  []DoorPosition{DoorTop, DoorBottom},
 	}
 
-	resp, err := GenerateBridgeRoom(req)
+	resp, err := GenerateBridgeRoom(context.Background(), req)
 	require.NoError(t, err)
 	require.NotNil(t, resp.DebugInfo)
 	require.NotNil(t, resp.DebugInfo.BridgeLayer)
@@ -0,0 +1,69 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// StepError is returned when a generation is cut short by its context: the
+// caller canceled it (e.g. the client disconnected) or its deadline passed.
+// Step is the pipeline step that was running; Completed lists the steps that
+// finished before it, so the caller can tell how far the room got.
+type StepError struct {
+	Shape     string   // Generator that stopped, e.g. "fullroom" or "regenerate"
+	Step      string   // Step that was cut short
+	Completed []string // Steps finished before Step, in order
+	Err       error    // context.Canceled or context.DeadlineExceeded
+}
+
+func (e *StepError) Error() string {
+	verb := "canceled"
+	if e.TimedOut() {
+		verb = "timed out"
+	}
+	msg := fmt.Sprintf("%s generation %s during step %q", e.Shape, verb, e.Step)
+	if len(e.Completed) > 0 {
+		msg += fmt.Sprintf(" (completed: %s)", strings.Join(e.Completed, ", "))
+	}
+	return msg
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// TimedOut reports whether the deadline, rather than a cancel, stopped the generation
+func (e *StepError) TimedOut() bool {
+	return errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+// stepTracker follows the pipeline steps of one generation run so a canceled
+// context can be reported against the step it interrupted
+type stepTracker struct {
+	ctx       context.Context
+	shape     string
+	completed []string
+}
+
+func newStepTracker(ctx context.Context, shape string) *stepTracker {
+	return &stepTracker{ctx: ctx, shape: shape}
+}
+
+// done marks a step finished and returns a *StepError if the context ended
+// meanwhile. The long placement loops stop early once the context ends, so a
+// step that returns after that is the one that was cut short and is not
+// counted as completed.
+func (s *stepTracker) done(step string) error {
+	if err := s.ctx.Err(); err != nil {
+		return &StepError{
+			Shape:     s.shape,
+			Step:      step,
+			Completed: append([]string{}, s.completed...),
+			Err:       err,
+		}
+	}
+	s.completed = append(s.completed, step)
+	return nil
+}
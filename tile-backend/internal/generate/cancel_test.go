@@ -0,0 +1,220 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"tile-backend/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// canceledContext returns a context that is already canceled
+func canceledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+// requireStepError asserts err is a *StepError for the shape and cause
+func requireStepError(t *testing.T, err error, shape string, cause error) *StepError {
+	t.Helper()
+	var stepErr *StepError
+	require.True(t, errors.As(err, &stepErr), "expected a *StepError, got %v", err)
+	assert.Equal(t, shape, stepErr.Shape)
+	assert.ErrorIs(t, err, cause)
+	return stepErr
+}
+
+func TestStepTracker_Done(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	steps := newStepTracker(ctx, "fullroom")
+
+	require.NoError(t, steps.done("ground"))
+	require.NoError(t, steps.done("soft edge"))
+	cancel()
+	err := steps.done("static")
+
+	stepErr := requireStepError(t, err, "fullroom", context.Canceled)
+	assert.Equal(t, "static", stepErr.Step)
+	assert.Equal(t, []string{"ground", "soft edge"}, stepErr.Completed)
+	assert.False(t, stepErr.TimedOut())
+	assert.Equal(t, `fullroom generation canceled during step "static" (completed: ground, soft edge)`, err.Error())
+}
+
+func TestStepError_TimedOut(t *testing.T) {
+	err := &StepError{Shape: "cave", Step: "ground", Err: context.DeadlineExceeded}
+	assert.True(t, err.TimedOut())
+	assert.Equal(t, `cave generation timed out during step "ground"`, err.Error())
+}
+
+func TestGenerate_CanceledContext(t *testing.T) {
+	for _, g := range Generators() {
+		t.Run(g.Info().Name, func(t *testing.T) {
+			req := g.RequestFor(RoomParams{Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}})
+			result, err := g.Generate(canceledContext(), req, GenerateOptions{})
+			assert.Nil(t, result)
+
+			stepErr := requireStepError(t, err, g.Info().Name, context.Canceled)
+			assert.Equal(t, "ground", stepErr.Step)
+			assert.Empty(t, stepErr.Completed)
+		})
+	}
+}
+
+func TestGenerateFullRoom_CanceledDuringDifficultyTarget(t *testing.T) {
+	_, err := GenerateFullRoom(canceledContext(), FullRoomGenerateRequest{
+		Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight},
+		DifficultyTarget: &DifficultyTarget{Overall: &DifficultyRange{Min: 0.9, Max: 1}},
+	})
+	requireStepError(t, err, "fullroom", context.Canceled)
+}
+
+func TestGenerateFullRoom_Deadline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("generates a 200x200 room")
+	}
+	// Without a deadline this room takes minutes: every static candidate costs
+	// a connectivity check and mob air ranking is quadratic in the room area
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	seed := int64(1)
+	start := time.Now()
+	_, err := GenerateFullRoom(ctx, FullRoomGenerateRequest{
+		Width: 200, Height: 200, Doors: []DoorPosition{DoorTop, DoorRight, DoorBottom, DoorLeft},
+		StaticCount: 200, MobAirCount: 100, HazardCount: 40, Seed: &seed,
+	})
+
+	stepErr := requireStepError(t, err, "fullroom", context.DeadlineExceeded)
+	assert.True(t, stepErr.TimedOut())
+	assert.NotEmpty(t, stepErr.Step)
+	assert.Less(t, time.Since(start), 5*time.Second, "generation should stop soon after the deadline")
+}
+
+func TestGenerateStaticLayer_StopsWhenCanceled(t *testing.T) {
+	ground := createEmptyLayer(20, 20)
+	for y := range ground {
+		for x := range ground[y] {
+			ground[y][x] = 1
+		}
+	}
+	doors := []DoorSite{doorSiteAt(DoorLeft, 0, 10), doorSiteAt(DoorRight, 19, 10)}
+
+	placed := newLayerContext(ground, nil, nil, nil, doors, 20, 20)
+	debug := generateStaticLayerWithDebugAndRail(context.Background(), rand.New(rand.NewSource(1)), placed, &ConstraintMasks{}, 5)
+	require.Greater(t, debug.PlacedCount, 0)
+
+	canceled := newLayerContext(ground, nil, nil, nil, doors, 20, 20)
+	debug = generateStaticLayerWithDebugAndRail(canceledContext(), rand.New(rand.NewSource(1)), canceled, &ConstraintMasks{}, 5)
+	assert.Equal(t, 0, debug.PlacedCount)
+	assert.Equal(t, 0, canceled.Static.Count())
+}
+
+func TestEnemyLayers_StopWhenCanceled(t *testing.T) {
+	ground := createEmptyLayer(20, 20)
+	for y := range ground {
+		for x := range ground[y] {
+			ground[y][x] = 1
+		}
+	}
+	empty := createEmptyLayer(20, 20)
+	doors := []DoorSite{doorSiteAt(DoorLeft, 0, 10), doorSiteAt(DoorRight, 19, 10)}
+	mainPath, _ := ComputeMainPath(ground, empty, nil, doors, 20, 20)
+
+	place := func(ctx context.Context, enemy string) (*EnemyLayerDebugInfo, *Grid) {
+		rng := rand.New(rand.NewSource(1))
+		lc := newLayerContext(ground, nil, nil, nil, doors, 20, 20)
+		lc.MainPath = mainPath
+		switch enemy {
+		case "zoner":
+			return GenerateZonerLayer(ctx, rng, lc, &ConstraintMasks{}, 3), lc.Zoner
+		case "chaser":
+			return GenerateChaserLayerRelaxed(ctx, rng, lc, &ConstraintMasks{}, 3), lc.Chaser
+		default:
+			return GenerateDPSLayer(ctx, rng, lc, &ConstraintMasks{}, 3), lc.DPS
+		}
+	}
+	for _, enemy := range []string{"zoner", "chaser", "dps"} {
+		debug, _ := place(context.Background(), enemy)
+		require.Greater(t, debug.PlacedCount, 0, enemy)
+
+		debug, placed := place(canceledContext(), enemy)
+		assert.Equal(t, 0, debug.PlacedCount, enemy)
+		assert.Equal(t, 0, placed.Count(), enemy)
+	}
+}
+
+func TestRegenerateTemplate_CanceledContext(t *testing.T) {
+	seed := int64(3)
+	resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, StaticCount: 2, Seed: &seed,
+	})
+	require.NoError(t, err)
+
+	_, err = RegenerateTemplate(canceledContext(), RegenerateRequest{Payload: resp.Payload, StaticCount: 2})
+	stepErr := requireStepError(t, err, "regenerate", context.Canceled)
+	assert.Equal(t, "soft edge", stepErr.Step)
+}
+
+func TestGenerateBatch_CanceledContext(t *testing.T) {
+	_, err := GenerateBatch(canceledContext(), BatchGenerateRequest{
+		Shape:   "fullroom",
+		Request: json.RawMessage(`{"width": 20, "height": 12, "doors": ["left", "right"]}`),
+		Count:   4,
+	})
+	requireStepError(t, err, "fullroom", context.Canceled)
+	assert.Contains(t, err.Error(), "batch stopped with 0 of 4 variants generated")
+}
+
+// countingCreator records saved templates
+type countingCreator struct {
+	saved     int
+	templates []model.Template
+	afterSave func() // Called after each save (optional)
+}
+
+func (c *countingCreator) Create(ctx context.Context, template model.Template) (*model.Template, error) {
+	c.saved++
+	c.templates = append(c.templates, template)
+	if c.afterSave != nil {
+		c.afterSave()
+	}
+	return &template, nil
+}
+
+func TestAutoFill_CanceledContext(t *testing.T) {
+	deficit := func(key string) map[string]model.DimensionStat {
+		return map[string]model.DimensionStat{key: {Required: 2, Deficit: 2}}
+	}
+	stats := &model.ProjectStats{TotalRooms: 2, Shape: deficit("full"), Door: deficit("10"), Stage: deficit("teaching")}
+	creator := &countingCreator{}
+
+	result, err := AutoFill(canceledContext(), &model.Project{Name: "cancel"}, stats, creator)
+	requireStepError(t, err, "fullroom", context.Canceled)
+	assert.Equal(t, 0, creator.saved)
+	require.NotNil(t, result)
+	assert.Equal(t, 0, result.TotalGenerated)
+}
+
+func TestAutoFill_CanceledAfterFirstRoom(t *testing.T) {
+	deficit := func(key string) map[string]model.DimensionStat {
+		return map[string]model.DimensionStat{key: {Required: 2, Deficit: 2}}
+	}
+	stats := &model.ProjectStats{TotalRooms: 2, Shape: deficit("full"), Door: deficit("10"), Stage: deficit("teaching")}
+	ctx, cancel := context.WithCancel(context.Background())
+	creator := &countingCreator{afterSave: cancel}
+
+	// The partial result reports the room saved before the cut
+	result, err := AutoFill(ctx, &model.Project{Name: "cancel"}, stats, creator)
+	requireStepError(t, err, "fullroom", context.Canceled)
+	require.NotNil(t, result)
+	assert.Equal(t, 1, creator.saved)
+	assert.Equal(t, 1, result.TotalGenerated)
+	require.Len(t, result.Items, 1)
+	assert.NotNil(t, result.Items[0].TemplateID)
+}
@@ -0,0 +1,660 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"tile-backend/internal/model"
+)
+
+const (
+	defaultCaveVoidProbability  = 0.45 // initial chance a cell starts as void
+	defaultCaveSmoothIterations = 5    // cellular-automata smoothing passes
+	maxCaveSmoothIterations     = 10
+	caveMinIslandSize           = 6 // smaller ground pockets are dropped before linking
+)
+
+// CaveGenerateRequest represents the request for generating a cave room
+type CaveGenerateRequest struct {
+	Width                 int                    `json:"width"`
+	Height                int                    `json:"height"`
+	Doors                 []DoorPosition         `json:"doors"`                           // Door sides (optional, zero doors allowed)
+	DoorDescriptors       []model.DoorDescriptor `json:"doorDescriptors,omitempty"`       // Door openings with side, offset and width; replaces doors when set (optional)
+	Symmetry              Symmetry               `json:"symmetry,omitempty"`              // Whole-room symmetry: none, horizontal, vertical, both, rotational-180 (optional, default none)
+	VoidProbability       float64                `json:"voidProbability"`                 // Initial chance a cell starts as void (optional, default 0.45, 0.1-0.7)
+	SmoothIterations      int                    `json:"smoothIterations"`                // Cellular-automata smoothing passes (optional, default 5, max 10)
+	SoftEdgeCount         int                    `json:"softEdgeCount"`                   // Suggested number of soft edges to place (optional)
+	RailEnabled           bool                   `json:"railEnabled"`                     // Whether to generate rail layer (optional)
+	HazardCount           int                    `json:"hazardCount"`                     // Suggested number of hazard strips (spikes, lava) to lay (optional)
+	StaticCount           int                    `json:"staticCount"`                     // Suggested number of statics to place (optional)
+	ChaserCount           int                    `json:"chaserCount"`                     // Suggested number of chasers to place (optional)
+	ZonerCount            int                    `json:"zonerCount"`                      // Suggested number of zoners to place (optional)
+	DPSCount              int                    `json:"dpsCount"`                        // Suggested number of DPS to place (optional)
+	MobAirCount           int                    `json:"mobAirCount"`                     // Suggested number of mob air (fly) to place (optional)
+	PickupCount           int                    `json:"pickupCount"`                     // Suggested number of pickups (chests, health) to place (optional)
+	StageType             string                 `json:"stageType"`                       // Room stage type (optional)
+	StageOverrides        model.StageOverrides   `json:"-"`                               // Project stage overrides, applied on top of the stage config (set from project_id, not read from the body)
+	RoomCategory          string                 `json:"roomCategory"`                    // Room category: normal, basement, test, cave (optional, default: cave)
+	Seed                  *int64                 `json:"seed,omitempty"`                  // Random seed for reproducible output (optional, random if omitted)
+	Trace                 bool                   `json:"-"`                               // Record a frame per pipeline step and rollback (set from the trace query parameter, not read from the body)
+	DifficultyTarget      *DifficultyTarget      `json:"difficultyTarget,omitempty"`      // Resample until the difficulty score is in range (optional)
+	MaxValidationAttempts int                    `json:"maxValidationAttempts,omitempty"` // Rooms to try until one passes strict validation (optional, default 3, max 20)
+	ConstraintMasks                              // Designer masks forceGround, forceVoid, noEnemy, noStatic (optional)
+}
+
+// CaveGenerateResponse represents the generated template
+type CaveGenerateResponse struct {
+	Payload          model.TemplatePayload   `json:"payload"`
+	DebugInfo        *CaveDebugInfo          `json:"debugInfo,omitempty"`
+	Difficulty       *DifficultyScore        `json:"difficulty,omitempty"`
+	Seed             int64                   `json:"seed"`                       // Seed used for this generation
+	DifficultyTarget *DifficultyTargetResult `json:"difficultyTarget,omitempty"` // Target search outcome (only when difficultyTarget was requested)
+	Trace            []TraceFrame            `json:"trace,omitempty"`            // Step-by-step layer frames (only when trace was requested)
+}
+
+// CaveDebugInfo contains debug information about the cave generation process
+type CaveDebugInfo struct {
+	Ground      *CaveGroundDebugInfo  `json:"ground,omitempty"`
+	SoftEdge    *SoftEdgeDebugInfo    `json:"softEdge,omitempty"`
+	BridgeLayer *BridgeLayerDebugInfo `json:"bridgeLayer,omitempty"`
+	Rail        *RailDebugInfo        `json:"rail,omitempty"`
+	Hazard      *HazardDebugInfo      `json:"hazard,omitempty"`
+	MainPath    *MainPathDebugInfo    `json:"mainPath,omitempty"`
+	Static      *StaticDebugInfo      `json:"static,omitempty"`
+	Chaser      *EnemyLayerDebugInfo  `json:"chaser,omitempty"`
+	Zoner       *EnemyLayerDebugInfo  `json:"zoner,omitempty"`
+	DPS         *EnemyLayerDebugInfo  `json:"dps,omitempty"`
+	MobAir      *MobAirDebugInfo      `json:"mobAir,omitempty"`
+	Pickup      *PickupDebugInfo      `json:"pickup,omitempty"`
+	Waves       *WaveDebugInfo        `json:"waves,omitempty"`
+	Patrol      *PatrolDebugInfo      `json:"patrol,omitempty"`
+	Constraints *ConstraintDebugInfo  `json:"constraints,omitempty"`
+	Symmetry    *SymmetryDebugInfo    `json:"symmetry,omitempty"`
+	Validation  *ValidationDebugInfo  `json:"validation,omitempty"`
+}
+
+// CaveGroundDebugInfo contains debug info for cave ground generation
+type CaveGroundDebugInfo struct {
+	VoidProbability    float64 `json:"voidProbability"`
+	SmoothIterations   int     `json:"smoothIterations"`
+	InitialGroundCells int     `json:"initialGroundCells"` // Ground cells after the random fill
+	SmoothedCells      int     `json:"smoothedCells"`      // Ground cells after smoothing
+	RemovedPockets     int     `json:"removedPockets"`     // Small ground pockets dropped before linking
+	LinkedIslands      int     `json:"linkedIslands"`      // Islands joined to the main cave by tunnels
+	DoorTunnels        int     `json:"doorTunnels"`        // Extra door-to-door tunnels needed after linking
+	GroundCells        int     `json:"groundCells"`        // Final ground cells
+}
+
+// caveGenerator is the registry entry for cave rooms
+var caveGenerator = &shapeGenerator[CaveGenerateRequest, CaveGenerateResponse]{
+	info: ShapeInfo{
+		Name:         "cave",
+		RoomType:     "cave",
+		PayloadShape: "cave",
+		Doors:        allDoorSides,
+		MinDoors:     0,
+		MinWidth:     4,
+		MinHeight:    4,
+	},
+	generate: GenerateCave,
+	setOptions: func(req *CaveGenerateRequest, opts GenerateOptions) {
+		opts.apply(&req.Seed, &req.StageOverrides, &req.Trace)
+	},
+	fromParams: func(p RoomParams) CaveGenerateRequest {
+		return CaveGenerateRequest{Width: p.Width, Height: p.Height, Doors: p.Doors, StageType: p.StageType, RoomCategory: "cave"}
+	},
+	result: func(resp *CaveGenerateResponse) (*model.TemplatePayload, *DifficultyScore, *ValidationDebugInfo) {
+		return &resp.Payload, resp.Difficulty, resp.DebugInfo.Validation
+	},
+}
+
+// GenerateCave generates a cave-type room with cellular-automata ground
+// (see generateSampled).
+func GenerateCave(ctx context.Context, req CaveGenerateRequest) (*CaveGenerateResponse, error) {
+	return generateSampled(ctx, req, generateCave)
+}
+
+func (r *CaveGenerateRequest) sampling() sampledRequest {
+	return sampledRequest{target: &r.DifficultyTarget, seed: &r.Seed, maxValidationAttempts: r.MaxValidationAttempts}
+}
+
+func (r *CaveGenerateResponse) sampling() sampledResponse {
+	return sampledResponse{payload: &r.Payload, difficulty: r.Difficulty, seed: &r.Seed, target: &r.DifficultyTarget, validation: &r.DebugInfo.Validation}
+}
+
+// generateCave generates one room from the request seed, without self-validation
+func generateCave(ctx context.Context, req CaveGenerateRequest) (*CaveGenerateResponse, error) {
+	// Validate input
+	if req.Width < 4 || req.Width > 200 {
+		return nil, fmt.Errorf("width must be between 4 and 200")
+	}
+	if req.Height < 4 || req.Height > 200 {
+		return nil, fmt.Errorf("height must be between 4 and 200")
+	}
+	if req.VoidProbability == 0 {
+		req.VoidProbability = defaultCaveVoidProbability
+	}
+	if req.VoidProbability < 0.1 || req.VoidProbability > 0.7 {
+		return nil, fmt.Errorf("voidProbability must be between 0.1 and 0.7")
+	}
+	if req.SmoothIterations == 0 {
+		req.SmoothIterations = defaultCaveSmoothIterations
+	}
+	if req.SmoothIterations < 1 || req.SmoothIterations > maxCaveSmoothIterations {
+		return nil, fmt.Errorf("smoothIterations must be between 1 and %d", maxCaveSmoothIterations)
+	}
+	if err := ValidateRoomCategory(req.RoomCategory); err != nil {
+		return nil, err
+	}
+	if err := req.ConstraintMasks.Validate(req.Width, req.Height); err != nil {
+		return nil, err
+	}
+	if err := ValidateSymmetry(req.Symmetry); err != nil {
+		return nil, err
+	}
+	if err := req.Symmetry.checkMasks(&req.ConstraintMasks, req.Width, req.Height); err != nil {
+		return nil, err
+	}
+	// Resolve door openings; descriptors replace the side list when given
+	doorPositions, sides, descriptors, err := resolveDoors(req.Doors, req.DoorDescriptors, req.Width, req.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mirror the doors so they match the symmetric room
+	addedDoors := 0
+	if req.Symmetry.enabled() {
+		requested := descriptors
+		if doorPositions, sides, descriptors, err = symmetricDoors(req.Symmetry, descriptors, req.Width, req.Height); err != nil {
+			return nil, err
+		}
+		addedDoors = countAddedDoors(requested, descriptors)
+	}
+
+	// All randomness for this request flows from a single seeded source
+	rng, seed := newRequestRand(req.Seed)
+	masks := &req.ConstraintMasks
+	trace := newTrace(req.Trace)
+	steps := newStepTracker(ctx, "cave")
+
+	debugInfo := &CaveDebugInfo{}
+
+	// Create layers
+	ground := createEmptyLayer(req.Width, req.Height)
+	emptyLayer := createEmptyLayer(req.Width, req.Height)
+
+	// Steps 1-5: cellular-automata ground with linked doors
+	groundDebug := generateCaveGround(rng, ground, masks, req.Width, req.Height, doorPositions, req.VoidProbability, req.SmoothIterations)
+	trace.snapshot("cave ground", map[string][][]int{"ground": ground})
+
+	// Step 6: Apply designer forceGround / forceVoid masks
+	constraintReasons := applyGroundConstraints(ground, masks, doorPositions, req.Width, req.Height, trace)
+	if !masks.isEmpty() {
+		trace.snapshot("constraints", map[string][][]int{"ground": ground})
+	}
+
+	// Symmetry: mirror the ground so every later layer is placed on a symmetric room
+	if req.Symmetry.enabled() {
+		debugInfo.Symmetry = symmetrizeGround(req.Symmetry, ground, masks, constraintReasons, req.Width, req.Height)
+		debugInfo.Symmetry.AddedDoors = addedDoors
+		trace.snapshot("symmetric ground", map[string][][]int{"ground": ground})
+	}
+	groundDebug.GroundCells = countCells(ground)
+
+	debugInfo.Ground = groundDebug
+	if err := steps.done("ground"); err != nil {
+		return nil, err
+	}
+
+	// Generate other layers using shared functions
+	// Soft edge
+	softEdgeLayer := copyLayer(emptyLayer)
+	if req.SoftEdgeCount > 0 {
+		softEdgeDebug := generateSoftEdgeLayerWithDebug(rng, softEdgeLayer, ground, doorPositions, req.Width, req.Height, req.SoftEdgeCount)
+		debugInfo.SoftEdge = softEdgeDebug
+		trace.snapshot("soft edge", map[string][][]int{"softEdge": softEdgeLayer})
+	} else {
+		debugInfo.SoftEdge = &SoftEdgeDebugInfo{
+			Skipped:    true,
+			SkipReason: "softEdgeCount is 0 or not specified",
+		}
+	}
+	if err := steps.done("soft edge"); err != nil {
+		return nil, err
+	}
+
+	// Bridge layer — caves are a single connected region, so like fullrooms
+	// they never have bridge tiles.
+	bridgeLayer := copyLayer(emptyLayer)
+	debugInfo.BridgeLayer = &BridgeLayerDebugInfo{
+		Skipped:    true,
+		SkipReason: "caves do not use bridge tiles",
+	}
+
+	// Rail layer
+	railLayer := copyLayer(emptyLayer)
+	if req.RailEnabled {
+		railDebug := GenerateRailLayer(rng, railLayer, ground, bridgeLayer, req.Width, req.Height)
+		debugInfo.Rail = railDebug
+		trace.snapshot("rail", map[string][][]int{"rail": railLayer})
+	} else {
+		debugInfo.Rail = &RailDebugInfo{
+			Skipped:    true,
+			SkipReason: "railEnabled is false or not specified",
+		}
+	}
+	if err := steps.done("rail"); err != nil {
+		return nil, err
+	}
+
+	// Hazard layer
+	hazardLayer := copyLayer(emptyLayer)
+	if req.HazardCount > 0 {
+		debugInfo.Hazard = GenerateHazardLayer(ctx, rng, hazardLayer, ground, bridgeLayer, railLayer, emptyLayer, doorPositions, req.Width, req.Height, req.HazardCount)
+		trace.snapshot("hazard", map[string][][]int{"hazard": hazardLayer})
+	} else {
+		debugInfo.Hazard = &HazardDebugInfo{Skipped: true, SkipReason: "hazardCount is 0 or not specified"}
+	}
+	if err := steps.done("hazard"); err != nil {
+		return nil, err
+	}
+	// Statics and enemies keep off hazards
+	placementMasks := masks.withBlocked(hazardLayer)
+
+	// Apply stage rules (validate + override counts if stage type specified)
+	stageResult, stageErr := ValidateAndApplyStage(rng, req.StageType, "cave", req.StageOverrides, sides, ground, req.Width, req.Height)
+	if stageErr != nil {
+		return nil, stageErr
+	}
+	var hints *StagePlacementHints
+	if stageResult != nil && stageResult.Valid && req.StageType != "" {
+		req.ChaserCount = stageResult.ChaserCount
+		req.ZonerCount = stageResult.ZonerCount
+		req.DPSCount = stageResult.DPSCount
+		req.MobAirCount = stageResult.MobAirCount
+		hints = stageResult.PlacementHints
+	}
+
+	// Main path computation
+	mainPathData, mainPathDebug := ComputeMainPath(ground, bridgeLayer, hazardLayer, doorPositions, req.Width, req.Height)
+	debugInfo.MainPath = mainPathDebug
+	if err := steps.done("main path"); err != nil {
+		return nil, err
+	}
+
+	// Statics and enemies are placed on grids, read back as layers once placement is done
+	lc := newLayerContext(ground, softEdgeLayer, bridgeLayer, railLayer, doorPositions, req.Width, req.Height)
+	lc.MainPath = mainPathData
+
+	// Static layer
+	if req.StaticCount > 0 {
+		staticDebug := generateStaticLayerWithDebugAndRail(ctx, rng, lc, placementMasks, req.StaticCount)
+		debugInfo.Static = staticDebug
+		trace.snapshotGrids("static", map[string]*Grid{"static": lc.Static})
+	} else {
+		debugInfo.Static = &StaticDebugInfo{
+			Skipped:    true,
+			SkipReason: "staticCount is 0 or not specified",
+		}
+	}
+	if err := steps.done("static"); err != nil {
+		return nil, err
+	}
+
+	// Use grouped or default placement depending on hints
+	if hints != nil && hints.GroupCount > 0 && len(hints.Groups) > 0 {
+		// Grouped placement — place enemies per region
+		for _, group := range hints.Groups {
+			minY, maxY, minX, maxX := GetRegionBounds(group.Region, req.Width, req.Height)
+			regionFilter := &RegionFilter{MinY: minY, MaxY: maxY, MinX: minX, MaxX: maxX}
+
+			if group.ZonerCount > 0 {
+				GenerateZonerLayer(ctx, rng, lc, placementMasks, group.ZonerCount, regionFilter)
+			}
+			if group.ChaserCount > 0 {
+				GenerateChaserLayer(ctx, rng, lc, placementMasks, group.ChaserCount, regionFilter)
+			}
+			if group.DPSCount > 0 {
+				GenerateDPSLayer(ctx, rng, lc, placementMasks, group.DPSCount, regionFilter)
+			}
+			if group.MobAirCount > 0 {
+				GenerateMobAirLayerNew(ctx, lc, placementMasks, group.MobAirCount, nil)
+			}
+		}
+
+		// Fallback: if grouped placement underplaced, fill remaining up to target
+		// using full-room placement (no region restriction). This guarantees that
+		// the stage minimum count is always met even when a region has too few
+		// valid positions (e.g. pressure stage chaser min=6 with tight room).
+		//
+		// Two-pass strategy:
+		//   1. Strict pass (respects 8-dir spacing) — preserves ideal spread.
+		//   2. Relaxed pass (drops spacing) — only used when strict pass still falls short,
+		//      guaranteeing the minimum is always met.
+		if remaining := req.ZonerCount - lc.Zoner.Count(); remaining > 0 {
+			GenerateZonerLayer(ctx, rng, lc, placementMasks, remaining, nil)
+		}
+		if remaining := req.ChaserCount - lc.Chaser.Count(); remaining > 0 {
+			GenerateChaserLayer(ctx, rng, lc, placementMasks, remaining, nil)
+			// Relaxed fallback: if strict pass still can't fill target, drop spacing constraint.
+			if remaining2 := req.ChaserCount - lc.Chaser.Count(); remaining2 > 0 {
+				GenerateChaserLayerRelaxed(ctx, rng, lc, placementMasks, remaining2)
+			}
+		}
+		if remaining := req.DPSCount - lc.DPS.Count(); remaining > 0 {
+			GenerateDPSLayer(ctx, rng, lc, placementMasks, remaining, nil)
+			// Relaxed fallback: if strict pass still can't fill target, drop spacing constraint.
+			if remaining2 := req.DPSCount - lc.DPS.Count(); remaining2 > 0 {
+				GenerateDPSLayerRelaxed(ctx, rng, lc, placementMasks, remaining2)
+			}
+		}
+		if remaining := req.MobAirCount - lc.MobAir.Count(); remaining > 0 {
+			GenerateMobAirLayerNew(ctx, lc, placementMasks, remaining, nil)
+		}
+
+		// Count placed for debug
+		debugInfo.Zoner = countLayerDebug(lc.Zoner, req.ZonerCount, "zoner")
+		debugInfo.Chaser = countLayerDebug(lc.Chaser, req.ChaserCount, "chaser")
+		debugInfo.DPS = countLayerDebug(lc.DPS, req.DPSCount, "dps")
+		debugInfo.MobAir = &MobAirDebugInfo{TargetCount: req.MobAirCount, PlacedCount: lc.MobAir.Count(), Strategy: "grouped"}
+	} else {
+		// Default placement (no grouping)
+		// Build region filter from hints
+		var dpsFilter, chaserFilter *RegionFilter
+		if hints != nil && hints.DPSYRange != [2]int{0, 0} {
+			dpsFilter = &RegionFilter{MinY: hints.DPSYRange[0], MaxY: hints.DPSYRange[1] + 1, MinX: 0, MaxX: req.Width}
+		}
+		if hints != nil && hints.ChaserCenterY {
+			centerY := req.Height / 2
+			chaserFilter = &RegionFilter{MinY: centerY - req.Height/4, MaxY: centerY + req.Height/4, MinX: 0, MaxX: req.Width}
+		}
+
+		if req.ZonerCount > 0 {
+			var zonerFilter *RegionFilter
+			if hints != nil && hints.ZonerCentral {
+				cx, cy := req.Width/2, req.Height/2
+				zonerFilter = &RegionFilter{MinY: cy - 3, MaxY: cy + 3, MinX: cx - 3, MaxX: cx + 3}
+			}
+			zonerDebug := GenerateZonerLayer(ctx, rng, lc, placementMasks, req.ZonerCount, zonerFilter)
+			debugInfo.Zoner = zonerDebug
+		} else {
+			debugInfo.Zoner = &EnemyLayerDebugInfo{Skipped: true, SkipReason: "zonerCount is 0 or not specified"}
+		}
+
+		if req.ChaserCount > 0 {
+			chaserDebug := GenerateChaserLayer(ctx, rng, lc, placementMasks, req.ChaserCount, chaserFilter)
+			debugInfo.Chaser = chaserDebug
+		} else {
+			debugInfo.Chaser = &EnemyLayerDebugInfo{Skipped: true, SkipReason: "chaserCount is 0 or not specified"}
+		}
+
+		if req.DPSCount > 0 {
+			dpsDebug := GenerateDPSLayer(ctx, rng, lc, placementMasks, req.DPSCount, dpsFilter)
+			debugInfo.DPS = dpsDebug
+		} else {
+			debugInfo.DPS = &EnemyLayerDebugInfo{Skipped: true, SkipReason: "dpsCount is 0 or not specified"}
+		}
+
+		if req.MobAirCount > 0 {
+			mobAirDebug := GenerateMobAirLayerNew(ctx, lc, placementMasks, req.MobAirCount, nil)
+			debugInfo.MobAir = mobAirDebug
+		} else {
+			debugInfo.MobAir = &MobAirDebugInfo{Skipped: true, SkipReason: "mobAirCount is 0 or not specified"}
+		}
+	}
+
+	staticLayer := lc.Static.Layer()
+	zonerLayer := lc.Zoner.Layer()
+	chaserLayer := lc.Chaser.Layer()
+	dpsLayer := lc.DPS.Layer()
+	mobAirLayer := lc.MobAir.Layer()
+
+	// Each enemy type only writes its own layer, so one frame per type shows its placement
+	trace.snapshot("zoner", map[string][][]int{"zoner": zonerLayer})
+	trace.snapshot("chaser", map[string][][]int{"chaser": chaserLayer})
+	trace.snapshot("dps", map[string][][]int{"dps": dpsLayer})
+	trace.snapshot("mobAir", map[string][][]int{"mobAir": mobAirLayer})
+	if err := steps.done("enemies"); err != nil {
+		return nil, err
+	}
+
+	// Pickup layer
+	pickupLayer := copyLayer(emptyLayer)
+	if req.PickupCount > 0 {
+		debugInfo.Pickup = GeneratePickupLayer(rng, pickupLayer, ground, bridgeLayer, railLayer, emptyLayer, hazardLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, doorPositions, mainPathData, req.Width, req.Height, req.PickupCount)
+		trace.snapshot("pickup", map[string][][]int{"pickup": pickupLayer})
+	} else {
+		debugInfo.Pickup = &PickupDebugInfo{Skipped: true, SkipReason: "pickupCount is 0 or not specified"}
+	}
+	if err := steps.done("pickup"); err != nil {
+		return nil, err
+	}
+
+	// Create door states
+	doorStates := model.DoorStatesFromDescriptors(descriptors)
+
+	// Symmetry: copy the source region of every layer onto the rest of the room,
+	// then recompute the main path over the mirrored bridges
+	if req.Symmetry.enabled() {
+		debugInfo.Symmetry.MirroredCells = mirrorLayers(req.Symmetry, req.Width, req.Height, softEdgeLayer, bridgeLayer, railLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, pickupLayer, hazardLayer)
+		debugInfo.Symmetry.RemovedCells += dropCuttingStatics(req.Symmetry, staticLayer, ground, doorPositions, req.Width, req.Height)
+		if n := dropCuttingHazards(hazardLayer, ground, bridgeLayer, doorPositions, req.Width, req.Height); n > 0 {
+			debugInfo.Symmetry.RemovedCells += n
+			debugInfo.Symmetry.HazardDropped = true
+		}
+		mainPathData, debugInfo.MainPath = ComputeMainPath(ground, bridgeLayer, hazardLayer, doorPositions, req.Width, req.Height)
+	}
+
+	// Build main path layer for output
+	mainPathLayer := copyLayer(emptyLayer)
+	if mainPathData != nil {
+		for y := 0; y < req.Height; y++ {
+			for x := 0; x < req.Width; x++ {
+				if mainPathData.OnMainPath[y][x] {
+					mainPathLayer[y][x] = 1
+				}
+			}
+		}
+	}
+
+	// Create payload
+	roomShape := "cave"
+	roomCategory := req.RoomCategory
+	if roomCategory == "" {
+		roomCategory = "cave"
+	}
+	stageStr := req.StageType
+	if stageStr == "" {
+		stageStr = "default"
+	}
+	stageType := &stageStr
+	payload := model.TemplatePayload{
+		Ground:          ground,
+		SoftEdge:        softEdgeLayer,
+		Bridge:          bridgeLayer,
+		Rail:            railLayer,
+		Static:          staticLayer,
+		Chaser:          chaserLayer,
+		Zoner:           zonerLayer,
+		DPS:             dpsLayer,
+		MobAir:          mobAirLayer,
+		MainPath:        mainPathLayer,
+		Doors:           doorStates,
+		DoorDescriptors: descriptors,
+		StageType:       stageType,
+		RoomShape:       &roomShape,
+		RoomCategory:    &roomCategory,
+		OpenDoors:       model.ComputeOpenDoors(doorStates),
+		Meta: model.TemplateMeta{
+			Name:    fmt.Sprintf("cave-%dx%d", req.Width, req.Height),
+			Version: 1,
+			Width:   req.Width,
+			Height:  req.Height,
+		},
+	}
+
+	if req.HazardCount > 0 {
+		payload.Hazard = hazardLayer
+	}
+	if req.PickupCount > 0 {
+		payload.Pickup = pickupLayer
+	}
+	payload.SetLineSegments()
+
+	// Validate the mirrored room, clearing mirrored cells that break a rule
+	if req.Symmetry.enabled() {
+		if err := finishSymmetricPayload(req.Symmetry, &payload, debugInfo.Symmetry); err != nil {
+			return nil, err
+		}
+	}
+
+	// Patrol routes are traced around the final chaser cells
+	payload.ChaserPatrols, debugInfo.Patrol = GenerateChaserPatrols(ctx, rng, req.Symmetry, chaserLayer, ground, staticLayer, hazardLayer, doorPositions, req.Width, req.Height)
+	if err := steps.done("patrols"); err != nil {
+		return nil, err
+	}
+
+	// Spawn waves are assigned to the final enemy cells
+	payload.Waves, debugInfo.Waves = assignWaves(hints, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, req.Width, req.Height)
+	trace.snapshotPayload("final", &payload)
+
+	// Compute difficulty
+	difficulty := ComputeDifficulty(ground, softEdgeLayer, hazardLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, mainPathData, req.Width, req.Height)
+
+	// Report designer masks that could not be honored
+	if !masks.isEmpty() {
+		debugInfo.Constraints = checkConstraints(masks, constraintReasons, ground, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer)
+	}
+
+	return &CaveGenerateResponse{
+		Payload:    payload,
+		DebugInfo:  debugInfo,
+		Difficulty: difficulty,
+		Seed:       seed,
+		Trace:      trace.Frames(),
+	}, nil
+}
+
+// generateCaveGround fills ground with noise, smooths it into organic caverns and
+// links every door into one connected region:
+//
+//  1. Random fill: each cell starts as void with voidProbability
+//  2. Smoothing: 4-5 rule over the 8 neighbours (out of bounds counts as void)
+//  3. Door openings: clear a one-cell margin around every door cell
+//  4. Pocket removal: drop ground islands smaller than caveMinIslandSize
+//  5. Linking: ensureGroundConnectivity tunnels islands into the main cave,
+//     then any doors still apart are joined with an L-shaped tunnel
+//
+// forceGround / forceVoid cells are pinned through steps 1-2 so the automaton
+// grows the cave around them.
+func generateCaveGround(rng *rand.Rand, ground [][]int, masks *ConstraintMasks, width, height int, doors []DoorSite, voidProbability float64, iterations int) *CaveGroundDebugInfo {
+	debug := &CaveGroundDebugInfo{
+		VoidProbability:  voidProbability,
+		SmoothIterations: iterations,
+	}
+
+	// Step 1: Random fill
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ground[y][x] = boolToInt(rng.Float64() >= voidProbability)
+		}
+	}
+	pinCaveCells(ground, masks, width, height)
+	debug.InitialGroundCells = countCells(ground)
+
+	// Step 2: Smoothing
+	for i := 0; i < iterations; i++ {
+		smoothCaveGround(ground, width, height)
+		pinCaveCells(ground, masks, width, height)
+	}
+	debug.SmoothedCells = countCells(ground)
+
+	// Step 3: Door openings
+	doorCells := make(map[Point]bool)
+	for _, door := range doors {
+		for _, c := range door.Cells {
+			for y := c.Y - 1; y <= c.Y+1; y++ {
+				for x := c.X - 1; x <= c.X+1; x++ {
+					if x >= 0 && x < width && y >= 0 && y < height {
+						ground[y][x] = 1
+						doorCells[Point{X: x, Y: y}] = true
+					}
+				}
+			}
+		}
+	}
+
+	// Step 4: Pocket removal (door openings and forceGround cells are never dropped)
+	for _, island := range findAllIslands(ground, width, height) {
+		if len(island.Cells) >= caveMinIslandSize {
+			continue
+		}
+		keep := false
+		for _, c := range island.Cells {
+			if doorCells[c] || masks.forceGroundAt(c.X, c.Y) {
+				keep = true
+				break
+			}
+		}
+		if keep {
+			continue
+		}
+		for _, c := range island.Cells {
+			ground[c.Y][c.X] = 0
+		}
+		debug.RemovedPockets++
+	}
+
+	// Step 5: Linking
+	if islands := findAllIslands(ground, width, height); len(islands) > 1 {
+		debug.LinkedIslands = len(islands) - 1
+		ensureGroundConnectivity(ground, width, height)
+	}
+	for i := 1; i < len(doors) && !areAllDoorsConnected(ground, width, height, doors); i++ {
+		drawLPath(ground, doors[0].Point, doors[i].Point, width, height)
+		debug.DoorTunnels++
+	}
+
+	debug.GroundCells = countCells(ground)
+	return debug
+}
+
+// smoothCaveGround runs one cellular-automata pass: a cell becomes ground with
+// 5+ ground neighbours, void with 3 or fewer, and is unchanged at exactly 4
+func smoothCaveGround(ground [][]int, width, height int) {
+	prev := copyLayer(ground)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			n := 0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := x+dx, y+dy
+					if (dx != 0 || dy != 0) && nx >= 0 && nx < width && ny >= 0 && ny < height {
+						n += prev[ny][nx]
+					}
+				}
+			}
+			if n >= 5 {
+				ground[y][x] = 1
+			} else if n <= 3 {
+				ground[y][x] = 0
+			}
+		}
+	}
+}
+
+// pinCaveCells re-applies forceGround / forceVoid so smoothing cannot drift them
+func pinCaveCells(ground [][]int, masks *ConstraintMasks, width, height int) {
+	if masks.isEmpty() {
+		return
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if masks.forceGroundAt(x, y) {
+				ground[y][x] = 1
+			} else if masks.forceVoidAt(x, y) {
+				ground[y][x] = 0
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCave_DoorsConnected(t *testing.T) {
+	doorSets := [][]DoorPosition{
+		{DoorLeft, DoorRight},
+		{DoorTop, DoorBottom},
+		{DoorTop, DoorRight, DoorBottom, DoorLeft},
+	}
+
+	for _, doors := range doorSets {
+		for i := int64(0); i < 20; i++ {
+			seed := i
+			resp, err := GenerateCave(context.Background(), CaveGenerateRequest{
+				Width:  20,
+				Height: 12,
+				Doors:  doors,
+				Seed:   &seed,
+			})
+			require.NoError(t, err)
+
+			ground := resp.Payload.Ground
+			assert.True(t, areAllDoorsConnected(ground, 20, 12, getDoorCenterPositions(20, 12, doors)), "doors %v seed %d", doors, seed)
+			assert.Len(t, findAllIslands(ground, 20, 12), 1, "doors %v seed %d", doors, seed)
+			for _, door := range getDoorCenterPositions(20, 12, doors) {
+				assert.Equal(t, 1, ground[door.Y][door.X], "door %s seed %d", door.Side, seed)
+			}
+		}
+	}
+}
+
+func TestGenerateCave_OrganicGround(t *testing.T) {
+	seed := int64(7)
+	resp, err := GenerateCave(context.Background(), CaveGenerateRequest{
+		Width:  30,
+		Height: 20,
+		Doors:  []DoorPosition{DoorLeft, DoorRight},
+		Seed:   &seed,
+	})
+	require.NoError(t, err)
+
+	// A cave is neither solid nor empty
+	ground := countCells(resp.Payload.Ground)
+	assert.Greater(t, ground, 30*20/5)
+	assert.Less(t, ground, 30*20)
+
+	debug := resp.DebugInfo.Ground
+	require.NotNil(t, debug)
+	assert.Equal(t, defaultCaveVoidProbability, debug.VoidProbability)
+	assert.Equal(t, defaultCaveSmoothIterations, debug.SmoothIterations)
+	assert.Equal(t, ground, debug.GroundCells)
+
+	require.NotNil(t, resp.Payload.RoomShape)
+	assert.Equal(t, "cave", *resp.Payload.RoomShape)
+	require.NotNil(t, resp.Payload.RoomCategory)
+	assert.Equal(t, "cave", *resp.Payload.RoomCategory)
+	assert.Equal(t, "cave-30x20", resp.Payload.Meta.Name)
+	assert.Equal(t, 0, countCells(resp.Payload.Bridge))
+}
+
+func TestGenerateCave_Reproducible(t *testing.T) {
+	seed := int64(99)
+	req := CaveGenerateRequest{
+		Width:       24,
+		Height:      16,
+		Doors:       []DoorPosition{DoorTop, DoorLeft},
+		StaticCount: 3,
+		ChaserCount: 2,
+		Seed:        &seed,
+	}
+
+	first, err := GenerateCave(context.Background(), req)
+	require.NoError(t, err)
+	second, err := GenerateCave(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, first.Payload.Ground, second.Payload.Ground)
+	assert.Equal(t, first.Payload.Static, second.Payload.Static)
+	assert.Equal(t, first.Payload.Chaser, second.Payload.Chaser)
+	assert.Equal(t, seed, first.Seed)
+}
+
+func TestGenerateCave_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name string
+		req  CaveGenerateRequest
+	}{
+		{"too narrow", CaveGenerateRequest{Width: 3, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}}},
+		{"void probability too high", CaveGenerateRequest{Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, VoidProbability: 0.9}},
+		{"too many iterations", CaveGenerateRequest{Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, SmoothIterations: maxCaveSmoothIterations + 1}},
+		{"duplicate door", CaveGenerateRequest{Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorLeft}}},
+		{"bad category", CaveGenerateRequest{Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, RoomCategory: "lava"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := GenerateCave(context.Background(), tt.req)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGenerateCave_StageRules(t *testing.T) {
+	seed := int64(4)
+	resp, err := GenerateCave(context.Background(), CaveGenerateRequest{
+		Width:     20,
+		Height:    12,
+		Doors:     []DoorPosition{DoorLeft, DoorRight},
+		StageType: "pressure",
+		Seed:      &seed,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, countCells(resp.Payload.Zoner))
+	assert.GreaterOrEqual(t, countCells(resp.Payload.Chaser), 1)
+
+	// Peak rooms must be full rooms
+	_, err = GenerateCave(context.Background(), CaveGenerateRequest{
+		Width:     20,
+		Height:    12,
+		Doors:     []DoorPosition{DoorLeft, DoorRight},
+		StageType: "peak",
+		Seed:      &seed,
+	})
+	assert.Error(t, err)
+}
+
+func TestGenerateCave_ForceVoidMask(t *testing.T) {
+	seed := int64(2)
+	resp, err := GenerateCave(context.Background(), CaveGenerateRequest{
+		Width:           20,
+		Height:          12,
+		Doors:           []DoorPosition{DoorLeft, DoorRight},
+		Seed:            &seed,
+		ConstraintMasks: ConstraintMasks{ForceVoid: maskRect(20, 12, 8, 0, 12, 3)},
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, resp.DebugInfo.Constraints)
+	assert.Empty(t, resp.DebugInfo.Constraints.Unhonored)
+	for y := 0; y < 3; y++ {
+		for x := 8; x < 12; x++ {
+			assert.Equal(t, 0, resp.Payload.Ground[y][x], "forceVoid cell (%d,%d)", x, y)
+		}
+	}
+}
+
+func TestGenerateBatch_Cave(t *testing.T) {
+	resp, err := GenerateBatch(context.Background(), BatchGenerateRequest{
+		Shape:   "cave",
+		Request: json.RawMessage(`{"width":20,"height":12,"doors":["left","right"],"seed":42}`),
+		Count:   3,
+	})
+	require.NoError(t, err)
+	assert.Len(t, resp.Variants, 3)
+	for _, v := range resp.Variants {
+		require.NotNil(t, v.Payload.RoomShape)
+		assert.Equal(t, "cave", *v.Payload.RoomShape)
+	}
+}
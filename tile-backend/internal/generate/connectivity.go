@@ -0,0 +1,281 @@
+package generate
+
+// doorConnectivity answers "do all doors stay connected if this rectangle is
+// blocked?" for a series of placement candidates without flooding the room
+// for each one. Its walkable grid is ground=1, static=0 cells; accepted
+// placements are recorded with block.
+//
+// A check is answered, in order, by:
+//   - the ring test: if every walkable cell next to the rectangle can reach the
+//     others through the ring of cells around it, any path through the
+//     rectangle can detour along the ring, so nothing is disconnected
+//   - cut vertices (Tarjan): a rectangle holding a cell whose removal alone
+//     separates a door from the first door disconnects the doors; for a single
+//     cell this is the exact answer
+//   - a flood fill that stops once every door is reached, for the remaining
+//     candidates that sit in passages a few cells wide
+//
+// The cut vertices are recomputed lazily, at most once per accepted placement.
+type doorConnectivity struct {
+	doors    []Point
+	walkable *Grid
+	start    Point // Walkable cell nearest the first door, where fills start
+	// connected records whether every door is reachable from start. Accepted
+	// placements keep the doors connected, so it only needs recomputing when a
+	// placement moves start.
+	connected bool
+	cuts      *cutVertices // nil until needed after the last placement
+
+	trial   *Grid // Walkable cells with the candidate blocked
+	reached *Grid // Cells reached by the fallback fill
+}
+
+// newDoorConnectivity returns a checker for the doors over ground=1, static=0 cells
+func newDoorConnectivity(ground, staticLayer *Grid, doorPositions []DoorSite) *doorConnectivity {
+	walkable := ground.Clone()
+	walkable.AndNot(staticLayer)
+	c := &doorConnectivity{
+		doors:    doorCenters(doorPositions),
+		walkable: walkable,
+		trial:    NewGrid(ground.Width(), ground.Height()),
+		reached:  NewGrid(ground.Width(), ground.Height()),
+	}
+	if len(c.doors) >= 2 {
+		c.start = walkable.Nearest(c.doors[0])
+		c.trial.CopyFrom(walkable)
+		c.connected = c.trial.connects(c.start, c.doors[1:], c.reached)
+	}
+	return c
+}
+
+// block records an accepted placement: the w×h rectangle at pos is no longer
+// walkable. Only rectangles allowsBlock accepted may be blocked.
+func (c *doorConnectivity) block(pos Point, w, h int) {
+	c.walkable.ClearRect(pos.X, pos.Y, w, h)
+	c.cuts = nil
+	if len(c.doors) < 2 {
+		return
+	}
+	// Fills now start from another cell if the nearest one was blocked
+	if start := c.walkable.Nearest(c.doors[0]); start != c.start {
+		c.start = start
+		c.trial.CopyFrom(c.walkable)
+		c.connected = c.trial.connects(c.start, c.doors[1:], c.reached)
+	}
+}
+
+// allowsBlock reports whether all doors stay connected with the w×h rectangle
+// at pos blocked. It gives the same answer as flooding the walkable cells with
+// the rectangle blocked, from the cell nearest the first door.
+func (c *doorConnectivity) allowsBlock(pos Point, w, h int) bool {
+	if len(c.doors) < 2 {
+		return true
+	}
+
+	inRect := func(p Point) bool {
+		return p.X >= pos.X && p.X < pos.X+w && p.Y >= pos.Y && p.Y < pos.Y+h
+	}
+	if !c.walkable.AnyInRect(pos.X, pos.Y, w, h) {
+		return c.connected // Nothing new is blocked
+	}
+	if inRect(c.start) || c.start.X < 0 {
+		return c.flood(pos, w, h) // The fill would start elsewhere
+	}
+	for _, door := range c.doors[1:] {
+		if inRect(door) {
+			return false // A blocked door is never reached
+		}
+	}
+	if !c.connected {
+		return false // Blocking cells cannot connect the doors
+	}
+
+	if c.ringConnected(pos, w, h) {
+		return true
+	}
+
+	if c.cuts == nil {
+		c.cuts = findCutVertices(c.walkable, c.start)
+	}
+	for y := pos.Y; y < pos.Y+h; y++ {
+		for x := pos.X; x < pos.X+w; x++ {
+			if c.walkable.Has(x, y) && c.cuts.separates(Point{X: x, Y: y}, c.doors[1:]) {
+				return false
+			}
+		}
+	}
+	if w == 1 && h == 1 {
+		return true // A single cell that is not a separating cut vertex
+	}
+	return c.flood(pos, w, h)
+}
+
+// ringConnected reports whether the walkable cells next to the rectangle all
+// lie on one run of walkable cells around its ring (the rectangle grown by one
+// cell, corners included). Blocking the rectangle then disconnects nothing.
+func (c *doorConnectivity) ringConnected(pos Point, w, h int) bool {
+	ring := rectangleRing(pos.X-1, pos.Y-1, w+2, h+2)
+
+	// Start at a blocked ring cell so runs do not wrap around the start
+	first := -1
+	for i, p := range ring {
+		if !c.walkable.Has(p.X, p.Y) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return true // The whole ring is walkable
+	}
+
+	runs := 0 // Runs that touch a walkable cell of the rectangle
+	touching := false
+	for k := 1; k <= len(ring); k++ {
+		p := ring[(first+k)%len(ring)]
+		if !c.walkable.Has(p.X, p.Y) {
+			if touching {
+				runs++
+				touching = false
+			}
+			continue
+		}
+		if c.touchesRect(p, pos, w, h) {
+			touching = true
+		}
+	}
+	return runs <= 1
+}
+
+// touchesRect reports whether p is 4-adjacent to a walkable cell of the rectangle
+func (c *doorConnectivity) touchesRect(p, pos Point, w, h int) bool {
+	for _, d := range [4]Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+		n := Point{X: p.X + d.X, Y: p.Y + d.Y}
+		if n.X >= pos.X && n.X < pos.X+w && n.Y >= pos.Y && n.Y < pos.Y+h && c.walkable.Has(n.X, n.Y) {
+			return true
+		}
+	}
+	return false
+}
+
+// flood answers the check by filling the walkable cells with the rectangle
+// blocked, stopping once every door is reached
+func (c *doorConnectivity) flood(pos Point, w, h int) bool {
+	c.trial.CopyFrom(c.walkable)
+	c.trial.ClearRect(pos.X, pos.Y, w, h)
+	return c.trial.connects(c.trial.Nearest(c.doors[0]), c.doors[1:], c.reached)
+}
+
+// cutVertices holds a depth-first search of the walkable cells from one root,
+// with Tarjan's low-links, so single-cell removals can be judged without a
+// new search. Cells are numbered by their row-major index.
+type cutVertices struct {
+	width  int
+	disc   []int32 // Discovery order, from 1; 0 for cells the search did not reach
+	low    []int32 // Lowest discovery order reachable from the cell's subtree through one back edge
+	last   []int32 // Highest discovery order in the cell's subtree
+	parent []int32 // Search-tree parent, -1 for the root and unreached cells
+}
+
+// findCutVertices searches the walkable cells reachable from root. The search
+// is iterative, so room size does not bound it by stack depth.
+func findCutVertices(walkable *Grid, root Point) *cutVertices {
+	width, n := walkable.Width(), walkable.Width()*walkable.Height()
+	cv := &cutVertices{
+		width:  width,
+		disc:   make([]int32, n),
+		low:    make([]int32, n),
+		last:   make([]int32, n),
+		parent: make([]int32, n),
+	}
+	for i := range cv.parent {
+		cv.parent[i] = -1
+	}
+	if !walkable.Has(root.X, root.Y) {
+		return cv
+	}
+
+	type frame struct {
+		cell, next int32 // next is the index of the next direction to try
+	}
+	dirs := [4]Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+	order := int32(0)
+	visit := func(i int32) {
+		order++
+		cv.disc[i], cv.low[i] = order, order
+	}
+
+	rootIdx := int32(root.Y*width + root.X)
+	visit(rootIdx)
+	stack := []frame{{cell: rootIdx}}
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		u := top.cell
+		if top.next < 4 {
+			d := dirs[top.next]
+			top.next++
+			x, y := int(u)%width+d.X, int(u)/width+d.Y
+			if !walkable.Has(x, y) {
+				continue
+			}
+			v := int32(y*width + x)
+			switch {
+			case cv.disc[v] == 0:
+				cv.parent[v] = u
+				visit(v)
+				stack = append(stack, frame{cell: v})
+			case v != cv.parent[u] && cv.disc[v] < cv.low[u]:
+				cv.low[u] = cv.disc[v] // Back edge
+			}
+			continue
+		}
+
+		// u is finished: its subtree spans discovery orders disc[u]..order
+		cv.last[u] = order
+		stack = stack[:len(stack)-1]
+		if p := cv.parent[u]; p >= 0 && cv.low[u] < cv.low[p] {
+			cv.low[p] = cv.low[u]
+		}
+	}
+	return cv
+}
+
+// separates reports whether removing cell c cuts any of the targets off from
+// the root. Targets the search did not reach are ignored, as is c being the root.
+func (cv *cutVertices) separates(c Point, targets []Point) bool {
+	ci := int32(c.Y*cv.width + c.X)
+	if cv.disc[ci] == 0 || cv.parent[ci] < 0 {
+		return false
+	}
+	for _, t := range targets {
+		td := cv.disc[t.Y*cv.width+t.X]
+		if td == 0 || td <= cv.disc[ci] || td > cv.last[ci] {
+			continue // Unreached, c itself, or outside c's subtree
+		}
+		// Find the child subtree holding the target; it is stranded when no
+		// back edge from it climbs above c
+		for _, child := range cv.children(ci) {
+			if td >= cv.disc[child] && td <= cv.last[child] {
+				if cv.low[child] >= cv.disc[ci] {
+					return true
+				}
+				break
+			}
+		}
+	}
+	return false
+}
+
+// children returns the search-tree children of cell i
+func (cv *cutVertices) children(i int32) []int32 {
+	w, n := int32(cv.width), int32(len(cv.disc))
+	var kids []int32
+	for _, v := range [4]int32{i - w, i + 1, i + w, i - 1} {
+		if v < 0 || v >= n || (v == i+1 && v%w == 0) || (v == i-1 && i%w == 0) {
+			continue
+		}
+		if cv.parent[v] == i {
+			kids = append(kids, v)
+		}
+	}
+	return kids
+}
@@ -0,0 +1,272 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// floodConnectivityAfterBlock is the check doorConnectivity replaced: build a
+// [][]bool walkable map with the w×h rectangle blocked and BFS from the first
+// door. Tests and benchmarks compare against it.
+func floodConnectivityAfterBlock(ground, staticLayer [][]int, doorPositions []DoorSite, pos Point, w, h, width, height int) bool {
+	if len(doorPositions) < 2 {
+		return true
+	}
+	walkable := make([][]bool, height)
+	for y := 0; y < height; y++ {
+		walkable[y] = make([]bool, width)
+		for x := 0; x < width; x++ {
+			walkable[y][x] = ground[y][x] == 1 && staticLayer[y][x] == 0
+		}
+	}
+	for dy := 0; dy < h; dy++ {
+		for dx := 0; dx < w; dx++ {
+			if pos.X+dx < width && pos.Y+dy < height {
+				walkable[pos.Y+dy][pos.X+dx] = false
+			}
+		}
+	}
+	doors := doorCenters(doorPositions)
+	visited := bfsConnectivity(walkable, doors[0], width, height)
+	for _, door := range doors[1:] {
+		if !visited[door.Y][door.X] {
+			return false
+		}
+	}
+	return true
+}
+
+// bfsConnectivity performs BFS to find all connected cells from a starting point
+func bfsConnectivity(walkable [][]bool, start Point, width, height int) [][]bool {
+	visited := make([][]bool, height)
+	for y := 0; y < height; y++ {
+		visited[y] = make([]bool, width)
+	}
+
+	// Find the nearest walkable cell to the start point (door might be on edge)
+	startCell := findNearestWalkable(walkable, start, width, height)
+	if startCell.X < 0 {
+		return visited // No walkable cell found
+	}
+
+	queue := []Point{startCell}
+	visited[startCell.Y][startCell.X] = true
+
+	// 4-directional movement
+	dxArr := []int{0, 1, 0, -1}
+	dyArr := []int{-1, 0, 1, 0}
+
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+
+		for i := 0; i < 4; i++ {
+			nx := curr.X + dxArr[i]
+			ny := curr.Y + dyArr[i]
+
+			if nx >= 0 && nx < width && ny >= 0 && ny < height && !visited[ny][nx] && walkable[ny][nx] {
+				visited[ny][nx] = true
+				queue = append(queue, Point{X: nx, Y: ny})
+			}
+		}
+	}
+
+	return visited
+}
+
+// findNearestWalkable finds the nearest walkable cell to the given point
+func findNearestWalkable(walkable [][]bool, pos Point, width, height int) Point {
+	// Check the point itself first
+	if pos.X >= 0 && pos.X < width && pos.Y >= 0 && pos.Y < height && walkable[pos.Y][pos.X] {
+		return pos
+	}
+
+	// Search in expanding squares
+	for radius := 1; radius < max(width, height); radius++ {
+		for dy := -radius; dy <= radius; dy++ {
+			for dx := -radius; dx <= radius; dx++ {
+				if abs(dx) != radius && abs(dy) != radius {
+					continue // Only check the perimeter
+				}
+				nx := pos.X + dx
+				ny := pos.Y + dy
+				if nx >= 0 && nx < width && ny >= 0 && ny < height && walkable[ny][nx] {
+					return Point{X: nx, Y: ny}
+				}
+			}
+		}
+	}
+
+	return Point{X: -1, Y: -1} // Not found
+}
+
+// randomConnectivityRoom returns ground with scattered void cells and narrow
+// passages, and doors on every side. Door cells are left as generated, so the
+// first door is sometimes void and fills start from a nearby cell.
+func randomConnectivityRoom(rng *rand.Rand, width, height int) ([][]int, []DoorSite) {
+	ground := createEmptyLayer(width, height)
+	voidChance := 2 + rng.Intn(4) // 20%-50% void
+	for y := range ground {
+		for x := range ground[y] {
+			if rng.Intn(10) >= voidChance {
+				ground[y][x] = 1
+			}
+		}
+	}
+	return ground, getDoorCenterPositions(width, height, []DoorPosition{DoorTop, DoorRight, DoorBottom, DoorLeft})
+}
+
+func TestDoorConnectivity_MatchesFloodCheck(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for room := 0; room < 40; room++ {
+		width, height := 8+rng.Intn(20), 6+rng.Intn(14)
+		ground, doors := randomConnectivityRoom(rng, width, height)
+		if room%4 == 0 {
+			ground, doors = benchmarkRoom(t, width, height)
+		}
+
+		for _, size := range []int{staticSize, 1} {
+			staticLayer := createEmptyLayer(width, height)
+			conn := newDoorConnectivity(GridFromLayer(ground, width, height), NewGrid(width, height), doors)
+
+			// Walk every candidate and block some of the accepted ones, so
+			// later checks run against a room that fills up
+			for y := 0; y <= height-size; y++ {
+				for x := 0; x <= width-size; x++ {
+					pos := Point{X: x, Y: y}
+					want := floodConnectivityAfterBlock(ground, staticLayer, doors, pos, size, size, width, height)
+					require.Equal(t, want, conn.allowsBlock(pos, size, size), "room %d, %dx%d block at %v", room, size, size, pos)
+					if want && rng.Intn(3) == 0 {
+						for dy := 0; dy < size; dy++ {
+							for dx := 0; dx < size; dx++ {
+								staticLayer[y+dy][x+dx] = 1
+							}
+						}
+						conn.block(pos, size, size)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDoorConnectivity_OneCellBlock(t *testing.T) {
+	// A one-cell-wide corridor between two doors
+	width, height := 7, 3
+	ground := createEmptyLayer(width, height)
+	for x := 0; x < width; x++ {
+		ground[1][x] = 1
+	}
+	ground[0][3], ground[2][3] = 1, 1 // A side pocket off the corridor
+	doors := []DoorSite{doorSiteAt(DoorLeft, 0, 1), doorSiteAt(DoorRight, 6, 1)}
+	conn := newDoorConnectivity(GridFromLayer(ground, width, height), NewGrid(width, height), doors)
+
+	assert.False(t, conn.allowsBlock(Point{X: 2, Y: 1}, 1, 1))
+	assert.True(t, conn.allowsBlock(Point{X: 3, Y: 0}, 1, 1))
+}
+
+func TestFindCutVertices(t *testing.T) {
+	// Two open rooms joined by a one-cell corridor at (3,1)
+	walkable := GridFromLayer([][]int{
+		{1, 1, 1, 0, 1, 1, 1},
+		{1, 1, 1, 1, 1, 1, 1},
+		{1, 1, 1, 0, 1, 1, 1},
+	}, 7, 3)
+	cuts := findCutVertices(walkable, Point{X: 0, Y: 0})
+	far := []Point{{X: 6, Y: 2}}
+
+	assert.True(t, cuts.separates(Point{X: 3, Y: 1}, far), "the corridor cell")
+	assert.True(t, cuts.separates(Point{X: 2, Y: 1}, far), "the corridor entrance")
+	assert.False(t, cuts.separates(Point{X: 1, Y: 1}, far), "inside the first room")
+	assert.False(t, cuts.separates(Point{X: 5, Y: 1}, far), "inside the second room")
+	assert.False(t, cuts.separates(Point{X: 3, Y: 1}, []Point{{X: 1, Y: 2}}), "target on the root's side")
+	assert.False(t, cuts.separates(Point{X: 0, Y: 0}, far), "the root itself")
+}
+
+func TestDoorConnectivity_RingTest(t *testing.T) {
+	width, height := 10, 10
+	ground := createEmptyLayer(width, height)
+	for y := range ground {
+		for x := range ground[y] {
+			ground[y][x] = 1
+		}
+	}
+	// A wall across the room with a two-cell gap at x=4..5
+	for x := 0; x < width; x++ {
+		if x != 4 && x != 5 {
+			ground[5][x] = 0
+		}
+	}
+	doors := []DoorSite{doorSiteAt(DoorTop, 5, 0), doorSiteAt(DoorBottom, 5, 9)}
+	conn := newDoorConnectivity(GridFromLayer(ground, width, height), NewGrid(width, height), doors)
+
+	assert.True(t, conn.ringConnected(Point{X: 1, Y: 1}, staticSize, staticSize), "open floor")
+	assert.False(t, conn.ringConnected(Point{X: 4, Y: 4}, staticSize, staticSize), "plugging the gap")
+	assert.False(t, conn.allowsBlock(Point{X: 4, Y: 4}, staticSize, staticSize))
+	assert.True(t, conn.allowsBlock(Point{X: 4, Y: 5}, 1, 1), "half the gap stays open")
+}
+
+// BenchmarkConnectivityCheck checks a fixed sample of static candidates. The
+// "flood" variants redo a full search per candidate: on [][]bool layers as
+// before the grid, and on grids as before the incremental checker.
+func BenchmarkConnectivityCheck(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		width, height := size[0], size[1]
+		ground, doors := benchmarkRoom(b, width, height)
+		staticLayer := createEmptyLayer(width, height)
+		rng := rand.New(rand.NewSource(1))
+		candidates := make([]Point, 64)
+		for i := range candidates {
+			candidates[i] = Point{X: rng.Intn(width - 1), Y: rng.Intn(height - 1)}
+		}
+		newChecker := func() *doorConnectivity {
+			return newDoorConnectivity(GridFromLayer(ground, width, height), NewGrid(width, height), doors)
+		}
+
+		b.Run(fmt.Sprintf("sliceFlood/%dx%d", width, height), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, pos := range candidates {
+					floodConnectivityAfterBlock(ground, staticLayer, doors, pos, staticSize, staticSize, width, height)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("gridFlood/%dx%d", width, height), func(b *testing.B) {
+			conn := newChecker()
+			for i := 0; i < b.N; i++ {
+				for _, pos := range candidates {
+					conn.flood(pos, staticSize, staticSize)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("incremental/%dx%d", width, height), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				// A fresh checker per run, so the cut vertices are found once per run
+				// as they are after each accepted placement
+				conn := newChecker()
+				for _, pos := range candidates {
+					conn.allowsBlock(pos, staticSize, staticSize)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkStaticPlacement runs the static step on a generated room, placing
+// a static per four columns
+func BenchmarkStaticPlacement(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		width, height := size[0], size[1]
+		ground, doors := benchmarkRoom(b, width, height)
+		b.Run(fmt.Sprintf("%dx%d", width, height), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				lc := newLayerContext(ground, nil, nil, nil, doors, width, height)
+				generateStaticLayerWithDebugAndRail(context.Background(), rand.New(rand.NewSource(int64(i))), lc, nil, width/4)
+			}
+		})
+	}
+}
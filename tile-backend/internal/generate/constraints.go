@@ -0,0 +1,249 @@
+package generate
+
+import "fmt"
+
+// ConstraintMasks are optional per-cell designer masks. Each mask is a
+// height x width grid of 0/1 values; an omitted mask constrains nothing.
+type ConstraintMasks struct {
+	ForceGround [][]int `json:"forceGround,omitempty"` // Cells that must end up as ground (optional)
+	ForceVoid   [][]int `json:"forceVoid,omitempty"`   // Cells that must end up as void (optional)
+	NoEnemy     [][]int `json:"noEnemy,omitempty"`     // Cells where no chaser/zoner/dps/mobAir may be placed (optional)
+	NoStatic    [][]int `json:"noStatic,omitempty"`    // Cells no static may cover (optional)
+}
+
+// ConstraintDebugInfo reports how the designer masks were applied
+type ConstraintDebugInfo struct {
+	ForceGroundCells int                   `json:"forceGroundCells"`
+	ForceVoidCells   int                   `json:"forceVoidCells"`
+	NoEnemyCells     int                   `json:"noEnemyCells"`
+	NoStaticCells    int                   `json:"noStaticCells"`
+	Unhonored        []UnhonoredConstraint `json:"unhonored,omitempty"`
+}
+
+// UnhonoredConstraint describes a masked cell the generator could not honor
+type UnhonoredConstraint struct {
+	Mask     string `json:"mask"`     // forceGround, forceVoid, noEnemy, noStatic
+	Position string `json:"position"` // Cell position (x,y)
+	Reason   string `json:"reason"`
+}
+
+// Validate checks mask dimensions and values against the room size
+func (m *ConstraintMasks) Validate(width, height int) error {
+	if m == nil {
+		return nil
+	}
+	masks := []struct {
+		name  string
+		cells [][]int
+	}{
+		{"forceGround", m.ForceGround},
+		{"forceVoid", m.ForceVoid},
+		{"noEnemy", m.NoEnemy},
+		{"noStatic", m.NoStatic},
+	}
+	for _, mask := range masks {
+		if mask.cells == nil {
+			continue
+		}
+		if len(mask.cells) != height {
+			return fmt.Errorf("%s must have %d rows, got %d", mask.name, height, len(mask.cells))
+		}
+		for y, row := range mask.cells {
+			if len(row) != width {
+				return fmt.Errorf("%s row %d must have %d columns, got %d", mask.name, y, width, len(row))
+			}
+			for x, v := range row {
+				if v != 0 && v != 1 {
+					return fmt.Errorf("%s[%d][%d] must be 0 or 1", mask.name, y, x)
+				}
+			}
+		}
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if m.forceGroundAt(x, y) && m.forceVoidAt(x, y) {
+				return fmt.Errorf("forceGround and forceVoid overlap at (%d,%d)", x, y)
+			}
+		}
+	}
+	return nil
+}
+
+// isEmpty reports whether no mask is set
+func (m *ConstraintMasks) isEmpty() bool {
+	return m == nil || (m.ForceGround == nil && m.ForceVoid == nil && m.NoEnemy == nil && m.NoStatic == nil)
+}
+
+// maskAt reports whether a mask marks the cell; nil masks and out-of-range cells are unmarked
+func maskAt(mask [][]int, x, y int) bool {
+	return y >= 0 && y < len(mask) && x >= 0 && x < len(mask[y]) && mask[y][x] != 0
+}
+
+func (m *ConstraintMasks) forceGroundAt(x, y int) bool {
+	return m != nil && maskAt(m.ForceGround, x, y)
+}
+
+func (m *ConstraintMasks) forceVoidAt(x, y int) bool {
+	return m != nil && maskAt(m.ForceVoid, x, y)
+}
+
+func (m *ConstraintMasks) noEnemyAt(x, y int) bool {
+	return m != nil && maskAt(m.NoEnemy, x, y)
+}
+
+func (m *ConstraintMasks) noStaticAt(x, y int) bool {
+	return m != nil && maskAt(m.NoStatic, x, y)
+}
+
+// withBlocked returns masks that additionally mark every set cell of the given
+// layers (pipeline, hazard) noStatic and noEnemy, so the placement steps after
+// them keep off those cells. Nil layers are ignored. The designer's masks are
+// left unchanged for constraint reporting.
+func (m *ConstraintMasks) withBlocked(layers ...[][]int) *ConstraintMasks {
+	var blocking [][][]int
+	for _, layer := range layers {
+		if layer != nil && countCells(layer) > 0 {
+			blocking = append(blocking, layer)
+		}
+	}
+	if len(blocking) == 0 {
+		return m
+	}
+	height := len(blocking[0])
+	width := len(blocking[0][0])
+
+	merged := &ConstraintMasks{}
+	if m != nil {
+		*merged = *m
+	}
+	merged.NoStatic = createEmptyLayer(width, height)
+	merged.NoEnemy = createEmptyLayer(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			blocked := false
+			for _, layer := range blocking {
+				blocked = blocked || layer[y][x] != 0
+			}
+			if blocked || m.noStaticAt(x, y) {
+				merged.NoStatic[y][x] = 1
+			}
+			if blocked || m.noEnemyAt(x, y) {
+				merged.NoEnemy[y][x] = 1
+			}
+		}
+	}
+	return merged
+}
+
+// applyGroundConstraints writes forceGround and forceVoid into a generated ground
+// layer. Door connectivity and a single ground region still win over forceVoid:
+// cells that have to stay ground are kept and returned with the reason.
+func applyGroundConstraints(ground [][]int, masks *ConstraintMasks, doors []DoorSite, width, height int, trace *Trace) map[Point]string {
+	reasons := make(map[Point]string)
+	if masks.isEmpty() {
+		return reasons
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if masks.forceGroundAt(x, y) {
+				ground[y][x] = 1
+			}
+		}
+	}
+
+	// Carve all forceVoid cells at once; fall back to cell-by-cell only when that disconnects the doors
+	carved := trace.tryMutate("constraints", "ground", "forceVoid disconnected the doors", ground, func() {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if masks.forceVoidAt(x, y) {
+					ground[y][x] = 0
+				}
+			}
+		}
+	}, func() bool { return areAllDoorsConnected(ground, width, height, doors) })
+	if !carved {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if !masks.forceVoidAt(x, y) || ground[y][x] == 0 {
+					continue
+				}
+				ground[y][x] = 0
+				if !areAllDoorsConnected(ground, width, height, doors) {
+					ground[y][x] = 1
+					reasons[Point{X: x, Y: y}] = "kept as ground to preserve door connectivity"
+				}
+			}
+		}
+	}
+
+	// Carving may have split the ground; reconnecting it can re-fill forceVoid cells
+	ensureGroundConnectivity(ground, width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := Point{X: x, Y: y}
+			if masks.forceVoidAt(x, y) && ground[y][x] == 1 && reasons[p] == "" {
+				reasons[p] = "re-filled to reconnect isolated ground"
+			}
+		}
+	}
+
+	return reasons
+}
+
+// checkConstraints compares the finished layers against the masks and lists every
+// masked cell that was not honored. groundReasons comes from applyGroundConstraints.
+func checkConstraints(masks *ConstraintMasks, groundReasons map[Point]string, ground, staticLayer [][]int, enemyLayers ...[][]int) *ConstraintDebugInfo {
+	debug := &ConstraintDebugInfo{}
+	height := len(ground)
+	width := 0
+	if height > 0 {
+		width = len(ground[0])
+	}
+
+	unhonored := func(mask string, x, y int, reason string) {
+		debug.Unhonored = append(debug.Unhonored, UnhonoredConstraint{
+			Mask:     mask,
+			Position: fmt.Sprintf("(%d,%d)", x, y),
+			Reason:   reason,
+		})
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if masks.forceGroundAt(x, y) {
+				debug.ForceGroundCells++
+				if ground[y][x] != 1 {
+					unhonored("forceGround", x, y, "cell is void")
+				}
+			}
+			if masks.forceVoidAt(x, y) {
+				debug.ForceVoidCells++
+				if ground[y][x] != 0 {
+					reason := groundReasons[Point{X: x, Y: y}]
+					if reason == "" {
+						reason = "cell is ground"
+					}
+					unhonored("forceVoid", x, y, reason)
+				}
+			}
+			if masks.noStaticAt(x, y) {
+				debug.NoStaticCells++
+				if staticLayer[y][x] != 0 {
+					unhonored("noStatic", x, y, "static placed on cell")
+				}
+			}
+			if masks.noEnemyAt(x, y) {
+				debug.NoEnemyCells++
+				for _, layer := range enemyLayers {
+					if layer[y][x] != 0 {
+						unhonored("noEnemy", x, y, "enemy placed on cell")
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return debug
+}
@@ -0,0 +1,183 @@
+package generate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// maskRect builds a width x height mask with the rectangle [x0,x1) x [y0,y1) set
+func maskRect(width, height, x0, y0, x1, y1 int) [][]int {
+	mask := createEmptyLayer(width, height)
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			mask[y][x] = 1
+		}
+	}
+	return mask
+}
+
+func TestConstraintMasks_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		masks   ConstraintMasks
+		wantErr bool
+	}{
+		{"empty", ConstraintMasks{}, false},
+		{"valid", ConstraintMasks{ForceGround: maskRect(6, 4, 0, 0, 2, 2), NoEnemy: maskRect(6, 4, 3, 0, 6, 4)}, false},
+		{"wrong rows", ConstraintMasks{NoStatic: maskRect(6, 3, 0, 0, 1, 1)}, true},
+		{"wrong columns", ConstraintMasks{ForceVoid: maskRect(5, 4, 0, 0, 1, 1)}, true},
+		{"bad value", ConstraintMasks{NoEnemy: [][]int{{0, 0, 0, 0, 0, 2}, {0, 0, 0, 0, 0, 0}, {0, 0, 0, 0, 0, 0}, {0, 0, 0, 0, 0, 0}}}, true},
+		{"force overlap", ConstraintMasks{ForceGround: maskRect(6, 4, 0, 0, 3, 3), ForceVoid: maskRect(6, 4, 2, 2, 4, 4)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.masks.Validate(6, 4)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEraseRect_KeepsForceGround(t *testing.T) {
+	ground := maskRect(6, 6, 0, 0, 6, 6)
+	masks := &ConstraintMasks{ForceGround: maskRect(6, 6, 2, 2, 3, 3)}
+
+	eraseRect(ground, 1, 1, 3, 3, masks, 6, 6)
+
+	assert.Equal(t, 1, ground[2][2])
+	assert.Equal(t, 0, ground[1][1])
+	assert.Equal(t, 0, ground[3][3])
+}
+
+func TestIsValidIslandPosition_RejectsForceVoid(t *testing.T) {
+	ground := createEmptyLayer(15, 15)
+	for y := 0; y < 15; y++ {
+		ground[y][1] = 1 // exactly minIslandGroundDistance+1 left of the island
+	}
+
+	assert.True(t, isValidIslandPosition(ground, 4, 4, 3, 3, nil, 15, 15))
+	masks := &ConstraintMasks{ForceVoid: maskRect(15, 15, 5, 5, 6, 6)}
+	assert.False(t, isValidIslandPosition(ground, 4, 4, 3, 3, masks, 15, 15))
+}
+
+func TestGenerateFullRoom_GroundMasks(t *testing.T) {
+	seed := int64(3)
+	resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width:  20,
+		Height: 12,
+		Doors:  []DoorPosition{DoorLeft, DoorRight},
+		Seed:   &seed,
+		ConstraintMasks: ConstraintMasks{
+			ForceVoid:   maskRect(20, 12, 8, 1, 12, 4),
+			ForceGround: maskRect(20, 12, 0, 0, 2, 2),
+		},
+	})
+	require.NoError(t, err)
+
+	ground := resp.Payload.Ground
+	for y := 1; y < 4; y++ {
+		for x := 8; x < 12; x++ {
+			assert.Equal(t, 0, ground[y][x], "forceVoid cell (%d,%d)", x, y)
+		}
+	}
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			assert.Equal(t, 1, ground[y][x], "forceGround cell (%d,%d)", x, y)
+		}
+	}
+	assert.Len(t, findAllIslands(ground, 20, 12), 1)
+
+	require.NotNil(t, resp.DebugInfo.Constraints)
+	assert.Equal(t, 12, resp.DebugInfo.Constraints.ForceVoidCells)
+	assert.Equal(t, 4, resp.DebugInfo.Constraints.ForceGroundCells)
+	assert.Empty(t, resp.DebugInfo.Constraints.Unhonored)
+}
+
+func TestGenerateFullRoom_ForceVoidKeepsDoorsConnected(t *testing.T) {
+	// A full-height void column would cut the left door off from the right one
+	seed := int64(1)
+	resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width:           20,
+		Height:          12,
+		Doors:           []DoorPosition{DoorLeft, DoorRight},
+		Seed:            &seed,
+		ConstraintMasks: ConstraintMasks{ForceVoid: maskRect(20, 12, 10, 0, 11, 12)},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, areAllDoorsConnected(resp.Payload.Ground, 20, 12, getDoorCenterPositions(20, 12, []DoorPosition{DoorLeft, DoorRight})))
+	require.NotNil(t, resp.DebugInfo.Constraints)
+	require.NotEmpty(t, resp.DebugInfo.Constraints.Unhonored)
+	for _, u := range resp.DebugInfo.Constraints.Unhonored {
+		assert.Equal(t, "forceVoid", u.Mask)
+		assert.NotEmpty(t, u.Reason)
+	}
+	assert.Less(t, len(resp.DebugInfo.Constraints.Unhonored), 12)
+}
+
+func TestGenerateRooms_PlacementMasks(t *testing.T) {
+	// Block enemies and statics on the whole left half
+	noLeft := maskRect(20, 12, 0, 0, 10, 12)
+	masks := ConstraintMasks{NoEnemy: noLeft, NoStatic: noLeft}
+
+	check := func(t *testing.T, static, chaser, zoner, dps, mobAir [][]int, constraints *ConstraintDebugInfo) {
+		for y := 0; y < 12; y++ {
+			for x := 0; x < 10; x++ {
+				assert.Equal(t, 0, static[y][x], "static at (%d,%d)", x, y)
+				assert.Equal(t, 0, chaser[y][x], "chaser at (%d,%d)", x, y)
+				assert.Equal(t, 0, zoner[y][x], "zoner at (%d,%d)", x, y)
+				assert.Equal(t, 0, dps[y][x], "dps at (%d,%d)", x, y)
+				assert.Equal(t, 0, mobAir[y][x], "mobAir at (%d,%d)", x, y)
+			}
+		}
+		require.NotNil(t, constraints)
+		assert.Equal(t, 120, constraints.NoEnemyCells)
+		assert.Empty(t, constraints.Unhonored)
+	}
+
+	for i := int64(0); i < 5; i++ {
+		seed := i
+		full, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+			Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight},
+			StaticCount: 4, StageType: "pressure", Seed: &seed, ConstraintMasks: masks,
+		})
+		require.NoError(t, err)
+		p := full.Payload
+		check(t, p.Static, p.Chaser, p.Zoner, p.DPS, p.MobAir, full.DebugInfo.Constraints)
+
+		bridge, err := GenerateBridgeRoom(context.Background(), BridgeGenerateRequest{
+			Width: 20, Height: 12, Doors: []DoorPosition{DoorTop, DoorBottom},
+			StaticCount: 4, ChaserCount: 3, ZonerCount: 2, DPSCount: 2, MobAirCount: 2, Seed: &seed, ConstraintMasks: masks,
+		})
+		require.NoError(t, err)
+		p = bridge.Payload
+		check(t, p.Static, p.Chaser, p.Zoner, p.DPS, p.MobAir, bridge.DebugInfo.Constraints)
+	}
+}
+
+func TestGeneratePlatformRoom_InvalidMask(t *testing.T) {
+	_, err := GeneratePlatformRoom(context.Background(), PlatformGenerateRequest{
+		Width:           20,
+		Height:          12,
+		Doors:           []DoorPosition{DoorTop, DoorBottom},
+		ConstraintMasks: ConstraintMasks{NoStatic: maskRect(12, 20, 0, 0, 1, 1)},
+	})
+	assert.Error(t, err)
+}
+
+func TestGeneratePlatformRoom_NoMasksNoConstraintDebug(t *testing.T) {
+	resp, err := GeneratePlatformRoom(context.Background(), PlatformGenerateRequest{
+		Width:  20,
+		Height: 12,
+		Doors:  []DoorPosition{DoorTop, DoorBottom},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, resp.DebugInfo.Constraints)
+}
@@ -1,6 +1,10 @@
 package generate
 
-import "math"
+import (
+	"math"
+
+	"tile-backend/internal/model"
+)
 
 // DifficultyScore holds the computed difficulty rating for a room
 type DifficultyScore struct {
@@ -13,12 +17,14 @@ type DifficultyScore struct {
 // DifficultyDetail holds per-factor breakdown
 type DifficultyDetail struct {
 	// Terrain factors
-	GroundCoverage   float64 `json:"groundCoverage"`   // ratio 0-1 (lower = harder)
-	NarrowPassages   int     `json:"narrowPassages"`   // count of 1-2 wide corridors
-	SoftEdgeCount    int     `json:"softEdgeCount"`    // number of soft edge cells
-	PathTortuosity   float64 `json:"pathTortuosity"`   // main path actual/straight ratio
-	PathStaticBlocks int     `json:"pathStaticBlocks"` // statics within 2 of main path
-	IslandCount      int     `json:"islandCount"`      // disconnected ground regions
+	GroundCoverage     float64 `json:"groundCoverage"`     // ratio 0-1 (lower = harder)
+	NarrowPassages     int     `json:"narrowPassages"`     // count of 1-2 wide corridors
+	SoftEdgeCount      int     `json:"softEdgeCount"`      // number of soft edge cells
+	PathTortuosity     float64 `json:"pathTortuosity"`     // main path actual/straight ratio
+	PathStaticBlocks   int     `json:"pathStaticBlocks"`   // statics within 2 of main path
+	IslandCount        int     `json:"islandCount"`        // disconnected ground regions
+	HazardCells        int     `json:"hazardCells"`        // number of hazard cells
+	UnavoidableHazards int     `json:"unavoidableHazards"` // hazard cells the main path has to cross
 
 	// Enemy factors
 	ChaserCount        int     `json:"chaserCount"`
@@ -27,10 +33,15 @@ type DifficultyDetail struct {
 	MobAirCount        int     `json:"mobAirCount"`
 	EnemyDensity       float64 `json:"enemyDensity"`       // enemies / walkable area
 	EnemyConcentration float64 `json:"enemyConcentration"` // spatial clustering 0-1 (higher = more clustered)
+
+	// Threat heatmap (default radius and weights)
+	PeakThreat     float64      `json:"peakThreat"`           // highest threat of any cell
+	MainPathThreat float64      `json:"mainPathThreat"`       // average threat along the main path
+	SafestCell     *model.Point `json:"safestCell,omitempty"` // standable cell with the least threat
 }
 
 // ComputeDifficulty calculates a difficulty score for the generated room
-func ComputeDifficulty(ground, softEdge, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer [][]int,
+func ComputeDifficulty(ground, softEdge, hazard, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer [][]int,
 	mainPath *MainPathData, width, height int) *DifficultyScore {
 
 	details := DifficultyDetail{}
@@ -64,6 +75,10 @@ func ComputeDifficulty(ground, softEdge, staticLayer, chaserLayer, zonerLayer, d
 	// 6. Island count
 	details.IslandCount = countIslands(ground, width, height)
 
+	// 7. Hazards, and the ones the main path cannot avoid
+	details.HazardCells = countCells(hazard)
+	details.UnavoidableHazards = countHazardsOnPath(hazard, mainPath, width, height)
+
 	// === Enemy factors ===
 	details.ChaserCount = countCells(chaserLayer)
 	details.ZonerCount = countCells(zonerLayer)
@@ -77,6 +92,17 @@ func ComputeDifficulty(ground, softEdge, staticLayer, chaserLayer, zonerLayer, d
 
 	details.EnemyConcentration = computeEnemyConcentration(chaserLayer, zonerLayer, dpsLayer, mobAirLayer, width, height)
 
+	// === Threat heatmap ===
+	threatSummary := computeThreatSummary(threatLayers{
+		ground: ground, static: staticLayer, hazard: hazard,
+		chaser: chaserLayer, zoner: zonerLayer, dps: dpsLayer, mobAir: mobAirLayer,
+		onMainPath: func(x, y int) bool { return mainPath != nil && mainPath.OnMainPath[y][x] },
+		width:      width, height: height,
+	})
+	details.PeakThreat = threatSummary.PeakThreat
+	details.MainPathThreat = threatSummary.MainPathThreat
+	details.SafestCell = threatSummary.SafestCell
+
 	// === Score computation ===
 	terrain := computeTerrainScore(details, width, height)
 	enemy := computeEnemyScore(details, width, height)
@@ -126,9 +152,30 @@ func computeTerrainScore(d DifficultyDetail, width, height int) float64 {
 	islandDiff := clamp01(float64(d.IslandCount-1) / 3.0)
 	score += islandDiff * 0.1
 
+	// Unavoidable hazards (weight 0.15)
+	// 0 cells on the main path = 0, 6+ cells = 1.0
+	hazardDiff := clamp01(float64(d.UnavoidableHazards) / 6.0)
+	score += hazardDiff * 0.15
+
 	return score
 }
 
+// countHazardsOnPath counts hazard cells the main path has to cross
+func countHazardsOnPath(hazard [][]int, mainPath *MainPathData, width, height int) int {
+	if hazard == nil || mainPath == nil {
+		return 0
+	}
+	count := 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if hazard[y][x] != 0 && mainPath.OnMainPath[y][x] {
+				count++
+			}
+		}
+	}
+	return count
+}
+
 // computeEnemyScore combines enemy factors into a 0-1 score
 func computeEnemyScore(d DifficultyDetail, width, height int) float64 {
 	score := 0.0
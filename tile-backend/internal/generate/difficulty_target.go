@@ -0,0 +1,129 @@
+package generate
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	defaultTargetAttempts = 20  // attempt budget when maxAttempts is not set
+	maxTargetAttempts     = 100 // hard cap on the attempt budget
+)
+
+// DifficultyRange is an inclusive [min, max] range on a 0-1 difficulty axis
+type DifficultyRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// DifficultyTarget asks the generator to resample until the difficulty score
+// falls inside every given range. At least one axis must be set.
+type DifficultyTarget struct {
+	Overall     *DifficultyRange `json:"overall,omitempty"`     // Range for DifficultyScore.Overall (optional)
+	Terrain     *DifficultyRange `json:"terrain,omitempty"`     // Range for DifficultyScore.Terrain (optional)
+	Enemy       *DifficultyRange `json:"enemy,omitempty"`       // Range for DifficultyScore.Enemy (optional)
+	MaxAttempts int              `json:"maxAttempts,omitempty"` // Attempt budget (optional, default 20, max 100)
+}
+
+// DifficultyTargetResult reports how the target search went
+type DifficultyTargetResult struct {
+	Met         bool    `json:"met"`         // Whether the returned room is inside every range
+	Attempts    int     `json:"attempts"`    // Rooms generated before stopping
+	MaxAttempts int     `json:"maxAttempts"` // Attempt budget that applied
+	Distance    float64 `json:"distance"`    // Summed distance outside the ranges (0 when met)
+	AttemptSeed int64   `json:"attemptSeed"` // Seed of the returned attempt; replays it without a target
+}
+
+// Validate checks the target ranges and attempt budget
+func (t *DifficultyTarget) Validate() error {
+	if t.Overall == nil && t.Terrain == nil && t.Enemy == nil {
+		return fmt.Errorf("difficultyTarget needs at least one of overall, terrain or enemy")
+	}
+	axes := []struct {
+		name string
+		r    *DifficultyRange
+	}{{"overall", t.Overall}, {"terrain", t.Terrain}, {"enemy", t.Enemy}}
+	for _, axis := range axes {
+		if axis.r == nil {
+			continue
+		}
+		if axis.r.Min < 0 || axis.r.Max > 1 || axis.r.Min > axis.r.Max {
+			return fmt.Errorf("difficultyTarget.%s must satisfy 0 <= min <= max <= 1", axis.name)
+		}
+	}
+	if t.MaxAttempts < 0 || t.MaxAttempts > maxTargetAttempts {
+		return fmt.Errorf("difficultyTarget.maxAttempts must be between 0 and %d (0 = default %d)", maxTargetAttempts, defaultTargetAttempts)
+	}
+	return nil
+}
+
+// attemptBudget returns the effective attempt budget
+func (t *DifficultyTarget) attemptBudget() int {
+	if t.MaxAttempts == 0 {
+		return defaultTargetAttempts
+	}
+	return t.MaxAttempts
+}
+
+// distance returns how far a score lies outside the target ranges, summed over axes
+func (t *DifficultyTarget) distance(score *DifficultyScore) float64 {
+	if score == nil {
+		return math.Inf(1)
+	}
+	return rangeDistance(t.Overall, score.Overall) +
+		rangeDistance(t.Terrain, score.Terrain) +
+		rangeDistance(t.Enemy, score.Enemy)
+}
+
+// rangeDistance returns 0 inside the range (or for a nil range), otherwise the gap to the nearest bound
+func rangeDistance(r *DifficultyRange, v float64) float64 {
+	if r == nil {
+		return 0
+	}
+	if v < r.Min {
+		return r.Min - v
+	}
+	if v > r.Max {
+		return v - r.Max
+	}
+	return 0
+}
+
+// generateToTarget runs attempt with a fresh seed per try until the score is in
+// range or the budget runs out, and returns the closest attempt. Attempt seeds
+// are drawn from the request seed so the whole search is reproducible.
+func generateToTarget[T any](target *DifficultyTarget, seed *int64,
+	attempt func(seed int64) (T, *DifficultyScore, error)) (T, *DifficultyTargetResult, int64, error) {
+
+	var best T
+	if err := target.Validate(); err != nil {
+		return best, nil, 0, err
+	}
+
+	rng, masterSeed := newRequestRand(seed)
+	result := &DifficultyTargetResult{MaxAttempts: target.attemptBudget()}
+	bestDistance := math.Inf(1)
+
+	for result.Attempts < result.MaxAttempts {
+		attemptSeed := rng.Int63()
+		resp, score, err := attempt(attemptSeed)
+		if err != nil {
+			// Generator errors come from the request itself, so retrying would not help
+			return best, nil, 0, err
+		}
+		result.Attempts++
+
+		if d := target.distance(score); d < bestDistance {
+			best = resp
+			bestDistance = d
+			result.Distance = d
+			result.AttemptSeed = attemptSeed
+		}
+		if bestDistance == 0 {
+			result.Met = true
+			break
+		}
+	}
+
+	return best, result, masterSeed, nil
+}
@@ -0,0 +1,133 @@
+package generate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDifficultyTarget_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  DifficultyTarget
+		wantErr bool
+	}{
+		{"overall only", DifficultyTarget{Overall: &DifficultyRange{Min: 0.55, Max: 0.65}}, false},
+		{"per axis", DifficultyTarget{Terrain: &DifficultyRange{Min: 0, Max: 0.3}, Enemy: &DifficultyRange{Min: 0.4, Max: 1}}, false},
+		{"no axis", DifficultyTarget{MaxAttempts: 5}, true},
+		{"min above max", DifficultyTarget{Overall: &DifficultyRange{Min: 0.7, Max: 0.6}}, true},
+		{"max above one", DifficultyTarget{Enemy: &DifficultyRange{Min: 0.5, Max: 1.2}}, true},
+		{"budget too large", DifficultyTarget{Overall: &DifficultyRange{Max: 1}, MaxAttempts: maxTargetAttempts + 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.target.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// 0 is a valid budget meaning the default, and the error says so
+	zero := DifficultyTarget{Overall: &DifficultyRange{Max: 1}}
+	assert.NoError(t, zero.Validate())
+	negative := DifficultyTarget{Overall: &DifficultyRange{Max: 1}, MaxAttempts: -1}
+	assert.EqualError(t, negative.Validate(), "difficultyTarget.maxAttempts must be between 0 and 100 (0 = default 20)")
+}
+
+func TestRangeDistance(t *testing.T) {
+	r := &DifficultyRange{Min: 0.4, Max: 0.6}
+	assert.Equal(t, 0.0, rangeDistance(nil, 0.9))
+	assert.Equal(t, 0.0, rangeDistance(r, 0.5))
+	assert.InDelta(t, 0.1, rangeDistance(r, 0.3), 1e-9)
+	assert.InDelta(t, 0.2, rangeDistance(r, 0.8), 1e-9)
+}
+
+func TestGenerateFullRoom_DifficultyTargetMet(t *testing.T) {
+	seed := int64(11)
+	req := FullRoomGenerateRequest{
+		Width:     20,
+		Height:    12,
+		Doors:     []DoorPosition{DoorLeft, DoorRight},
+		StageType: "pressure",
+		Seed:      &seed,
+		DifficultyTarget: &DifficultyTarget{
+			Overall:     &DifficultyRange{Min: 0, Max: 1},
+			MaxAttempts: 10,
+		},
+	}
+
+	resp, err := GenerateFullRoom(context.Background(), req)
+	require.NoError(t, err)
+	require.NotNil(t, resp.DifficultyTarget)
+	assert.True(t, resp.DifficultyTarget.Met)
+	assert.Equal(t, 1, resp.DifficultyTarget.Attempts)
+	assert.Equal(t, 0.0, resp.DifficultyTarget.Distance)
+	assert.Equal(t, seed, resp.Seed)
+
+	// The attempt seed replays the returned room without the target
+	attemptSeed := resp.DifficultyTarget.AttemptSeed
+	req.DifficultyTarget = nil
+	req.Seed = &attemptSeed
+	replay, err := GenerateFullRoom(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, resp.Payload.Ground, replay.Payload.Ground)
+	assert.Equal(t, resp.Difficulty.Overall, replay.Difficulty.Overall)
+}
+
+func TestGeneratePlatformRoom_DifficultyTargetUnreachable(t *testing.T) {
+	// An enemy score of exactly 1 is unreachable without enemies, so the whole budget is spent
+	resp, err := GeneratePlatformRoom(context.Background(), PlatformGenerateRequest{
+		Width:  20,
+		Height: 12,
+		Doors:  []DoorPosition{DoorTop, DoorBottom},
+		DifficultyTarget: &DifficultyTarget{
+			Enemy:       &DifficultyRange{Min: 1, Max: 1},
+			MaxAttempts: 4,
+		},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp.DifficultyTarget)
+	assert.False(t, resp.DifficultyTarget.Met)
+	assert.Equal(t, 4, resp.DifficultyTarget.Attempts)
+	assert.InDelta(t, 1-resp.Difficulty.Enemy, resp.DifficultyTarget.Distance, 1e-9)
+}
+
+func TestGenerateBridgeRoom_DifficultyTargetInvalid(t *testing.T) {
+	_, err := GenerateBridgeRoom(context.Background(), BridgeGenerateRequest{
+		Width:            20,
+		Height:           12,
+		Doors:            []DoorPosition{DoorTop, DoorBottom},
+		DifficultyTarget: &DifficultyTarget{},
+	})
+	assert.Error(t, err)
+}
+
+func TestGenerateBridgeRoom_DifficultyTargetReproducible(t *testing.T) {
+	seed := int64(5)
+	req := BridgeGenerateRequest{
+		Width:     20,
+		Height:    12,
+		Doors:     []DoorPosition{DoorTop, DoorRight, DoorBottom, DoorLeft},
+		StageType: "building",
+		Seed:      &seed,
+		DifficultyTarget: &DifficultyTarget{
+			Overall:     &DifficultyRange{Min: 0.45, Max: 0.5},
+			MaxAttempts: 8,
+		},
+	}
+
+	first, err := GenerateBridgeRoom(context.Background(), req)
+	require.NoError(t, err)
+	second, err := GenerateBridgeRoom(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, first.DifficultyTarget, second.DifficultyTarget)
+	assert.Equal(t, first.Payload.Ground, second.Payload.Ground)
+	assert.Equal(t, first.Payload.Chaser, second.Payload.Chaser)
+}
@@ -0,0 +1,148 @@
+package generate
+
+import (
+	"fmt"
+	"sort"
+	"tile-backend/internal/model"
+)
+
+// DoorSite is a resolved door opening. The embedded Point is the center cell of
+// the opening, used as the endpoint for paths and distances; Cells lists every
+// cell the opening spans.
+type DoorSite struct {
+	Point
+	Side  DoorPosition
+	Cells []Point
+}
+
+// newDoorSite resolves a door descriptor against the room size
+func newDoorSite(d model.DoorDescriptor, width, height int) DoorSite {
+	cells := d.Cells(width, height)
+	site := DoorSite{Side: DoorPosition(d.Side), Cells: make([]Point, len(cells))}
+	for i, c := range cells {
+		site.Cells[i] = Point{X: c.X, Y: c.Y}
+	}
+	site.Point = site.Cells[len(site.Cells)/2]
+	return site
+}
+
+// getDoorCenterPositions returns a one-cell door site at the center of each
+// listed side, in canonical door order
+func getDoorCenterPositions(width, height int, doors []DoorPosition) []DoorSite {
+	sites := make([]DoorSite, 0, len(doors))
+	for _, door := range doorOrder {
+		for _, d := range doors {
+			if d == door {
+				sites = append(sites, newDoorSite(model.LegacyDoorDescriptor(string(door), width, height), width, height))
+				break
+			}
+		}
+	}
+	return sites
+}
+
+// resolveDoors turns the request doors into door sites. Descriptors, when given,
+// replace the side list; otherwise every side gets a one-cell door at its
+// center. Returns the sites in canonical order (by side, then offset), the
+// side of each door, and the descriptors to store in the payload.
+func resolveDoors(doors []DoorPosition, descriptors []model.DoorDescriptor, width, height int) ([]DoorSite, []DoorPosition, []model.DoorDescriptor, error) {
+	if len(descriptors) == 0 {
+		seen := make(map[DoorPosition]bool)
+		for _, door := range doors {
+			if seen[door] {
+				return nil, nil, nil, fmt.Errorf("duplicate door: %s", door)
+			}
+			seen[door] = true
+			switch door {
+			case DoorTop, DoorRight, DoorBottom, DoorLeft:
+			default:
+				return nil, nil, nil, fmt.Errorf("invalid door: %s", door)
+			}
+		}
+		sites := getDoorCenterPositions(width, height, doors)
+		resolved := make([]model.DoorDescriptor, 0, len(sites))
+		for _, site := range sites {
+			resolved = append(resolved, model.LegacyDoorDescriptor(string(site.Side), width, height))
+		}
+		return sites, doorSides(sites), resolved, nil
+	}
+
+	if err := model.ValidateDoorDescriptors(descriptors, width, height); err != nil {
+		return nil, nil, nil, err
+	}
+	resolved := make([]model.DoorDescriptor, len(descriptors))
+	copy(resolved, descriptors)
+	sort.SliceStable(resolved, func(i, j int) bool {
+		si, sj := sideIndex(DoorPosition(resolved[i].Side)), sideIndex(DoorPosition(resolved[j].Side))
+		if si != sj {
+			return si < sj
+		}
+		return resolved[i].Offset < resolved[j].Offset
+	})
+	sites := make([]DoorSite, 0, len(resolved))
+	for _, d := range resolved {
+		sites = append(sites, newDoorSite(d, width, height))
+	}
+	return sites, doorSides(sites), resolved, nil
+}
+
+// sideIndex returns a side's position in canonical door order
+func sideIndex(side DoorPosition) int {
+	for i, d := range doorOrder {
+		if d == side {
+			return i
+		}
+	}
+	return len(doorOrder)
+}
+
+// doorSides returns the side of every door in site order; a side with two
+// doors appears twice, so stage door limits count doors rather than sides
+func doorSides(sites []DoorSite) []DoorPosition {
+	sides := make([]DoorPosition, 0, len(sites))
+	for _, site := range sites {
+		sides = append(sides, site.Side)
+	}
+	return sides
+}
+
+// firstDoorOnSide returns the first door on a side, if any
+func firstDoorOnSide(sites []DoorSite, side DoorPosition) (DoorSite, bool) {
+	for _, site := range sites {
+		if site.Side == side {
+			return site, true
+		}
+	}
+	return DoorSite{}, false
+}
+
+// doorCenters returns the center cell of every door, in site order
+func doorCenters(sites []DoorSite) []Point {
+	points := make([]Point, 0, len(sites))
+	for _, site := range sites {
+		points = append(points, site.Point)
+	}
+	return points
+}
+
+// openDoorCells makes every cell of every door opening ground
+func openDoorCells(ground [][]int, sites []DoorSite, width, height int) {
+	for _, site := range sites {
+		for _, c := range site.Cells {
+			if c.X >= 0 && c.X < width && c.Y >= 0 && c.Y < height {
+				ground[c.Y][c.X] = 1
+			}
+		}
+	}
+}
+
+// distanceToDoor returns the Manhattan distance from p to the nearest cell of the opening
+func distanceToDoor(p Point, site DoorSite) int {
+	best := -1
+	for _, c := range site.Cells {
+		if d := manhattanDistance(p, c); best < 0 || d < best {
+			best = d
+		}
+	}
+	return best
+}
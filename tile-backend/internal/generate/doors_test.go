@@ -0,0 +1,166 @@
+package generate
+
+import (
+	"context"
+	"testing"
+
+	"tile-backend/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// doorSiteAt builds a one-cell door site at (x, y)
+func doorSiteAt(side DoorPosition, x, y int) DoorSite {
+	p := Point{X: x, Y: y}
+	return DoorSite{Point: p, Side: side, Cells: []Point{p}}
+}
+
+func TestResolveDoors_Legacy(t *testing.T) {
+	sites, sides, descriptors, err := resolveDoors([]DoorPosition{DoorLeft, DoorTop}, nil, 20, 12)
+	require.NoError(t, err)
+
+	// Canonical order regardless of request order
+	assert.Equal(t, []DoorPosition{DoorTop, DoorLeft}, sides)
+	require.Len(t, sites, 2)
+	assert.Equal(t, Point{X: 10, Y: 0}, sites[0].Point)
+	assert.Equal(t, Point{X: 0, Y: 6}, sites[1].Point)
+	assert.Equal(t, []model.DoorDescriptor{
+		{Side: "top", Offset: 10, Width: 1},
+		{Side: "left", Offset: 6, Width: 1},
+	}, descriptors)
+
+	_, _, _, err = resolveDoors([]DoorPosition{DoorLeft, DoorLeft}, nil, 20, 12)
+	assert.Error(t, err)
+	_, _, _, err = resolveDoors([]DoorPosition{"middle"}, nil, 20, 12)
+	assert.Error(t, err)
+}
+
+func TestResolveDoors_LegacyMatchesDoorList(t *testing.T) {
+	// Generated doors and the doors read back from a bitmask-only payload agree
+	for _, size := range [][2]int{{20, 12}, {15, 9}} {
+		width, height := size[0], size[1]
+		_, _, descriptors, err := resolveDoors(allDoorSides, nil, width, height)
+		require.NoError(t, err)
+		payload := model.TemplatePayload{Doors: &model.DoorStates{Top: 1, Right: 1, Bottom: 1, Left: 1}}
+		assert.Equal(t, descriptors, payload.DoorList(width, height))
+	}
+}
+
+func TestResolveDoors_Descriptors(t *testing.T) {
+	sites, sides, descriptors, err := resolveDoors([]DoorPosition{DoorTop}, []model.DoorDescriptor{
+		{Side: "left", Offset: 7, Width: 3},
+		{Side: "left", Offset: 1, Width: 2},
+		{Side: "top", Offset: 15},
+	}, 20, 12)
+	require.NoError(t, err)
+
+	// Descriptors replace the side list and are sorted by side, then offset
+	assert.Equal(t, []DoorPosition{DoorTop, DoorLeft, DoorLeft}, sides)
+	assert.Equal(t, 1, descriptors[1].Offset)
+	assert.Equal(t, []Point{{X: 15, Y: 0}}, sites[0].Cells)
+	assert.Equal(t, []Point{{X: 0, Y: 1}, {X: 0, Y: 2}}, sites[1].Cells)
+	assert.Equal(t, Point{X: 0, Y: 8}, sites[2].Point)
+}
+
+func TestResolveDoors_InvalidDescriptors(t *testing.T) {
+	tests := []struct {
+		name  string
+		doors []model.DoorDescriptor
+	}{
+		{"bad side", []model.DoorDescriptor{{Side: "middle", Offset: 1}}},
+		{"negative offset", []model.DoorDescriptor{{Side: "top", Offset: -1}}},
+		{"past the end", []model.DoorDescriptor{{Side: "left", Offset: 10, Width: 3}}},
+		{"overlap", []model.DoorDescriptor{{Side: "top", Offset: 2, Width: 3}, {Side: "top", Offset: 4}}},
+		{"touching", []model.DoorDescriptor{{Side: "top", Offset: 2, Width: 2}, {Side: "top", Offset: 4}}},
+		{"three on a side", []model.DoorDescriptor{{Side: "top", Offset: 1}, {Side: "top", Offset: 5}, {Side: "top", Offset: 9}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := resolveDoors(nil, tt.doors, 20, 12)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestAreAllDoorsConnected_WideDoor(t *testing.T) {
+	// Only the last cell of the wide top door reaches the corridor down to the bottom door
+	ground := createEmptyLayer(6, 6)
+	for y := 0; y < 6; y++ {
+		ground[y][4] = 1
+	}
+	top := newDoorSite(model.DoorDescriptor{Side: "top", Offset: 2, Width: 3}, 6, 6)
+	bottom := newDoorSite(model.DoorDescriptor{Side: "bottom", Offset: 4}, 6, 6)
+	assert.True(t, areAllDoorsConnected(ground, 6, 6, []DoorSite{top, bottom}))
+
+	narrow := newDoorSite(model.DoorDescriptor{Side: "top", Offset: 1}, 6, 6)
+	assert.False(t, areAllDoorsConnected(ground, 6, 6, []DoorSite{narrow, bottom}))
+}
+
+func TestGenerateRooms_DoorDescriptors(t *testing.T) {
+	descriptors := []model.DoorDescriptor{
+		{Side: "left", Offset: 2, Width: 2},
+		{Side: "left", Offset: 8, Width: 2},
+		{Side: "right", Offset: 4, Width: 3},
+	}
+	sites, _, _, err := resolveDoors(nil, descriptors, 24, 14)
+	require.NoError(t, err)
+
+	check := func(t *testing.T, name string, payload model.TemplatePayload) {
+		for _, site := range sites {
+			for _, c := range site.Cells {
+				assert.Equal(t, 1, payload.Ground[c.Y][c.X], "%s: door cell (%d,%d)", name, c.X, c.Y)
+			}
+		}
+		assert.True(t, areAllDoorsConnected(payload.Ground, 24, 14, sites), name)
+		assert.Len(t, findAllIslands(payload.Ground, 24, 14), 1, name)
+
+		require.NotNil(t, payload.Doors, name)
+		assert.Equal(t, model.DoorStates{Left: 1, Right: 1}, *payload.Doors, name)
+		require.Len(t, payload.DoorDescriptors, 3, name)
+		assert.Equal(t, "right", payload.DoorDescriptors[0].Side, name)
+		assert.Equal(t, 8, payload.DoorDescriptors[2].Offset, name)
+	}
+
+	for i := int64(0); i < 10; i++ {
+		seed := i
+		bridge, err := GenerateBridgeRoom(context.Background(), BridgeGenerateRequest{Width: 24, Height: 14, DoorDescriptors: descriptors, Seed: &seed})
+		require.NoError(t, err)
+		check(t, "bridge", bridge.Payload)
+
+		platform, err := GeneratePlatformRoom(context.Background(), PlatformGenerateRequest{Width: 24, Height: 14, DoorDescriptors: descriptors, Seed: &seed})
+		require.NoError(t, err)
+		check(t, "platform", platform.Payload)
+
+		full, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{Width: 24, Height: 14, DoorDescriptors: descriptors, Seed: &seed})
+		require.NoError(t, err)
+		check(t, "full", full.Payload)
+
+		cave, err := GenerateCave(context.Background(), CaveGenerateRequest{Width: 24, Height: 14, DoorDescriptors: descriptors, Seed: &seed})
+		require.NoError(t, err)
+		check(t, "cave", cave.Payload)
+	}
+}
+
+func TestGenerateBridgeRoom_DescriptorDoorCount(t *testing.T) {
+	_, err := GenerateBridgeRoom(context.Background(), BridgeGenerateRequest{
+		Width:           20,
+		Height:          12,
+		Doors:           []DoorPosition{DoorLeft, DoorRight},
+		DoorDescriptors: []model.DoorDescriptor{{Side: "left", Offset: 3}},
+	})
+	assert.Error(t, err)
+}
+
+func TestGetDoorForbiddenCells_WideDoor(t *testing.T) {
+	site := newDoorSite(model.DoorDescriptor{Side: "top", Offset: 4, Width: 4}, 20, 20)
+	forbidden := getDoorForbiddenCells([]DoorSite{site}, 20, 20)
+
+	// Every cell of the opening and the cells in front of both ends are forbidden
+	for x := 4; x < 8; x++ {
+		assert.True(t, forbidden[Point{X: x, Y: 0}], "door cell x=%d", x)
+	}
+	assert.True(t, forbidden[Point{X: 4, Y: 1}])
+	assert.True(t, forbidden[Point{X: 7, Y: 1}])
+}
@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"tile-backend/internal/model"
@@ -8,25 +9,40 @@ import (
 
 // FullRoomGenerateRequest represents the request for generating a full room
 type FullRoomGenerateRequest struct {
-	Width         int            `json:"width"`
-	Height        int            `json:"height"`
-	Doors         []DoorPosition `json:"doors"`         // At least 2 doors required
-	SoftEdgeCount int            `json:"softEdgeCount"` // Suggested number of soft edges to place (optional)
-	RailEnabled   bool           `json:"railEnabled"`   // Whether to generate rail layer (optional)
-	StaticCount   int            `json:"staticCount"`   // Suggested number of statics to place (optional)
-	ChaserCount   int            `json:"chaserCount"`   // Suggested number of chasers to place (optional)
-	ZonerCount    int            `json:"zonerCount"`    // Suggested number of zoners to place (optional)
-	DPSCount      int            `json:"dpsCount"`      // Suggested number of DPS to place (optional)
-	MobAirCount   int            `json:"mobAirCount"`   // Suggested number of mob air (fly) to place (optional)
-	StageType     string         `json:"stageType"`     // Room stage type (optional)
-	RoomCategory  string         `json:"roomCategory"`  // Room category: normal, basement, test, cave (optional, default: normal)
+	Width                 int                    `json:"width"`
+	Height                int                    `json:"height"`
+	Doors                 []DoorPosition         `json:"doors"`                           // Door sides (optional, zero doors allowed)
+	DoorDescriptors       []model.DoorDescriptor `json:"doorDescriptors,omitempty"`       // Door openings with side, offset and width; replaces doors when set (optional)
+	Symmetry              Symmetry               `json:"symmetry,omitempty"`              // Whole-room symmetry: none, horizontal, vertical, both, rotational-180 (optional, default none)
+	SoftEdgeCount         int                    `json:"softEdgeCount"`                   // Suggested number of soft edges to place (optional)
+	RailEnabled           bool                   `json:"railEnabled"`                     // Whether to generate rail layer (optional)
+	PipelineEnabled       bool                   `json:"pipelineEnabled"`                 // Whether to generate pipeline layer (optional)
+	PipelineCount         int                    `json:"pipelineCount"`                   // Suggested number of pipeline runs to lay (optional, default 2)
+	HazardCount           int                    `json:"hazardCount"`                     // Suggested number of hazard strips (spikes, lava) to lay (optional)
+	StaticCount           int                    `json:"staticCount"`                     // Suggested number of statics to place (optional)
+	ChaserCount           int                    `json:"chaserCount"`                     // Suggested number of chasers to place (optional)
+	ZonerCount            int                    `json:"zonerCount"`                      // Suggested number of zoners to place (optional)
+	DPSCount              int                    `json:"dpsCount"`                        // Suggested number of DPS to place (optional)
+	MobAirCount           int                    `json:"mobAirCount"`                     // Suggested number of mob air (fly) to place (optional)
+	PickupCount           int                    `json:"pickupCount"`                     // Suggested number of pickups (chests, health) to place (optional)
+	StageType             string                 `json:"stageType"`                       // Room stage type (optional)
+	StageOverrides        model.StageOverrides   `json:"-"`                               // Project stage overrides, applied on top of the stage config (set from project_id, not read from the body)
+	RoomCategory          string                 `json:"roomCategory"`                    // Room category: normal, basement, test, cave (optional, default: normal)
+	Seed                  *int64                 `json:"seed,omitempty"`                  // Random seed for reproducible output (optional, random if omitted)
+	Trace                 bool                   `json:"-"`                               // Record a frame per pipeline step and rollback (set from the trace query parameter, not read from the body)
+	DifficultyTarget      *DifficultyTarget      `json:"difficultyTarget,omitempty"`      // Resample until the difficulty score is in range (optional)
+	MaxValidationAttempts int                    `json:"maxValidationAttempts,omitempty"` // Rooms to try until one passes strict validation (optional, default 3, max 20)
+	ConstraintMasks                              // Designer masks forceGround, forceVoid, noEnemy, noStatic (optional)
 }
 
 // FullRoomGenerateResponse represents the generated template
 type FullRoomGenerateResponse struct {
-	Payload    model.TemplatePayload `json:"payload"`
-	DebugInfo  *FullRoomDebugInfo    `json:"debugInfo,omitempty"`
-	Difficulty *DifficultyScore      `json:"difficulty,omitempty"`
+	Payload          model.TemplatePayload   `json:"payload"`
+	DebugInfo        *FullRoomDebugInfo      `json:"debugInfo,omitempty"`
+	Difficulty       *DifficultyScore        `json:"difficulty,omitempty"`
+	Seed             int64                   `json:"seed"`                       // Seed used for this generation
+	DifficultyTarget *DifficultyTargetResult `json:"difficultyTarget,omitempty"` // Target search outcome (only when difficultyTarget was requested)
+	Trace            []TraceFrame            `json:"trace,omitempty"`            // Step-by-step layer frames (only when trace was requested)
 }
 
 // FullRoomDebugInfo contains debug information about the full room generation process
@@ -35,12 +51,20 @@ type FullRoomDebugInfo struct {
 	SoftEdge    *SoftEdgeDebugInfo       `json:"softEdge,omitempty"`
 	BridgeLayer *BridgeLayerDebugInfo    `json:"bridgeLayer,omitempty"`
 	Rail        *RailDebugInfo           `json:"rail,omitempty"`
+	Pipeline    *PipelineDebugInfo       `json:"pipeline,omitempty"`
+	Hazard      *HazardDebugInfo         `json:"hazard,omitempty"`
 	MainPath    *MainPathDebugInfo       `json:"mainPath,omitempty"`
 	Static      *StaticDebugInfo         `json:"static,omitempty"`
 	Chaser      *EnemyLayerDebugInfo     `json:"chaser,omitempty"`
 	Zoner       *EnemyLayerDebugInfo     `json:"zoner,omitempty"`
 	DPS         *EnemyLayerDebugInfo     `json:"dps,omitempty"`
 	MobAir      *MobAirDebugInfo         `json:"mobAir,omitempty"`
+	Pickup      *PickupDebugInfo         `json:"pickup,omitempty"`
+	Waves       *WaveDebugInfo           `json:"waves,omitempty"`
+	Patrol      *PatrolDebugInfo         `json:"patrol,omitempty"`
+	Constraints *ConstraintDebugInfo     `json:"constraints,omitempty"`
+	Symmetry    *SymmetryDebugInfo       `json:"symmetry,omitempty"`
+	Validation  *ValidationDebugInfo     `json:"validation,omitempty"`
 }
 
 // FullRoomGroundDebugInfo contains debug info for full room ground layer generation
@@ -130,8 +154,44 @@ var cornerCombos = []cornerCombo{
 	{[]cornerID{cornerBottomRight}, 1.0, "[BR]"},
 }
 
-// GenerateFullRoom generates a full-type room
-func GenerateFullRoom(req FullRoomGenerateRequest) (*FullRoomGenerateResponse, error) {
+// fullRoomGenerator is the registry entry for full rooms
+var fullRoomGenerator = &shapeGenerator[FullRoomGenerateRequest, FullRoomGenerateResponse]{
+	info: ShapeInfo{
+		Name:         "fullroom",
+		RoomType:     "full",
+		PayloadShape: "all",
+		Doors:        allDoorSides,
+		MinDoors:     0,
+		MinWidth:     4,
+		MinHeight:    4,
+	},
+	generate: GenerateFullRoom,
+	setOptions: func(req *FullRoomGenerateRequest, opts GenerateOptions) {
+		opts.apply(&req.Seed, &req.StageOverrides, &req.Trace)
+	},
+	fromParams: func(p RoomParams) FullRoomGenerateRequest {
+		return FullRoomGenerateRequest{Width: p.Width, Height: p.Height, Doors: p.Doors, StageType: p.StageType, RoomCategory: "normal"}
+	},
+	result: func(resp *FullRoomGenerateResponse) (*model.TemplatePayload, *DifficultyScore, *ValidationDebugInfo) {
+		return &resp.Payload, resp.Difficulty, resp.DebugInfo.Validation
+	},
+}
+
+// GenerateFullRoom generates a full-type room (see generateSampled).
+func GenerateFullRoom(ctx context.Context, req FullRoomGenerateRequest) (*FullRoomGenerateResponse, error) {
+	return generateSampled(ctx, req, generateFullRoom)
+}
+
+func (r *FullRoomGenerateRequest) sampling() sampledRequest {
+	return sampledRequest{target: &r.DifficultyTarget, seed: &r.Seed, maxValidationAttempts: r.MaxValidationAttempts}
+}
+
+func (r *FullRoomGenerateResponse) sampling() sampledResponse {
+	return sampledResponse{payload: &r.Payload, difficulty: r.Difficulty, seed: &r.Seed, target: &r.DifficultyTarget, validation: &r.DebugInfo.Validation}
+}
+
+// generateFullRoom generates one room from the request seed, without self-validation
+func generateFullRoom(ctx context.Context, req FullRoomGenerateRequest) (*FullRoomGenerateResponse, error) {
 	// Validate input
 	if req.Width < 4 || req.Width > 200 {
 		return nil, fmt.Errorf("width must be between 4 and 200")
@@ -142,57 +202,103 @@ func GenerateFullRoom(req FullRoomGenerateRequest) (*FullRoomGenerateResponse, e
 	if err := ValidateRoomCategory(req.RoomCategory); err != nil {
 		return nil, err
 	}
-	// Check for duplicate doors
-	doorSet := make(map[DoorPosition]bool)
-	for _, door := range req.Doors {
-		if doorSet[door] {
-			return nil, fmt.Errorf("duplicate door: %s", door)
+	if err := req.ConstraintMasks.Validate(req.Width, req.Height); err != nil {
+		return nil, err
+	}
+	if err := ValidateSymmetry(req.Symmetry); err != nil {
+		return nil, err
+	}
+	if err := req.Symmetry.checkMasks(&req.ConstraintMasks, req.Width, req.Height); err != nil {
+		return nil, err
+	}
+	// Resolve door openings; descriptors replace the side list when given
+	doorPositions, sides, descriptors, err := resolveDoors(req.Doors, req.DoorDescriptors, req.Width, req.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mirror the doors so they match the symmetric room
+	addedDoors := 0
+	if req.Symmetry.enabled() {
+		requested := descriptors
+		if doorPositions, sides, descriptors, err = symmetricDoors(req.Symmetry, descriptors, req.Width, req.Height); err != nil {
+			return nil, err
 		}
-		doorSet[door] = true
+		addedDoors = countAddedDoors(requested, descriptors)
 	}
 
+	// All randomness for this request flows from a single seeded source
+	rng, seed := newRequestRand(req.Seed)
+	masks := &req.ConstraintMasks
+	trace := newTrace(req.Trace)
+	steps := newStepTracker(ctx, "fullroom")
+
 	debugInfo := &FullRoomDebugInfo{}
 
 	// Create layers
 	ground := createEmptyLayer(req.Width, req.Height)
 	emptyLayer := createEmptyLayer(req.Width, req.Height)
 
-	// Get door center positions
-	doorPositions := getDoorCenterPositions(req.Width, req.Height, req.Doors)
-
 	// Step 1: Fill all ground tiles
 	for y := 0; y < req.Height; y++ {
 		for x := 0; x < req.Width; x++ {
 			ground[y][x] = 1
 		}
 	}
+	trace.snapshot("ground fill", map[string][][]int{"ground": ground})
 
 	// Step 2: Corner erase (40% probability)
 	groundDebug := &FullRoomGroundDebugInfo{}
-	generateFullRoomCornerErase(ground, req.Width, req.Height, req.Doors, groundDebug)
+	generateFullRoomCornerErase(rng, ground, masks, req.Width, req.Height, doorPositions, groundDebug, trace)
+	trace.snapshot("corner erase", map[string][][]int{"ground": ground})
 
 	// Step 3: Center pits (30% probability)
-	generateFullRoomCenterPits(ground, req.Width, req.Height, req.Doors, groundDebug)
+	generateFullRoomCenterPits(rng, ground, masks, req.Width, req.Height, doorPositions, groundDebug, trace)
+	trace.snapshot("center pits", map[string][][]int{"ground": ground})
+
+	// Every cell of every door opening is walkable
+	openDoorCells(ground, doorPositions, req.Width, req.Height)
 
 	// Step 3.5: Repair any disconnected ground fragments that may remain after
 	// corner erasing / pit carving. The per-step rollback only guards door
 	// connectivity, so small isolated chunks can still appear.
 	ensureGroundConnectivity(ground, req.Width, req.Height)
+	trace.snapshot("connectivity repair", map[string][][]int{"ground": ground})
+
+	// Step 3.6: Apply designer forceGround / forceVoid masks
+	constraintReasons := applyGroundConstraints(ground, masks, doorPositions, req.Width, req.Height, trace)
+	if !masks.isEmpty() {
+		trace.snapshot("constraints", map[string][][]int{"ground": ground})
+	}
+
+	// Symmetry: mirror the ground so every later layer is placed on a symmetric room
+	if req.Symmetry.enabled() {
+		debugInfo.Symmetry = symmetrizeGround(req.Symmetry, ground, masks, constraintReasons, req.Width, req.Height)
+		debugInfo.Symmetry.AddedDoors = addedDoors
+		trace.snapshot("symmetric ground", map[string][][]int{"ground": ground})
+	}
 
 	debugInfo.Ground = groundDebug
+	if err := steps.done("ground"); err != nil {
+		return nil, err
+	}
 
 	// Generate other layers using shared functions
 	// Soft edge
 	softEdgeLayer := copyLayer(emptyLayer)
 	if req.SoftEdgeCount > 0 {
-		softEdgeDebug := generateSoftEdgeLayerWithDebug(softEdgeLayer, ground, doorPositions, req.Width, req.Height, req.SoftEdgeCount)
+		softEdgeDebug := generateSoftEdgeLayerWithDebug(rng, softEdgeLayer, ground, doorPositions, req.Width, req.Height, req.SoftEdgeCount)
 		debugInfo.SoftEdge = softEdgeDebug
+		trace.snapshot("soft edge", map[string][][]int{"softEdge": softEdgeLayer})
 	} else {
 		debugInfo.SoftEdge = &SoftEdgeDebugInfo{
 			Skipped:    true,
 			SkipReason: "softEdgeCount is 0 or not specified",
 		}
 	}
+	if err := steps.done("soft edge"); err != nil {
+		return nil, err
+	}
 
 	// Bridge layer — fullrooms never have bridge tiles.
 	// Bridge tiles are only meaningful in bridge rooms (floating islands over void).
@@ -207,17 +313,50 @@ func GenerateFullRoom(req FullRoomGenerateRequest) (*FullRoomGenerateResponse, e
 	// Rail layer
 	railLayer := copyLayer(emptyLayer)
 	if req.RailEnabled {
-		railDebug := GenerateRailLayer(railLayer, ground, bridgeLayer, req.Width, req.Height)
+		railDebug := GenerateRailLayer(rng, railLayer, ground, bridgeLayer, req.Width, req.Height)
 		debugInfo.Rail = railDebug
+		trace.snapshot("rail", map[string][][]int{"rail": railLayer})
 	} else {
 		debugInfo.Rail = &RailDebugInfo{
 			Skipped:    true,
 			SkipReason: "railEnabled is false or not specified",
 		}
 	}
+	if err := steps.done("rail"); err != nil {
+		return nil, err
+	}
+
+	// Pipeline layer
+	pipelineLayer := copyLayer(emptyLayer)
+	if req.PipelineEnabled {
+		debugInfo.Pipeline = GeneratePipelineLayer(rng, pipelineLayer, ground, bridgeLayer, railLayer, doorPositions, req.Width, req.Height, req.PipelineCount)
+		trace.snapshot("pipeline", map[string][][]int{"pipeline": pipelineLayer})
+	} else {
+		debugInfo.Pipeline = &PipelineDebugInfo{
+			Skipped:    true,
+			SkipReason: "pipelineEnabled is false or not specified",
+		}
+	}
+	if err := steps.done("pipeline"); err != nil {
+		return nil, err
+	}
+	// Hazard layer
+	hazardLayer := copyLayer(emptyLayer)
+	if req.HazardCount > 0 {
+		debugInfo.Hazard = GenerateHazardLayer(ctx, rng, hazardLayer, ground, bridgeLayer, railLayer, pipelineLayer, doorPositions, req.Width, req.Height, req.HazardCount)
+		trace.snapshot("hazard", map[string][][]int{"hazard": hazardLayer})
+	} else {
+		debugInfo.Hazard = &HazardDebugInfo{Skipped: true, SkipReason: "hazardCount is 0 or not specified"}
+	}
+	if err := steps.done("hazard"); err != nil {
+		return nil, err
+	}
+
+	// Statics and enemies keep off the pipeline and hazards
+	placementMasks := masks.withBlocked(pipelineLayer, hazardLayer)
 
 	// Apply stage rules (validate + override counts if stage type specified)
-	stageResult, stageErr := ValidateAndApplyStage(req.StageType, "full", req.Doors, ground, req.Width, req.Height)
+	stageResult, stageErr := ValidateAndApplyStage(rng, req.StageType, "full", req.StageOverrides, sides, ground, req.Width, req.Height)
 	if stageErr != nil {
 		return nil, stageErr
 	}
@@ -231,27 +370,32 @@ func GenerateFullRoom(req FullRoomGenerateRequest) (*FullRoomGenerateResponse, e
 	}
 
 	// Main path computation
-	mainPathData, mainPathDebug := ComputeMainPath(ground, bridgeLayer, doorPositions, req.Width, req.Height)
+	mainPathData, mainPathDebug := ComputeMainPath(ground, bridgeLayer, hazardLayer, doorPositions, req.Width, req.Height)
 	debugInfo.MainPath = mainPathDebug
+	if err := steps.done("main path"); err != nil {
+		return nil, err
+	}
+
+	// Statics and enemies are placed on grids, read back as layers once placement is done
+	lc := newLayerContext(ground, softEdgeLayer, bridgeLayer, railLayer, doorPositions, req.Width, req.Height)
+	lc.MainPath = mainPathData
 
 	// Static layer
-	staticLayer := copyLayer(emptyLayer)
 	if req.StaticCount > 0 {
-		staticDebug := generateStaticLayerWithDebugAndRail(staticLayer, ground, softEdgeLayer, bridgeLayer, railLayer, doorPositions, req.Width, req.Height, req.StaticCount)
+		staticDebug := generateStaticLayerWithDebugAndRail(ctx, rng, lc, placementMasks, req.StaticCount)
 		debugInfo.Static = staticDebug
+		trace.snapshotGrids("static", map[string]*Grid{"static": lc.Static})
 	} else {
 		debugInfo.Static = &StaticDebugInfo{
 			Skipped:    true,
 			SkipReason: "staticCount is 0 or not specified",
 		}
 	}
+	if err := steps.done("static"); err != nil {
+		return nil, err
+	}
 
 	// Use grouped or default placement depending on hints
-	zonerLayer := copyLayer(emptyLayer)
-	chaserLayer := copyLayer(emptyLayer)
-	dpsLayer := copyLayer(emptyLayer)
-	mobAirLayer := copyLayer(emptyLayer)
-
 	if hints != nil && hints.GroupCount > 0 && len(hints.Groups) > 0 {
 		// Grouped placement — place enemies per region
 		for _, group := range hints.Groups {
@@ -259,16 +403,16 @@ func GenerateFullRoom(req FullRoomGenerateRequest) (*FullRoomGenerateResponse, e
 			regionFilter := &RegionFilter{MinY: minY, MaxY: maxY, MinX: minX, MaxX: maxX}
 
 			if group.ZonerCount > 0 {
-				GenerateZonerLayer(zonerLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, doorPositions, mainPathData, req.Width, req.Height, group.ZonerCount, regionFilter)
+				GenerateZonerLayer(ctx, rng, lc, placementMasks, group.ZonerCount, regionFilter)
 			}
 			if group.ChaserCount > 0 {
-				GenerateChaserLayer(chaserLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, doorPositions, mainPathData, req.Width, req.Height, group.ChaserCount, regionFilter)
+				GenerateChaserLayer(ctx, rng, lc, placementMasks, group.ChaserCount, regionFilter)
 			}
 			if group.DPSCount > 0 {
-				GenerateDPSLayer(dpsLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, chaserLayer, doorPositions, mainPathData, req.Width, req.Height, group.DPSCount, regionFilter)
+				GenerateDPSLayer(ctx, rng, lc, placementMasks, group.DPSCount, regionFilter)
 			}
 			if group.MobAirCount > 0 {
-				GenerateMobAirLayerNew(mobAirLayer, ground, softEdgeLayer, bridgeLayer, staticLayer, zonerLayer, chaserLayer, dpsLayer, doorPositions, req.Width, req.Height, group.MobAirCount, nil)
+				GenerateMobAirLayerNew(ctx, lc, placementMasks, group.MobAirCount, nil)
 			}
 		}
 
@@ -281,32 +425,32 @@ func GenerateFullRoom(req FullRoomGenerateRequest) (*FullRoomGenerateResponse, e
 		//   1. Strict pass (respects 8-dir spacing) — preserves ideal spread.
 		//   2. Relaxed pass (drops spacing) — only used when strict pass still falls short,
 		//      guaranteeing the minimum is always met.
-		if remaining := req.ZonerCount - countCells(zonerLayer); remaining > 0 {
-			GenerateZonerLayer(zonerLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, doorPositions, mainPathData, req.Width, req.Height, remaining, nil)
+		if remaining := req.ZonerCount - lc.Zoner.Count(); remaining > 0 {
+			GenerateZonerLayer(ctx, rng, lc, placementMasks, remaining, nil)
 		}
-		if remaining := req.ChaserCount - countCells(chaserLayer); remaining > 0 {
-			GenerateChaserLayer(chaserLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, doorPositions, mainPathData, req.Width, req.Height, remaining, nil)
+		if remaining := req.ChaserCount - lc.Chaser.Count(); remaining > 0 {
+			GenerateChaserLayer(ctx, rng, lc, placementMasks, remaining, nil)
 			// Relaxed fallback: if strict pass still can't fill target, drop spacing constraint.
-			if remaining2 := req.ChaserCount - countCells(chaserLayer); remaining2 > 0 {
-				GenerateChaserLayerRelaxed(chaserLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, doorPositions, mainPathData, req.Width, req.Height, remaining2)
+			if remaining2 := req.ChaserCount - lc.Chaser.Count(); remaining2 > 0 {
+				GenerateChaserLayerRelaxed(ctx, rng, lc, placementMasks, remaining2)
 			}
 		}
-		if remaining := req.DPSCount - countCells(dpsLayer); remaining > 0 {
-			GenerateDPSLayer(dpsLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, chaserLayer, doorPositions, mainPathData, req.Width, req.Height, remaining, nil)
+		if remaining := req.DPSCount - lc.DPS.Count(); remaining > 0 {
+			GenerateDPSLayer(ctx, rng, lc, placementMasks, remaining, nil)
 			// Relaxed fallback: if strict pass still can't fill target, drop spacing constraint.
-			if remaining2 := req.DPSCount - countCells(dpsLayer); remaining2 > 0 {
-				GenerateDPSLayerRelaxed(dpsLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, chaserLayer, doorPositions, mainPathData, req.Width, req.Height, remaining2)
+			if remaining2 := req.DPSCount - lc.DPS.Count(); remaining2 > 0 {
+				GenerateDPSLayerRelaxed(ctx, rng, lc, placementMasks, remaining2)
 			}
 		}
-		if remaining := req.MobAirCount - countCells(mobAirLayer); remaining > 0 {
-			GenerateMobAirLayerNew(mobAirLayer, ground, softEdgeLayer, bridgeLayer, staticLayer, zonerLayer, chaserLayer, dpsLayer, doorPositions, req.Width, req.Height, remaining, nil)
+		if remaining := req.MobAirCount - lc.MobAir.Count(); remaining > 0 {
+			GenerateMobAirLayerNew(ctx, lc, placementMasks, remaining, nil)
 		}
 
 		// Count placed for debug
-		debugInfo.Zoner = countLayerDebug(zonerLayer, req.ZonerCount, "zoner")
-		debugInfo.Chaser = countLayerDebug(chaserLayer, req.ChaserCount, "chaser")
-		debugInfo.DPS = countLayerDebug(dpsLayer, req.DPSCount, "dps")
-		debugInfo.MobAir = &MobAirDebugInfo{TargetCount: req.MobAirCount, PlacedCount: countCells(mobAirLayer), Strategy: "grouped"}
+		debugInfo.Zoner = countLayerDebug(lc.Zoner, req.ZonerCount, "zoner")
+		debugInfo.Chaser = countLayerDebug(lc.Chaser, req.ChaserCount, "chaser")
+		debugInfo.DPS = countLayerDebug(lc.DPS, req.DPSCount, "dps")
+		debugInfo.MobAir = &MobAirDebugInfo{TargetCount: req.MobAirCount, PlacedCount: lc.MobAir.Count(), Strategy: "grouped"}
 	} else {
 		// Default placement (no grouping)
 		// Build region filter from hints
@@ -325,52 +469,74 @@ func GenerateFullRoom(req FullRoomGenerateRequest) (*FullRoomGenerateResponse, e
 				cx, cy := req.Width/2, req.Height/2
 				zonerFilter = &RegionFilter{MinY: cy - 3, MaxY: cy + 3, MinX: cx - 3, MaxX: cx + 3}
 			}
-			zonerDebug := GenerateZonerLayer(zonerLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, doorPositions, mainPathData, req.Width, req.Height, req.ZonerCount, zonerFilter)
+			zonerDebug := GenerateZonerLayer(ctx, rng, lc, placementMasks, req.ZonerCount, zonerFilter)
 			debugInfo.Zoner = zonerDebug
 		} else {
 			debugInfo.Zoner = &EnemyLayerDebugInfo{Skipped: true, SkipReason: "zonerCount is 0 or not specified"}
 		}
 
 		if req.ChaserCount > 0 {
-			chaserDebug := GenerateChaserLayer(chaserLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, doorPositions, mainPathData, req.Width, req.Height, req.ChaserCount, chaserFilter)
+			chaserDebug := GenerateChaserLayer(ctx, rng, lc, placementMasks, req.ChaserCount, chaserFilter)
 			debugInfo.Chaser = chaserDebug
 		} else {
 			debugInfo.Chaser = &EnemyLayerDebugInfo{Skipped: true, SkipReason: "chaserCount is 0 or not specified"}
 		}
 
 		if req.DPSCount > 0 {
-			dpsDebug := GenerateDPSLayer(dpsLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, chaserLayer, doorPositions, mainPathData, req.Width, req.Height, req.DPSCount, dpsFilter)
+			dpsDebug := GenerateDPSLayer(ctx, rng, lc, placementMasks, req.DPSCount, dpsFilter)
 			debugInfo.DPS = dpsDebug
 		} else {
 			debugInfo.DPS = &EnemyLayerDebugInfo{Skipped: true, SkipReason: "dpsCount is 0 or not specified"}
 		}
 
 		if req.MobAirCount > 0 {
-			mobAirDebug := GenerateMobAirLayerNew(mobAirLayer, ground, softEdgeLayer, bridgeLayer, staticLayer, zonerLayer, chaserLayer, dpsLayer, doorPositions, req.Width, req.Height, req.MobAirCount, nil)
+			mobAirDebug := GenerateMobAirLayerNew(ctx, lc, placementMasks, req.MobAirCount, nil)
 			debugInfo.MobAir = mobAirDebug
 		} else {
 			debugInfo.MobAir = &MobAirDebugInfo{Skipped: true, SkipReason: "mobAirCount is 0 or not specified"}
 		}
 	}
 
+	staticLayer := lc.Static.Layer()
+	zonerLayer := lc.Zoner.Layer()
+	chaserLayer := lc.Chaser.Layer()
+	dpsLayer := lc.DPS.Layer()
+	mobAirLayer := lc.MobAir.Layer()
+
+	// Each enemy type only writes its own layer, so one frame per type shows its placement
+	trace.snapshot("zoner", map[string][][]int{"zoner": zonerLayer})
+	trace.snapshot("chaser", map[string][][]int{"chaser": chaserLayer})
+	trace.snapshot("dps", map[string][][]int{"dps": dpsLayer})
+	trace.snapshot("mobAir", map[string][][]int{"mobAir": mobAirLayer})
+	if err := steps.done("enemies"); err != nil {
+		return nil, err
+	}
+
+	// Pickup layer
+	pickupLayer := copyLayer(emptyLayer)
+	if req.PickupCount > 0 {
+		debugInfo.Pickup = GeneratePickupLayer(rng, pickupLayer, ground, bridgeLayer, railLayer, pipelineLayer, hazardLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, doorPositions, mainPathData, req.Width, req.Height, req.PickupCount)
+		trace.snapshot("pickup", map[string][][]int{"pickup": pickupLayer})
+	} else {
+		debugInfo.Pickup = &PickupDebugInfo{Skipped: true, SkipReason: "pickupCount is 0 or not specified"}
+	}
+	if err := steps.done("pickup"); err != nil {
+		return nil, err
+	}
+
 	// Create door states
-	doorStates := &model.DoorStates{
-		Top:    0,
-		Right:  0,
-		Bottom: 0,
-		Left:   0,
-	}
-	for _, door := range req.Doors {
-		switch door {
-		case DoorTop:
-			doorStates.Top = 1
-		case DoorRight:
-			doorStates.Right = 1
-		case DoorBottom:
-			doorStates.Bottom = 1
-		case DoorLeft:
-			doorStates.Left = 1
+	doorStates := model.DoorStatesFromDescriptors(descriptors)
+
+	// Symmetry: copy the source region of every layer onto the rest of the room,
+	// then recompute the main path over the mirrored bridges
+	if req.Symmetry.enabled() {
+		debugInfo.Symmetry.MirroredCells = mirrorLayers(req.Symmetry, req.Width, req.Height, softEdgeLayer, bridgeLayer, railLayer, pipelineLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, pickupLayer, hazardLayer)
+		debugInfo.Symmetry.RemovedCells += dropCuttingStatics(req.Symmetry, staticLayer, ground, doorPositions, req.Width, req.Height)
+		if n := dropCuttingHazards(hazardLayer, ground, bridgeLayer, doorPositions, req.Width, req.Height); n > 0 {
+			debugInfo.Symmetry.RemovedCells += n
+			debugInfo.Symmetry.HazardDropped = true
 		}
+		mainPathData, debugInfo.MainPath = ComputeMainPath(ground, bridgeLayer, hazardLayer, doorPositions, req.Width, req.Height)
 	}
 
 	// Build main path layer for output
@@ -397,21 +563,22 @@ func GenerateFullRoom(req FullRoomGenerateRequest) (*FullRoomGenerateResponse, e
 	}
 	stageType := &stageStr
 	payload := model.TemplatePayload{
-		Ground:       ground,
-		SoftEdge:     softEdgeLayer,
-		Bridge:       bridgeLayer,
-		Rail:         railLayer,
-		Static:       staticLayer,
-		Chaser:       chaserLayer,
-		Zoner:        zonerLayer,
-		DPS:          dpsLayer,
-		MobAir:       mobAirLayer,
-		MainPath:     mainPathLayer,
-		Doors:        doorStates,
-		StageType:    stageType,
-		RoomShape:    &roomShape,
-		RoomCategory: &roomCategory,
-		OpenDoors:    model.ComputeOpenDoors(doorStates),
+		Ground:          ground,
+		SoftEdge:        softEdgeLayer,
+		Bridge:          bridgeLayer,
+		Rail:            railLayer,
+		Static:          staticLayer,
+		Chaser:          chaserLayer,
+		Zoner:           zonerLayer,
+		DPS:             dpsLayer,
+		MobAir:          mobAirLayer,
+		MainPath:        mainPathLayer,
+		Doors:           doorStates,
+		DoorDescriptors: descriptors,
+		StageType:       stageType,
+		RoomShape:       &roomShape,
+		RoomCategory:    &roomCategory,
+		OpenDoors:       model.ComputeOpenDoors(doorStates),
 		Meta: model.TemplateMeta{
 			Name:    fmt.Sprintf("full-%dx%d", req.Width, req.Height),
 			Version: 1,
@@ -420,22 +587,57 @@ func GenerateFullRoom(req FullRoomGenerateRequest) (*FullRoomGenerateResponse, e
 		},
 	}
 
+	if req.PipelineEnabled {
+		payload.Pipeline = pipelineLayer
+	}
+	if req.HazardCount > 0 {
+		payload.Hazard = hazardLayer
+	}
+	if req.PickupCount > 0 {
+		payload.Pickup = pickupLayer
+	}
+	payload.SetLineSegments()
+
+	// Validate the mirrored room, clearing mirrored cells that break a rule
+	if req.Symmetry.enabled() {
+		if err := finishSymmetricPayload(req.Symmetry, &payload, debugInfo.Symmetry); err != nil {
+			return nil, err
+		}
+	}
+
+	// Patrol routes are traced around the final chaser cells
+	payload.ChaserPatrols, debugInfo.Patrol = GenerateChaserPatrols(ctx, rng, req.Symmetry, chaserLayer, ground, staticLayer, hazardLayer, doorPositions, req.Width, req.Height)
+	if err := steps.done("patrols"); err != nil {
+		return nil, err
+	}
+
+	// Spawn waves are assigned to the final enemy cells
+	payload.Waves, debugInfo.Waves = assignWaves(hints, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, req.Width, req.Height)
+	trace.snapshotPayload("final", &payload)
+
 	// Compute difficulty
-	difficulty := ComputeDifficulty(ground, softEdgeLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, mainPathData, req.Width, req.Height)
+	difficulty := ComputeDifficulty(ground, softEdgeLayer, hazardLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, mainPathData, req.Width, req.Height)
+
+	// Report designer masks that could not be honored
+	if !masks.isEmpty() {
+		debugInfo.Constraints = checkConstraints(masks, constraintReasons, ground, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer)
+	}
 
 	return &FullRoomGenerateResponse{
 		Payload:    payload,
 		DebugInfo:  debugInfo,
 		Difficulty: difficulty,
+		Seed:       seed,
+		Trace:      trace.Frames(),
 	}, nil
 }
 
 // generateFullRoomCornerErase performs step 2: erase corners with 40% probability
-func generateFullRoomCornerErase(ground [][]int, width, height int, doors []DoorPosition, debug *FullRoomGroundDebugInfo) {
+func generateFullRoomCornerErase(rng *rand.Rand, ground [][]int, masks *ConstraintMasks, width, height int, doors []DoorSite, debug *FullRoomGroundDebugInfo, trace *Trace) {
 	cornerDebug := &CornerEraseDebugInfo{}
 
 	// 40% probability to execute
-	if rand.Float64() >= 0.4 {
+	if rng.Float64() >= 0.4 {
 		cornerDebug.Skipped = true
 		cornerDebug.SkipReason = "did not pass 40% probability check"
 		debug.CornerErase = cornerDebug
@@ -446,15 +648,15 @@ func generateFullRoomCornerErase(ground [][]int, width, height int, doors []Door
 
 	// Choose brush type (50/50)
 	var brushW, brushH int
-	if rand.Float64() < 0.5 {
+	if rng.Float64() < 0.5 {
 		// Brush 1: 1 <= x <= M/2, 1 <= y <= 2
 		cornerDebug.BrushType = "horizontal"
 		maxW := width / 2
 		if maxW < 1 {
 			maxW = 1
 		}
-		brushW = 1 + rand.Intn(maxW)
-		brushH = 1 + rand.Intn(2)
+		brushW = 1 + rng.Intn(maxW)
+		brushH = 1 + rng.Intn(2)
 	} else {
 		// Brush 2: 1 <= x <= 2, 1 <= y <= N/2
 		cornerDebug.BrushType = "vertical"
@@ -462,14 +664,14 @@ func generateFullRoomCornerErase(ground [][]int, width, height int, doors []Door
 		if maxH < 1 {
 			maxH = 1
 		}
-		brushW = 1 + rand.Intn(2)
-		brushH = 1 + rand.Intn(maxH)
+		brushW = 1 + rng.Intn(2)
+		brushH = 1 + rng.Intn(maxH)
 	}
 
 	cornerDebug.BrushSize = fmt.Sprintf("%dx%d", brushW, brushH)
 
 	// Select corner combination by probability
-	r := rand.Float64()
+	r := rng.Float64()
 	var selected cornerCombo
 	for _, combo := range cornerCombos {
 		if r < combo.weight {
@@ -479,7 +681,9 @@ func generateFullRoomCornerErase(ground [][]int, width, height int, doors []Door
 	}
 	cornerDebug.Combo = selected.label
 
-	// Apply each corner erase
+	doorsConnected := func() bool { return areAllDoorsConnected(ground, width, height, doors) }
+
+	// Apply each corner erase, rolling back any that disconnects the doors
 	for _, corner := range selected.corners {
 		x, y := getCornerPosition(corner, width, height, brushW, brushH)
 
@@ -489,17 +693,10 @@ func generateFullRoomCornerErase(ground [][]int, width, height int, doors []Door
 			Size:     fmt.Sprintf("%dx%d", brushW, brushH),
 		}
 
-		// Save state for rollback
-		backup := copyLayer(ground)
-
-		// Erase the corner
-		eraseRect(ground, x, y, brushW, brushH, width, height)
-
-		// Check connectivity
-		if !areAllDoorsConnected(ground, width, height, doors) {
-			// Rollback
-			restoreLayer(ground, backup)
-
+		erased := trace.tryMutate("corner erase", "ground", "broke door connectivity", ground, func() {
+			eraseRect(ground, x, y, brushW, brushH, masks, width, height)
+		}, doorsConnected)
+		if !erased {
 			// Retry once: try a smaller brush
 			retryW := brushW
 			retryH := brushH
@@ -517,11 +714,11 @@ func generateFullRoomCornerErase(ground [][]int, width, height int, doors []Door
 			}
 
 			x2, y2 := getCornerPosition(corner, width, height, retryW, retryH)
-			eraseRect(ground, x2, y2, retryW, retryH, width, height)
-
-			if !areAllDoorsConnected(ground, width, height, doors) {
-				// Rollback again and skip remaining corners
-				restoreLayer(ground, backup)
+			erased = trace.tryMutate("corner erase", "ground", "broke door connectivity after retry", ground, func() {
+				eraseRect(ground, x2, y2, retryW, retryH, masks, width, height)
+			}, doorsConnected)
+			if !erased {
+				// Skip remaining corners
 				info.RolledBack = true
 				info.Reason = "broke door connectivity after retry, skipping remaining corners"
 				cornerDebug.Corners = append(cornerDebug.Corners, info)
@@ -540,11 +737,11 @@ func generateFullRoomCornerErase(ground [][]int, width, height int, doors []Door
 }
 
 // generateFullRoomCenterPits performs step 3: center pits with 30% probability
-func generateFullRoomCenterPits(ground [][]int, width, height int, doors []DoorPosition, debug *FullRoomGroundDebugInfo) {
+func generateFullRoomCenterPits(rng *rand.Rand, ground [][]int, masks *ConstraintMasks, width, height int, doors []DoorSite, debug *FullRoomGroundDebugInfo, trace *Trace) {
 	pitsDebug := &CenterPitsDebugInfo{}
 
 	// 30% probability to execute
-	if rand.Float64() >= 0.3 {
+	if rng.Float64() >= 0.3 {
 		pitsDebug.Skipped = true
 		pitsDebug.SkipReason = "did not pass 30% probability check"
 		debug.CenterPits = pitsDebug
@@ -562,17 +759,17 @@ func generateFullRoomCenterPits(ground [][]int, width, height int, doors []DoorP
 	if maxBrushH < 2 {
 		maxBrushH = 2
 	}
-	brushW := 2 + rand.Intn(maxBrushW-1)
-	brushH := 2 + rand.Intn(maxBrushH-1)
+	brushW := 2 + rng.Intn(maxBrushW-1)
+	brushH := 2 + rng.Intn(maxBrushH-1)
 
 	pitsDebug.BrushSize = fmt.Sprintf("%dx%d", brushW, brushH)
 
 	// Select 1~4 pits
-	pitCount := 1 + rand.Intn(4)
+	pitCount := 1 + rng.Intn(4)
 	pitsDebug.PitCount = pitCount
 
 	// Choose symmetry: left-right or top-bottom
-	isLeftRight := rand.Float64() < 0.5
+	isLeftRight := rng.Float64() < 0.5
 	if isLeftRight {
 		pitsDebug.Symmetry = "left-right"
 	} else {
@@ -592,9 +789,9 @@ func generateFullRoomCenterPits(ground [][]int, width, height int, doors []DoorP
 		if isLeftRight {
 			// Left-right symmetric: spread pits vertically, mirror horizontally
 			// Random offset from center on the X axis
-			offsetX := 1 + rand.Intn(maxBrushW+1)
+			offsetX := 1 + rng.Intn(maxBrushW+1)
 			// Random Y position spread
-			offsetY := rand.Intn(height/3+1) - height/6
+			offsetY := rng.Intn(height/3+1) - height/6
 			pitY := centerY + offsetY - brushH/2
 
 			// Left pit
@@ -605,8 +802,8 @@ func generateFullRoomCenterPits(ground [][]int, width, height int, doors []DoorP
 			pitPositions = append(pitPositions, pitPos{rightX, pitY})
 		} else {
 			// Top-bottom symmetric: spread pits horizontally, mirror vertically
-			offsetY := 1 + rand.Intn(maxBrushH/2+1)
-			offsetX := rand.Intn(width/3+1) - width/6
+			offsetY := 1 + rng.Intn(maxBrushH/2+1)
+			offsetX := rng.Intn(width/3+1) - width/6
 			pitX := centerX + offsetX - brushW/2
 
 			// Top pit
@@ -620,12 +817,7 @@ func generateFullRoomCenterPits(ground [][]int, width, height int, doors []DoorP
 
 	// Apply pits, rollback each pair if connectivity breaks
 	for i := 0; i < len(pitPositions); i += 2 {
-		backup := copyLayer(ground)
-
-		// Apply the pair (or single if odd)
 		pit1 := pitPositions[i]
-		eraseRect(ground, pit1.x, pit1.y, brushW, brushH, width, height)
-
 		info1 := CenterPitInfo{
 			Position: fmt.Sprintf("(%d,%d)", pit1.x, pit1.y),
 			Size:     fmt.Sprintf("%dx%d", brushW, brushH),
@@ -634,16 +826,21 @@ func generateFullRoomCenterPits(ground [][]int, width, height int, doors []DoorP
 		var info2 *CenterPitInfo
 		if i+1 < len(pitPositions) {
 			pit2 := pitPositions[i+1]
-			eraseRect(ground, pit2.x, pit2.y, brushW, brushH, width, height)
 			info2 = &CenterPitInfo{
 				Position: fmt.Sprintf("(%d,%d)", pit2.x, pit2.y),
 				Size:     fmt.Sprintf("%dx%d", brushW, brushH),
 			}
 		}
 
-		// Check connectivity
-		if !areAllDoorsConnected(ground, width, height, doors) {
-			restoreLayer(ground, backup)
+		// Apply the pair (or single if odd)
+		carved := trace.tryMutate("center pits", "ground", "broke door connectivity", ground, func() {
+			eraseRect(ground, pit1.x, pit1.y, brushW, brushH, masks, width, height)
+			if i+1 < len(pitPositions) {
+				pit2 := pitPositions[i+1]
+				eraseRect(ground, pit2.x, pit2.y, brushW, brushH, masks, width, height)
+			}
+		}, func() bool { return areAllDoorsConnected(ground, width, height, doors) })
+		if !carved {
 			info1.RolledBack = true
 			info1.Reason = "broke door connectivity"
 			if info2 != nil {
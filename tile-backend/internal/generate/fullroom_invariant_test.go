@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"context"
 	"testing"
 )
 
@@ -54,7 +55,7 @@ func TestFullRoomGroundAlwaysConnected(t *testing.T) {
 			RailEnabled:   trial%2 == 0,
 		}
 
-		resp, err := GenerateFullRoom(req)
+		resp, err := GenerateFullRoom(context.Background(), req)
 		if err != nil {
 			continue
 		}
@@ -160,7 +161,7 @@ func TestFullRoomNoBridgeTiles(t *testing.T) {
 			RailEnabled:   trial%2 == 0,
 		}
 
-		resp, err := GenerateFullRoom(req)
+		resp, err := GenerateFullRoom(context.Background(), req)
 		if err != nil {
 			continue
 		}
@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -72,7 +73,7 @@ func TestGenerateFullRoom_ValidInput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resp, err := GenerateFullRoom(tt.req)
+			resp, err := GenerateFullRoom(context.Background(), tt.req)
 			require.NoError(t, err)
 			require.NotNil(t, resp)
 
@@ -147,7 +148,7 @@ func TestGenerateFullRoom_InvalidInput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resp, err := GenerateFullRoom(tt.req)
+			resp, err := GenerateFullRoom(context.Background(), tt.req)
 			require.Error(t, err)
 			assert.Nil(t, resp)
 			assert.Contains(t, err.Error(), tt.expectedErr)
@@ -169,7 +170,7 @@ func TestGenerateFullRoom_SingleDoor(t *testing.T) {
 			Height: 20,
 			Doors:  doors,
 		}
-		resp, err := GenerateFullRoom(req)
+		resp, err := GenerateFullRoom(context.Background(), req)
 		require.NoError(t, err, "expected no error for doors=%v", doors)
 		require.NotNil(t, resp)
 	}
@@ -196,10 +197,10 @@ func TestGenerateFullRoom_DoorsAlwaysConnected(t *testing.T) {
 				Doors:  doors,
 			}
 
-			resp, err := GenerateFullRoom(req)
+			resp, err := GenerateFullRoom(context.Background(), req)
 			require.NoError(t, err)
 
-			assert.True(t, areAllDoorsConnected(resp.Payload.Ground, req.Width, req.Height, doors),
+			assert.True(t, areAllDoorsConnected(resp.Payload.Ground, req.Width, req.Height, getDoorCenterPositions(req.Width, req.Height, doors)),
 				"doors should always be connected (doors=%v, iteration=%d)", doors, i)
 		}
 	}
@@ -214,7 +215,7 @@ func TestGenerateFullRoom_HighGroundCoverage(t *testing.T) {
 
 	// Run multiple times to account for randomness
 	for i := 0; i < 10; i++ {
-		resp, err := GenerateFullRoom(req)
+		resp, err := GenerateFullRoom(context.Background(), req)
 		require.NoError(t, err)
 
 		// Count walkable tiles
@@ -249,7 +250,7 @@ func TestGenerateFullRoom_WithOptionalLayers(t *testing.T) {
 		MobAirCount:   4,
 	}
 
-	resp, err := GenerateFullRoom(req)
+	resp, err := GenerateFullRoom(context.Background(), req)
 	require.NoError(t, err)
 	require.NotNil(t, resp.DebugInfo)
 
@@ -276,7 +277,7 @@ func TestGenerateFullRoom_PressureStageZonerCount(t *testing.T) {
 			StaticCount:   5,
 			RailEnabled:   true,
 		}
-		resp, err := GenerateFullRoom(req)
+		resp, err := GenerateFullRoom(context.Background(), req)
 		require.NoError(t, err, "iteration %d", i)
 		require.NotNil(t, resp, "iteration %d", i)
 
@@ -306,7 +307,7 @@ func TestGenerateFullRoom_DebugInfoPopulated(t *testing.T) {
 		MobAirCount:   2,
 	}
 
-	resp, err := GenerateFullRoom(req)
+	resp, err := GenerateFullRoom(context.Background(), req)
 	require.NoError(t, err)
 	require.NotNil(t, resp.DebugInfo)
 	require.NotNil(t, resp.DebugInfo.Ground)
@@ -0,0 +1,295 @@
+package generate
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// Grid is a binary layer packed one bit per cell in row-major order. Placement
+// rules test and flood-fill grids instead of [][]int layers, so a candidate
+// check touches a few words rather than allocating a fresh [][]bool. Cells
+// outside the grid read as unset and ignore writes.
+type Grid struct {
+	width, height int
+	words         []uint64
+	queue         []int // Flood-fill queue of cell indices, kept between fills to avoid reallocating
+}
+
+// NewGrid returns an empty width×height grid
+func NewGrid(width, height int) *Grid {
+	return &Grid{
+		width:  width,
+		height: height,
+		words:  make([]uint64, (width*height+63)/64),
+	}
+}
+
+// GridFromLayer returns a grid with every non-zero cell of layer set. A nil
+// layer gives an empty grid.
+func GridFromLayer(layer [][]int, width, height int) *Grid {
+	g := NewGrid(width, height)
+	for y := 0; y < height && y < len(layer); y++ {
+		for x := 0; x < width && x < len(layer[y]); x++ {
+			if layer[y][x] != 0 {
+				g.Set(x, y)
+			}
+		}
+	}
+	return g
+}
+
+// gridFromPoints returns a grid with the given cells set
+func gridFromPoints(points map[Point]bool, width, height int) *Grid {
+	g := NewGrid(width, height)
+	for p, ok := range points {
+		if ok {
+			g.Set(p.X, p.Y)
+		}
+	}
+	return g
+}
+
+// Layer converts the grid back to a [][]int layer of 0s and 1s
+func (g *Grid) Layer() [][]int {
+	layer := createEmptyLayer(g.width, g.height)
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			if g.Has(x, y) {
+				layer[y][x] = 1
+			}
+		}
+	}
+	return layer
+}
+
+// Width returns the grid width in cells
+func (g *Grid) Width() int {
+	return g.width
+}
+
+// Height returns the grid height in cells
+func (g *Grid) Height() int {
+	return g.height
+}
+
+// InBounds reports whether (x, y) is a cell of the grid
+func (g *Grid) InBounds(x, y int) bool {
+	return x >= 0 && x < g.width && y >= 0 && y < g.height
+}
+
+// Has reports whether the cell is set
+func (g *Grid) Has(x, y int) bool {
+	if !g.InBounds(x, y) {
+		return false
+	}
+	i := y*g.width + x
+	return g.words[i>>6]&(1<<(uint(i)&63)) != 0
+}
+
+// Set sets the cell
+func (g *Grid) Set(x, y int) {
+	if g.InBounds(x, y) {
+		i := y*g.width + x
+		g.words[i>>6] |= 1 << (uint(i) & 63)
+	}
+}
+
+// Clear unsets the cell
+func (g *Grid) Clear(x, y int) {
+	if g.InBounds(x, y) {
+		i := y*g.width + x
+		g.words[i>>6] &^= 1 << (uint(i) & 63)
+	}
+}
+
+// SetRect sets every cell of the w×h rectangle with top-left corner (x, y)
+func (g *Grid) SetRect(x, y, w, h int) {
+	for dy := 0; dy < h; dy++ {
+		for dx := 0; dx < w; dx++ {
+			g.Set(x+dx, y+dy)
+		}
+	}
+}
+
+// ClearRect unsets every cell of the w×h rectangle with top-left corner (x, y)
+func (g *Grid) ClearRect(x, y, w, h int) {
+	for dy := 0; dy < h; dy++ {
+		for dx := 0; dx < w; dx++ {
+			g.Clear(x+dx, y+dy)
+		}
+	}
+}
+
+// AnyInRect reports whether any cell of the w×h rectangle with top-left
+// corner (x, y) is set
+func (g *Grid) AnyInRect(x, y, w, h int) bool {
+	for dy := 0; dy < h; dy++ {
+		for dx := 0; dx < w; dx++ {
+			if g.Has(x+dx, y+dy) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Count returns the number of set cells
+func (g *Grid) Count() int {
+	n := 0
+	for _, w := range g.words {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Reset unsets every cell
+func (g *Grid) Reset() {
+	clear(g.words)
+}
+
+// Clone returns an independent copy of the grid
+func (g *Grid) Clone() *Grid {
+	c := NewGrid(g.width, g.height)
+	copy(c.words, g.words)
+	return c
+}
+
+// CopyFrom overwrites the grid with src, which must have the same size. The
+// grid's storage is reused, so copying in a loop does not allocate.
+func (g *Grid) CopyFrom(src *Grid) {
+	copy(g.words, src.words)
+}
+
+// Or sets every cell that is set in other
+func (g *Grid) Or(other *Grid) {
+	for i := range g.words {
+		g.words[i] |= other.words[i]
+	}
+}
+
+// AndNot unsets every cell that is set in other
+func (g *Grid) AndNot(other *Grid) {
+	for i := range g.words {
+		g.words[i] &^= other.words[i]
+	}
+}
+
+// Neighbors appends the set 4-connected neighbours of p to buf, in the order
+// up, right, down, left, and returns the extended slice
+func (g *Grid) Neighbors(p Point, buf []Point) []Point {
+	for _, d := range [4]Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+		if g.Has(p.X+d.X, p.Y+d.Y) {
+			buf = append(buf, Point{X: p.X + d.X, Y: p.Y + d.Y})
+		}
+	}
+	return buf
+}
+
+// Touches reports whether any 8-directional neighbour of p is set
+func (g *Grid) Touches(p Point) bool {
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if (dx != 0 || dy != 0) && g.Has(p.X+dx, p.Y+dy) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Nearest returns the set cell closest to p, searching p itself and then
+// square rings of growing radius, or (-1,-1) if the grid is empty. The search
+// order matches findNearestWalkablePoint, so both pick the same cell.
+func (g *Grid) Nearest(p Point) Point {
+	if g.Has(p.X, p.Y) {
+		return p
+	}
+	for radius := 1; radius < max(g.width, g.height); radius++ {
+		for dy := -radius; dy <= radius; dy++ {
+			for dx := -radius; dx <= radius; dx++ {
+				if abs(dx) != radius && abs(dy) != radius {
+					continue // Only check the perimeter
+				}
+				if g.Has(p.X+dx, p.Y+dy) {
+					return Point{X: p.X + dx, Y: p.Y + dy}
+				}
+			}
+		}
+	}
+	return Point{X: -1, Y: -1}
+}
+
+// FloodFill resets reached and sets in it every cell of g that is 4-connected
+// to start through set cells. Nothing is reached when start is unset.
+func (g *Grid) FloodFill(start Point, reached *Grid) {
+	g.fill(start, reached, nil)
+}
+
+// connects reports whether every target is 4-connected to start through set
+// cells. The fill stops as soon as the last target is reached.
+func (g *Grid) connects(start Point, targets []Point, reached *Grid) bool {
+	remaining := 0
+	for i, t := range targets {
+		if !g.Has(t.X, t.Y) {
+			return false
+		}
+		if !slices.Contains(targets[:i], t) {
+			remaining++
+		}
+	}
+	if remaining == 0 {
+		return true
+	}
+	g.fill(start, reached, func(cell int) bool {
+		for _, t := range targets {
+			if t.Y*g.width+t.X == cell {
+				remaining--
+				break
+			}
+		}
+		return remaining == 0
+	})
+	return remaining == 0
+}
+
+// fill floods from start into reached, calling stop (if set) with the index
+// of each newly reached cell; the fill ends early once stop returns true
+func (g *Grid) fill(start Point, reached *Grid, stop func(cell int) bool) {
+	reached.Reset()
+	if !g.Has(start.X, start.Y) {
+		return
+	}
+
+	// Walk cell indices rather than points so each neighbour costs two bit tests
+	w, n := g.width, g.width*g.height
+	done := false
+	visit := func(i int) bool {
+		mask := uint64(1) << (uint(i) & 63)
+		if g.words[i>>6]&mask == 0 || reached.words[i>>6]&mask != 0 {
+			return false
+		}
+		reached.words[i>>6] |= mask
+		if stop != nil && stop(i) {
+			done = true
+		}
+		return true
+	}
+	queue := append(g.queue[:0], start.Y*w+start.X)
+	visit(queue[0])
+	for head := 0; head < len(queue) && !done; head++ {
+		i := queue[head]
+		if i >= w && visit(i-w) {
+			queue = append(queue, i-w)
+		}
+		if i%w != w-1 && visit(i+1) {
+			queue = append(queue, i+1)
+		}
+		if i+w < n && visit(i+w) {
+			queue = append(queue, i+w)
+		}
+		if i%w != 0 && visit(i-1) {
+			queue = append(queue, i-1)
+		}
+	}
+	g.queue = queue
+}
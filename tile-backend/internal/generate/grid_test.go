@@ -0,0 +1,228 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrid_SetHasClear(t *testing.T) {
+	g := NewGrid(70, 3) // Rows straddle word boundaries
+	g.Set(0, 0)
+	g.Set(63, 0)
+	g.Set(64, 0)
+	g.Set(69, 2)
+
+	assert.True(t, g.Has(0, 0))
+	assert.True(t, g.Has(63, 0))
+	assert.True(t, g.Has(64, 0))
+	assert.True(t, g.Has(69, 2))
+	assert.False(t, g.Has(1, 0))
+	assert.Equal(t, 4, g.Count())
+
+	g.Clear(63, 0)
+	assert.False(t, g.Has(63, 0))
+	assert.Equal(t, 3, g.Count())
+
+	// Cells outside the grid read as unset and ignore writes
+	g.Set(-1, 0)
+	g.Set(70, 0)
+	g.Set(0, 3)
+	assert.False(t, g.Has(-1, 0))
+	assert.False(t, g.Has(70, 0))
+	assert.False(t, g.Has(0, 3))
+	assert.Equal(t, 3, g.Count())
+}
+
+func TestGrid_LayerRoundTrip(t *testing.T) {
+	layer := [][]int{
+		{0, 1, 0},
+		{1, 1, 0},
+	}
+	g := GridFromLayer(layer, 3, 2)
+	assert.Equal(t, 3, g.Count())
+	assert.Equal(t, layer, g.Layer())
+
+	// A nil layer reads as empty
+	assert.Equal(t, 0, GridFromLayer(nil, 3, 2).Count())
+}
+
+func TestGrid_Touches(t *testing.T) {
+	g := NewGrid(5, 5)
+	g.Set(2, 2)
+	assert.True(t, g.Touches(Point{X: 1, Y: 1}), "diagonal neighbours count")
+	assert.True(t, g.Touches(Point{X: 3, Y: 2}))
+	assert.False(t, g.Touches(Point{X: 2, Y: 2}), "the cell itself does not")
+	assert.False(t, g.Touches(Point{X: 4, Y: 4}))
+	assert.False(t, g.Touches(Point{X: -1, Y: 0}))
+}
+
+func TestGrid_RectsAndSetOps(t *testing.T) {
+	g := NewGrid(6, 6)
+	g.SetRect(1, 1, 3, 2)
+	assert.Equal(t, 6, g.Count())
+	assert.True(t, g.AnyInRect(3, 2, 2, 2))
+	assert.False(t, g.AnyInRect(4, 3, 2, 2))
+
+	other := NewGrid(6, 6)
+	other.SetRect(2, 1, 1, 2)
+	g.AndNot(other)
+	assert.Equal(t, 4, g.Count())
+	assert.False(t, g.Has(2, 1))
+
+	clone := g.Clone()
+	clone.Or(other)
+	assert.Equal(t, 6, clone.Count())
+	assert.Equal(t, 4, g.Count(), "clone is independent")
+
+	g.CopyFrom(clone)
+	assert.Equal(t, clone.Layer(), g.Layer())
+
+	g.ClearRect(0, 0, 6, 6)
+	assert.Equal(t, 0, g.Count())
+}
+
+func TestGrid_Neighbors(t *testing.T) {
+	g := GridFromLayer([][]int{
+		{0, 1, 0},
+		{1, 1, 0},
+		{0, 1, 0},
+	}, 3, 3)
+	assert.Equal(t, []Point{{1, 0}, {1, 2}, {0, 1}}, g.Neighbors(Point{X: 1, Y: 1}, nil))
+	assert.Equal(t, []Point{{1, 1}}, g.Neighbors(Point{X: 1, Y: 2}, nil))
+}
+
+func TestGrid_FloodFill(t *testing.T) {
+	g := GridFromLayer([][]int{
+		{1, 1, 0, 1},
+		{0, 1, 0, 1},
+		{1, 1, 0, 1},
+	}, 4, 3)
+	reached := NewGrid(4, 3)
+
+	g.FloodFill(Point{X: 0, Y: 0}, reached)
+	assert.Equal(t, [][]int{
+		{1, 1, 0, 0},
+		{0, 1, 0, 0},
+		{1, 1, 0, 0},
+	}, reached.Layer())
+
+	// The previous fill is reset
+	g.FloodFill(Point{X: 3, Y: 1}, reached)
+	assert.Equal(t, 3, reached.Count())
+
+	// Nothing is reached from an unset cell
+	g.FloodFill(Point{X: 2, Y: 1}, reached)
+	assert.Equal(t, 0, reached.Count())
+}
+
+func TestGrid_NearestMatchesFindNearestWalkable(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		width, height := 5+rng.Intn(20), 5+rng.Intn(20)
+		layer := createEmptyLayer(width, height)
+		for y := range layer {
+			for x := range layer[y] {
+				if rng.Intn(10) == 0 {
+					layer[y][x] = 1
+				}
+			}
+		}
+		p := Point{X: rng.Intn(width), Y: rng.Intn(height)}
+		assert.Equal(t, findNearestWalkablePoint(boolLayer(layer), p, width, height), GridFromLayer(layer, width, height).Nearest(p))
+	}
+	assert.Equal(t, Point{X: -1, Y: -1}, NewGrid(4, 4).Nearest(Point{X: 1, Y: 1}))
+}
+
+func TestStableSortByDistance_MatchesSliceStable(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	positions := make([]Point, 500)
+	for i := range positions {
+		positions[i] = Point{X: rng.Intn(40), Y: rng.Intn(30)}
+	}
+	distance := func(p Point) int { return distanceFromCenter(p, 20, 15) }
+
+	want := append([]Point{}, positions...)
+	sort.SliceStable(want, func(i, j int) bool { return distance(want[i]) < distance(want[j]) })
+	stableSortByDistance(positions, distance)
+	assert.Equal(t, want, positions)
+}
+
+// boolLayer converts a layer to the [][]bool form findNearestWalkablePoint reads
+func boolLayer(layer [][]int) [][]bool {
+	walkable := make([][]bool, len(layer))
+	for y := range layer {
+		walkable[y] = make([]bool, len(layer[y]))
+		for x := range layer[y] {
+			walkable[y][x] = layer[y][x] != 0
+		}
+	}
+	return walkable
+}
+
+// benchmarkRoom returns the ground and doors of a generated full room
+func benchmarkRoom(tb testing.TB, width, height int) ([][]int, []DoorSite) {
+	tb.Helper()
+	seed := int64(1)
+	doors := []DoorPosition{DoorTop, DoorRight, DoorBottom, DoorLeft}
+	resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{Width: width, Height: height, Doors: doors, Seed: &seed})
+	require.NoError(tb, err)
+	return resp.Payload.Ground, getDoorCenterPositions(width, height, doors)
+}
+
+var benchmarkSizes = [][2]int{{20, 12}, {200, 200}}
+
+// BenchmarkSortPositions compares the comparison sort static placement used
+// with the counting sort, over every 2x2 candidate of the room
+func BenchmarkSortPositions(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		width, height := size[0], size[1]
+		var shuffled []Point
+		for y := 0; y <= height-staticSize; y++ {
+			for x := 0; x <= width-staticSize; x++ {
+				shuffled = append(shuffled, Point{X: x, Y: y})
+			}
+		}
+		rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		positions := make([]Point, len(shuffled))
+		distance := func(p Point) int { return distanceFromCenter(p, width/2, height/2) }
+
+		b.Run(fmt.Sprintf("sliceStable/%dx%d", width, height), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				copy(positions, shuffled)
+				sort.SliceStable(positions, func(i, j int) bool { return distance(positions[i]) < distance(positions[j]) })
+			}
+		})
+		b.Run(fmt.Sprintf("counting/%dx%d", width, height), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				copy(positions, shuffled)
+				stableSortByDistance(positions, distance)
+			}
+		})
+	}
+}
+
+// BenchmarkGenerateFullRoom measures a whole generation with a static per
+// four columns, the step the grid and counting sort speed up
+func BenchmarkGenerateFullRoom(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		width, height := size[0], size[1]
+		b.Run(fmt.Sprintf("%dx%d", width, height), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				seed := int64(i)
+				_, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+					Width: width, Height: height, Doors: []DoorPosition{DoorTop, DoorRight, DoorBottom, DoorLeft},
+					StaticCount: width / 4, Seed: &seed,
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
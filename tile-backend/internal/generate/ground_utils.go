@@ -3,8 +3,24 @@ package generate
 import (
 	"fmt"
 	"math/rand"
+	"time"
 )
 
+// newRequestRand builds the per-request random source. When seed is nil a
+// fresh seed is drawn from the clock; the seed actually used is returned so it
+// can be echoed back and the room reproduced later.
+func newRequestRand(seed *int64) (*rand.Rand, int64) {
+	s := time.Now().UnixNano()
+	if seed != nil {
+		s = *seed
+	}
+	return rand.New(rand.NewSource(s)), s
+}
+
+// doorOrder is the canonical door iteration order. Door maps must be walked in
+// this order wherever the result depends on it, since Go map order is random.
+var doorOrder = []DoorPosition{DoorTop, DoorRight, DoorBottom, DoorLeft}
+
 // createEmptyLayer creates a new empty (all-zero) layer of the given dimensions
 func createEmptyLayer(width, height int) [][]int {
 	layer := make([][]int, height)
@@ -46,24 +62,6 @@ func max(a, b int) int {
 	return b
 }
 
-// getDoorCenterPositions returns the center position of each door
-func getDoorCenterPositions(width, height int, doors []DoorPosition) map[DoorPosition]Point {
-	positions := make(map[DoorPosition]Point)
-	for _, door := range doors {
-		switch door {
-		case DoorTop:
-			positions[door] = Point{X: width / 2, Y: 0}
-		case DoorBottom:
-			positions[door] = Point{X: width / 2, Y: height - 1}
-		case DoorLeft:
-			positions[door] = Point{X: 0, Y: height / 2}
-		case DoorRight:
-			positions[door] = Point{X: width - 1, Y: height / 2}
-		}
-	}
-	return positions
-}
-
 // drawLine draws a line between two points using the specified brush
 func drawLine(ground [][]int, from, to Point, brush BrushSize, width, height int) {
 	// Bresenham-like line drawing
@@ -142,7 +140,7 @@ func manhattanDistance(p1, p2 Point) int {
 // getDoorForbiddenCells returns all cells within Manhattan distance doorForbiddenRadius of any door.
 // This is the same radius used for enemy placement forbidden zones, ensuring statics
 // also respect the door exclusion area.
-func getDoorForbiddenCells(doorPositions map[DoorPosition]Point, width, height int) map[Point]bool {
+func getDoorForbiddenCells(doorPositions []DoorSite, width, height int) map[Point]bool {
 	return getDoorForbiddenCellsRadius(doorPositions, width, height, doorForbiddenRadius)
 }
 
@@ -181,8 +179,8 @@ func countCells(layer [][]int) int {
 }
 
 // countLayerDebug creates a simple debug info by counting placed cells
-func countLayerDebug(layer [][]int, target int, name string) *EnemyLayerDebugInfo {
-	placed := countCells(layer)
+func countLayerDebug(layer *Grid, target int, name string) *EnemyLayerDebugInfo {
+	placed := layer.Count()
 	return &EnemyLayerDebugInfo{
 		TargetCount: target,
 		PlacedCount: placed,
@@ -282,7 +280,7 @@ func ensureGroundConnectivity(ground [][]int, width, height int) {
 }
 
 // selectByWeight selects a strategy index by weight
-func selectByWeight(strategies []Strategy) int {
+func selectByWeight(rng *rand.Rand, strategies []Strategy) int {
 	if len(strategies) == 0 {
 		return -1
 	}
@@ -296,7 +294,7 @@ func selectByWeight(strategies []Strategy) int {
 		return -1
 	}
 
-	r := rand.Intn(totalWeight)
+	r := rng.Intn(totalWeight)
 	cumulative := 0
 	for i, s := range strategies {
 		cumulative += s.Weight
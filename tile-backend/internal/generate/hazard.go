@@ -0,0 +1,230 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+)
+
+// Hazard generation constants
+const (
+	minHazardStripLength    = 2  // Shortest strip, in cells
+	maxHazardStripLength    = 5  // Longest strip, in cells
+	hazardPlacementAttempts = 40 // Random starts tried per strip
+)
+
+// cellClass is how a cell can be crossed by the player
+type cellClass int
+
+const (
+	cellBlocked  cellClass = iota // Void: cannot be walked on
+	cellWalkable                  // Ground or bridge
+	cellCostly                    // Walkable, but damaging (hazard)
+)
+
+// passable reports whether the player can walk across the cell at all
+func (c cellClass) passable() bool {
+	return c != cellBlocked
+}
+
+// classifyCells sorts every cell into blocked, walkable or costly. Ground and
+// bridge are walkable; hazard cells on them are costly. hazard may be nil.
+func classifyCells(ground, bridge, hazard [][]int, width, height int) [][]cellClass {
+	classes := make([][]cellClass, height)
+	for y := 0; y < height; y++ {
+		classes[y] = make([]cellClass, width)
+		for x := 0; x < width; x++ {
+			switch {
+			case ground[y][x] != 1 && bridge[y][x] != 1:
+				classes[y][x] = cellBlocked
+			case hazard != nil && hazard[y][x] != 0:
+				classes[y][x] = cellCostly
+			default:
+				classes[y][x] = cellWalkable
+			}
+		}
+	}
+	return classes
+}
+
+// doorsSafelyConnected reports whether every door reaches every other door over
+// walkable cells alone, i.e. without crossing a costly cell
+func doorsSafelyConnected(classes [][]cellClass, doorPositions []DoorSite, width, height int) bool {
+	safe := make([][]bool, height)
+	for y := 0; y < height; y++ {
+		safe[y] = make([]bool, width)
+		for x := 0; x < width; x++ {
+			safe[y][x] = classes[y][x] == cellWalkable
+		}
+	}
+
+	doors := doorCenters(doorPositions)
+	if len(doors) < 2 {
+		return true
+	}
+	start := findNearestWalkablePoint(safe, doors[0], width, height)
+	if start.X < 0 {
+		return false
+	}
+
+	reached := make([][]bool, height)
+	for y := range reached {
+		reached[y] = make([]bool, width)
+	}
+	reached[start.Y][start.X] = true
+	queue := []Point{start}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, d := range []Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+			nx, ny := cur.X+d.X, cur.Y+d.Y
+			if nx >= 0 && nx < width && ny >= 0 && ny < height && safe[ny][nx] && !reached[ny][nx] {
+				reached[ny][nx] = true
+				queue = append(queue, Point{nx, ny})
+			}
+		}
+	}
+
+	for _, door := range doors[1:] {
+		p := findNearestWalkablePoint(safe, door, width, height)
+		if p.X < 0 || !reached[p.Y][p.X] {
+			return false
+		}
+	}
+	return true
+}
+
+// HazardDebugInfo contains debug information about hazard generation
+type HazardDebugInfo struct {
+	Skipped     bool              `json:"skipped"`
+	SkipReason  string            `json:"skipReason,omitempty"`
+	TargetCount int               `json:"targetCount"`
+	PlacedCount int               `json:"placedCount"`
+	Strips      []HazardStripInfo `json:"strips"`
+	Misses      []MissInfo        `json:"misses,omitempty"`
+}
+
+// HazardStripInfo describes a placed hazard strip
+type HazardStripInfo struct {
+	Start  string `json:"start"`  // First cell of the strip
+	End    string `json:"end"`    // Last cell of the strip
+	Length int    `json:"length"` // Number of cells covered
+}
+
+// GenerateHazardLayer lays straight hazard strips (spikes, lava) on the ground.
+// Strips stay off bridge, rail, pipeline and door approaches. Hazards are
+// walkable but costly, and a strip is only kept if the doors can still reach
+// each other without stepping on any hazard. Every candidate strip costs a
+// path search, so placement stops early once ctx is done.
+func GenerateHazardLayer(ctx context.Context, rng *rand.Rand, hazardLayer, ground, bridge, rail, pipeline [][]int, doorPositions []DoorSite, width, height, targetCount int) *HazardDebugInfo {
+	debug := &HazardDebugInfo{
+		TargetCount: targetCount,
+		Strips:      []HazardStripInfo{},
+	}
+
+	forbidden := getDoorForbiddenCells(doorPositions, width, height)
+	canPlace := func(p Point) bool {
+		if p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height {
+			return false
+		}
+		return ground[p.Y][p.X] == 1 && bridge[p.Y][p.X] == 0 && rail[p.Y][p.X] == 0 &&
+			pipeline[p.Y][p.X] == 0 && hazardLayer[p.Y][p.X] == 0 && !forbidden[p]
+	}
+
+	var starts []Point
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if canPlace(Point{X: x, Y: y}) {
+				starts = append(starts, Point{X: x, Y: y})
+			}
+		}
+	}
+	if len(starts) == 0 {
+		debug.Skipped = true
+		debug.SkipReason = "no ground cell is free of bridge, rail, pipeline and door approaches"
+		return debug
+	}
+	if !doorsSafelyConnected(classifyCells(ground, bridge, hazardLayer, width, height), doorPositions, width, height) {
+		debug.Skipped = true
+		debug.SkipReason = "doors are not connected by walkable cells"
+		return debug
+	}
+
+	blocked, cutting := 0, 0
+	for debug.PlacedCount < targetCount && ctx.Err() == nil {
+		var strip *HazardStripInfo
+		for attempt := 0; attempt < hazardPlacementAttempts && strip == nil; attempt++ {
+			start := starts[rng.Intn(len(starts))]
+			dir := pipelineDirections[rng.Intn(len(pipelineDirections))]
+			length := minHazardStripLength + rng.Intn(maxHazardStripLength-minHazardStripLength+1)
+			cells := pipelineLeg(start, dir, length)
+
+			fits := true
+			for _, c := range cells {
+				if !canPlace(c) {
+					fits = false
+					break
+				}
+			}
+			if !fits {
+				blocked++
+				continue
+			}
+
+			for _, c := range cells {
+				hazardLayer[c.Y][c.X] = 1
+			}
+			if !doorsSafelyConnected(classifyCells(ground, bridge, hazardLayer, width, height), doorPositions, width, height) {
+				for _, c := range cells {
+					hazardLayer[c.Y][c.X] = 0
+				}
+				cutting++
+				continue
+			}
+
+			end := cells[len(cells)-1]
+			strip = &HazardStripInfo{
+				Start:  fmt.Sprintf("(%d,%d)", start.X, start.Y),
+				End:    fmt.Sprintf("(%d,%d)", end.X, end.Y),
+				Length: len(cells),
+			}
+		}
+		if strip == nil {
+			break
+		}
+		debug.Strips = append(debug.Strips, *strip)
+		debug.PlacedCount++
+	}
+
+	if blocked > 0 {
+		debug.Misses = append(debug.Misses, MissInfo{
+			Reason: "strip blocked by void, bridge, rail, pipeline, door approach or another hazard",
+			Count:  blocked,
+		})
+	}
+	if cutting > 0 {
+		debug.Misses = append(debug.Misses, MissInfo{
+			Reason: "strip would cut the last hazard-free route between doors",
+			Count:  cutting,
+		})
+	}
+	if remaining := targetCount - debug.PlacedCount; remaining > 0 {
+		debug.Misses = append(debug.Misses, MissInfo{
+			Reason: fmt.Sprintf("could not place %d more hazard strips", remaining),
+		})
+	}
+
+	return debug
+}
+
+// dropCuttingHazards clears the hazard layer if the doors can no longer reach each
+// other without stepping on a hazard, which mirroring strips can cause. Returns the
+// number of cells removed.
+func dropCuttingHazards(hazardLayer, ground, bridge [][]int, doorPositions []DoorSite, width, height int) int {
+	if doorsSafelyConnected(classifyCells(ground, bridge, hazardLayer, width, height), doorPositions, width, height) {
+		return 0
+	}
+	removed := countCells(hazardLayer)
+	clearLayer(hazardLayer)
+	return removed
+}
@@ -0,0 +1,181 @@
+package generate
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"tile-backend/internal/model"
+	"tile-backend/internal/validate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertHazardPayload checks that hazards sit on free ground, leave a hazard-free
+// route between the doors and that the payload validates strictly
+func assertHazardPayload(t *testing.T, payload model.TemplatePayload, msg string) {
+	require.NotNil(t, payload.Hazard, msg)
+	for y := range payload.Hazard {
+		for x, v := range payload.Hazard[y] {
+			if v != 1 {
+				continue
+			}
+			assert.Equal(t, 1, payload.Ground[y][x], "%s: hazard off ground at (%d,%d)", msg, x, y)
+			for name, layer := range map[string]model.Layer{
+				"static": payload.Static, "rail": payload.Rail, "pipeline": payload.Pipeline, "pickup": payload.Pickup,
+				"chaser": payload.Chaser, "zoner": payload.Zoner, "dps": payload.DPS,
+			} {
+				if layer != nil {
+					assert.Zero(t, layer[y][x], "%s: hazard under %s at (%d,%d)", msg, name, x, y)
+				}
+			}
+		}
+	}
+	doors, _, _, err := resolveDoors(nil, payload.DoorDescriptors, payload.Meta.Width, payload.Meta.Height)
+	require.NoError(t, err, msg)
+	classes := classifyCells(payload.Ground, payload.Bridge, payload.Hazard, payload.Meta.Width, payload.Meta.Height)
+	assert.True(t, doorsSafelyConnected(classes, doors, payload.Meta.Width, payload.Meta.Height), msg)
+	result := validate.ValidateTemplate(&payload, true)
+	assert.True(t, result.Valid, "%s: %v", msg, result.Errors)
+}
+
+func TestGenerateHazardLayer_KeepsSafeRoute(t *testing.T) {
+	width, height := 16, 10
+	ground := createEmptyLayer(width, height)
+	for y := 1; y < height-1; y++ {
+		for x := 0; x < width; x++ {
+			ground[y][x] = 1
+		}
+	}
+	empty := createEmptyLayer(width, height)
+	doors := []DoorSite{doorSiteAt(DoorLeft, 0, 4), doorSiteAt(DoorRight, width-1, 4)}
+
+	for i := int64(0); i < 20; i++ {
+		hazard := createEmptyLayer(width, height)
+		debug := GenerateHazardLayer(context.Background(), rand.New(rand.NewSource(i)), hazard, ground, empty, empty, empty, doors, width, height, 8)
+		assert.False(t, debug.Skipped)
+		assert.Len(t, debug.Strips, debug.PlacedCount)
+		assert.NotZero(t, countCells(hazard), "seed %d", i)
+		classes := classifyCells(ground, empty, hazard, width, height)
+		assert.True(t, doorsSafelyConnected(classes, doors, width, height), "seed %d", i)
+	}
+}
+
+func TestGenerateHazardLayer_NarrowCorridor(t *testing.T) {
+	// A single-cell corridor: every strip on it would cut the only safe route
+	width, height := 16, 5
+	ground := createEmptyLayer(width, height)
+	for x := 0; x < width; x++ {
+		ground[2][x] = 1
+	}
+	empty := createEmptyLayer(width, height)
+	doors := []DoorSite{doorSiteAt(DoorLeft, 0, 2), doorSiteAt(DoorRight, width-1, 2)}
+
+	hazard := createEmptyLayer(width, height)
+	debug := GenerateHazardLayer(context.Background(), rand.New(rand.NewSource(1)), hazard, ground, empty, empty, empty, doors, width, height, 3)
+	assert.Zero(t, debug.PlacedCount)
+	assert.Zero(t, countCells(hazard))
+	assert.NotEmpty(t, debug.Misses)
+}
+
+func TestComputeMainPath_Hazards(t *testing.T) {
+	// Two rows of ground between a left and a right door on row 1
+	width, height := 10, 4
+	ground := createEmptyLayer(width, height)
+	for x := 0; x < width; x++ {
+		ground[1][x] = 1
+		ground[2][x] = 1
+	}
+	empty := createEmptyLayer(width, height)
+	doors := []DoorSite{doorSiteAt(DoorLeft, 0, 1), doorSiteAt(DoorRight, width-1, 1)}
+
+	// A strip on row 1 is avoided by stepping down to row 2
+	hazard := createEmptyLayer(width, height)
+	for x := 3; x <= 6; x++ {
+		hazard[1][x] = 1
+	}
+	mainPath, debug := ComputeMainPath(ground, empty, hazard, doors, width, height)
+	assert.Zero(t, debug.HazardCells)
+	assert.Empty(t, debug.Misses)
+	for x := 3; x <= 6; x++ {
+		assert.False(t, mainPath.OnMainPath[1][x], "path on hazard at (%d,1)", x)
+	}
+	difficulty := ComputeDifficulty(ground, empty, hazard, empty, empty, empty, empty, empty, mainPath, width, height)
+	assert.Equal(t, 4, difficulty.Details.HazardCells)
+	assert.Zero(t, difficulty.Details.UnavoidableHazards)
+
+	// A full-height wall of hazards has to be crossed, once
+	hazard = createEmptyLayer(width, height)
+	hazard[1][5] = 1
+	hazard[2][5] = 1
+	mainPath, debug = ComputeMainPath(ground, empty, hazard, doors, width, height)
+	assert.Equal(t, 1, debug.HazardCells)
+	assert.Empty(t, debug.Misses)
+	difficulty = ComputeDifficulty(ground, empty, hazard, empty, empty, empty, empty, empty, mainPath, width, height)
+	assert.Equal(t, 2, difficulty.Details.HazardCells)
+	assert.Equal(t, 1, difficulty.Details.UnavoidableHazards)
+
+	withoutHazard := ComputeDifficulty(ground, empty, nil, empty, empty, empty, empty, empty, mainPath, width, height)
+	assert.Greater(t, difficulty.Terrain, withoutHazard.Terrain)
+}
+
+func TestGenerateRooms_Hazard(t *testing.T) {
+	for i := int64(0); i < 10; i++ {
+		seed := i
+
+		bridge, err := GenerateBridgeRoom(context.Background(), BridgeGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight},
+			StaticCount: 4, ChaserCount: 3, DPSCount: 2, PipelineEnabled: true, HazardCount: 3, PickupCount: 2, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertHazardPayload(t, bridge.Payload, "bridge")
+		assert.Equal(t, 3, bridge.DebugInfo.Hazard.TargetCount)
+
+		platform, err := GeneratePlatformRoom(context.Background(), PlatformGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorTop, DoorBottom},
+			StaticCount: 4, StageType: "pressure", HazardCount: 3, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertHazardPayload(t, platform.Payload, "platform")
+
+		full, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight, DoorTop},
+			StaticCount: 6, StageType: "peak", HazardCount: 4, PickupCount: 2, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertHazardPayload(t, full.Payload, "full")
+
+		cave, err := GenerateCave(context.Background(), CaveGenerateRequest{
+			Width: 30, Height: 20, Doors: []DoorPosition{DoorLeft, DoorRight}, StaticCount: 4, HazardCount: 3, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertHazardPayload(t, cave.Payload, "cave")
+	}
+}
+
+func TestGenerateRooms_HazardSymmetry(t *testing.T) {
+	for _, mode := range symmetryModes {
+		seed := int64(6)
+		resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+			Width: 22, Height: 14, Doors: []DoorPosition{DoorLeft, DoorRight},
+			StaticCount: 4, ChaserCount: 2, HazardCount: 4, Symmetry: mode, Seed: &seed,
+		})
+		require.NoError(t, err, mode)
+		require.NotNil(t, resp.Payload.Hazard, mode)
+		assert.True(t, mode.isSymmetric(resp.Payload.Hazard, 22, 14), mode)
+		assertSymmetricPayload(t, mode, resp.Payload, string(mode))
+		assertHazardPayload(t, resp.Payload, string(mode))
+	}
+}
+
+func TestGenerateRooms_HazardDisabled(t *testing.T) {
+	seed := int64(2)
+	resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, StaticCount: 3, Seed: &seed,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, resp.Payload.Hazard)
+	require.NotNil(t, resp.DebugInfo.Hazard)
+	assert.True(t, resp.DebugInfo.Hazard.Skipped)
+}
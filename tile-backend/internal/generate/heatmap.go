@@ -0,0 +1,275 @@
+package generate
+
+import (
+	"fmt"
+	"math"
+
+	"tile-backend/internal/model"
+)
+
+const (
+	defaultHeatmapRadius = 5  // influence radius when a request does not set one
+	maxHeatmapRadius     = 20 // hard cap on the influence radius
+
+	defaultDPSThreat    = 1.0
+	defaultZonerThreat  = 0.8
+	defaultChaserThreat = 0.6
+	defaultMobAirThreat = 0.5
+)
+
+// HeatmapWeights sets how much threat each enemy type radiates. Omitted weights
+// use the defaults; 0 leaves an enemy type out of the heatmap.
+type HeatmapWeights struct {
+	Chaser *float64 `json:"chaser,omitempty"`
+	Zoner  *float64 `json:"zoner,omitempty"`
+	DPS    *float64 `json:"dps,omitempty"`
+	MobAir *float64 `json:"mobAir,omitempty"`
+}
+
+// HeatmapConfig configures a threat heatmap
+type HeatmapConfig struct {
+	Radius  int            `json:"radius,omitempty"`  // Influence radius in cells, 1-20 (default 5)
+	Weights HeatmapWeights `json:"weights,omitempty"` // Per-enemy weights (optional)
+}
+
+// HeatmapRequest represents a request to compute the threat heatmap of a template
+type HeatmapRequest struct {
+	Payload model.TemplatePayload `json:"payload"`
+	HeatmapConfig
+}
+
+// Heatmap is the threat every cell is under. Each enemy adds its weight to the
+// cells within the radius, falling off linearly with distance; a cell holding
+// several enemy types counts the highest weight.
+type Heatmap struct {
+	Width   int            `json:"width"`
+	Height  int            `json:"height"`
+	Radius  int            `json:"radius"`
+	Weights HeatmapWeights `json:"weights"` // Weights that applied, defaults filled in
+	Threat  [][]float64    `json:"threat"`  // Per-cell threat normalized to 0-1 by the peak
+	Summary HeatmapSummary `json:"summary"`
+}
+
+// HeatmapSummary condenses a heatmap into comparable numbers. Threat values here
+// are raw sums of weighted influence, so they compare across rooms.
+type HeatmapSummary struct {
+	PeakThreat     float64      `json:"peakThreat"`           // highest threat of any cell
+	MainPathThreat float64      `json:"mainPathThreat"`       // average threat along the main path
+	SafestCell     *model.Point `json:"safestCell,omitempty"` // standable cell with the least threat
+}
+
+// threatWeights are resolved per-enemy weights
+type threatWeights struct {
+	chaser, zoner, dps, mobAir float64
+}
+
+// threatLayers are the layers a heatmap reads; missing layers count as empty
+type threatLayers struct {
+	ground, static, hazard     [][]int
+	chaser, zoner, dps, mobAir [][]int
+	onMainPath                 func(x, y int) bool
+	width, height              int
+}
+
+// resolve checks the config and fills in the defaults
+func (c HeatmapConfig) resolve() (int, threatWeights, error) {
+	radius := c.Radius
+	if radius == 0 {
+		radius = defaultHeatmapRadius
+	}
+	if radius < 1 || radius > maxHeatmapRadius {
+		return 0, threatWeights{}, fmt.Errorf("radius must be between 1 and %d", maxHeatmapRadius)
+	}
+
+	weights := threatWeights{defaultChaserThreat, defaultZonerThreat, defaultDPSThreat, defaultMobAirThreat}
+	overrides := []struct {
+		name  string
+		value *float64
+		dst   *float64
+	}{
+		{"chaser", c.Weights.Chaser, &weights.chaser},
+		{"zoner", c.Weights.Zoner, &weights.zoner},
+		{"dps", c.Weights.DPS, &weights.dps},
+		{"mobAir", c.Weights.MobAir, &weights.mobAir},
+	}
+	for _, o := range overrides {
+		if o.value == nil {
+			continue
+		}
+		if *o.value < 0 || math.IsNaN(*o.value) || math.IsInf(*o.value, 0) {
+			return 0, threatWeights{}, fmt.Errorf("%s weight must be a non-negative number", o.name)
+		}
+		*o.dst = *o.value
+	}
+	return radius, weights, nil
+}
+
+// ComputeHeatmap computes the threat heatmap of a template
+func ComputeHeatmap(payload *model.TemplatePayload, cfg HeatmapConfig) (*Heatmap, error) {
+	radius, weights, err := cfg.resolve()
+	if err != nil {
+		return nil, err
+	}
+	width, height := payload.Meta.Width, payload.Meta.Height
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("payload has no size")
+	}
+
+	layers := threatLayers{
+		ground: payload.Ground, static: payload.Static, hazard: payload.Hazard,
+		chaser: payload.Chaser, zoner: payload.Zoner, dps: payload.DPS, mobAir: payload.MobAir,
+		onMainPath: func(x, y int) bool { return layerCell(payload.MainPath, x, y) == 1 },
+		width:      width, height: height,
+	}
+	raw := computeThreat(layers, radius, weights)
+	summary := summarizeThreat(layers, raw)
+
+	threat := make([][]float64, height)
+	for y := range threat {
+		threat[y] = make([]float64, width)
+		if summary.PeakThreat > 0 {
+			for x := range threat[y] {
+				threat[y][x] = raw[y][x] / summary.PeakThreat
+			}
+		}
+	}
+
+	return &Heatmap{
+		Width:  width,
+		Height: height,
+		Radius: radius,
+		Weights: HeatmapWeights{
+			Chaser: &weights.chaser,
+			Zoner:  &weights.zoner,
+			DPS:    &weights.dps,
+			MobAir: &weights.mobAir,
+		},
+		Threat:  threat,
+		Summary: summary,
+	}, nil
+}
+
+// computeThreatSummary summarizes the heatmap of the default radius and weights
+func computeThreatSummary(l threatLayers) HeatmapSummary {
+	radius, weights, _ := HeatmapConfig{}.resolve()
+	return summarizeThreat(l, computeThreat(l, radius, weights))
+}
+
+// computeThreat returns the raw threat of every cell
+func computeThreat(l threatLayers, radius int, w threatWeights) [][]float64 {
+	threat := make([][]float64, l.height)
+	for y := range threat {
+		threat[y] = make([]float64, l.width)
+	}
+
+	for ey := 0; ey < l.height; ey++ {
+		for ex := 0; ex < l.width; ex++ {
+			weight := l.enemyWeight(ex, ey, w)
+			if weight == 0 {
+				continue
+			}
+			for dy := -radius; dy <= radius; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					x, y := ex+dx, ey+dy
+					if x < 0 || x >= l.width || y < 0 || y >= l.height {
+						continue
+					}
+					dist := math.Sqrt(float64(dx*dx + dy*dy))
+					if dist > float64(radius) {
+						continue
+					}
+					threat[y][x] += weight * (1 - dist/float64(radius+1))
+				}
+			}
+		}
+	}
+	return threat
+}
+
+// summarizeThreat finds the peak, the main path average and the safest cell.
+// Ties for the safest cell go to the one farthest from any enemy, then to the
+// first in row-major order.
+func summarizeThreat(l threatLayers, threat [][]float64) HeatmapSummary {
+	var summary HeatmapSummary
+	var enemies []Point
+	pathSum, pathCells := 0.0, 0
+	for y := 0; y < l.height; y++ {
+		for x := 0; x < l.width; x++ {
+			summary.PeakThreat = math.Max(summary.PeakThreat, threat[y][x])
+			if l.onMainPath != nil && l.onMainPath(x, y) {
+				pathSum += threat[y][x]
+				pathCells++
+			}
+			if l.isEnemy(x, y) {
+				enemies = append(enemies, Point{x, y})
+			}
+		}
+	}
+	if pathCells > 0 {
+		summary.MainPathThreat = pathSum / float64(pathCells)
+	}
+
+	bestDist := -1
+	for y := 0; y < l.height; y++ {
+		for x := 0; x < l.width; x++ {
+			if layerCell(l.ground, x, y) != 1 || layerCell(l.static, x, y) == 1 || layerCell(l.hazard, x, y) == 1 {
+				continue
+			}
+			dist := nearestEnemyDist2(enemies, x, y)
+			if summary.SafestCell != nil {
+				best := threat[summary.SafestCell.Y][summary.SafestCell.X]
+				if threat[y][x] > best || (threat[y][x] == best && dist <= bestDist) {
+					continue
+				}
+			}
+			summary.SafestCell = &model.Point{X: x, Y: y}
+			bestDist = dist
+		}
+	}
+	return summary
+}
+
+// enemyWeight returns the highest weight of the enemies on a cell, or 0
+func (l threatLayers) enemyWeight(x, y int, w threatWeights) float64 {
+	weight := 0.0
+	if layerCell(l.chaser, x, y) == 1 {
+		weight = math.Max(weight, w.chaser)
+	}
+	if layerCell(l.zoner, x, y) == 1 {
+		weight = math.Max(weight, w.zoner)
+	}
+	if layerCell(l.dps, x, y) == 1 {
+		weight = math.Max(weight, w.dps)
+	}
+	if layerCell(l.mobAir, x, y) == 1 {
+		weight = math.Max(weight, w.mobAir)
+	}
+	return weight
+}
+
+// isEnemy reports whether any enemy stands on a cell
+func (l threatLayers) isEnemy(x, y int) bool {
+	return layerCell(l.chaser, x, y) == 1 || layerCell(l.zoner, x, y) == 1 ||
+		layerCell(l.dps, x, y) == 1 || layerCell(l.mobAir, x, y) == 1
+}
+
+// nearestEnemyDist2 returns the squared distance to the nearest enemy, or
+// math.MaxInt when there are none
+func nearestEnemyDist2(enemies []Point, x, y int) int {
+	best := math.MaxInt
+	for _, e := range enemies {
+		dx, dy := e.X-x, e.Y-y
+		if d := dx*dx + dy*dy; d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+// layerCell reads a cell, treating a missing layer or an out-of-range cell as 0
+func layerCell(layer [][]int, x, y int) int {
+	if y < 0 || y >= len(layer) || x < 0 || x >= len(layer[y]) {
+		return 0
+	}
+	return layer[y][x]
+}
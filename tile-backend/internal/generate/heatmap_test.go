@@ -0,0 +1,134 @@
+package generate
+
+import (
+	"context"
+	"testing"
+
+	"tile-backend/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// heatmapPayload returns an all-ground room with empty enemy layers
+func heatmapPayload(width, height int) *model.TemplatePayload {
+	return &model.TemplatePayload{
+		Ground: filledLayer(width, height, 1),
+		Static: filledLayer(width, height, 0),
+		Chaser: filledLayer(width, height, 0),
+		Zoner:  filledLayer(width, height, 0),
+		DPS:    filledLayer(width, height, 0),
+		MobAir: filledLayer(width, height, 0),
+		Meta:   model.TemplateMeta{Name: "heatmap", Version: 1, Width: width, Height: height},
+	}
+}
+
+func filledLayer(width, height, v int) model.Layer {
+	layer := make(model.Layer, height)
+	for y := range layer {
+		layer[y] = make([]int, width)
+		for x := range layer[y] {
+			layer[y][x] = v
+		}
+	}
+	return layer
+}
+
+func weight(v float64) *float64 { return &v }
+
+func TestComputeHeatmap_Falloff(t *testing.T) {
+	p := heatmapPayload(13, 13)
+	p.DPS[6][6] = 1
+
+	hm, err := ComputeHeatmap(p, HeatmapConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, defaultHeatmapRadius, hm.Radius)
+	assert.Equal(t, defaultZonerThreat, *hm.Weights.Zoner, "defaults are filled in")
+
+	assert.InDelta(t, 1.0, hm.Summary.PeakThreat, 1e-9)
+	assert.InDelta(t, 1.0, hm.Threat[6][6], 1e-9)
+	assert.InDelta(t, 1-1.0/6, hm.Threat[6][7], 1e-9)
+	assert.InDelta(t, 1-5.0/6, hm.Threat[6][11], 1e-9, "the radius edge still counts")
+	assert.Zero(t, hm.Threat[6][12], "cells past the radius get nothing")
+	assert.Zero(t, hm.Threat[0][0])
+
+	// The safest cell is a corner, the first in row-major order of the four
+	require.NotNil(t, hm.Summary.SafestCell)
+	assert.Equal(t, model.Point{X: 0, Y: 0}, *hm.Summary.SafestCell)
+}
+
+func TestComputeHeatmap_Weights(t *testing.T) {
+	p := heatmapPayload(9, 9)
+	p.DPS[4][4] = 1
+	p.Zoner[4][4] = 1
+	p.Chaser[0][0] = 1
+
+	hm, err := ComputeHeatmap(p, HeatmapConfig{Radius: 2})
+	require.NoError(t, err)
+	assert.InDelta(t, defaultDPSThreat, hm.Summary.PeakThreat, 1e-9, "a shared cell counts its highest weight")
+
+	// Chasers and mob air radiate too
+	assert.Greater(t, hm.Threat[0][0], 0.0)
+
+	// A zero weight leaves the enemy type out; the shared cell falls back to zoner
+	hm, err = ComputeHeatmap(p, HeatmapConfig{Radius: 2, Weights: HeatmapWeights{DPS: weight(0), Chaser: weight(0)}})
+	require.NoError(t, err)
+	assert.InDelta(t, defaultZonerThreat, hm.Summary.PeakThreat, 1e-9)
+	assert.Zero(t, hm.Threat[0][0])
+}
+
+func TestComputeHeatmap_Summary(t *testing.T) {
+	p := heatmapPayload(10, 5)
+	p.DPS[2][0] = 1
+	p.MainPath = filledLayer(10, 5, 0)
+	for x := 0; x < 10; x++ {
+		p.MainPath[2][x] = 1
+	}
+	// The two cells farthest from the enemy are not standable
+	p.Static[0][9] = 1
+	p.Hazard = filledLayer(10, 5, 0)
+	p.Hazard[4][9] = 1
+
+	hm, err := ComputeHeatmap(p, HeatmapConfig{Radius: 3})
+	require.NoError(t, err)
+
+	// Row 2 gets 1, 3/4, 2/4 and 1/4 from x=0 to x=3
+	assert.InDelta(t, 2.5/10, hm.Summary.MainPathThreat, 1e-9)
+
+	// Untouched cells tie, so the one farthest from the enemy wins
+	require.NotNil(t, hm.Summary.SafestCell)
+	assert.Equal(t, model.Point{X: 9, Y: 1}, *hm.Summary.SafestCell)
+}
+
+func TestComputeHeatmap_Errors(t *testing.T) {
+	p := heatmapPayload(5, 5)
+	for _, cfg := range []HeatmapConfig{
+		{Radius: -1},
+		{Radius: maxHeatmapRadius + 1},
+		{Weights: HeatmapWeights{MobAir: weight(-0.5)}},
+	} {
+		_, err := ComputeHeatmap(p, cfg)
+		assert.Error(t, err, "%+v", cfg)
+	}
+
+	p.Meta.Width = 0
+	_, err := ComputeHeatmap(p, HeatmapConfig{})
+	assert.Error(t, err)
+}
+
+func TestComputeDifficulty_ThreatSummary(t *testing.T) {
+	seed := int64(3)
+	resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width: 24, Height: 14, Doors: []DoorPosition{DoorLeft, DoorRight}, StageType: "peak", Seed: &seed,
+	})
+	require.NoError(t, err)
+	details := resp.Difficulty.Details
+
+	// The difficulty details agree with the heatmap of the returned room
+	hm, err := ComputeHeatmap(&resp.Payload, HeatmapConfig{})
+	require.NoError(t, err)
+	assert.Greater(t, details.PeakThreat, 0.0)
+	assert.InDelta(t, hm.Summary.PeakThreat, details.PeakThreat, 1e-9)
+	assert.InDelta(t, hm.Summary.MainPathThreat, details.MainPathThreat, 1e-9)
+	assert.Equal(t, hm.Summary.SafestCell, details.SafestCell)
+}
@@ -1,32 +1,30 @@
 package generate
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
 	"sort"
 )
 
-// GenerateChaserLayer generates the chaser layer.
+// GenerateChaserLayer places chasers into lc.Chaser.
 // Chasers must be on ground, within 0-3 of main path, prefer LOW squishy score.
-func GenerateChaserLayer(chaserLayer, ground, softEdge, bridge, rail, staticLayer, zonerLayer [][]int,
-	doorPositions map[DoorPosition]Point, mainPath *MainPathData, width, height, targetCount int, regionFilter ...*RegionFilter) *EnemyLayerDebugInfo {
-	return generateChaserLayerCore(chaserLayer, ground, softEdge, bridge, rail, staticLayer, zonerLayer,
-		doorPositions, mainPath, width, height, targetCount, false, regionFilter...)
+// Stops early once ctx is done.
+func GenerateChaserLayer(ctx context.Context, rng *rand.Rand, lc *LayerContext, masks *ConstraintMasks, targetCount int, regionFilter ...*RegionFilter) *EnemyLayerDebugInfo {
+	return generateChaserLayerCore(ctx, rng, lc, masks, targetCount, false, regionFilter...)
 }
 
 // GenerateChaserLayerRelaxed is like GenerateChaserLayer but skips the 8-directional
 // spacing constraint. It is used as a last-resort fallback when strict placement
 // exhausts all spaced candidates but the stage minimum has not been met.
-func GenerateChaserLayerRelaxed(chaserLayer, ground, softEdge, bridge, rail, staticLayer, zonerLayer [][]int,
-	doorPositions map[DoorPosition]Point, mainPath *MainPathData, width, height, targetCount int) *EnemyLayerDebugInfo {
-	return generateChaserLayerCore(chaserLayer, ground, softEdge, bridge, rail, staticLayer, zonerLayer,
-		doorPositions, mainPath, width, height, targetCount, true)
+func GenerateChaserLayerRelaxed(ctx context.Context, rng *rand.Rand, lc *LayerContext, masks *ConstraintMasks, targetCount int) *EnemyLayerDebugInfo {
+	return generateChaserLayerCore(ctx, rng, lc, masks, targetCount, true)
 }
 
 // generateChaserLayerCore is the shared implementation. When relaxSpacing is true the
 // 8-directional spacing constraint is not enforced — this allows meeting minimum counts
 // in constrained rooms.
-func generateChaserLayerCore(chaserLayer, ground, softEdge, bridge, rail, staticLayer, zonerLayer [][]int,
-	doorPositions map[DoorPosition]Point, mainPath *MainPathData, width, height, targetCount int, relaxSpacing bool, regionFilter ...*RegionFilter) *EnemyLayerDebugInfo {
+func generateChaserLayerCore(ctx context.Context, rng *rand.Rand, lc *LayerContext, masks *ConstraintMasks, targetCount int, relaxSpacing bool, regionFilter ...*RegionFilter) *EnemyLayerDebugInfo {
 
 	debug := &EnemyLayerDebugInfo{
 		TargetCount: targetCount,
@@ -34,7 +32,8 @@ func generateChaserLayerCore(chaserLayer, ground, softEdge, bridge, rail, static
 		Misses:      []MissInfo{},
 	}
 
-	forbidden := getDoorForbiddenCellsRadius(doorPositions, width, height, doorForbiddenRadius)
+	mainPath, width, height := lc.MainPath, lc.Width, lc.Height
+	forbidden := gridFromPoints(getDoorForbiddenCellsRadius(lc.DoorPositions, width, height, doorForbiddenRadius), width, height)
 
 	// Get optional region filter
 	var rf *RegionFilter
@@ -44,17 +43,17 @@ func generateChaserLayerCore(chaserLayer, ground, softEdge, bridge, rail, static
 
 	// Find valid positions
 	var candidates []Point
-	for y := 0; y < height; y++ {
+	for y := 0; y < height && ctx.Err() == nil; y++ {
 		for x := 0; x < width; x++ {
 			if !rf.Contains(x, y) {
 				continue
 			}
 			pos := Point{x, y}
-			if !isValidEnemyPosition(pos, ground, softEdge, bridge, rail, staticLayer, forbidden, width, height) {
+			if !isValidEnemyPosition(pos, lc, forbidden, masks) {
 				continue
 			}
 			// Cannot overlap zoner
-			if zonerLayer[y][x] != 0 {
+			if lc.Zoner.Has(x, y) {
 				continue
 			}
 			// Must be within chaserMaxPathDist of main path
@@ -63,7 +62,7 @@ func generateChaserLayerCore(chaserLayer, ground, softEdge, bridge, rail, static
 			}
 			// In relaxed mode, skip candidates that already have a chaser placed
 			// (only exclude the exact cell, not adjacents)
-			if relaxSpacing && chaserLayer[y][x] != 0 {
+			if relaxSpacing && lc.Chaser.Has(x, y) {
 				continue
 			}
 			candidates = append(candidates, pos)
@@ -83,19 +82,19 @@ func generateChaserLayerCore(chaserLayer, ground, softEdge, bridge, rail, static
 	})
 
 	remaining := targetCount
-	for remaining > 0 && len(candidates) > 0 {
+	for remaining > 0 && len(candidates) > 0 && ctx.Err() == nil {
 		// Pick randomly from top 30% of candidates (min 3)
-		pos, idx := pickFromTopN(candidates, 0.3, 3)
+		pos, idx := pickFromTopN(rng, candidates, 0.3, 3)
 
 		if !relaxSpacing {
 			// Re-check: no adjacent existing chaser (8-directional)
-			if touchesLayer(pos, chaserLayer, width, height) {
+			if lc.Chaser.Touches(pos) {
 				candidates = append(candidates[:idx], candidates[idx+1:]...)
 				continue
 			}
 		}
 
-		chaserLayer[pos.Y][pos.X] = 1
+		lc.Chaser.Set(pos.X, pos.Y)
 		candidates = append(candidates[:idx], candidates[idx+1:]...)
 
 		if !relaxSpacing {
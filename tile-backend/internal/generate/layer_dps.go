@@ -1,33 +1,31 @@
 package generate
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
 	"sort"
 )
 
-// GenerateDPSLayer generates the DPS layer.
+// GenerateDPSLayer places DPS into lc.DPS.
 // DPS must be on ground, within 0-4 of main path. Can be near chaser/static.
-func GenerateDPSLayer(dpsLayer, ground, softEdge, bridge, rail, staticLayer, zonerLayer, chaserLayer [][]int,
-	doorPositions map[DoorPosition]Point, mainPath *MainPathData, width, height, targetCount int, regionFilter ...*RegionFilter) *EnemyLayerDebugInfo {
-	return generateDPSLayerCore(dpsLayer, ground, softEdge, bridge, rail, staticLayer, zonerLayer, chaserLayer,
-		doorPositions, mainPath, width, height, targetCount, false, regionFilter...)
+// Stops early once ctx is done.
+func GenerateDPSLayer(ctx context.Context, rng *rand.Rand, lc *LayerContext, masks *ConstraintMasks, targetCount int, regionFilter ...*RegionFilter) *EnemyLayerDebugInfo {
+	return generateDPSLayerCore(ctx, rng, lc, masks, targetCount, false, regionFilter...)
 }
 
 // GenerateDPSLayerRelaxed is like GenerateDPSLayer but skips the 8-directional
 // spacing constraint and allows overlap with chaser cells. It is used as a
 // last-resort fallback when strict placement exhausts all spaced candidates
 // but the stage minimum has not been met.
-func GenerateDPSLayerRelaxed(dpsLayer, ground, softEdge, bridge, rail, staticLayer, zonerLayer, chaserLayer [][]int,
-	doorPositions map[DoorPosition]Point, mainPath *MainPathData, width, height, targetCount int) *EnemyLayerDebugInfo {
-	return generateDPSLayerCore(dpsLayer, ground, softEdge, bridge, rail, staticLayer, zonerLayer, chaserLayer,
-		doorPositions, mainPath, width, height, targetCount, true)
+func GenerateDPSLayerRelaxed(ctx context.Context, rng *rand.Rand, lc *LayerContext, masks *ConstraintMasks, targetCount int) *EnemyLayerDebugInfo {
+	return generateDPSLayerCore(ctx, rng, lc, masks, targetCount, true)
 }
 
 // generateDPSLayerCore is the shared implementation. When relaxSpacing is true the
 // 8-directional spacing constraint and chaser-overlap check are not enforced —
 // this allows meeting minimum counts in constrained rooms.
-func generateDPSLayerCore(dpsLayer, ground, softEdge, bridge, rail, staticLayer, zonerLayer, chaserLayer [][]int,
-	doorPositions map[DoorPosition]Point, mainPath *MainPathData, width, height, targetCount int, relaxSpacing bool, regionFilter ...*RegionFilter) *EnemyLayerDebugInfo {
+func generateDPSLayerCore(ctx context.Context, rng *rand.Rand, lc *LayerContext, masks *ConstraintMasks, targetCount int, relaxSpacing bool, regionFilter ...*RegionFilter) *EnemyLayerDebugInfo {
 
 	debug := &EnemyLayerDebugInfo{
 		TargetCount: targetCount,
@@ -35,7 +33,8 @@ func generateDPSLayerCore(dpsLayer, ground, softEdge, bridge, rail, staticLayer,
 		Misses:      []MissInfo{},
 	}
 
-	forbidden := getDoorForbiddenCellsRadius(doorPositions, width, height, doorForbiddenRadius)
+	mainPath, width, height := lc.MainPath, lc.Width, lc.Height
+	forbidden := gridFromPoints(getDoorForbiddenCellsRadius(lc.DoorPositions, width, height, doorForbiddenRadius), width, height)
 
 	var rf *RegionFilter
 	if len(regionFilter) > 0 {
@@ -44,17 +43,17 @@ func generateDPSLayerCore(dpsLayer, ground, softEdge, bridge, rail, staticLayer,
 
 	// Find valid positions
 	var candidates []Point
-	for y := 0; y < height; y++ {
+	for y := 0; y < height && ctx.Err() == nil; y++ {
 		for x := 0; x < width; x++ {
 			if !rf.Contains(x, y) {
 				continue
 			}
 			pos := Point{x, y}
-			if !isValidEnemyPosition(pos, ground, softEdge, bridge, rail, staticLayer, forbidden, width, height) {
+			if !isValidEnemyPosition(pos, lc, forbidden, masks) {
 				continue
 			}
 			// Cannot overlap zoner (but CAN overlap chaser adjacency — relaxed constraint)
-			if zonerLayer[y][x] != 0 {
+			if lc.Zoner.Has(x, y) {
 				continue
 			}
 			// Must be within dpsMaxPathDist of main path
@@ -62,7 +61,7 @@ func generateDPSLayerCore(dpsLayer, ground, softEdge, bridge, rail, staticLayer,
 				continue
 			}
 			// In relaxed mode, skip candidates that already have a DPS placed
-			if relaxSpacing && dpsLayer[y][x] != 0 {
+			if relaxSpacing && lc.DPS.Has(x, y) {
 				continue
 			}
 			candidates = append(candidates, pos)
@@ -78,24 +77,24 @@ func generateDPSLayerCore(dpsLayer, ground, softEdge, bridge, rail, staticLayer,
 	// Also prefer moderate squishy score
 	sort.Slice(candidates, func(i, j int) bool {
 		pi, pj := candidates[i], candidates[j]
-		si := dpsScore(pi, mainPath, chaserLayer, staticLayer, width, height)
-		sj := dpsScore(pj, mainPath, chaserLayer, staticLayer, width, height)
+		si := dpsScore(pi, lc)
+		sj := dpsScore(pj, lc)
 		return si > sj // higher score = better
 	})
 
 	remaining := targetCount
-	for remaining > 0 && len(candidates) > 0 {
-		pos, idx := pickFromTopN(candidates, 0.3, 3)
+	for remaining > 0 && len(candidates) > 0 && ctx.Err() == nil {
+		pos, idx := pickFromTopN(rng, candidates, 0.3, 3)
 
 		if !relaxSpacing {
 			// No adjacent existing DPS and cannot overlap chaser
-			if touchesLayer(pos, dpsLayer, width, height) || chaserLayer[pos.Y][pos.X] != 0 {
+			if lc.DPS.Touches(pos) || lc.Chaser.Has(pos.X, pos.Y) {
 				candidates = append(candidates[:idx], candidates[idx+1:]...)
 				continue
 			}
 		}
 
-		dpsLayer[pos.Y][pos.X] = 1
+		lc.DPS.Set(pos.X, pos.Y)
 		candidates = append(candidates[:idx], candidates[idx+1:]...)
 
 		if !relaxSpacing {
@@ -122,27 +121,25 @@ func generateDPSLayerCore(dpsLayer, ground, softEdge, bridge, rail, staticLayer,
 
 // dpsScore computes a placement preference score for DPS.
 // Higher score = better. Prefers proximity to chaser/static.
-func dpsScore(pos Point, mainPath *MainPathData, chaserLayer, staticLayer [][]int, width, height int) float64 {
+func dpsScore(pos Point, lc *LayerContext) float64 {
 	score := 0.0
 
 	// Bonus for being near chaser or static
 	for dy := -2; dy <= 2; dy++ {
 		for dx := -2; dx <= 2; dx++ {
 			nx, ny := pos.X+dx, pos.Y+dy
-			if nx >= 0 && nx < width && ny >= 0 && ny < height {
-				if chaserLayer[ny][nx] == 1 {
-					score += 3.0
-				}
-				if staticLayer[ny][nx] == 1 {
-					score += 2.0
-				}
+			if lc.Chaser.Has(nx, ny) {
+				score += 3.0
+			}
+			if lc.Static.Has(nx, ny) {
+				score += 2.0
 			}
 		}
 	}
 
 	// Moderate squishy score bonus
-	if mainPath != nil {
-		score += mainPath.SquishyScore[pos.Y][pos.X] * 0.5
+	if lc.MainPath != nil {
+		score += lc.MainPath.SquishyScore[pos.Y][pos.X] * 0.5
 	}
 
 	return score
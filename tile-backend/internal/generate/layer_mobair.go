@@ -15,19 +15,19 @@ const (
 )
 
 // generateMobAirLayer generates the mob air layer with the given constraints
-func generateMobAirLayer(mobAirLayer, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer [][]int,
-	doorPositions map[DoorPosition]Point, width, height, targetCount int) {
+func generateMobAirLayer(rng *rand.Rand, mobAirLayer, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer [][]int,
+	doorPositions []DoorSite, masks *ConstraintMasks, width, height, targetCount int) {
 
 	if targetCount <= 0 {
 		return
 	}
 
 	// Select strategy randomly
-	strategy := MobAirStrategy(rand.Intn(2))
+	strategy := MobAirStrategy(rng.Intn(2))
 
 	// Find all valid positions
 	validPositions := findValidMobAirPositions(ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer, mobAirLayer,
-		doorPositions, width, height)
+		doorPositions, masks, width, height)
 
 	if len(validPositions) == 0 {
 		return
@@ -52,7 +52,7 @@ func generateMobAirLayer(mobAirLayer, ground, softEdge, bridge, staticLayer, tur
 
 		// Verify position is still valid (may have been invalidated by previous placements)
 		if !isValidMobAirPosition(pos, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer, mobAirLayer,
-			doorPositions, width, height) {
+			doorPositions, masks, width, height) {
 			continue
 		}
 
@@ -63,8 +63,8 @@ func generateMobAirLayer(mobAirLayer, ground, softEdge, bridge, staticLayer, tur
 }
 
 // generateMobAirLayerWithDebug generates the mob air layer with debug info
-func generateMobAirLayerWithDebug(mobAirLayer, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer [][]int,
-	doorPositions map[DoorPosition]Point, width, height, targetCount int) *MobAirDebugInfo {
+func generateMobAirLayerWithDebug(rng *rand.Rand, mobAirLayer, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer [][]int,
+	doorPositions []DoorSite, masks *ConstraintMasks, width, height, targetCount int) *MobAirDebugInfo {
 
 	debug := &MobAirDebugInfo{
 		TargetCount: targetCount,
@@ -81,7 +81,7 @@ func generateMobAirLayerWithDebug(mobAirLayer, ground, softEdge, bridge, staticL
 	}
 
 	// Select strategy randomly
-	strategy := MobAirStrategy(rand.Intn(2))
+	strategy := MobAirStrategy(rng.Intn(2))
 
 	switch strategy {
 	case MobAirStrategyCenterOutward:
@@ -92,7 +92,7 @@ func generateMobAirLayerWithDebug(mobAirLayer, ground, softEdge, bridge, staticL
 
 	// Find all valid positions
 	validPositions := findValidMobAirPositions(ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer, mobAirLayer,
-		doorPositions, width, height)
+		doorPositions, masks, width, height)
 
 	if len(validPositions) == 0 {
 		debug.Misses = append(debug.Misses, MissInfo{
@@ -123,7 +123,7 @@ func generateMobAirLayerWithDebug(mobAirLayer, ground, softEdge, bridge, staticL
 
 		// Verify position is still valid (may have been invalidated by previous placements)
 		if !isValidMobAirPosition(pos, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer, mobAirLayer,
-			doorPositions, width, height) {
+			doorPositions, masks, width, height) {
 			invalidatedCount++
 			continue
 		}
@@ -0,0 +1,134 @@
+package generate
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Pickup placement constants
+const (
+	pickupDeadEndBonus = 8.0 // Score bonus for a cell with a single open neighbour
+	pickupTopFraction  = 0.2 // Pick among the best-scoring fifth of the candidates
+)
+
+// PickupDebugInfo contains debug info for pickup layer generation
+type PickupDebugInfo struct {
+	Skipped     bool        `json:"skipped"`
+	SkipReason  string      `json:"skipReason,omitempty"`
+	TargetCount int         `json:"targetCount"`
+	PlacedCount int         `json:"placedCount"`
+	Placements  []PlaceInfo `json:"placements"`
+	Misses      []MissInfo  `json:"misses,omitempty"`
+}
+
+// GeneratePickupLayer places chests and health pickups on walkable ground.
+// Pickups keep off statics, rail, pipeline, hazards, enemies and door approaches, never
+// touch each other, and prefer dead ends and cells far from the main path, so
+// picking them up is a detour rather than something the player walks over.
+func GeneratePickupLayer(rng *rand.Rand, pickupLayer, ground, bridge, rail, pipeline, hazard, staticLayer, chaserLayer, zonerLayer, dpsLayer [][]int,
+	doorPositions []DoorSite, mainPath *MainPathData, width, height, targetCount int) *PickupDebugInfo {
+
+	debug := &PickupDebugInfo{
+		TargetCount: targetCount,
+		Placements:  []PlaceInfo{},
+	}
+
+	forbidden := getDoorForbiddenCellsRadius(doorPositions, width, height, doorForbiddenRadius)
+
+	var candidates []Point
+	scores := make(map[Point]float64)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pos := Point{x, y}
+			if ground[y][x] != 1 || staticLayer[y][x] != 0 || forbidden[pos] {
+				continue
+			}
+			if rail[y][x] != 0 || pipeline[y][x] != 0 || hazard[y][x] != 0 {
+				continue
+			}
+			if chaserLayer[y][x] != 0 || zonerLayer[y][x] != 0 || dpsLayer[y][x] != 0 {
+				continue
+			}
+			// Cells the player cannot reach from the main path are useless
+			if mainPath != nil && mainPath.WalkingDistance[y][x] < 0 {
+				continue
+			}
+			candidates = append(candidates, pos)
+			scores[pos] = pickupScore(pos, ground, bridge, staticLayer, mainPath, width, height)
+		}
+	}
+
+	if len(candidates) == 0 {
+		debug.Misses = append(debug.Misses, MissInfo{Reason: "no valid positions found"})
+		return debug
+	}
+
+	// Shuffle first so equal scores do not always favour the top-left corner
+	rng.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return scores[candidates[i]] > scores[candidates[j]]
+	})
+
+	remaining := targetCount
+	for remaining > 0 && len(candidates) > 0 {
+		pos, idx := pickFromTopN(rng, candidates, pickupTopFraction, 3)
+		candidates = append(candidates[:idx], candidates[idx+1:]...)
+		if touchesLayer(pos, pickupLayer, width, height) {
+			continue
+		}
+
+		pickupLayer[pos.Y][pos.X] = 1
+		candidates = filterAdjacent(candidates, pos)
+
+		remaining--
+		debug.PlacedCount++
+		walkDist := 0
+		if mainPath != nil {
+			walkDist = mainPath.WalkingDistance[pos.Y][pos.X]
+		}
+		debug.Placements = append(debug.Placements, PlaceInfo{
+			Position: fmt.Sprintf("(%d,%d)", pos.X, pos.Y),
+			Size:     "1x1",
+			Reason:   fmt.Sprintf("walkDist=%d deadEnd=%v", walkDist, isDeadEnd(pos, ground, bridge, staticLayer, width, height)),
+		})
+	}
+
+	if remaining > 0 {
+		debug.Misses = append(debug.Misses, MissInfo{
+			Reason: fmt.Sprintf("could not place %d more pickups", remaining),
+		})
+	}
+
+	return debug
+}
+
+// pickupScore computes a placement preference score for pickups.
+// Higher score = better. Prefers dead ends and a long walk from the main path.
+func pickupScore(pos Point, ground, bridge, staticLayer [][]int, mainPath *MainPathData, width, height int) float64 {
+	score := 0.0
+	if mainPath != nil {
+		score += float64(mainPath.WalkingDistance[pos.Y][pos.X])
+	}
+	if isDeadEnd(pos, ground, bridge, staticLayer, width, height) {
+		score += pickupDeadEndBonus
+	}
+	return score
+}
+
+// isDeadEnd reports whether at most one of the 4 neighbours of pos can be walked on
+func isDeadEnd(pos Point, ground, bridge, staticLayer [][]int, width, height int) bool {
+	open := 0
+	for _, d := range []Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+		nx, ny := pos.X+d.X, pos.Y+d.Y
+		if nx < 0 || nx >= width || ny < 0 || ny >= height {
+			continue
+		}
+		if (ground[ny][nx] == 1 || bridge[ny][nx] == 1) && staticLayer[ny][nx] == 0 {
+			open++
+		}
+	}
+	return open <= 1
+}
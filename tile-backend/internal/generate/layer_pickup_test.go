@@ -0,0 +1,170 @@
+package generate
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"tile-backend/internal/model"
+	"tile-backend/internal/validate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertPickupPayload checks that pickups sit on free walkable ground and the payload validates strictly
+func assertPickupPayload(t *testing.T, payload model.TemplatePayload, want int, msg string) {
+	require.NotNil(t, payload.Pickup, msg)
+	assert.NotZero(t, countCells(payload.Pickup), msg)
+	assert.LessOrEqual(t, countCells(payload.Pickup), want, msg)
+	for y := range payload.Pickup {
+		for x, v := range payload.Pickup[y] {
+			if v != 1 {
+				continue
+			}
+			assert.Equal(t, 1, payload.Ground[y][x], "%s: pickup off ground at (%d,%d)", msg, x, y)
+			for name, layer := range map[string]model.Layer{
+				"static": payload.Static, "rail": payload.Rail, "chaser": payload.Chaser, "zoner": payload.Zoner, "dps": payload.DPS,
+			} {
+				assert.Zero(t, layer[y][x], "%s: pickup on %s at (%d,%d)", msg, name, x, y)
+			}
+		}
+	}
+	result := validate.ValidateTemplate(&payload, true)
+	assert.True(t, result.Valid, "%s: %v", msg, result.Errors)
+}
+
+func TestGeneratePickupLayer_PrefersDeadEndsFarFromPath(t *testing.T) {
+	// A corridor along row 2 with a long side passage ending in a dead end at (2,8)
+	width, height := 12, 10
+	ground := createEmptyLayer(width, height)
+	for x := 0; x < width; x++ {
+		ground[2][x] = 1
+	}
+	for y := 2; y < height-1; y++ {
+		ground[y][2] = 1
+	}
+	empty := createEmptyLayer(width, height)
+	doors := []DoorSite{doorSiteAt(DoorLeft, 0, 2), doorSiteAt(DoorRight, width-1, 2)}
+	mainPath, _ := ComputeMainPath(ground, empty, nil, doors, width, height)
+
+	for i := int64(0); i < 10; i++ {
+		pickup := createEmptyLayer(width, height)
+		debug := GeneratePickupLayer(rand.New(rand.NewSource(i)), pickup, ground, empty, empty, empty, empty, empty, empty, empty, empty, doors, mainPath, width, height, 1)
+		assert.Equal(t, 1, debug.PlacedCount)
+		assert.Equal(t, 1, pickup[6][2]+pickup[7][2]+pickup[8][2], "seed %d: pickup deep in the side passage", i)
+	}
+}
+
+func TestGeneratePickupLayer_Spacing(t *testing.T) {
+	width, height := 16, 12
+	ground := createEmptyLayer(width, height)
+	for y := range ground {
+		for x := range ground[y] {
+			ground[y][x] = 1
+		}
+	}
+	empty := createEmptyLayer(width, height)
+	doors := []DoorSite{doorSiteAt(DoorLeft, 0, 6), doorSiteAt(DoorRight, width-1, 6)}
+	mainPath, _ := ComputeMainPath(ground, empty, nil, doors, width, height)
+	forbidden := getDoorForbiddenCellsRadius(doors, width, height, doorForbiddenRadius)
+
+	pickup := createEmptyLayer(width, height)
+	debug := GeneratePickupLayer(rand.New(rand.NewSource(5)), pickup, ground, empty, empty, empty, empty, empty, empty, empty, empty, doors, mainPath, width, height, 6)
+	assert.Equal(t, 6, debug.PlacedCount)
+	assert.Len(t, debug.Placements, 6)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if pickup[y][x] == 0 {
+				continue
+			}
+			assert.False(t, forbidden[Point{X: x, Y: y}], "door approach (%d,%d)", x, y)
+			pickup[y][x] = 0
+			assert.False(t, touchesLayer(Point{X: x, Y: y}, pickup, width, height), "pickups touch at (%d,%d)", x, y)
+			pickup[y][x] = 1
+		}
+	}
+}
+
+func TestGenerateRooms_Pickup(t *testing.T) {
+	for i := int64(0); i < 10; i++ {
+		seed := i
+
+		bridge, err := GenerateBridgeRoom(context.Background(), BridgeGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight},
+			StaticCount: 4, ChaserCount: 3, DPSCount: 2, PipelineEnabled: true, PickupCount: 3, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertPickupPayload(t, bridge.Payload, 3, "bridge")
+		assert.Equal(t, 3, bridge.DebugInfo.Pickup.TargetCount)
+
+		platform, err := GeneratePlatformRoom(context.Background(), PlatformGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorTop, DoorBottom},
+			StaticCount: 4, StageType: "pressure", PickupCount: 2, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertPickupPayload(t, platform.Payload, 2, "platform")
+
+		full, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight, DoorTop},
+			StaticCount: 6, StageType: "peak", PickupCount: 2, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertPickupPayload(t, full.Payload, 2, "full")
+
+		cave, err := GenerateCave(context.Background(), CaveGenerateRequest{
+			Width: 30, Height: 20, Doors: []DoorPosition{DoorLeft, DoorRight}, StaticCount: 4, PickupCount: 2, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertPickupPayload(t, cave.Payload, 2, "cave")
+	}
+}
+
+func TestGenerateRooms_PickupSymmetry(t *testing.T) {
+	for _, mode := range symmetryModes {
+		seed := int64(4)
+		resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+			Width: 22, Height: 14, Doors: []DoorPosition{DoorLeft, DoorRight},
+			StaticCount: 4, ChaserCount: 2, PickupCount: 4, Symmetry: mode, Seed: &seed,
+		})
+		require.NoError(t, err, mode)
+		require.NotNil(t, resp.Payload.Pickup, mode)
+		assert.True(t, mode.isSymmetric(resp.Payload.Pickup, 22, 14), mode)
+		assertSymmetricPayload(t, mode, resp.Payload, string(mode))
+	}
+}
+
+func TestGenerateRooms_PickupDisabled(t *testing.T) {
+	seed := int64(2)
+	resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, StaticCount: 3, Seed: &seed,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, resp.Payload.Pickup)
+	require.NotNil(t, resp.DebugInfo.Pickup)
+	assert.True(t, resp.DebugInfo.Pickup.Skipped)
+}
+
+func TestRegenerateTemplate_Pickup(t *testing.T) {
+	seed := int64(3)
+	full, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight}, StaticCount: 4, PickupCount: 3, Seed: &seed,
+	})
+	require.NoError(t, err)
+
+	// Locked pickups are kept and new statics keep off them
+	resp, err := RegenerateTemplate(context.Background(), RegenerateRequest{
+		Payload: full.Payload, LockedLayers: []string{"pickup"}, StaticCount: 10, ChaserCount: 3, Seed: &seed,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, full.Payload.Pickup, resp.Payload.Pickup)
+	assertPickupPayload(t, resp.Payload, 3, "locked")
+
+	// Unlocked pickups are re-rolled, or dropped when pickupCount is 0
+	resp, err = RegenerateTemplate(context.Background(), RegenerateRequest{Payload: full.Payload, StaticCount: 4, PickupCount: 2, Seed: &seed})
+	require.NoError(t, err)
+	assertPickupPayload(t, resp.Payload, 2, "re-rolled")
+	resp, err = RegenerateTemplate(context.Background(), RegenerateRequest{Payload: full.Payload, StaticCount: 4, Seed: &seed})
+	require.NoError(t, err)
+	assert.Nil(t, resp.Payload.Pickup)
+}
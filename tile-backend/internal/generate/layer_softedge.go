@@ -15,7 +15,7 @@ const softEdgeMinLength = 3 // Minimum length (N > 2, so N >= 3)
 
 // generateSoftEdgeLayerWithDebug generates the soft edge layer with debug info
 // Soft edges are 1×N or N×1 strips (N > 2) placed in ground concave areas
-func generateSoftEdgeLayerWithDebug(softEdgeLayer, ground [][]int, doorPositions map[DoorPosition]Point, width, height, targetCount int) *SoftEdgeDebugInfo {
+func generateSoftEdgeLayerWithDebug(rng *rand.Rand, softEdgeLayer, ground [][]int, doorPositions []DoorSite, width, height, targetCount int) *SoftEdgeDebugInfo {
 	debug := &SoftEdgeDebugInfo{
 		TargetCount: targetCount,
 		PlacedCount: 0,
@@ -40,7 +40,7 @@ func generateSoftEdgeLayerWithDebug(softEdgeLayer, ground [][]int, doorPositions
 
 	// Shuffle placements for variety
 	for i := len(placements) - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
+		j := rng.Intn(i + 1)
 		placements[i], placements[j] = placements[j], placements[i]
 	}
 
@@ -89,7 +89,7 @@ func generateSoftEdgeLayerWithDebug(softEdgeLayer, ground [][]int, doorPositions
 
 // findValidSoftEdgePlacements finds all valid positions for soft edge placement
 // A valid soft edge is a 1×N or N×1 strip (N >= 3) in a ground concave area
-func findValidSoftEdgePlacements(ground, softEdgeLayer [][]int, doorPositions map[DoorPosition]Point, width, height int) []SoftEdgePlacement {
+func findValidSoftEdgePlacements(ground, softEdgeLayer [][]int, doorPositions []DoorSite, width, height int) []SoftEdgePlacement {
 	var placements []SoftEdgePlacement
 
 	// Find horizontal soft edges (1×N, height=1, width=N)
@@ -118,7 +118,7 @@ func findValidSoftEdgePlacements(ground, softEdgeLayer [][]int, doorPositions ma
 // findHorizontalConcave finds a horizontal concave area (1×N) starting at (x, y)
 // A horizontal concave is a void notch: void cells with ground on one horizontal edge (top or bottom)
 // and ground cells on both ends (left and right), forming a U-shaped depression
-func findHorizontalConcave(ground, softEdgeLayer [][]int, doorPositions map[DoorPosition]Point, startX, startY, width, height int) *SoftEdgePlacement {
+func findHorizontalConcave(ground, softEdgeLayer [][]int, doorPositions []DoorSite, startX, startY, width, height int) *SoftEdgePlacement {
 	// Check if starting position is valid
 	if startX >= width || startY >= height {
 		return nil
@@ -200,7 +200,7 @@ func findHorizontalConcave(ground, softEdgeLayer [][]int, doorPositions map[Door
 // findVerticalConcave finds a vertical concave area (N×1) starting at (x, y)
 // A vertical concave is a void notch: void cells with ground on one vertical edge (left or right)
 // and ground cells on both ends (top and bottom), forming a U-shaped depression
-func findVerticalConcave(ground, softEdgeLayer [][]int, doorPositions map[DoorPosition]Point, startX, startY, width, height int) *SoftEdgePlacement {
+func findVerticalConcave(ground, softEdgeLayer [][]int, doorPositions []DoorSite, startX, startY, width, height int) *SoftEdgePlacement {
 	// Check if starting position is valid
 	if startX >= width || startY >= height {
 		return nil
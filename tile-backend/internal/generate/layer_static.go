@@ -1,9 +1,9 @@
 package generate
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
-	"sort"
 )
 
 // PlacementStrategy represents the strategy for placing statics
@@ -15,19 +15,17 @@ const (
 )
 
 // generateStaticLayer generates the static layer with the given constraints
-// staticLayer: output layer to place statics
-// ground: ground layer (static requires ground=1)
-// softEdge: soft edge layer (static cannot overlap)
-// bridge: bridge layer (static cannot overlap)
-// doorPositions: positions of doors
-// width, height: dimensions
+// lc: placement context; statics are placed into lc.Static (rail is not read)
+// masks: designer constraint masks (noStatic cells are skipped, may be nil)
 // targetCount: suggested number of statics to place
-func generateStaticLayer(staticLayer, ground, softEdge, bridge [][]int, doorPositions map[DoorPosition]Point, width, height, targetCount int) {
-	// Get all cells within doorForbiddenRadius of any door (forbidden zone)
-	forbiddenCells := getDoorForbiddenCells(doorPositions, width, height)
+func generateStaticLayer(rng *rand.Rand, lc *LayerContext, masks *ConstraintMasks, targetCount int) {
+	// Cells within doorForbiddenRadius of any door and noStatic cells are blocked
+	doorPositions, width, height := lc.DoorPositions, lc.Width, lc.Height
+	blocked := staticBlockedCells(doorPositions, masks, width, height)
+	doors := newDoorConnectivity(lc.Ground, lc.Static, doorPositions)
 
 	// Find all valid 2x2 positions for static placement
-	validPositions := findValidStaticPositions(ground, softEdge, bridge, staticLayer, forbiddenCells, width, height)
+	validPositions := findValidStaticPositions(lc, blocked)
 	if len(validPositions) == 0 {
 		return
 	}
@@ -42,23 +40,24 @@ func generateStaticLayer(staticLayer, ground, softEdge, bridge [][]int, doorPosi
 
 	for remaining > 0 && strategyAttempts < maxStrategyAttempts {
 		// Sort valid positions based on current strategy
-		sortPositionsByStrategy(validPositions, currentStrategy, centerX, centerY, width, height)
+		sortPositionsByStrategy(rng, validPositions, currentStrategy, centerX, centerY, width, height)
 
 		// Try to place one static
 		placed := false
 		for i, pos := range validPositions {
 			// Check if this position is still valid (may have been invalidated by previous placements)
-			if !isValidStaticPosition(pos, ground, softEdge, bridge, staticLayer, forbiddenCells, width, height) {
+			if !isValidStaticPosition(pos, lc, blocked) {
 				continue
 			}
 
 			// Check connectivity after placement
-			if !checkConnectivityAfterPlacement(ground, staticLayer, doorPositions, pos, width, height) {
+			if !doors.allowsBlock(pos, staticSize, staticSize) {
 				continue
 			}
 
 			// Place the static (2x2)
-			placeStatic(staticLayer, pos)
+			placeStatic(lc, pos)
+			doors.block(pos, staticSize, staticSize)
 			remaining--
 			placed = true
 
@@ -85,7 +84,7 @@ func generateStaticLayer(staticLayer, ground, softEdge, bridge [][]int, doorPosi
 }
 
 // generateStaticLayerWithDebug generates the static layer with debug info
-func generateStaticLayerWithDebug(staticLayer, ground, softEdge, bridge [][]int, doorPositions map[DoorPosition]Point, width, height, targetCount int) *StaticDebugInfo {
+func generateStaticLayerWithDebug(rng *rand.Rand, lc *LayerContext, masks *ConstraintMasks, targetCount int) *StaticDebugInfo {
 	debug := &StaticDebugInfo{
 		TargetCount: targetCount,
 		PlacedCount: 0,
@@ -93,11 +92,13 @@ func generateStaticLayerWithDebug(staticLayer, ground, softEdge, bridge [][]int,
 		Misses:      []MissInfo{},
 	}
 
-	// Get all cells within doorForbiddenRadius of any door (forbidden zone)
-	forbiddenCells := getDoorForbiddenCells(doorPositions, width, height)
+	// Cells within doorForbiddenRadius of any door and noStatic cells are blocked
+	doorPositions, width, height := lc.DoorPositions, lc.Width, lc.Height
+	blocked := staticBlockedCells(doorPositions, masks, width, height)
+	doors := newDoorConnectivity(lc.Ground, lc.Static, doorPositions)
 
 	// Find all valid 2x2 positions for static placement
-	validPositions := findValidStaticPositions(ground, softEdge, bridge, staticLayer, forbiddenCells, width, height)
+	validPositions := findValidStaticPositions(lc, blocked)
 	if len(validPositions) == 0 {
 		debug.Misses = append(debug.Misses, MissInfo{
 			Reason: "no valid 2x2 positions found (all positions blocked by ground, doors, softEdge, or bridge)",
@@ -117,7 +118,7 @@ func generateStaticLayerWithDebug(staticLayer, ground, softEdge, bridge [][]int,
 
 	for remaining > 0 && strategyAttempts < maxStrategyAttempts {
 		// Sort valid positions based on current strategy
-		sortPositionsByStrategy(validPositions, currentStrategy, centerX, centerY, width, height)
+		sortPositionsByStrategy(rng, validPositions, currentStrategy, centerX, centerY, width, height)
 
 		strategyName := "center_outward"
 		if currentStrategy == StrategyEdgeInward {
@@ -128,19 +129,20 @@ func generateStaticLayerWithDebug(staticLayer, ground, softEdge, bridge [][]int,
 		placed := false
 		for i, pos := range validPositions {
 			// Check if this position is still valid (may have been invalidated by previous placements)
-			if !isValidStaticPosition(pos, ground, softEdge, bridge, staticLayer, forbiddenCells, width, height) {
+			if !isValidStaticPosition(pos, lc, blocked) {
 				invalidatedCount++
 				continue
 			}
 
 			// Check connectivity after placement
-			if !checkConnectivityAfterPlacement(ground, staticLayer, doorPositions, pos, width, height) {
+			if !doors.allowsBlock(pos, staticSize, staticSize) {
 				connectivityBlockedCount++
 				continue
 			}
 
 			// Place the static (2x2)
-			placeStatic(staticLayer, pos)
+			placeStatic(lc, pos)
+			doors.block(pos, staticSize, staticSize)
 			remaining--
 			placed = true
 			debug.PlacedCount++
@@ -199,8 +201,10 @@ func generateStaticLayerWithDebug(staticLayer, ground, softEdge, bridge [][]int,
 	return debug
 }
 
-// generateStaticLayerWithDebugAndRail generates the static layer avoiding rail positions
-func generateStaticLayerWithDebugAndRail(staticLayer, ground, softEdge, bridge, rail [][]int, doorPositions map[DoorPosition]Point, width, height, targetCount int) *StaticDebugInfo {
+// generateStaticLayerWithDebugAndRail places statics into lc.Static avoiding rail positions.
+// Every candidate costs a connectivity check over the whole room, so placement
+// stops early once ctx is done.
+func generateStaticLayerWithDebugAndRail(ctx context.Context, rng *rand.Rand, lc *LayerContext, masks *ConstraintMasks, targetCount int) *StaticDebugInfo {
 	debug := &StaticDebugInfo{
 		TargetCount: targetCount,
 		PlacedCount: 0,
@@ -208,14 +212,16 @@ func generateStaticLayerWithDebugAndRail(staticLayer, ground, softEdge, bridge,
 		Misses:      []MissInfo{},
 	}
 
-	// Get all cells within doorForbiddenRadius of any door (forbidden zone)
-	forbiddenCells := getDoorForbiddenCells(doorPositions, width, height)
+	// Cells within doorForbiddenRadius of any door and noStatic cells are blocked
+	doorPositions, width, height := lc.DoorPositions, lc.Width, lc.Height
+	blocked := staticBlockedCells(doorPositions, masks, width, height)
+	doors := newDoorConnectivity(lc.Ground, lc.Static, doorPositions)
 
 	// Find all valid 2x2 positions for static placement (avoiding rail)
-	validPositions := findValidStaticPositionsWithRail(ground, softEdge, bridge, rail, staticLayer, forbiddenCells, width, height)
+	validPositions := findValidStaticPositionsWithRail(lc, blocked)
 
 	// Get rail indent cells (inside rail loop) - these are prioritized
-	railIndentCells := GetRailIndentCells(rail, width, height)
+	railIndentCells := GetRailIndentCells(lc.Rail)
 	priorityPositions := filterPositionsInRailIndent(validPositions, railIndentCells)
 
 	if len(validPositions) == 0 {
@@ -237,22 +243,26 @@ func generateStaticLayerWithDebugAndRail(staticLayer, ground, softEdge, bridge,
 	railBlockedCount := 0
 
 	// First try to place in priority positions (inside rail loop)
-	for remaining > 0 && len(priorityPositions) > 0 {
-		sortPositionsByStrategy(priorityPositions, currentStrategy, centerX, centerY, width, height)
+	for remaining > 0 && len(priorityPositions) > 0 && ctx.Err() == nil {
+		sortPositionsByStrategy(rng, priorityPositions, currentStrategy, centerX, centerY, width, height)
 
 		placed := false
 		for i, pos := range priorityPositions {
-			if !isValidStaticPositionWithRail(pos, ground, softEdge, bridge, rail, staticLayer, forbiddenCells, width, height) {
+			if !isValidStaticPositionWithRail(pos, lc, blocked) {
 				invalidatedCount++
 				continue
 			}
+			if ctx.Err() != nil {
+				break
+			}
 
-			if !checkConnectivityAfterPlacement(ground, staticLayer, doorPositions, pos, width, height) {
+			if !doors.allowsBlock(pos, staticSize, staticSize) {
 				connectivityBlockedCount++
 				continue
 			}
 
-			placeStatic(staticLayer, pos)
+			placeStatic(lc, pos)
+			doors.block(pos, staticSize, staticSize)
 			remaining--
 			placed = true
 			debug.PlacedCount++
@@ -278,8 +288,8 @@ func generateStaticLayerWithDebugAndRail(staticLayer, ground, softEdge, bridge,
 	}
 
 	// Then place remaining in regular positions
-	for remaining > 0 && strategyAttempts < maxStrategyAttempts {
-		sortPositionsByStrategy(validPositions, currentStrategy, centerX, centerY, width, height)
+	for remaining > 0 && strategyAttempts < maxStrategyAttempts && ctx.Err() == nil {
+		sortPositionsByStrategy(rng, validPositions, currentStrategy, centerX, centerY, width, height)
 
 		strategyName := "center_outward"
 		if currentStrategy == StrategyEdgeInward {
@@ -288,17 +298,21 @@ func generateStaticLayerWithDebugAndRail(staticLayer, ground, softEdge, bridge,
 
 		placed := false
 		for i, pos := range validPositions {
-			if !isValidStaticPositionWithRail(pos, ground, softEdge, bridge, rail, staticLayer, forbiddenCells, width, height) {
+			if !isValidStaticPositionWithRail(pos, lc, blocked) {
 				invalidatedCount++
 				continue
 			}
+			if ctx.Err() != nil {
+				break
+			}
 
-			if !checkConnectivityAfterPlacement(ground, staticLayer, doorPositions, pos, width, height) {
+			if !doors.allowsBlock(pos, staticSize, staticSize) {
 				connectivityBlockedCount++
 				continue
 			}
 
-			placeStatic(staticLayer, pos)
+			placeStatic(lc, pos)
+			doors.block(pos, staticSize, staticSize)
 			remaining--
 			placed = true
 			debug.PlacedCount++
@@ -354,21 +368,45 @@ func generateStaticLayerWithDebugAndRail(staticLayer, ground, softEdge, bridge,
 }
 
 // sortPositionsByStrategy sorts positions based on the placement strategy
-func sortPositionsByStrategy(positions []Point, strategy PlacementStrategy, centerX, centerY, width, height int) {
+func sortPositionsByStrategy(rng *rand.Rand, positions []Point, strategy PlacementStrategy, centerX, centerY, width, height int) {
 	// Shuffle first so equal-distance positions get random order
-	rand.Shuffle(len(positions), func(i, j int) {
+	rng.Shuffle(len(positions), func(i, j int) {
 		positions[i], positions[j] = positions[j], positions[i]
 	})
 	switch strategy {
 	case StrategyCenterOutward:
-		sort.SliceStable(positions, func(i, j int) bool {
-			return distanceFromCenter(positions[i], centerX, centerY) < distanceFromCenter(positions[j], centerX, centerY)
-		})
+		stableSortByDistance(positions, func(p Point) int { return distanceFromCenter(p, centerX, centerY) })
 	case StrategyEdgeInward:
-		sort.SliceStable(positions, func(i, j int) bool {
-			return distanceFromEdge(positions[i], width, height) < distanceFromEdge(positions[j], width, height)
-		})
+		stableSortByDistance(positions, func(p Point) int { return distanceFromEdge(p, width, height) })
+	}
+}
+
+// stableSortByDistance orders positions by a non-negative distance, keeping
+// the order of equal distances. Distances are bounded by the room size, so a
+// counting sort does this in linear time: large rooms re-sort tens of
+// thousands of candidates after every placement.
+func stableSortByDistance(positions []Point, distance func(Point) int) {
+	dists := make([]int, len(positions))
+	maxDist := 0
+	for i, pos := range positions {
+		dists[i] = distance(pos)
+		maxDist = max(maxDist, dists[i])
+	}
+
+	// starts[d] is the next free index for distance d
+	starts := make([]int, maxDist+2)
+	for _, d := range dists {
+		starts[d+1]++
+	}
+	for d := 1; d < len(starts); d++ {
+		starts[d] += starts[d-1]
 	}
+	sorted := make([]Point, len(positions))
+	for i, pos := range positions {
+		sorted[starts[dists[i]]] = pos
+		starts[dists[i]]++
+	}
+	copy(positions, sorted)
 }
 
 // distanceFromCenter calculates the Manhattan distance from center
@@ -404,12 +442,8 @@ func distanceFromEdge(pos Point, width, height int) int {
 }
 
 // placeStatic places a 2x2 static at the given top-left corner
-func placeStatic(staticLayer [][]int, pos Point) {
-	for dy := 0; dy < staticSize; dy++ {
-		for dx := 0; dx < staticSize; dx++ {
-			staticLayer[pos.Y+dy][pos.X+dx] = 1
-		}
-	}
+func placeStatic(lc *LayerContext, pos Point) {
+	lc.Static.SetRect(pos.X, pos.Y, staticSize, staticSize)
 }
 
 // filterTouchingPositions removes positions that would touch the newly placed static
@@ -1,15 +1,16 @@
 package generate
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
 	"sort"
 )
 
-// GenerateZonerLayer generates the zoner layer.
+// GenerateZonerLayer places zoners into lc.Zoner.
 // Zoners must be on ground, within 0-5 of main path, prefer HIGH squishy score,
-// and no static between zoner and main path.
-func GenerateZonerLayer(zonerLayer, ground, softEdge, bridge, rail, staticLayer [][]int,
-	doorPositions map[DoorPosition]Point, mainPath *MainPathData, width, height, targetCount int, regionFilter ...*RegionFilter) *EnemyLayerDebugInfo {
+// and no static between zoner and main path. Stops early once ctx is done.
+func GenerateZonerLayer(ctx context.Context, rng *rand.Rand, lc *LayerContext, masks *ConstraintMasks, targetCount int, regionFilter ...*RegionFilter) *EnemyLayerDebugInfo {
 
 	debug := &EnemyLayerDebugInfo{
 		TargetCount: targetCount,
@@ -17,7 +18,8 @@ func GenerateZonerLayer(zonerLayer, ground, softEdge, bridge, rail, staticLayer
 		Misses:      []MissInfo{},
 	}
 
-	forbidden := getDoorForbiddenCellsRadius(doorPositions, width, height, doorForbiddenRadius)
+	mainPath, width, height := lc.MainPath, lc.Width, lc.Height
+	forbidden := gridFromPoints(getDoorForbiddenCellsRadius(lc.DoorPositions, width, height, doorForbiddenRadius), width, height)
 
 	var rf *RegionFilter
 	if len(regionFilter) > 0 {
@@ -26,13 +28,13 @@ func GenerateZonerLayer(zonerLayer, ground, softEdge, bridge, rail, staticLayer
 
 	// Find valid positions
 	var candidates []Point
-	for y := 0; y < height; y++ {
+	for y := 0; y < height && ctx.Err() == nil; y++ {
 		for x := 0; x < width; x++ {
 			if !rf.Contains(x, y) {
 				continue
 			}
 			pos := Point{x, y}
-			if !isValidEnemyPosition(pos, ground, softEdge, bridge, rail, staticLayer, forbidden, width, height) {
+			if !isValidEnemyPosition(pos, lc, forbidden, masks) {
 				continue
 			}
 			// Must be within zonerMaxPathDist of main path
@@ -40,7 +42,7 @@ func GenerateZonerLayer(zonerLayer, ground, softEdge, bridge, rail, staticLayer
 				continue
 			}
 			// No static between this position and main path
-			if hasStaticBlockingPath(pos, mainPath, staticLayer, width, height) {
+			if hasStaticBlockingPath(pos, lc) {
 				continue
 			}
 			candidates = append(candidates, pos)
@@ -51,13 +53,13 @@ func GenerateZonerLayer(zonerLayer, ground, softEdge, bridge, rail, staticLayer
 	// This ensures at least some valid positions exist in heavily-static rooms.
 	if len(candidates) == 0 {
 		debug.Misses = append(debug.Misses, MissInfo{Reason: "no valid positions with LOS constraint, retrying without static-blocking-path filter"})
-		for y := 0; y < height; y++ {
+		for y := 0; y < height && ctx.Err() == nil; y++ {
 			for x := 0; x < width; x++ {
 				if !rf.Contains(x, y) {
 					continue
 				}
 				pos := Point{x, y}
-				if !isValidEnemyPosition(pos, ground, softEdge, bridge, rail, staticLayer, forbidden, width, height) {
+				if !isValidEnemyPosition(pos, lc, forbidden, masks) {
 					continue
 				}
 				if mainPath == nil || mainPath.DirectDistance[y][x] > zonerMaxPathDist {
@@ -81,13 +83,13 @@ func GenerateZonerLayer(zonerLayer, ground, softEdge, bridge, rail, staticLayer
 	})
 
 	remaining := targetCount
-	for remaining > 0 && len(candidates) > 0 {
-		pos, idx := pickFromTopN(candidates, 0.3, 3)
-		if touchesLayer(pos, zonerLayer, width, height) {
+	for remaining > 0 && len(candidates) > 0 && ctx.Err() == nil {
+		pos, idx := pickFromTopN(rng, candidates, 0.3, 3)
+		if lc.Zoner.Touches(pos) {
 			candidates = append(candidates[:idx], candidates[idx+1:]...)
 			continue
 		}
-		zonerLayer[pos.Y][pos.X] = 1
+		lc.Zoner.Set(pos.X, pos.Y)
 		candidates = append(candidates[:idx], candidates[idx+1:]...)
 		candidates = filterAdjacent(candidates, pos)
 		remaining--
@@ -110,7 +112,8 @@ func GenerateZonerLayer(zonerLayer, ground, softEdge, bridge, rail, staticLayer
 
 // hasStaticBlockingPath checks if there is a static obstacle between pos and the nearest main path cell.
 // Uses Bresenham-style line check.
-func hasStaticBlockingPath(pos Point, mainPath *MainPathData, staticLayer [][]int, width, height int) bool {
+func hasStaticBlockingPath(pos Point, lc *LayerContext) bool {
+	mainPath, width, height := lc.MainPath, lc.Width, lc.Height
 	if mainPath == nil {
 		return false
 	}
@@ -137,11 +140,11 @@ func hasStaticBlockingPath(pos Point, mainPath *MainPathData, staticLayer [][]in
 	}
 
 	// Walk a line from pos to nearest and check for statics
-	return lineHasStatic(pos, nearest, staticLayer, width, height)
+	return lineHasStatic(pos, nearest, lc.Static)
 }
 
 // lineHasStatic checks if a straight line between two points crosses a static cell.
-func lineHasStatic(from, to Point, staticLayer [][]int, width, height int) bool {
+func lineHasStatic(from, to Point, staticLayer *Grid) bool {
 	dx := abs(to.X - from.X)
 	dy := abs(to.Y - from.Y)
 	sx := 1
@@ -159,7 +162,7 @@ func lineHasStatic(from, to Point, staticLayer [][]int, width, height int) bool
 	for {
 		// Skip start and end points
 		if !(x == from.X && y == from.Y) && !(x == to.X && y == to.Y) {
-			if x >= 0 && x < width && y >= 0 && y < height && staticLayer[y][x] == 1 {
+			if staticLayer.Has(x, y) {
 				return true
 			}
 		}
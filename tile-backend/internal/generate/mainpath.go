@@ -6,24 +6,24 @@ import (
 )
 
 // ComputeMainPath finds paths through the room center connecting all required doors,
-// then computes per-cell distance metrics for enemy placement.
-func ComputeMainPath(ground, bridge [][]int, doorPositions map[DoorPosition]Point, width, height int) (*MainPathData, *MainPathDebugInfo) {
+// then computes per-cell distance metrics for enemy placement. Hazard cells are
+// passable but costly: the path only crosses them when no hazard-free route exists.
+// hazard may be nil.
+func ComputeMainPath(ground, bridge, hazard [][]int, doorPositions []DoorSite, width, height int) (*MainPathData, *MainPathDebugInfo) {
 	debug := &MainPathDebugInfo{}
 
-	// Build walkable grid (ground or bridge)
+	// Build walkable grid (ground or bridge, hazards included)
+	classes := classifyCells(ground, bridge, hazard, width, height)
 	walkable := make([][]bool, height)
 	for y := 0; y < height; y++ {
 		walkable[y] = make([]bool, width)
 		for x := 0; x < width; x++ {
-			walkable[y][x] = ground[y][x] == 1 || bridge[y][x] == 1
+			walkable[y][x] = classes[y][x].passable()
 		}
 	}
 
 	// Collect door positions
-	doors := make([]Point, 0, len(doorPositions))
-	for _, pos := range doorPositions {
-		doors = append(doors, pos)
-	}
+	doors := doorCenters(doorPositions)
 
 	if len(doors) < 2 {
 		debug.Misses = append(debug.Misses, "fewer than 2 doors, no main path")
@@ -42,7 +42,7 @@ func ComputeMainPath(ground, bridge [][]int, doorPositions map[DoorPosition]Poin
 	// Connect all doors through center using center-biased A*
 	for i := 0; i < len(doors); i++ {
 		for j := i + 1; j < len(doors); j++ {
-			path := findCenterBiasedPath(walkable, doors[i], doors[j], centerX, centerY, width, height)
+			path := findCenterBiasedPath(classes, doors[i], doors[j], centerX, centerY, width, height)
 			if path != nil {
 				for _, p := range path {
 					onMainPath[p.Y][p.X] = true
@@ -62,6 +62,9 @@ func ComputeMainPath(ground, bridge [][]int, doorPositions map[DoorPosition]Poin
 		for x := 0; x < width; x++ {
 			if onMainPath[y][x] {
 				pathCellCount++
+				if classes[y][x] == cellCostly {
+					debug.HazardCells++
+				}
 			}
 		}
 	}
@@ -100,18 +103,33 @@ func ComputeMainPath(ground, bridge [][]int, doorPositions map[DoorPosition]Poin
 
 // findCenterBiasedPath finds a path from start to end that prefers going through the center.
 // Uses weighted BFS (Dijkstra) where cells closer to center have lower cost.
-func findCenterBiasedPath(walkable [][]bool, start, end Point, centerX, centerY, width, height int) []Point {
+func findCenterBiasedPath(classes [][]cellClass, start, end Point, centerX, centerY, width, height int) []Point {
 	type node struct {
 		pos  Point
 		cost float64
 	}
 
+	walkable := make([][]bool, height)
+	for y := 0; y < height; y++ {
+		walkable[y] = make([]bool, width)
+		for x := 0; x < width; x++ {
+			walkable[y][x] = classes[y][x].passable()
+		}
+	}
+
 	// Cost function: lower cost for cells near center
 	maxDist := float64(width + height)
+	// A costly cell outweighs any hazard-free route (each cell costs at most 3.0),
+	// so hazards are crossed only when unavoidable, and then as few as possible
+	costlyPenalty := 3.0*float64(width*height) + 1
 	cellCost := func(p Point) float64 {
 		distToCenter := math.Abs(float64(p.X-centerX)) + math.Abs(float64(p.Y-centerY))
 		// Cells near center cost 1.0, cells far from center cost up to 3.0
-		return 1.0 + 2.0*(distToCenter/maxDist)
+		cost := 1.0 + 2.0*(distToCenter/maxDist)
+		if classes[p.Y][p.X] == cellCostly {
+			cost += costlyPenalty
+		}
+		return cost
 	}
 
 	// Find nearest walkable to start and end
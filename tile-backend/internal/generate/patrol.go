@@ -0,0 +1,313 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"tile-backend/internal/model"
+)
+
+// Patrol route constants
+const (
+	patrolMinSide     = 3 // Smallest loop rectangle side, in cells
+	patrolMaxSide     = 5 // Largest loop rectangle side, in cells
+	patrolMinDistance = 2 // Shortest out-and-back route, in steps
+	patrolMaxDistance = 6 // Longest out-and-back route, in steps
+)
+
+// PatrolDebugInfo contains debug info for chaser patrol generation
+type PatrolDebugInfo struct {
+	Skipped     bool         `json:"skipped"`
+	SkipReason  string       `json:"skipReason,omitempty"`
+	TargetCount int          `json:"targetCount"` // Chasers in the room
+	PlacedCount int          `json:"placedCount"` // Chasers that got a route
+	Routes      []PatrolInfo `json:"routes"`
+	Misses      []MissInfo   `json:"misses,omitempty"`
+}
+
+// PatrolInfo describes one chaser's patrol route
+type PatrolInfo struct {
+	Chaser string `json:"chaser"`
+	Shape  string `json:"shape"`  // "loop" or "line" (walked back and forth)
+	Length int    `json:"length"` // Cells on the route
+}
+
+// GenerateChaserPatrols routes a patrol for every chaser. Routes stay on
+// ground, off statics, hazards, other chasers and door approaches, and never
+// share a cell with another route. A chaser first tries a rectangular loop
+// with itself on the edge; failing that it walks back and forth along the
+// shortest path to a cell a few steps away. A chaser without room for either
+// keeps standing still. With symmetry enabled only the source chaser of each
+// orbit is routed and its mirror images get the mirrored routes; a chaser the
+// mode maps onto itself keeps standing still. Routing stops early once ctx is done.
+func GenerateChaserPatrols(ctx context.Context, rng *rand.Rand, symmetry Symmetry, chaserLayer, ground, staticLayer, hazard [][]int,
+	doorPositions []DoorSite, width, height int) ([]model.ChaserPatrol, *PatrolDebugInfo) {
+
+	chasers := layerCells(chaserLayer, width, height)
+	if len(chasers) == 0 {
+		return nil, &PatrolDebugInfo{Skipped: true, SkipReason: "no chasers placed"}
+	}
+	debug := &PatrolDebugInfo{TargetCount: len(chasers), Routes: []PatrolInfo{}}
+
+	forbidden := getDoorForbiddenCellsRadius(doorPositions, width, height, doorForbiddenRadius)
+	used := make(map[Point]bool)
+	free := func(p Point) bool {
+		if p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height {
+			return false
+		}
+		if ground[p.Y][p.X] != 1 || staticLayer[p.Y][p.X] != 0 || forbidden[p] || used[p] {
+			return false
+		}
+		return hazard == nil || hazard[p.Y][p.X] == 0
+	}
+
+	var patrols []model.ChaserPatrol
+	stuck := 0
+	for _, chaser := range chasers {
+		if ctx.Err() != nil {
+			break
+		}
+		// Other chasers block the route; the chaser's own cell does not
+		canWalk := func(p Point) bool {
+			if p != chaser && p.X >= 0 && p.X < width && p.Y >= 0 && p.Y < height && chaserLayer[p.Y][p.X] != 0 {
+				return false
+			}
+			return p == chaser || free(p)
+		}
+		// Mirror images are routed together with their source chaser
+		orbit := symmetry.orbit(chaser, width, height)
+		if symmetry.source(chaser, width, height) != chaser {
+			continue
+		}
+		if used[chaser] || len(orbit) <= len(symmetry.flips()) {
+			stuck += len(orbit)
+			continue
+		}
+
+		shape := "loop"
+		route := patrolLoop(rng, chaser, canWalk)
+		if route == nil {
+			shape = "line"
+			route = patrolLine(rng, chaser, canWalk, width, height)
+		}
+		routes := mirrorRoute(symmetry, route, used, width, height)
+		if routes == nil {
+			stuck += len(orbit)
+			continue
+		}
+
+		for _, r := range routes {
+			for _, p := range r {
+				used[p] = true
+			}
+			patrols = append(patrols, model.ChaserPatrol{
+				Chaser: model.Point{X: r[0].X, Y: r[0].Y},
+				Lines:  polylineSegments(r),
+			})
+			debug.PlacedCount++
+			length := len(r)
+			if shape == "loop" {
+				length-- // The loop returns to its first cell
+			}
+			debug.Routes = append(debug.Routes, PatrolInfo{
+				Chaser: fmt.Sprintf("(%d,%d)", r[0].X, r[0].Y),
+				Shape:  shape,
+				Length: length,
+			})
+		}
+	}
+
+	if stuck > 0 {
+		debug.Misses = append(debug.Misses, MissInfo{
+			Reason: "no free walkable cells around the chaser for a patrol",
+			Count:  stuck,
+		})
+	}
+	return patrols, debug
+}
+
+// mirrorRoute returns the route followed by its image under each of the mode's
+// flips, or nil when there is no route or the images overlap the route, each
+// other or a used cell. Mirrored statics, hazards, chasers and doors keep the
+// images as walkable as the route itself.
+func mirrorRoute(symmetry Symmetry, route []Point, used map[Point]bool, width, height int) [][]Point {
+	if route == nil {
+		return nil
+	}
+	routes := [][]Point{route}
+	taken := make(map[Point]bool, len(route))
+	for _, p := range route {
+		taken[p] = true
+	}
+	for _, f := range symmetry.flips() {
+		image := make([]Point, len(route))
+		for i, p := range route {
+			image[i] = f.apply(p, width, height)
+		}
+		for i, q := range image {
+			// A loop's last cell repeats its first
+			if i == len(image)-1 && q == image[0] {
+				continue
+			}
+			if taken[q] || used[q] {
+				return nil
+			}
+			taken[q] = true
+		}
+		routes = append(routes, image)
+	}
+	return routes
+}
+
+// patrolLoop returns a rectangular loop through start, walked clockwise from
+// start back to start, or nil if no rectangle fits. The largest rectangles are
+// preferred.
+func patrolLoop(rng *rand.Rand, start Point, canWalk func(Point) bool) []Point {
+	var best [][]Point
+	bestLen := 0
+	for w := patrolMinSide; w <= patrolMaxSide; w++ {
+		for h := patrolMinSide; h <= patrolMaxSide; h++ {
+			for x0 := start.X - w + 1; x0 <= start.X; x0++ {
+				for y0 := start.Y - h + 1; y0 <= start.Y; y0++ {
+					ring := rectangleRing(x0, y0, w, h)
+					idx := -1
+					fits := true
+					for i, p := range ring {
+						if p == start {
+							idx = i
+						}
+						if !canWalk(p) {
+							fits = false
+							break
+						}
+					}
+					if !fits || idx < 0 {
+						continue
+					}
+					// Rotate the ring so it starts and ends at start
+					loop := append(append([]Point{}, ring[idx:]...), ring[:idx]...)
+					loop = append(loop, start)
+					switch {
+					case len(loop) > bestLen:
+						best, bestLen = [][]Point{loop}, len(loop)
+					case len(loop) == bestLen:
+						best = append(best, loop)
+					}
+				}
+			}
+		}
+	}
+	if len(best) == 0 {
+		return nil
+	}
+	return best[rng.Intn(len(best))]
+}
+
+// rectangleRing returns the edge cells of a w×h rectangle clockwise from its
+// top-left corner
+func rectangleRing(x0, y0, w, h int) []Point {
+	x1, y1 := x0+w-1, y0+h-1
+	var ring []Point
+	for x := x0; x < x1; x++ {
+		ring = append(ring, Point{X: x, Y: y0})
+	}
+	for y := y0; y < y1; y++ {
+		ring = append(ring, Point{X: x1, Y: y})
+	}
+	for x := x1; x > x0; x-- {
+		ring = append(ring, Point{X: x, Y: y1})
+	}
+	for y := y1; y > y0; y-- {
+		ring = append(ring, Point{X: x0, Y: y})
+	}
+	return ring
+}
+
+// patrolLine returns the shortest path from start to a random cell among the
+// farthest reachable within patrolMaxDistance steps, or nil if nothing lies at
+// least patrolMinDistance steps away
+func patrolLine(rng *rand.Rand, start Point, canWalk func(Point) bool, width, height int) []Point {
+	walkable := make([][]bool, height)
+	origin := make([][]bool, height)
+	for y := 0; y < height; y++ {
+		walkable[y] = make([]bool, width)
+		origin[y] = make([]bool, width)
+		for x := 0; x < width; x++ {
+			walkable[y][x] = canWalk(Point{X: x, Y: y})
+		}
+	}
+	origin[start.Y][start.X] = true
+	dist := computeWalkingDistance(origin, walkable, width, height)
+
+	var ends []Point
+	farthest := patrolMinDistance
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			d := dist[y][x]
+			if d > patrolMaxDistance || d < farthest {
+				continue
+			}
+			if d > farthest {
+				ends, farthest = nil, d
+			}
+			ends = append(ends, Point{X: x, Y: y})
+		}
+	}
+	if len(ends) == 0 {
+		return nil
+	}
+
+	// Walk back from the end along strictly decreasing distances
+	end := ends[rng.Intn(len(ends))]
+	path := []Point{end}
+	for cur := end; cur != start; {
+		for _, d := range []Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+			n := Point{X: cur.X + d.X, Y: cur.Y + d.Y}
+			if n.X >= 0 && n.X < width && n.Y >= 0 && n.Y < height && dist[n.Y][n.X] == dist[cur.Y][cur.X]-1 {
+				cur = n
+				break
+			}
+		}
+		path = append(path, cur)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// polylineSegments merges a path of 4-connected cells into straight segments,
+// each starting where the previous one ended
+func polylineSegments(path []Point) []model.LineSegment {
+	toModel := func(p Point) model.Point { return model.Point{X: p.X, Y: p.Y} }
+	var lines []model.LineSegment
+	start := path[0]
+	for i := 1; i < len(path); i++ {
+		last := i == len(path)-1
+		if !last {
+			// Keep going while the next step has the same direction
+			d1 := Point{X: path[i].X - path[i-1].X, Y: path[i].Y - path[i-1].Y}
+			d2 := Point{X: path[i+1].X - path[i].X, Y: path[i+1].Y - path[i].Y}
+			if d1 == d2 {
+				continue
+			}
+		}
+		lines = append(lines, model.LineSegment{Start: toModel(start), End: toModel(path[i])})
+		start = path[i]
+	}
+	return lines
+}
+
+// layerCells returns the set cells of a layer in row-major order
+func layerCells(layer [][]int, width, height int) []Point {
+	var cells []Point
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if layer[y][x] != 0 {
+				cells = append(cells, Point{X: x, Y: y})
+			}
+		}
+	}
+	return cells
+}
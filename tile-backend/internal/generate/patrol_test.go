@@ -0,0 +1,138 @@
+package generate
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"tile-backend/internal/model"
+	"tile-backend/internal/validate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertPatrolPayload checks that every chaser route is walkable, stays off
+// door approaches and other routes, and that the payload validates strictly
+func assertPatrolPayload(t *testing.T, payload model.TemplatePayload, msg string) {
+	doors, _, _, err := resolveDoors(nil, payload.DoorDescriptors, payload.Meta.Width, payload.Meta.Height)
+	require.NoError(t, err, msg)
+	forbidden := getDoorForbiddenCellsRadius(doors, payload.Meta.Width, payload.Meta.Height, doorForbiddenRadius)
+
+	seen := make(map[model.Point]bool)
+	for _, patrol := range payload.ChaserPatrols {
+		cells, err := patrol.PatrolCells()
+		require.NoError(t, err, msg)
+		if patrol.IsLoop() {
+			cells = cells[:len(cells)-1]
+		}
+		for _, c := range cells {
+			assert.False(t, seen[c], "%s: routes share (%d,%d)", msg, c.X, c.Y)
+			seen[c] = true
+			if c != patrol.Chaser {
+				assert.False(t, forbidden[Point{X: c.X, Y: c.Y}], "%s: route on door approach at (%d,%d)", msg, c.X, c.Y)
+				assert.Zero(t, payload.Chaser[c.Y][c.X], "%s: route through another chaser at (%d,%d)", msg, c.X, c.Y)
+			}
+		}
+	}
+	result := validate.ValidateTemplate(&payload, true)
+	assert.True(t, result.Valid, "%s: %v", msg, result.Errors)
+}
+
+func TestGenerateChaserPatrols_Loop(t *testing.T) {
+	width, height := 12, 10
+	ground := createEmptyLayer(width, height)
+	for y := range ground {
+		for x := range ground[y] {
+			ground[y][x] = 1
+		}
+	}
+	empty := createEmptyLayer(width, height)
+	chaser := createEmptyLayer(width, height)
+	chaser[5][6] = 1
+	doors := []DoorSite{doorSiteAt(DoorLeft, 0, 5), doorSiteAt(DoorRight, width-1, 5)}
+
+	for i := int64(0); i < 10; i++ {
+		patrols, debug := GenerateChaserPatrols(context.Background(), rand.New(rand.NewSource(i)), SymmetryNone, chaser, ground, empty, nil, doors, width, height)
+		require.Len(t, patrols, 1)
+		assert.Equal(t, 1, debug.PlacedCount)
+		assert.Equal(t, "loop", debug.Routes[0].Shape)
+		assert.Equal(t, 16, debug.Routes[0].Length, "seed %d: largest 5x5 loop", i)
+		assert.True(t, patrols[0].IsLoop())
+		// Four sides, the one the chaser stands on split in two unless it is a corner
+		assert.Contains(t, []int{4, 5}, len(patrols[0].Lines))
+	}
+}
+
+func TestGenerateChaserPatrols_Corridor(t *testing.T) {
+	// A one-cell corridor leaves no room for a loop; a static caps it at x=4
+	width, height := 12, 5
+	ground := createEmptyLayer(width, height)
+	for x := 0; x < width; x++ {
+		ground[2][x] = 1
+	}
+	static := createEmptyLayer(width, height)
+	static[2][4] = 1
+	chaser := createEmptyLayer(width, height)
+	chaser[2][7] = 1
+	chaser[2][5] = 1
+
+	patrols, debug := GenerateChaserPatrols(context.Background(), rand.New(rand.NewSource(1)), SymmetryNone, chaser, ground, static, nil, nil, width, height)
+	// The chaser at x=5 is boxed in by the static and the other chaser
+	require.Len(t, patrols, 1)
+	assert.Equal(t, model.Point{X: 7, Y: 2}, patrols[0].Chaser)
+	assert.Equal(t, []model.LineSegment{{Start: model.Point{X: 7, Y: 2}, End: model.Point{X: 11, Y: 2}}}, patrols[0].Lines)
+	assert.Equal(t, "line", debug.Routes[0].Shape)
+	assert.Equal(t, []MissInfo{{Reason: "no free walkable cells around the chaser for a patrol", Count: 1}}, debug.Misses)
+
+	_, debug = GenerateChaserPatrols(context.Background(), rand.New(rand.NewSource(1)), SymmetryNone, createEmptyLayer(width, height), ground, static, nil, nil, width, height)
+	assert.True(t, debug.Skipped)
+}
+
+func TestPolylineSegments(t *testing.T) {
+	path := []Point{{0, 0}, {1, 0}, {2, 0}, {2, 1}, {2, 2}, {1, 2}}
+	assert.Equal(t, []model.LineSegment{
+		{Start: model.Point{X: 0, Y: 0}, End: model.Point{X: 2, Y: 0}},
+		{Start: model.Point{X: 2, Y: 0}, End: model.Point{X: 2, Y: 2}},
+		{Start: model.Point{X: 2, Y: 2}, End: model.Point{X: 1, Y: 2}},
+	}, polylineSegments(path))
+}
+
+func TestGenerateRooms_Patrols(t *testing.T) {
+	for i := int64(0); i < 10; i++ {
+		seed := i
+
+		bridge, err := GenerateBridgeRoom(context.Background(), BridgeGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight},
+			StaticCount: 4, ChaserCount: 3, HazardCount: 2, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertPatrolPayload(t, bridge.Payload, "bridge")
+
+		platform, err := GeneratePlatformRoom(context.Background(), PlatformGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorTop, DoorBottom}, StaticCount: 4, StageType: "pressure", Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertPatrolPayload(t, platform.Payload, "platform")
+
+		full, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight, DoorTop},
+			StaticCount: 6, StageType: "peak", HazardCount: 3, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertPatrolPayload(t, full.Payload, "full")
+		assert.Equal(t, full.DebugInfo.Patrol.PlacedCount, len(full.Payload.ChaserPatrols))
+
+		cave, err := GenerateCave(context.Background(), CaveGenerateRequest{
+			Width: 30, Height: 20, Doors: []DoorPosition{DoorLeft, DoorRight}, StaticCount: 4, StageType: "pressure", Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertPatrolPayload(t, cave.Payload, "cave")
+
+		regen, err := RegenerateTemplate(context.Background(), RegenerateRequest{
+			Payload: full.Payload, LockedLayers: []string{"chaser"}, StaticCount: 8, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertPatrolPayload(t, regen.Payload, "regenerate")
+	}
+}
@@ -0,0 +1,164 @@
+package generate
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Pipeline generation constants
+const (
+	defaultPipelineCount      = 2  // Runs to lay when pipelineCount is not set
+	minPipelineLegLength      = 3  // Shortest straight leg, in cells
+	maxPipelineLegLength      = 10 // Longest straight leg, in cells
+	pipelineLShapeProb        = 50 // Probability of bending a run into an L (50%)
+	pipelinePlacementAttempts = 40 // Random starts tried per run
+)
+
+// PipelineDebugInfo contains debug information about pipeline generation
+type PipelineDebugInfo struct {
+	Skipped     bool              `json:"skipped"`
+	SkipReason  string            `json:"skipReason,omitempty"`
+	TargetCount int               `json:"targetCount"`
+	PlacedCount int               `json:"placedCount"`
+	Runs        []PipelineRunInfo `json:"runs"`
+	Misses      []MissInfo        `json:"misses,omitempty"`
+}
+
+// PipelineRunInfo describes a placed pipeline run
+type PipelineRunInfo struct {
+	Shape  string `json:"shape"`            // straight or L
+	Start  string `json:"start"`            // First cell of the run
+	Corner string `json:"corner,omitempty"` // Bend cell (L runs only)
+	End    string `json:"end"`              // Last cell of the run
+	Length int    `json:"length"`           // Number of cells covered
+}
+
+// pipelineDirections are the four axis directions a leg can run in
+var pipelineDirections = []Point{{X: 1, Y: 0}, {X: -1, Y: 0}, {X: 0, Y: 1}, {X: 0, Y: -1}}
+
+// GeneratePipelineLayer lays straight and L-shaped pipeline runs across the ground.
+// Runs stay off void, bridge, rail and door approach cells, and never touch each
+// other (not even diagonally), so every run reads back as its own line segments.
+func GeneratePipelineLayer(rng *rand.Rand, pipelineLayer, ground, bridge, rail [][]int, doorPositions []DoorSite, width, height, targetCount int) *PipelineDebugInfo {
+	if targetCount <= 0 {
+		targetCount = defaultPipelineCount
+	}
+	debug := &PipelineDebugInfo{
+		TargetCount: targetCount,
+		Runs:        []PipelineRunInfo{},
+	}
+
+	forbidden := getDoorForbiddenCells(doorPositions, width, height)
+	var starts []Point
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if canPlacePipeline(Point{X: x, Y: y}, pipelineLayer, ground, bridge, rail, forbidden, width, height) {
+				starts = append(starts, Point{X: x, Y: y})
+			}
+		}
+	}
+	if len(starts) == 0 {
+		debug.Skipped = true
+		debug.SkipReason = "no ground cell is free of bridge, rail and door approaches"
+		return debug
+	}
+
+	blocked := 0
+	for debug.PlacedCount < targetCount {
+		var run *PipelineRunInfo
+		for attempt := 0; attempt < pipelinePlacementAttempts && run == nil; attempt++ {
+			start := starts[rng.Intn(len(starts))]
+			if run = tryPlacePipelineRun(rng, pipelineLayer, ground, bridge, rail, forbidden, start, width, height); run == nil {
+				blocked++
+			}
+		}
+		if run == nil {
+			break
+		}
+		debug.Runs = append(debug.Runs, *run)
+		debug.PlacedCount++
+	}
+
+	if blocked > 0 {
+		debug.Misses = append(debug.Misses, MissInfo{
+			Reason: "run blocked by void, bridge, rail, door approach or another pipeline",
+			Count:  blocked,
+		})
+	}
+	if remaining := targetCount - debug.PlacedCount; remaining > 0 {
+		debug.Misses = append(debug.Misses, MissInfo{
+			Reason: fmt.Sprintf("could not place %d more pipeline runs", remaining),
+		})
+	}
+
+	return debug
+}
+
+// tryPlacePipelineRun draws one run from start if every cell of it is free;
+// returns nil and leaves the layer untouched otherwise
+func tryPlacePipelineRun(rng *rand.Rand, pipelineLayer, ground, bridge, rail [][]int, forbidden map[Point]bool, start Point, width, height int) *PipelineRunInfo {
+	dir := pipelineDirections[rng.Intn(len(pipelineDirections))]
+	cells := pipelineLeg(start, dir, randomPipelineLegLength(rng))
+	end := cells[len(cells)-1]
+	info := &PipelineRunInfo{Shape: "straight", Start: fmt.Sprintf("(%d,%d)", start.X, start.Y)}
+
+	if rng.Intn(100) < pipelineLShapeProb {
+		// Turn left or right at the end of the first leg
+		turn := Point{X: dir.Y, Y: dir.X}
+		if rng.Intn(2) == 0 {
+			turn = Point{X: -turn.X, Y: -turn.Y}
+		}
+		leg := pipelineLeg(end, turn, randomPipelineLegLength(rng))
+		cells = append(cells, leg[1:]...)
+		info.Shape = "L"
+		info.Corner = fmt.Sprintf("(%d,%d)", end.X, end.Y)
+		end = leg[len(leg)-1]
+	}
+
+	for _, c := range cells {
+		if !canPlacePipeline(c, pipelineLayer, ground, bridge, rail, forbidden, width, height) {
+			return nil
+		}
+	}
+	for _, c := range cells {
+		pipelineLayer[c.Y][c.X] = 1
+	}
+
+	info.End = fmt.Sprintf("(%d,%d)", end.X, end.Y)
+	info.Length = len(cells)
+	return info
+}
+
+// randomPipelineLegLength picks a leg length in [minPipelineLegLength, maxPipelineLegLength]
+func randomPipelineLegLength(rng *rand.Rand) int {
+	return minPipelineLegLength + rng.Intn(maxPipelineLegLength-minPipelineLegLength+1)
+}
+
+// pipelineLeg returns the cells of a straight leg of the given length starting at start
+func pipelineLeg(start, dir Point, length int) []Point {
+	cells := make([]Point, length)
+	for i := range cells {
+		cells[i] = Point{X: start.X + dir.X*i, Y: start.Y + dir.Y*i}
+	}
+	return cells
+}
+
+// canPlacePipeline checks if a pipeline cell may go at p: on ground, off bridge,
+// rail and door approaches, and clear of every existing pipeline cell around it
+func canPlacePipeline(p Point, pipelineLayer, ground, bridge, rail [][]int, forbidden map[Point]bool, width, height int) bool {
+	if p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height {
+		return false
+	}
+	if ground[p.Y][p.X] != 1 || bridge[p.Y][p.X] != 0 || rail[p.Y][p.X] != 0 || forbidden[p] {
+		return false
+	}
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			nx, ny := p.X+dx, p.Y+dy
+			if nx >= 0 && nx < width && ny >= 0 && ny < height && pipelineLayer[ny][nx] != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
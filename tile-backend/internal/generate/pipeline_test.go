@@ -0,0 +1,185 @@
+package generate
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"tile-backend/internal/model"
+	"tile-backend/internal/validate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// linesCells expands line segments back into the cells they cover
+func linesCells(lines []model.LineSegment) map[Point]bool {
+	cells := make(map[Point]bool)
+	for _, l := range lines {
+		for y := min(l.Start.Y, l.End.Y); y <= max(l.Start.Y, l.End.Y); y++ {
+			for x := min(l.Start.X, l.End.X); x <= max(l.Start.X, l.End.X); x++ {
+				cells[Point{X: x, Y: y}] = true
+			}
+		}
+	}
+	return cells
+}
+
+// assertPipelinePayload checks that pipeline lines match the layer and nothing sits on the pipeline
+func assertPipelinePayload(t *testing.T, payload model.TemplatePayload, msg string) {
+	require.NotNil(t, payload.Pipeline, msg)
+	cells := linesCells(payload.PipelineLines)
+	for y := range payload.Pipeline {
+		for x, v := range payload.Pipeline[y] {
+			assert.Equal(t, v == 1, cells[Point{X: x, Y: y}], "%s: line coverage at (%d,%d)", msg, x, y)
+			if v != 1 {
+				continue
+			}
+			for name, layer := range map[string]model.Layer{
+				"static": payload.Static, "chaser": payload.Chaser, "zoner": payload.Zoner, "dps": payload.DPS, "mobAir": payload.MobAir,
+			} {
+				assert.Zero(t, layer[y][x], "%s: %s on pipeline at (%d,%d)", msg, name, x, y)
+			}
+		}
+	}
+	result := validate.ValidateTemplate(&payload, true)
+	assert.True(t, result.Valid, "%s: %v", msg, result.Errors)
+}
+
+func TestGeneratePipelineLayer_FullGround(t *testing.T) {
+	width, height := 20, 14
+	ground := createEmptyLayer(width, height)
+	for y := range ground {
+		for x := range ground[y] {
+			ground[y][x] = 1
+		}
+	}
+	bridge := createEmptyLayer(width, height)
+	rail := createEmptyLayer(width, height)
+	doors := []DoorSite{doorSiteAt(DoorLeft, 0, 7)}
+	forbidden := getDoorForbiddenCells(doors, width, height)
+
+	for i := int64(0); i < 20; i++ {
+		pipeline := createEmptyLayer(width, height)
+		debug := GeneratePipelineLayer(rand.New(rand.NewSource(i)), pipeline, ground, bridge, rail, doors, width, height, 3)
+		assert.False(t, debug.Skipped)
+		assert.Equal(t, 3, debug.PlacedCount, "seed %d", i)
+		assert.Len(t, debug.Runs, debug.PlacedCount)
+
+		total := 0
+		for _, run := range debug.Runs {
+			total += run.Length
+		}
+		assert.Equal(t, total, countCells(pipeline), "runs never overlap")
+		for p := range forbidden {
+			assert.Zero(t, pipeline[p.Y][p.X], "door approach (%d,%d)", p.X, p.Y)
+		}
+	}
+}
+
+func TestGeneratePipelineLayer_AvoidsBridgeAndRail(t *testing.T) {
+	width, height := 12, 12
+	ground := createEmptyLayer(width, height)
+	bridge := createEmptyLayer(width, height)
+	rail := createEmptyLayer(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			ground[y][x] = 1
+			if x >= 6 {
+				rail[y][x] = 1
+			}
+		}
+	}
+	bridge[3][2] = 1
+
+	pipeline := createEmptyLayer(width, height)
+	GeneratePipelineLayer(rand.New(rand.NewSource(3)), pipeline, ground, bridge, rail, nil, width, height, 4)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if pipeline[y][x] == 1 {
+				assert.Less(t, x, 6)
+				assert.Zero(t, bridge[y][x])
+			}
+		}
+	}
+}
+
+func TestGeneratePipelineLayer_NoGround(t *testing.T) {
+	empty := createEmptyLayer(10, 10)
+	debug := GeneratePipelineLayer(rand.New(rand.NewSource(1)), createEmptyLayer(10, 10), empty, empty, empty, nil, 10, 10, 2)
+	assert.True(t, debug.Skipped)
+	assert.Equal(t, 0, debug.PlacedCount)
+}
+
+func TestGenerateRooms_Pipeline(t *testing.T) {
+	for i := int64(0); i < 10; i++ {
+		seed := i
+
+		bridge, err := GenerateBridgeRoom(context.Background(), BridgeGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight},
+			StaticCount: 4, ChaserCount: 3, ZonerCount: 2, DPSCount: 2, MobAirCount: 2,
+			PipelineEnabled: true, PipelineCount: 3, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertPipelinePayload(t, bridge.Payload, "bridge")
+		require.NotNil(t, bridge.DebugInfo.Pipeline)
+		assert.Equal(t, 3, bridge.DebugInfo.Pipeline.TargetCount)
+
+		platform, err := GeneratePlatformRoom(context.Background(), PlatformGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorTop, DoorBottom},
+			StaticCount: 4, StageType: "pressure", PipelineEnabled: true, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertPipelinePayload(t, platform.Payload, "platform")
+		assert.Equal(t, defaultPipelineCount, platform.DebugInfo.Pipeline.TargetCount)
+
+		full, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight, DoorTop},
+			StaticCount: 6, StageType: "peak", PipelineEnabled: true, PipelineCount: 4, Seed: &seed,
+		})
+		require.NoError(t, err)
+		assertPipelinePayload(t, full.Payload, "full")
+		assert.NotZero(t, full.DebugInfo.Pipeline.PlacedCount)
+	}
+}
+
+func TestGenerateRooms_PipelineSymmetry(t *testing.T) {
+	for _, mode := range symmetryModes {
+		seed := int64(4)
+		resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+			Width: 22, Height: 14, Doors: []DoorPosition{DoorLeft, DoorRight},
+			StaticCount: 4, ChaserCount: 2, PipelineEnabled: true, Symmetry: mode, Seed: &seed,
+		})
+		require.NoError(t, err, mode)
+		assertPipelinePayload(t, resp.Payload, string(mode))
+		assertSymmetricPayload(t, mode, resp.Payload, string(mode))
+		assert.True(t, mode.isSymmetric(resp.Payload.Pipeline, 22, 14), mode)
+	}
+}
+
+func TestGenerateRooms_PipelineDisabled(t *testing.T) {
+	seed := int64(2)
+	resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, StaticCount: 3, Seed: &seed,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, resp.Payload.Pipeline)
+	assert.Nil(t, resp.Payload.PipelineLines)
+	require.NotNil(t, resp.DebugInfo.Pipeline)
+	assert.True(t, resp.DebugInfo.Pipeline.Skipped)
+}
+
+func TestRegenerateTemplate_KeepsPipeline(t *testing.T) {
+	seed := int64(6)
+	full, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight}, PipelineEnabled: true, PipelineCount: 4, Seed: &seed,
+	})
+	require.NoError(t, err)
+
+	resp, err := RegenerateTemplate(context.Background(), RegenerateRequest{
+		Payload: full.Payload, StaticCount: 6, ChaserCount: 3, DPSCount: 2, Seed: &seed,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, full.Payload.Pipeline, resp.Payload.Pipeline)
+	assertPipelinePayload(t, resp.Payload, "regenerated")
+}
@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"tile-backend/internal/model"
@@ -8,25 +9,40 @@ import (
 
 // PlatformGenerateRequest represents the request for generating a platform room
 type PlatformGenerateRequest struct {
-	Width         int            `json:"width"`
-	Height        int            `json:"height"`
-	Doors         []DoorPosition `json:"doors"`         // At least 2 doors required
-	SoftEdgeCount int            `json:"softEdgeCount"` // Suggested number of soft edges to place (optional)
-	RailEnabled   bool           `json:"railEnabled"`   // Whether to generate rail layer (optional)
-	StaticCount   int            `json:"staticCount"`   // Suggested number of statics to place (optional)
-	ChaserCount   int            `json:"chaserCount"`   // Suggested number of chasers to place (optional)
-	ZonerCount    int            `json:"zonerCount"`    // Suggested number of zoners to place (optional)
-	DPSCount      int            `json:"dpsCount"`      // Suggested number of DPS to place (optional)
-	MobAirCount   int            `json:"mobAirCount"`   // Suggested number of mob air (fly) to place (optional)
-	StageType     string         `json:"stageType"`     // Room stage type (optional)
-	RoomCategory  string         `json:"roomCategory"`  // Room category: normal, basement, test, cave (optional, default: normal)
+	Width                 int                    `json:"width"`
+	Height                int                    `json:"height"`
+	Doors                 []DoorPosition         `json:"doors"`                           // Door sides (optional, zero doors allowed)
+	DoorDescriptors       []model.DoorDescriptor `json:"doorDescriptors,omitempty"`       // Door openings with side, offset and width; replaces doors when set (optional)
+	Symmetry              Symmetry               `json:"symmetry,omitempty"`              // Whole-room symmetry: none, horizontal, vertical, both, rotational-180 (optional, default none)
+	SoftEdgeCount         int                    `json:"softEdgeCount"`                   // Suggested number of soft edges to place (optional)
+	RailEnabled           bool                   `json:"railEnabled"`                     // Whether to generate rail layer (optional)
+	PipelineEnabled       bool                   `json:"pipelineEnabled"`                 // Whether to generate pipeline layer (optional)
+	PipelineCount         int                    `json:"pipelineCount"`                   // Suggested number of pipeline runs to lay (optional, default 2)
+	HazardCount           int                    `json:"hazardCount"`                     // Suggested number of hazard strips (spikes, lava) to lay (optional)
+	StaticCount           int                    `json:"staticCount"`                     // Suggested number of statics to place (optional)
+	ChaserCount           int                    `json:"chaserCount"`                     // Suggested number of chasers to place (optional)
+	ZonerCount            int                    `json:"zonerCount"`                      // Suggested number of zoners to place (optional)
+	DPSCount              int                    `json:"dpsCount"`                        // Suggested number of DPS to place (optional)
+	MobAirCount           int                    `json:"mobAirCount"`                     // Suggested number of mob air (fly) to place (optional)
+	PickupCount           int                    `json:"pickupCount"`                     // Suggested number of pickups (chests, health) to place (optional)
+	StageType             string                 `json:"stageType"`                       // Room stage type (optional)
+	StageOverrides        model.StageOverrides   `json:"-"`                               // Project stage overrides, applied on top of the stage config (set from project_id, not read from the body)
+	RoomCategory          string                 `json:"roomCategory"`                    // Room category: normal, basement, test, cave (optional, default: normal)
+	Seed                  *int64                 `json:"seed,omitempty"`                  // Random seed for reproducible output (optional, random if omitted)
+	Trace                 bool                   `json:"-"`                               // Record a frame per pipeline step and rollback (set from the trace query parameter, not read from the body)
+	DifficultyTarget      *DifficultyTarget      `json:"difficultyTarget,omitempty"`      // Resample until the difficulty score is in range (optional)
+	MaxValidationAttempts int                    `json:"maxValidationAttempts,omitempty"` // Rooms to try until one passes strict validation (optional, default 3, max 20)
+	ConstraintMasks                              // Designer masks forceGround, forceVoid, noEnemy, noStatic (optional)
 }
 
 // PlatformGenerateResponse represents the generated template
 type PlatformGenerateResponse struct {
-	Payload    model.TemplatePayload `json:"payload"`
-	DebugInfo  *PlatformDebugInfo    `json:"debugInfo,omitempty"`
-	Difficulty *DifficultyScore      `json:"difficulty,omitempty"`
+	Payload          model.TemplatePayload   `json:"payload"`
+	DebugInfo        *PlatformDebugInfo      `json:"debugInfo,omitempty"`
+	Difficulty       *DifficultyScore        `json:"difficulty,omitempty"`
+	Seed             int64                   `json:"seed"`                       // Seed used for this generation
+	DifficultyTarget *DifficultyTargetResult `json:"difficultyTarget,omitempty"` // Target search outcome (only when difficultyTarget was requested)
+	Trace            []TraceFrame            `json:"trace,omitempty"`            // Step-by-step layer frames (only when trace was requested)
 }
 
 // PlatformDebugInfo contains debug information about the platform generation process
@@ -35,12 +51,20 @@ type PlatformDebugInfo struct {
 	SoftEdge    *SoftEdgeDebugInfo       `json:"softEdge,omitempty"`
 	BridgeLayer *BridgeLayerDebugInfo    `json:"bridgeLayer,omitempty"`
 	Rail        *RailDebugInfo           `json:"rail,omitempty"`
+	Pipeline    *PipelineDebugInfo       `json:"pipeline,omitempty"`
+	Hazard      *HazardDebugInfo         `json:"hazard,omitempty"`
 	MainPath    *MainPathDebugInfo       `json:"mainPath,omitempty"`
 	Static      *StaticDebugInfo         `json:"static,omitempty"`
 	Chaser      *EnemyLayerDebugInfo     `json:"chaser,omitempty"`
 	Zoner       *EnemyLayerDebugInfo     `json:"zoner,omitempty"`
 	DPS         *EnemyLayerDebugInfo     `json:"dps,omitempty"`
 	MobAir      *MobAirDebugInfo         `json:"mobAir,omitempty"`
+	Pickup      *PickupDebugInfo         `json:"pickup,omitempty"`
+	Waves       *WaveDebugInfo           `json:"waves,omitempty"`
+	Patrol      *PatrolDebugInfo         `json:"patrol,omitempty"`
+	Constraints *ConstraintDebugInfo     `json:"constraints,omitempty"`
+	Symmetry    *SymmetryDebugInfo       `json:"symmetry,omitempty"`
+	Validation  *ValidationDebugInfo     `json:"validation,omitempty"`
 }
 
 // PlatformGroundDebugInfo contains debug info for platform ground layer generation
@@ -77,8 +101,44 @@ const (
 	GroupBottomRight DoorGroup = "bottom-right"
 )
 
-// GeneratePlatformRoom generates a platform-type room
-func GeneratePlatformRoom(req PlatformGenerateRequest) (*PlatformGenerateResponse, error) {
+// platformGenerator is the registry entry for platform rooms
+var platformGenerator = &shapeGenerator[PlatformGenerateRequest, PlatformGenerateResponse]{
+	info: ShapeInfo{
+		Name:         "platform",
+		RoomType:     "platform",
+		PayloadShape: "platform",
+		Doors:        allDoorSides,
+		MinDoors:     0,
+		MinWidth:     10,
+		MinHeight:    10,
+	},
+	generate: GeneratePlatformRoom,
+	setOptions: func(req *PlatformGenerateRequest, opts GenerateOptions) {
+		opts.apply(&req.Seed, &req.StageOverrides, &req.Trace)
+	},
+	fromParams: func(p RoomParams) PlatformGenerateRequest {
+		return PlatformGenerateRequest{Width: p.Width, Height: p.Height, Doors: p.Doors, StageType: p.StageType, RoomCategory: "normal"}
+	},
+	result: func(resp *PlatformGenerateResponse) (*model.TemplatePayload, *DifficultyScore, *ValidationDebugInfo) {
+		return &resp.Payload, resp.Difficulty, resp.DebugInfo.Validation
+	},
+}
+
+// GeneratePlatformRoom generates a platform-type room (see generateSampled).
+func GeneratePlatformRoom(ctx context.Context, req PlatformGenerateRequest) (*PlatformGenerateResponse, error) {
+	return generateSampled(ctx, req, generatePlatformRoom)
+}
+
+func (r *PlatformGenerateRequest) sampling() sampledRequest {
+	return sampledRequest{target: &r.DifficultyTarget, seed: &r.Seed, maxValidationAttempts: r.MaxValidationAttempts}
+}
+
+func (r *PlatformGenerateResponse) sampling() sampledResponse {
+	return sampledResponse{payload: &r.Payload, difficulty: r.Difficulty, seed: &r.Seed, target: &r.DifficultyTarget, validation: &r.DebugInfo.Validation}
+}
+
+// generatePlatformRoom generates one room from the request seed, without self-validation
+func generatePlatformRoom(ctx context.Context, req PlatformGenerateRequest) (*PlatformGenerateResponse, error) {
 	// Validate input
 	if req.Width < 10 || req.Width > 200 {
 		return nil, fmt.Errorf("width must be between 10 and 200")
@@ -89,45 +149,89 @@ func GeneratePlatformRoom(req PlatformGenerateRequest) (*PlatformGenerateRespons
 	if err := ValidateRoomCategory(req.RoomCategory); err != nil {
 		return nil, err
 	}
-	// Check for duplicate doors
-	doorSet := make(map[DoorPosition]bool)
-	for _, door := range req.Doors {
-		if doorSet[door] {
-			return nil, fmt.Errorf("duplicate door: %s", door)
+	if err := req.ConstraintMasks.Validate(req.Width, req.Height); err != nil {
+		return nil, err
+	}
+	if err := ValidateSymmetry(req.Symmetry); err != nil {
+		return nil, err
+	}
+	if err := req.Symmetry.checkMasks(&req.ConstraintMasks, req.Width, req.Height); err != nil {
+		return nil, err
+	}
+	// Resolve door openings; descriptors replace the side list when given
+	doorPositions, sides, descriptors, err := resolveDoors(req.Doors, req.DoorDescriptors, req.Width, req.Height)
+	if err != nil {
+		return nil, err
+	}
+
+	// Mirror the doors so they match the symmetric room
+	addedDoors := 0
+	if req.Symmetry.enabled() {
+		requested := descriptors
+		if doorPositions, sides, descriptors, err = symmetricDoors(req.Symmetry, descriptors, req.Width, req.Height); err != nil {
+			return nil, err
 		}
-		doorSet[door] = true
+		addedDoors = countAddedDoors(requested, descriptors)
 	}
 
+	// All randomness for this request flows from a single seeded source
+	rng, seed := newRequestRand(req.Seed)
+	masks := &req.ConstraintMasks
+	trace := newTrace(req.Trace)
+	steps := newStepTracker(ctx, "platform")
+
 	debugInfo := &PlatformDebugInfo{}
 
 	// Create empty layers
 	ground := createEmptyLayer(req.Width, req.Height)
 	emptyLayer := createEmptyLayer(req.Width, req.Height)
 
-	// Get door center positions
-	doorPositions := getDoorCenterPositions(req.Width, req.Height, req.Doors)
-
 	// Step 1: Generate ground layer with platforms
-	groundDebug := generatePlatformGround(ground, req.Width, req.Height, req.Doors)
+	groundDebug := generatePlatformGround(rng, ground, masks, req.Width, req.Height, doorPositions)
+	trace.snapshot("platform ground", map[string][][]int{"ground": ground})
+
+	// Every cell of every door opening is walkable
+	openDoorCells(ground, doorPositions, req.Width, req.Height)
 
 	// Step 1.5: Repair any disconnected ground fragments produced by the platform
 	// generator. All ground cells must form a single 4-connected region before
 	// subsequent layers are built on top.
 	ensureGroundConnectivity(ground, req.Width, req.Height)
+	trace.snapshot("connectivity repair", map[string][][]int{"ground": ground})
+
+	// Step 1.6: Apply designer forceGround / forceVoid masks
+	constraintReasons := applyGroundConstraints(ground, masks, doorPositions, req.Width, req.Height, trace)
+	if !masks.isEmpty() {
+		trace.snapshot("constraints", map[string][][]int{"ground": ground})
+	}
+
+	// Symmetry: mirror the ground so every later layer is placed on a symmetric room
+	if req.Symmetry.enabled() {
+		debugInfo.Symmetry = symmetrizeGround(req.Symmetry, ground, masks, constraintReasons, req.Width, req.Height)
+		debugInfo.Symmetry.AddedDoors = addedDoors
+		trace.snapshot("symmetric ground", map[string][][]int{"ground": ground})
+	}
 
 	debugInfo.Ground = groundDebug
+	if err := steps.done("ground"); err != nil {
+		return nil, err
+	}
 
 	// Step 2: Generate soft edge layer
 	softEdgeLayer := copyLayer(emptyLayer)
 	if req.SoftEdgeCount > 0 {
-		softEdgeDebug := generateSoftEdgeLayerWithDebug(softEdgeLayer, ground, doorPositions, req.Width, req.Height, req.SoftEdgeCount)
+		softEdgeDebug := generateSoftEdgeLayerWithDebug(rng, softEdgeLayer, ground, doorPositions, req.Width, req.Height, req.SoftEdgeCount)
 		debugInfo.SoftEdge = softEdgeDebug
+		trace.snapshot("soft edge", map[string][][]int{"softEdge": softEdgeLayer})
 	} else {
 		debugInfo.SoftEdge = &SoftEdgeDebugInfo{
 			Skipped:    true,
 			SkipReason: "softEdgeCount is 0 or not specified",
 		}
 	}
+	if err := steps.done("soft edge"); err != nil {
+		return nil, err
+	}
 
 	// Step 3: Bridge layer — platform rooms never have bridge tiles.
 	// Bridge tiles are only meaningful in bridge rooms (floating islands over void).
@@ -142,29 +246,68 @@ func GeneratePlatformRoom(req PlatformGenerateRequest) (*PlatformGenerateRespons
 	// Step 3.5: Generate rail layer
 	railLayer := copyLayer(emptyLayer)
 	if req.RailEnabled {
-		railDebug := GenerateRailLayer(railLayer, ground, bridgeLayer, req.Width, req.Height)
+		railDebug := GenerateRailLayer(rng, railLayer, ground, bridgeLayer, req.Width, req.Height)
 		debugInfo.Rail = railDebug
+		trace.snapshot("rail", map[string][][]int{"rail": railLayer})
 	} else {
 		debugInfo.Rail = &RailDebugInfo{
 			Skipped:    true,
 			SkipReason: "railEnabled is false or not specified",
 		}
 	}
+	if err := steps.done("rail"); err != nil {
+		return nil, err
+	}
+
+	// Step 3.6: Generate pipeline layer
+	pipelineLayer := copyLayer(emptyLayer)
+	if req.PipelineEnabled {
+		debugInfo.Pipeline = GeneratePipelineLayer(rng, pipelineLayer, ground, bridgeLayer, railLayer, doorPositions, req.Width, req.Height, req.PipelineCount)
+		trace.snapshot("pipeline", map[string][][]int{"pipeline": pipelineLayer})
+	} else {
+		debugInfo.Pipeline = &PipelineDebugInfo{
+			Skipped:    true,
+			SkipReason: "pipelineEnabled is false or not specified",
+		}
+	}
+	if err := steps.done("pipeline"); err != nil {
+		return nil, err
+	}
+	// Step 3.7: Generate hazard layer
+	hazardLayer := copyLayer(emptyLayer)
+	if req.HazardCount > 0 {
+		debugInfo.Hazard = GenerateHazardLayer(ctx, rng, hazardLayer, ground, bridgeLayer, railLayer, pipelineLayer, doorPositions, req.Width, req.Height, req.HazardCount)
+		trace.snapshot("hazard", map[string][][]int{"hazard": hazardLayer})
+	} else {
+		debugInfo.Hazard = &HazardDebugInfo{Skipped: true, SkipReason: "hazardCount is 0 or not specified"}
+	}
+	if err := steps.done("hazard"); err != nil {
+		return nil, err
+	}
+
+	// Statics and enemies keep off the pipeline and hazards
+	placementMasks := masks.withBlocked(pipelineLayer, hazardLayer)
+
+	// Statics and enemies are placed on grids, read back as layers once placement is done
+	lc := newLayerContext(ground, softEdgeLayer, bridgeLayer, railLayer, doorPositions, req.Width, req.Height)
 
 	// Step 4: Generate static layer
-	staticLayer := copyLayer(emptyLayer)
 	if req.StaticCount > 0 {
-		staticDebug := generateStaticLayerWithDebugAndRail(staticLayer, ground, softEdgeLayer, bridgeLayer, railLayer, doorPositions, req.Width, req.Height, req.StaticCount)
+		staticDebug := generateStaticLayerWithDebugAndRail(ctx, rng, lc, placementMasks, req.StaticCount)
 		debugInfo.Static = staticDebug
+		trace.snapshotGrids("static", map[string]*Grid{"static": lc.Static})
 	} else {
 		debugInfo.Static = &StaticDebugInfo{
 			Skipped:    true,
 			SkipReason: "staticCount is 0 or not specified",
 		}
 	}
+	if err := steps.done("static"); err != nil {
+		return nil, err
+	}
 
 	// Apply stage rules
-	stageResult, stageErr := ValidateAndApplyStage(req.StageType, "platform", req.Doors, ground, req.Width, req.Height)
+	stageResult, stageErr := ValidateAndApplyStage(rng, req.StageType, "platform", req.StageOverrides, sides, ground, req.Width, req.Height)
 	if stageErr != nil {
 		return nil, stageErr
 	}
@@ -176,34 +319,35 @@ func GeneratePlatformRoom(req PlatformGenerateRequest) (*PlatformGenerateRespons
 	}
 
 	// Main path computation
-	mainPathData, mainPathDebug := ComputeMainPath(ground, bridgeLayer, doorPositions, req.Width, req.Height)
+	mainPathData, mainPathDebug := ComputeMainPath(ground, bridgeLayer, hazardLayer, doorPositions, req.Width, req.Height)
+	lc.MainPath = mainPathData
 	debugInfo.MainPath = mainPathDebug
+	if err := steps.done("main path"); err != nil {
+		return nil, err
+	}
 
 	// Step 5: Generate zoner layer
-	zonerLayer := copyLayer(emptyLayer)
 	if req.ZonerCount > 0 {
-		zonerDebug := GenerateZonerLayer(zonerLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, doorPositions, mainPathData, req.Width, req.Height, req.ZonerCount)
+		zonerDebug := GenerateZonerLayer(ctx, rng, lc, placementMasks, req.ZonerCount)
 		debugInfo.Zoner = zonerDebug
 	} else {
 		debugInfo.Zoner = &EnemyLayerDebugInfo{Skipped: true, SkipReason: "zonerCount is 0 or not specified"}
 	}
 
 	// Step 6: Generate chaser layer
-	chaserLayer := copyLayer(emptyLayer)
 	if req.ChaserCount > 0 {
-		chaserDebug := GenerateChaserLayer(chaserLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, doorPositions, mainPathData, req.Width, req.Height, req.ChaserCount)
+		chaserDebug := GenerateChaserLayer(ctx, rng, lc, placementMasks, req.ChaserCount)
 		debugInfo.Chaser = chaserDebug
 	} else {
 		debugInfo.Chaser = &EnemyLayerDebugInfo{Skipped: true, SkipReason: "chaserCount is 0 or not specified"}
 	}
 
 	// Step 6.5: Generate DPS layer
-	dpsLayer := copyLayer(emptyLayer)
 	if req.DPSCount > 0 {
-		dpsDebug := GenerateDPSLayer(dpsLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, chaserLayer, doorPositions, mainPathData, req.Width, req.Height, req.DPSCount)
+		dpsDebug := GenerateDPSLayer(ctx, rng, lc, placementMasks, req.DPSCount)
 		// Relaxed fallback: if strict pass still can't fill target, drop spacing constraint.
 		if remaining := req.DPSCount - dpsDebug.PlacedCount; remaining > 0 {
-			GenerateDPSLayerRelaxed(dpsLayer, ground, softEdgeLayer, bridgeLayer, railLayer, staticLayer, zonerLayer, chaserLayer, doorPositions, mainPathData, req.Width, req.Height, remaining)
+			GenerateDPSLayerRelaxed(ctx, rng, lc, placementMasks, remaining)
 		}
 		debugInfo.DPS = dpsDebug
 	} else {
@@ -211,32 +355,53 @@ func GeneratePlatformRoom(req PlatformGenerateRequest) (*PlatformGenerateRespons
 	}
 
 	// Step 7: Generate mob air layer
-	mobAirLayer := copyLayer(emptyLayer)
 	if req.MobAirCount > 0 {
-		mobAirDebug := GenerateMobAirLayerNew(mobAirLayer, ground, softEdgeLayer, bridgeLayer, staticLayer, zonerLayer, chaserLayer, dpsLayer, doorPositions, req.Width, req.Height, req.MobAirCount)
+		mobAirDebug := GenerateMobAirLayerNew(ctx, lc, placementMasks, req.MobAirCount)
 		debugInfo.MobAir = mobAirDebug
 	} else {
 		debugInfo.MobAir = &MobAirDebugInfo{Skipped: true, SkipReason: "mobAirCount is 0 or not specified"}
 	}
 
-	// Create doors configuration
-	doorStates := &model.DoorStates{
-		Top:    0,
-		Right:  0,
-		Bottom: 0,
-		Left:   0,
+	// Create door states
+	doorStates := model.DoorStatesFromDescriptors(descriptors)
+
+	staticLayer := lc.Static.Layer()
+	zonerLayer := lc.Zoner.Layer()
+	chaserLayer := lc.Chaser.Layer()
+	dpsLayer := lc.DPS.Layer()
+	mobAirLayer := lc.MobAir.Layer()
+
+	// Each enemy type only writes its own layer, so one frame per type shows its placement
+	trace.snapshot("zoner", map[string][][]int{"zoner": zonerLayer})
+	trace.snapshot("chaser", map[string][][]int{"chaser": chaserLayer})
+	trace.snapshot("dps", map[string][][]int{"dps": dpsLayer})
+	trace.snapshot("mobAir", map[string][][]int{"mobAir": mobAirLayer})
+	if err := steps.done("enemies"); err != nil {
+		return nil, err
 	}
-	for _, door := range req.Doors {
-		switch door {
-		case DoorTop:
-			doorStates.Top = 1
-		case DoorRight:
-			doorStates.Right = 1
-		case DoorBottom:
-			doorStates.Bottom = 1
-		case DoorLeft:
-			doorStates.Left = 1
+
+	// Step 8: Generate pickup layer
+	pickupLayer := copyLayer(emptyLayer)
+	if req.PickupCount > 0 {
+		debugInfo.Pickup = GeneratePickupLayer(rng, pickupLayer, ground, bridgeLayer, railLayer, pipelineLayer, hazardLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, doorPositions, mainPathData, req.Width, req.Height, req.PickupCount)
+		trace.snapshot("pickup", map[string][][]int{"pickup": pickupLayer})
+	} else {
+		debugInfo.Pickup = &PickupDebugInfo{Skipped: true, SkipReason: "pickupCount is 0 or not specified"}
+	}
+	if err := steps.done("pickup"); err != nil {
+		return nil, err
+	}
+
+	// Symmetry: copy the source region of every layer onto the rest of the room,
+	// then recompute the main path over the mirrored bridges
+	if req.Symmetry.enabled() {
+		debugInfo.Symmetry.MirroredCells = mirrorLayers(req.Symmetry, req.Width, req.Height, softEdgeLayer, bridgeLayer, railLayer, pipelineLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, pickupLayer, hazardLayer)
+		debugInfo.Symmetry.RemovedCells += dropCuttingStatics(req.Symmetry, staticLayer, ground, doorPositions, req.Width, req.Height)
+		if n := dropCuttingHazards(hazardLayer, ground, bridgeLayer, doorPositions, req.Width, req.Height); n > 0 {
+			debugInfo.Symmetry.RemovedCells += n
+			debugInfo.Symmetry.HazardDropped = true
 		}
+		mainPathData, debugInfo.MainPath = ComputeMainPath(ground, bridgeLayer, hazardLayer, doorPositions, req.Width, req.Height)
 	}
 
 	// Build main path layer for output
@@ -263,21 +428,22 @@ func GeneratePlatformRoom(req PlatformGenerateRequest) (*PlatformGenerateRespons
 	}
 	stageType := &stageStr
 	payload := model.TemplatePayload{
-		Ground:       ground,
-		SoftEdge:     softEdgeLayer,
-		Bridge:       bridgeLayer,
-		Rail:         railLayer,
-		Static:       staticLayer,
-		Chaser:       chaserLayer,
-		Zoner:        zonerLayer,
-		DPS:          dpsLayer,
-		MobAir:       mobAirLayer,
-		MainPath:     mainPathLayer,
-		Doors:        doorStates,
-		StageType:    stageType,
-		RoomShape:    &roomShape,
-		RoomCategory: &roomCategory,
-		OpenDoors:    model.ComputeOpenDoors(doorStates),
+		Ground:          ground,
+		SoftEdge:        softEdgeLayer,
+		Bridge:          bridgeLayer,
+		Rail:            railLayer,
+		Static:          staticLayer,
+		Chaser:          chaserLayer,
+		Zoner:           zonerLayer,
+		DPS:             dpsLayer,
+		MobAir:          mobAirLayer,
+		MainPath:        mainPathLayer,
+		Doors:           doorStates,
+		DoorDescriptors: descriptors,
+		StageType:       stageType,
+		RoomShape:       &roomShape,
+		RoomCategory:    &roomCategory,
+		OpenDoors:       model.ComputeOpenDoors(doorStates),
 		Meta: model.TemplateMeta{
 			Name:    fmt.Sprintf("platform-%dx%d", req.Width, req.Height),
 			Version: 1,
@@ -286,38 +452,73 @@ func GeneratePlatformRoom(req PlatformGenerateRequest) (*PlatformGenerateRespons
 		},
 	}
 
-	difficulty := ComputeDifficulty(ground, softEdgeLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, mainPathData, req.Width, req.Height)
+	if req.PipelineEnabled {
+		payload.Pipeline = pipelineLayer
+	}
+	if req.HazardCount > 0 {
+		payload.Hazard = hazardLayer
+	}
+	if req.PickupCount > 0 {
+		payload.Pickup = pickupLayer
+	}
+	payload.SetLineSegments()
+
+	// Validate the mirrored room, clearing mirrored cells that break a rule
+	if req.Symmetry.enabled() {
+		if err := finishSymmetricPayload(req.Symmetry, &payload, debugInfo.Symmetry); err != nil {
+			return nil, err
+		}
+	}
+
+	// Patrol routes are traced around the final chaser cells
+	payload.ChaserPatrols, debugInfo.Patrol = GenerateChaserPatrols(ctx, rng, req.Symmetry, chaserLayer, ground, staticLayer, hazardLayer, doorPositions, req.Width, req.Height)
+	if err := steps.done("patrols"); err != nil {
+		return nil, err
+	}
+
+	// Spawn waves are assigned to the final enemy cells
+	payload.Waves, debugInfo.Waves = assignWaves(stageResult.PlacementHints, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, req.Width, req.Height)
+	trace.snapshotPayload("final", &payload)
+
+	difficulty := ComputeDifficulty(ground, softEdgeLayer, hazardLayer, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, mainPathData, req.Width, req.Height)
+
+	// Report designer masks that could not be honored
+	if !masks.isEmpty() {
+		debugInfo.Constraints = checkConstraints(masks, constraintReasons, ground, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer)
+	}
 
 	return &PlatformGenerateResponse{
 		Payload:    payload,
 		DebugInfo:  debugInfo,
 		Difficulty: difficulty,
+		Seed:       seed,
+		Trace:      trace.Frames(),
 	}, nil
 }
 
 // generatePlatformGround generates the ground layer for platform rooms
-func generatePlatformGround(ground [][]int, width, height int, doors []DoorPosition) *PlatformGroundDebugInfo {
+func generatePlatformGround(rng *rand.Rand, ground [][]int, masks *ConstraintMasks, width, height int, doors []DoorSite) *PlatformGroundDebugInfo {
 	debug := &PlatformGroundDebugInfo{}
 
 	// Check if strategy 2 is possible (doors can be grouped into corner pairs)
-	canUseStrategy2 := canGroupDoorsIntoCorners(doors)
+	canUseStrategy2 := canGroupDoorsIntoCorners(doorSides(doors))
 
 	// Choose strategy
 	var useStrategy2 bool
 	if canUseStrategy2 {
-		useStrategy2 = rand.Float64() < 0.5 // 50% chance to use strategy 2 if possible
+		useStrategy2 = rng.Float64() < 0.5 // 50% chance to use strategy 2 if possible
 	}
 
 	if useStrategy2 {
 		debug.Strategy = "strategy2_corner_groups"
-		generatePlatformStrategy2(ground, width, height, doors, debug)
+		generatePlatformStrategy2(rng, ground, width, height, doors, debug)
 	} else {
 		debug.Strategy = "strategy1_center_platform"
-		generatePlatformStrategy1(ground, width, height, doors, debug)
+		generatePlatformStrategy1(rng, ground, width, height, doors, debug)
 	}
 
 	// Apply eraser operations
-	applyEraserOperations(ground, width, height, doors, useStrategy2, debug)
+	applyEraserOperations(rng, ground, masks, width, height, doors, useStrategy2, debug)
 
 	return debug
 }
@@ -340,7 +541,7 @@ func canGroupDoorsIntoCorners(doors []DoorPosition) bool {
 }
 
 // generatePlatformStrategy1 generates a large center platform and connects all doors
-func generatePlatformStrategy1(ground [][]int, width, height int, doors []DoorPosition, debug *PlatformGroundDebugInfo) {
+func generatePlatformStrategy1(rng *rand.Rand, ground [][]int, width, height int, doors []DoorSite, debug *PlatformGroundDebugInfo) {
 	// Generate large center platform: L > width/2, W > height/2
 	minPlatformW := width/2 + 1
 	minPlatformH := height/2 + 1
@@ -354,8 +555,8 @@ func generatePlatformStrategy1(ground [][]int, width, height int, doors []DoorPo
 		maxPlatformH = minPlatformH
 	}
 
-	platformW := minPlatformW + rand.Intn(maxPlatformW-minPlatformW+1)
-	platformH := minPlatformH + rand.Intn(maxPlatformH-minPlatformH+1)
+	platformW := minPlatformW + rng.Intn(maxPlatformW-minPlatformW+1)
+	platformH := minPlatformH + rng.Intn(maxPlatformH-minPlatformH+1)
 
 	// Center the platform
 	platformX := (width - platformW) / 2
@@ -375,32 +576,32 @@ func generatePlatformStrategy1(ground [][]int, width, height int, doors []DoorPo
 
 	// Connect doors using 2x2, 3x3, or 4x4 brush
 	brushSizes := []int{2, 3, 4}
-	brushSize := brushSizes[rand.Intn(len(brushSizes))]
+	brushSize := brushSizes[rng.Intn(len(brushSizes))]
 
 	centerX := width / 2
 	centerY := height / 2
 
 	for _, door := range doors {
-		doorX, doorY := getDoorPosition(door, width, height)
+		doorX, doorY := door.X, door.Y
 
 		// Choose path type: direct or via center
 		viaCenterProb := 0.5
-		viaCenter := rand.Float64() < viaCenterProb
+		viaCenter := rng.Float64() < viaCenterProb
 
 		var pathType string
 		if viaCenter {
 			pathType = "via center"
 			// Draw from door to center
-			drawPath(ground, doorX, doorY, centerX, centerY, brushSize)
+			drawPath(rng, ground, doorX, doorY, centerX, centerY, brushSize)
 		} else {
 			pathType = "direct to platform"
 			// Draw from door to nearest platform edge
 			targetX, targetY := getNearestPlatformEdge(doorX, doorY, platformX, platformY, platformW, platformH)
-			drawPath(ground, doorX, doorY, targetX, targetY, brushSize)
+			drawPath(rng, ground, doorX, doorY, targetX, targetY, brushSize)
 		}
 
 		debug.DoorConnections = append(debug.DoorConnections, DoorConnectionInfo{
-			From:      fmt.Sprintf("%s (%d,%d)", door, doorX, doorY),
+			From:      fmt.Sprintf("%s (%d,%d)", door.Side, doorX, doorY),
 			To:        "platform",
 			PathType:  pathType,
 			BrushSize: fmt.Sprintf("%dx%d", brushSize, brushSize),
@@ -409,10 +610,10 @@ func generatePlatformStrategy1(ground [][]int, width, height int, doors []DoorPo
 }
 
 // generatePlatformStrategy2 generates platforms for corner groups
-func generatePlatformStrategy2(ground [][]int, width, height int, doors []DoorPosition, debug *PlatformGroundDebugInfo) {
+func generatePlatformStrategy2(rng *rand.Rand, ground [][]int, width, height int, doors []DoorSite, debug *PlatformGroundDebugInfo) {
 	doorSet := make(map[DoorPosition]bool)
 	for _, door := range doors {
-		doorSet[door] = true
+		doorSet[door.Side] = true
 	}
 
 	// Find valid corner groups
@@ -445,8 +646,8 @@ func generatePlatformStrategy2(ground [][]int, width, height int, doors []DoorPo
 		maxPlatformW := width * 4 / 5
 		maxPlatformH := height * 4 / 5
 
-		platformW := minPlatformW + rand.Intn(maxPlatformW-minPlatformW+1)
-		platformH := minPlatformH + rand.Intn(maxPlatformH-minPlatformH+1)
+		platformW := minPlatformW + rng.Intn(maxPlatformW-minPlatformW+1)
+		platformH := minPlatformH + rng.Intn(maxPlatformH-minPlatformH+1)
 
 		// Position platform based on corner group
 		var platformX, platformY int
@@ -488,22 +689,27 @@ func generatePlatformStrategy2(ground [][]int, width, height int, doors []DoorPo
 
 		// Connect the two doors in this group
 		brushSizes := []int{2, 3, 4}
-		brushSize := brushSizes[rand.Intn(len(brushSizes))]
-
-		for _, door := range group.doors {
-			doorX, doorY := getDoorPosition(door, width, height)
-
-			// Draw path from door to platform
-			targetX := platformX + platformW/2
-			targetY := platformY + platformH/2
-			drawPath(ground, doorX, doorY, targetX, targetY, brushSize)
+		brushSize := brushSizes[rng.Intn(len(brushSizes))]
 
-			debug.DoorConnections = append(debug.DoorConnections, DoorConnectionInfo{
-				From:      fmt.Sprintf("%s (%d,%d)", door, doorX, doorY),
-				To:        fmt.Sprintf("platform %s", group.group),
-				PathType:  "direct",
-				BrushSize: fmt.Sprintf("%dx%d", brushSize, brushSize),
-			})
+		for _, side := range group.doors {
+			for _, door := range doors {
+				if door.Side != side {
+					continue
+				}
+				doorX, doorY := door.X, door.Y
+
+				// Draw path from door to platform
+				targetX := platformX + platformW/2
+				targetY := platformY + platformH/2
+				drawPath(rng, ground, doorX, doorY, targetX, targetY, brushSize)
+
+				debug.DoorConnections = append(debug.DoorConnections, DoorConnectionInfo{
+					From:      fmt.Sprintf("%s (%d,%d)", side, doorX, doorY),
+					To:        fmt.Sprintf("platform %s", group.group),
+					PathType:  "direct",
+					BrushSize: fmt.Sprintf("%dx%d", brushSize, brushSize),
+				})
+			}
 		}
 	}
 }
@@ -542,9 +748,9 @@ const (
 )
 
 // applyEraserOperations applies eraser operations to create void areas
-func applyEraserOperations(ground [][]int, width, height int, doors []DoorPosition, isStrategy2 bool, debug *PlatformGroundDebugInfo) {
+func applyEraserOperations(rng *rand.Rand, ground [][]int, masks *ConstraintMasks, width, height int, doors []DoorSite, isStrategy2 bool, debug *PlatformGroundDebugInfo) {
 	// Randomly select 0-3 erase operations
-	eraseCount := rand.Intn(4)
+	eraseCount := rng.Intn(4)
 
 	if eraseCount == 0 {
 		return
@@ -564,7 +770,7 @@ func applyEraserOperations(ground [][]int, width, height int, doors []DoorPositi
 		allDoors := []DoorPosition{DoorTop, DoorRight, DoorBottom, DoorLeft}
 		doorSet := make(map[DoorPosition]bool)
 		for _, d := range doors {
-			doorSet[d] = true
+			doorSet[d.Side] = true
 		}
 		for _, d := range allDoors {
 			if !doorSet[d] {
@@ -592,14 +798,14 @@ func applyEraserOperations(ground [][]int, width, height int, doors []DoorPositi
 		}
 
 		// Select random method
-		method := remaining[rand.Intn(len(remaining))]
+		method := remaining[rng.Intn(len(remaining))]
 		usedMethods[method] = true
 
 		// Save ground state for potential rollback
 		groundBackup := copyLayer(ground)
 
 		// Apply eraser method
-		opInfo := applyEraserMethod(ground, width, height, doors, isStrategy2, method, debug)
+		opInfo := applyEraserMethod(rng, ground, masks, width, height, doors, isStrategy2, method, debug)
 
 		// Check connectivity: multi-door connectivity AND no isolated ground islands
 		disconnected := !areAllDoorsConnected(ground, width, height, doors) ||
@@ -620,7 +826,7 @@ func applyEraserOperations(ground [][]int, width, height int, doors []DoorPositi
 }
 
 // applyEraserMethod applies a specific eraser method
-func applyEraserMethod(ground [][]int, width, height int, doors []DoorPosition, isStrategy2 bool, method eraserMethod, debug *PlatformGroundDebugInfo) EraserOpInfo {
+func applyEraserMethod(rng *rand.Rand, ground [][]int, masks *ConstraintMasks, width, height int, doors []DoorSite, isStrategy2 bool, method eraserMethod, debug *PlatformGroundDebugInfo) EraserOpInfo {
 	centerX := width / 2
 	centerY := height / 2
 
@@ -630,10 +836,10 @@ func applyEraserMethod(ground [][]int, width, height int, doors []DoorPosition,
 		sizes := []struct{ w, h int }{
 			{2, 2}, {3, 3}, {3, 4}, {4, 4}, {4, 5},
 		}
-		size := sizes[rand.Intn(len(sizes))]
+		size := sizes[rng.Intn(len(sizes))]
 		x := centerX - size.w/2
 		y := centerY - size.h/2
-		eraseRect(ground, x, y, size.w, size.h, width, height)
+		eraseRect(ground, x, y, size.w, size.h, masks, width, height)
 		return EraserOpInfo{
 			Method:   "center_single",
 			Position: fmt.Sprintf("(%d,%d)", x, y),
@@ -645,27 +851,27 @@ func applyEraserMethod(ground [][]int, width, height int, doors []DoorPosition,
 		sizes := []struct{ w, h int }{
 			{2, 2}, {3, 3}, {3, 4},
 		}
-		size := sizes[rand.Intn(len(sizes))]
+		size := sizes[rng.Intn(len(sizes))]
 
 		// Random symmetry: left-right or top-bottom
-		isLeftRight := rand.Float64() < 0.5
+		isLeftRight := rng.Float64() < 0.5
 
 		var positions string
 		if isLeftRight {
-			offset := width/4 + rand.Intn(width/4)
+			offset := width/4 + rng.Intn(width/4)
 			x1 := centerX - offset - size.w/2
 			x2 := centerX + offset - size.w/2
 			y := centerY - size.h/2
-			eraseRect(ground, x1, y, size.w, size.h, width, height)
-			eraseRect(ground, x2, y, size.w, size.h, width, height)
+			eraseRect(ground, x1, y, size.w, size.h, masks, width, height)
+			eraseRect(ground, x2, y, size.w, size.h, masks, width, height)
 			positions = fmt.Sprintf("(%d,%d) and (%d,%d)", x1, y, x2, y)
 		} else {
-			offset := height/4 + rand.Intn(height/4)
+			offset := height/4 + rng.Intn(height/4)
 			x := centerX - size.w/2
 			y1 := centerY - offset - size.h/2
 			y2 := centerY + offset - size.h/2
-			eraseRect(ground, x, y1, size.w, size.h, width, height)
-			eraseRect(ground, x, y2, size.w, size.h, width, height)
+			eraseRect(ground, x, y1, size.w, size.h, masks, width, height)
+			eraseRect(ground, x, y2, size.w, size.h, masks, width, height)
 			positions = fmt.Sprintf("(%d,%d) and (%d,%d)", x, y1, x, y2)
 		}
 		return EraserOpInfo{
@@ -679,28 +885,28 @@ func applyEraserMethod(ground [][]int, width, height int, doors []DoorPosition,
 		sizes := []struct{ w, h int }{
 			{2, 2}, {3, 3}, {3, 4},
 		}
-		size := sizes[rand.Intn(len(sizes))]
+		size := sizes[rng.Intn(len(sizes))]
 
 		// One in center, two symmetric
 		x := centerX - size.w/2
 		y := centerY - size.h/2
-		eraseRect(ground, x, y, size.w, size.h, width, height)
+		eraseRect(ground, x, y, size.w, size.h, masks, width, height)
 
-		isLeftRight := rand.Float64() < 0.5
+		isLeftRight := rng.Float64() < 0.5
 		var positions string
 		if isLeftRight {
-			offset := width/3 + rand.Intn(width/6)
+			offset := width/3 + rng.Intn(width/6)
 			x1 := centerX - offset - size.w/2
 			x2 := centerX + offset - size.w/2
-			eraseRect(ground, x1, y, size.w, size.h, width, height)
-			eraseRect(ground, x2, y, size.w, size.h, width, height)
+			eraseRect(ground, x1, y, size.w, size.h, masks, width, height)
+			eraseRect(ground, x2, y, size.w, size.h, masks, width, height)
 			positions = fmt.Sprintf("(%d,%d), (%d,%d), (%d,%d)", x, y, x1, y, x2, y)
 		} else {
-			offset := height/3 + rand.Intn(height/6)
+			offset := height/3 + rng.Intn(height/6)
 			y1 := centerY - offset - size.h/2
 			y2 := centerY + offset - size.h/2
-			eraseRect(ground, x, y1, size.w, size.h, width, height)
-			eraseRect(ground, x, y2, size.w, size.h, width, height)
+			eraseRect(ground, x, y1, size.w, size.h, masks, width, height)
+			eraseRect(ground, x, y2, size.w, size.h, masks, width, height)
 			positions = fmt.Sprintf("(%d,%d), (%d,%d), (%d,%d)", x, y, x, y1, x, y2)
 		}
 		return EraserOpInfo{
@@ -711,7 +917,7 @@ func applyEraserMethod(ground [][]int, width, height int, doors []DoorPosition,
 
 	case eraserCorners:
 		// Erase platform corners: 5% for 1, 20% for 2, 5% for 3, 70% for 4
-		r := rand.Float64()
+		r := rng.Float64()
 		var cornerCount int
 		if r < 0.05 {
 			cornerCount = 1
@@ -726,7 +932,7 @@ func applyEraserMethod(ground [][]int, width, height int, doors []DoorPosition,
 		sizes := []struct{ w, h int }{
 			{2, 2}, {3, 3}, {3, 4},
 		}
-		size := sizes[rand.Intn(len(sizes))]
+		size := sizes[rng.Intn(len(sizes))]
 
 		corners := []struct{ x, y int }{
 			{0, 0},                            // top-left
@@ -736,13 +942,13 @@ func applyEraserMethod(ground [][]int, width, height int, doors []DoorPosition,
 		}
 
 		// Shuffle corners
-		rand.Shuffle(len(corners), func(i, j int) {
+		rng.Shuffle(len(corners), func(i, j int) {
 			corners[i], corners[j] = corners[j], corners[i]
 		})
 
 		var positions []string
 		for i := 0; i < cornerCount && i < len(corners); i++ {
-			eraseRect(ground, corners[i].x, corners[i].y, size.w, size.h, width, height)
+			eraseRect(ground, corners[i].x, corners[i].y, size.w, size.h, masks, width, height)
 			positions = append(positions, fmt.Sprintf("(%d,%d)", corners[i].x, corners[i].y))
 		}
 
@@ -757,7 +963,7 @@ func applyEraserMethod(ground [][]int, width, height int, doors []DoorPosition,
 		allDoors := []DoorPosition{DoorTop, DoorRight, DoorBottom, DoorLeft}
 		doorSet := make(map[DoorPosition]bool)
 		for _, d := range doors {
-			doorSet[d] = true
+			doorSet[d.Side] = true
 		}
 
 		var unconnected []DoorPosition
@@ -771,11 +977,11 @@ func applyEraserMethod(ground [][]int, width, height int, doors []DoorPosition,
 			return EraserOpInfo{Method: "unconnected_door_direction", Reason: "no unconnected doors"}
 		}
 
-		door := unconnected[rand.Intn(len(unconnected))]
+		door := unconnected[rng.Intn(len(unconnected))]
 		sizes := []struct{ w, h int }{
 			{3, 3}, {3, 4}, {4, 4},
 		}
-		size := sizes[rand.Intn(len(sizes))]
+		size := sizes[rng.Intn(len(sizes))]
 
 		// Position based on door direction (inside platform)
 		var x, y int
@@ -794,7 +1000,7 @@ func applyEraserMethod(ground [][]int, width, height int, doors []DoorPosition,
 			y = centerY - size.h/2
 		}
 
-		eraseRect(ground, x, y, size.w, size.h, width, height)
+		eraseRect(ground, x, y, size.w, size.h, masks, width, height)
 		return EraserOpInfo{
 			Method:   "unconnected_door_direction",
 			Position: fmt.Sprintf("(%d,%d) towards %s", x, y, door),
@@ -806,18 +1012,18 @@ func applyEraserMethod(ground [][]int, width, height int, doors []DoorPosition,
 		sizes := []struct{ w, h int }{
 			{2, 2}, {3, 3},
 		}
-		size := sizes[rand.Intn(len(sizes))]
+		size := sizes[rng.Intn(len(sizes))]
 
 		// Pick a random corner
 		corners := []struct{ x, y int }{
-			{rand.Intn(width / 3), rand.Intn(height / 3)},                                // top-left area
-			{width - rand.Intn(width/3) - size.w, rand.Intn(height / 3)},                 // top-right area
-			{rand.Intn(width / 3), height - rand.Intn(height/3) - size.h},                // bottom-left area
-			{width - rand.Intn(width/3) - size.w, height - rand.Intn(height/3) - size.h}, // bottom-right area
+			{rng.Intn(width / 3), rng.Intn(height / 3)},                                // top-left area
+			{width - rng.Intn(width/3) - size.w, rng.Intn(height / 3)},                 // top-right area
+			{rng.Intn(width / 3), height - rng.Intn(height/3) - size.h},                // bottom-left area
+			{width - rng.Intn(width/3) - size.w, height - rng.Intn(height/3) - size.h}, // bottom-right area
 		}
-		corner := corners[rand.Intn(len(corners))]
+		corner := corners[rng.Intn(len(corners))]
 
-		eraseRect(ground, corner.x, corner.y, size.w, size.h, width, height)
+		eraseRect(ground, corner.x, corner.y, size.w, size.h, masks, width, height)
 		return EraserOpInfo{
 			Method:   "strategy2_corner",
 			Position: fmt.Sprintf("(%d,%d)", corner.x, corner.y),
@@ -828,13 +1034,13 @@ func applyEraserMethod(ground [][]int, width, height int, doors []DoorPosition,
 	return EraserOpInfo{}
 }
 
-// eraseRect erases a rectangle (sets ground to 0)
-func eraseRect(ground [][]int, x, y, w, h, width, height int) {
+// eraseRect erases a rectangle (sets ground to 0), leaving forceGround cells untouched
+func eraseRect(ground [][]int, x, y, w, h int, masks *ConstraintMasks, width, height int) {
 	for dy := 0; dy < h; dy++ {
 		for dx := 0; dx < w; dx++ {
 			px := x + dx
 			py := y + dy
-			if px >= 0 && px < width && py >= 0 && py < height {
+			if px >= 0 && px < width && py >= 0 && py < height && !masks.forceGroundAt(px, py) {
 				ground[py][px] = 0
 			}
 		}
@@ -842,13 +1048,13 @@ func eraseRect(ground [][]int, x, y, w, h, width, height int) {
 }
 
 // drawPath draws a path between two points using the given brush size
-func drawPath(ground [][]int, fromX, fromY, toX, toY, brushSize int) {
+func drawPath(rng *rand.Rand, ground [][]int, fromX, fromY, toX, toY, brushSize int) {
 	height := len(ground)
 	width := len(ground[0])
 
 	// Draw L-shaped path
 	// First horizontal, then vertical
-	if rand.Float64() < 0.5 {
+	if rng.Float64() < 0.5 {
 		// Horizontal first
 		drawHorizontalLine(ground, fromX, toX, fromY, brushSize, width, height)
 		drawVerticalLine(ground, fromY, toY, toX, brushSize, width, height)
@@ -898,19 +1104,3 @@ func drawVerticalLine(ground [][]int, fromY, toY, x, brushSize, width, height in
 		}
 	}
 }
-
-// getDoorPosition returns the center position for a door
-func getDoorPosition(door DoorPosition, width, height int) (int, int) {
-	switch door {
-	case DoorTop:
-		return width / 2, 0
-	case DoorBottom:
-		return width / 2, height - 1
-	case DoorLeft:
-		return 0, height / 2
-	case DoorRight:
-		return width - 1, height / 2
-	default:
-		return width / 2, height / 2
-	}
-}
@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"context"
 	"testing"
 )
 
@@ -54,7 +55,7 @@ func TestPlatformGroundAlwaysConnected(t *testing.T) {
 						RailEnabled:   trial%2 == 0,
 					}
 
-					resp, err := GeneratePlatformRoom(req)
+					resp, err := GeneratePlatformRoom(context.Background(), req)
 					if err != nil {
 						continue
 					}
@@ -122,7 +123,7 @@ func TestPlatformNoBridgeTiles(t *testing.T) {
 						RailEnabled:   trial%2 == 0,
 					}
 
-					resp, err := GeneratePlatformRoom(req)
+					resp, err := GeneratePlatformRoom(context.Background(), req)
 					if err != nil {
 						continue
 					}
@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -64,7 +65,7 @@ func TestGeneratePlatformRoom_ValidInput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resp, err := GeneratePlatformRoom(tt.req)
+			resp, err := GeneratePlatformRoom(context.Background(), tt.req)
 			require.NoError(t, err)
 			require.NotNil(t, resp)
 
@@ -139,7 +140,7 @@ func TestGeneratePlatformRoom_InvalidInput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resp, err := GeneratePlatformRoom(tt.req)
+			resp, err := GeneratePlatformRoom(context.Background(), tt.req)
 			require.Error(t, err)
 			assert.Nil(t, resp)
 			assert.Contains(t, err.Error(), tt.expectedErr)
@@ -161,7 +162,7 @@ func TestGeneratePlatformRoom_SingleDoor(t *testing.T) {
 			Height: 20,
 			Doors:  doors,
 		}
-		resp, err := GeneratePlatformRoom(req)
+		resp, err := GeneratePlatformRoom(context.Background(), req)
 		require.NoError(t, err, "expected no error for doors=%v", doors)
 		require.NotNil(t, resp)
 	}
@@ -190,11 +191,11 @@ func TestGeneratePlatformRoom_DoorsConnected(t *testing.T) {
 				Doors:  tt.doors,
 			}
 
-			resp, err := GeneratePlatformRoom(req)
+			resp, err := GeneratePlatformRoom(context.Background(), req)
 			require.NoError(t, err)
 
 			// Verify doors are connected
-			assert.True(t, areAllDoorsConnected(resp.Payload.Ground, req.Width, req.Height, tt.doors),
+			assert.True(t, areAllDoorsConnected(resp.Payload.Ground, req.Width, req.Height, getDoorCenterPositions(req.Width, req.Height, tt.doors)),
 				"all doors should be connected")
 		})
 	}
@@ -207,7 +208,7 @@ func TestGeneratePlatformRoom_GroundHasWalkableTiles(t *testing.T) {
 		Doors:  []DoorPosition{DoorTop, DoorBottom, DoorLeft, DoorRight},
 	}
 
-	resp, err := GeneratePlatformRoom(req)
+	resp, err := GeneratePlatformRoom(context.Background(), req)
 	require.NoError(t, err)
 
 	// Count walkable tiles
@@ -241,7 +242,7 @@ func TestGeneratePlatformRoom_WithOptionalLayers(t *testing.T) {
 		MobAirCount:   4,
 	}
 
-	resp, err := GeneratePlatformRoom(req)
+	resp, err := GeneratePlatformRoom(context.Background(), req)
 	require.NoError(t, err)
 	require.NotNil(t, resp.DebugInfo)
 
@@ -289,7 +290,7 @@ func TestGeneratePlatformRoom_DebugInfoPopulated(t *testing.T) {
 		MobAirCount:   2,
 	}
 
-	resp, err := GeneratePlatformRoom(req)
+	resp, err := GeneratePlatformRoom(context.Background(), req)
 	require.NoError(t, err)
 	require.NotNil(t, resp.DebugInfo)
 	require.NotNil(t, resp.DebugInfo.Ground)
@@ -353,7 +354,7 @@ func TestAreAllDoorsConnected(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			width := len(tt.ground[0])
 			height := len(tt.ground)
-			result := areAllDoorsConnected(tt.ground, width, height, tt.doors)
+			result := areAllDoorsConnected(tt.ground, width, height, getDoorCenterPositions(width, height, tt.doors))
 			assert.Equal(t, tt.expected, result)
 		})
 	}
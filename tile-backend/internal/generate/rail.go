@@ -107,7 +107,7 @@ type RailLoop struct {
 }
 
 // GenerateRailLayer generates the rail layer based on ground and bridge layers
-func GenerateRailLayer(railLayer, ground, bridge [][]int, width, height int) *RailDebugInfo {
+func GenerateRailLayer(rng *rand.Rand, railLayer, ground, bridge [][]int, width, height int) *RailDebugInfo {
 	debug := &RailDebugInfo{
 		RailLoops: []RailLoopInfo{},
 		Misses:    []MissInfo{},
@@ -124,7 +124,7 @@ func GenerateRailLayer(railLayer, ground, bridge [][]int, width, height int) *Ra
 	}
 
 	// Shuffle platforms for random selection
-	rand.Shuffle(len(platforms), func(i, j int) {
+	rng.Shuffle(len(platforms), func(i, j int) {
 		platforms[i], platforms[j] = platforms[j], platforms[i]
 	})
 
@@ -135,13 +135,13 @@ func GenerateRailLayer(railLayer, ground, bridge [][]int, width, height int) *Ra
 		platforms = platforms[1:]
 
 		// Try to place a rail loop on this platform
-		loopInfo := tryPlaceRailLoop(railLayer, ground, bridge, platform, width, height, debug)
+		loopInfo := tryPlaceRailLoop(rng, railLayer, ground, bridge, platform, width, height, debug)
 		if loopInfo != nil {
 			debug.RailLoops = append(debug.RailLoops, *loopInfo)
 		}
 
 		// 50% probability to continue placing more rails
-		if rand.Intn(100) >= railRetryProb {
+		if rng.Intn(100) >= railRetryProb {
 			break
 		}
 	}
@@ -352,15 +352,15 @@ func mergeRailPlatforms(a, b RailPlatform) *RailPlatform {
 // tryPlaceRailLoop attempts to place a rail loop on the given platform.
 // For simple rectangles: draws a hollow rectangle perimeter with optional indents.
 // For merged shapes (Cells set): draws the outer perimeter of the union shape.
-func tryPlaceRailLoop(railLayer, ground, bridge [][]int, platform RailPlatform, width, height int, debug *RailDebugInfo) *RailLoopInfo {
+func tryPlaceRailLoop(rng *rand.Rand, railLayer, ground, bridge [][]int, platform RailPlatform, width, height int, debug *RailDebugInfo) *RailLoopInfo {
 	if platform.Cells != nil {
 		return tryPlaceMergedRailLoop(railLayer, ground, bridge, platform, width, height, debug)
 	}
-	return tryPlaceRectRailLoop(railLayer, ground, bridge, platform, width, height, debug)
+	return tryPlaceRectRailLoop(rng, railLayer, ground, bridge, platform, width, height, debug)
 }
 
 // tryPlaceRectRailLoop places a rail loop for a simple rectangular platform
-func tryPlaceRectRailLoop(railLayer, ground, bridge [][]int, platform RailPlatform, width, height int, debug *RailDebugInfo) *RailLoopInfo {
+func tryPlaceRectRailLoop(rng *rand.Rand, railLayer, ground, bridge [][]int, platform RailPlatform, width, height int, debug *RailDebugInfo) *RailLoopInfo {
 	railX := platform.X
 	railY := platform.Y
 	railW := platform.Width
@@ -377,12 +377,12 @@ func tryPlaceRectRailLoop(railLayer, ground, bridge [][]int, platform RailPlatfo
 
 	// Randomly shrink for variety
 	if railW > minRailSize+2 {
-		shrink := rand.Intn((railW - minRailSize) / 2)
+		shrink := rng.Intn((railW - minRailSize) / 2)
 		railX += shrink
 		railW -= shrink * 2
 	}
 	if railH > minRailSize+2 {
-		shrink := rand.Intn((railH - minRailSize) / 2)
+		shrink := rng.Intn((railH - minRailSize) / 2)
 		railY += shrink
 		railH -= shrink * 2
 	}
@@ -415,10 +415,10 @@ func tryPlaceRectRailLoop(railLayer, ground, bridge [][]int, platform RailPlatfo
 	// Add random indents
 	indentCount := 0
 	for indentCount < maxRailIndents {
-		if rand.Intn(100) >= railIndentProb {
+		if rng.Intn(100) >= railIndentProb {
 			break
 		}
-		indent := tryAddRailIndent(railLayer, ground, bridge, railX, railY, railW, railH, width, height)
+		indent := tryAddRailIndent(rng, railLayer, ground, bridge, railX, railY, railW, railH, width, height)
 		if indent != nil {
 			loopInfo.Indents = append(loopInfo.Indents, *indent)
 			loopInfo.Perimeter += indent.Size * 2
@@ -561,9 +561,9 @@ func verifyRailPositions(railX, railY, railW, railH int, ground, bridge [][]int,
 }
 
 // tryAddRailIndent attempts to add an indent to the rail loop
-func tryAddRailIndent(railLayer, ground, bridge [][]int, railX, railY, railW, railH, width, height int) *IndentInfo {
+func tryAddRailIndent(rng *rand.Rand, railLayer, ground, bridge [][]int, railX, railY, railW, railH, width, height int) *IndentInfo {
 	// Choose a random edge to add indent
-	edge := rand.Intn(4) // 0=top, 1=right, 2=bottom, 3=left
+	edge := rng.Intn(4) // 0=top, 1=right, 2=bottom, 3=left
 
 	var indentX, indentY, indentSize int
 	var direction string
@@ -574,9 +574,9 @@ func tryAddRailIndent(railLayer, ground, bridge [][]int, railX, railY, railW, ra
 		if railW <= 4 {
 			return nil
 		}
-		indentX = railX + 2 + rand.Intn(railW-4)
+		indentX = railX + 2 + rng.Intn(railW-4)
 		indentY = railY
-		indentSize = 1 + rand.Intn(min(2, railH/2-1))
+		indentSize = 1 + rng.Intn(min(2, railH/2-1))
 		direction = "inward-down"
 
 		// Check if indent positions are valid
@@ -600,8 +600,8 @@ func tryAddRailIndent(railLayer, ground, bridge [][]int, railX, railY, railW, ra
 			return nil
 		}
 		indentX = railX + railW - 1
-		indentY = railY + 2 + rand.Intn(railH-4)
-		indentSize = 1 + rand.Intn(min(2, railW/2-1))
+		indentY = railY + 2 + rng.Intn(railH-4)
+		indentSize = 1 + rng.Intn(min(2, railW/2-1))
 		direction = "inward-left"
 
 		for dx := 1; dx <= indentSize; dx++ {
@@ -622,9 +622,9 @@ func tryAddRailIndent(railLayer, ground, bridge [][]int, railX, railY, railW, ra
 		if railW <= 4 {
 			return nil
 		}
-		indentX = railX + 2 + rand.Intn(railW-4)
+		indentX = railX + 2 + rng.Intn(railW-4)
 		indentY = railY + railH - 1
-		indentSize = 1 + rand.Intn(min(2, railH/2-1))
+		indentSize = 1 + rng.Intn(min(2, railH/2-1))
 		direction = "inward-up"
 
 		for dy := 1; dy <= indentSize; dy++ {
@@ -646,8 +646,8 @@ func tryAddRailIndent(railLayer, ground, bridge [][]int, railX, railY, railW, ra
 			return nil
 		}
 		indentX = railX
-		indentY = railY + 2 + rand.Intn(railH-4)
-		indentSize = 1 + rand.Intn(min(2, railW/2-1))
+		indentY = railY + 2 + rng.Intn(railH-4)
+		indentSize = 1 + rng.Intn(min(2, railW/2-1))
 		direction = "inward-right"
 
 		for dx := 1; dx <= indentSize; dx++ {
@@ -825,10 +825,11 @@ func countRailNeighborsLocal(railLayer [][]int, x, y, width, height int) int {
 
 // GetRailIndentCells returns all cells that are indents (cells on rail that are inside the bounding box)
 // These cells are preferred positions for static/turret/mobGround placement
-func GetRailIndentCells(railLayer [][]int, width, height int) []Point {
-	if !hasAnyRail(railLayer, width, height) {
+func GetRailIndentCells(rail *Grid) []Point {
+	if rail.Count() == 0 {
 		return nil
 	}
+	width, height := rail.Width(), rail.Height()
 
 	// Find the bounding box of the rail
 	minX, minY := width, height
@@ -836,7 +837,7 @@ func GetRailIndentCells(railLayer [][]int, width, height int) []Point {
 
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
-			if railLayer[y][x] == 1 {
+			if rail.Has(x, y) {
 				if x < minX {
 					minX = x
 				}
@@ -860,10 +861,10 @@ func GetRailIndentCells(railLayer [][]int, width, height int) []Point {
 	for y := minY + 1; y < maxY; y++ {
 		for x := minX + 1; x < maxX; x++ {
 			// Check if this cell is inside the rail loop (not on rail, but enclosed)
-			if railLayer[y][x] == 0 {
+			if !rail.Has(x, y) {
 				// Use flood fill or ray casting to check if inside
 				// Simple approach: check if surrounded by rail on all sides at some point
-				if isInsideRailLoop(railLayer, x, y, minX, minY, maxX, maxY, width, height) {
+				if isInsideRailLoop(rail, x, y, minX) {
 					indentCells = append(indentCells, Point{X: x, Y: y})
 				}
 			}
@@ -873,24 +874,12 @@ func GetRailIndentCells(railLayer [][]int, width, height int) []Point {
 	return indentCells
 }
 
-// hasAnyRail checks if there's any rail in the layer
-func hasAnyRail(railLayer [][]int, width, height int) bool {
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			if railLayer[y][x] == 1 {
-				return true
-			}
-		}
-	}
-	return false
-}
-
 // isInsideRailLoop checks if a point is inside the rail loop using ray casting
-func isInsideRailLoop(railLayer [][]int, x, y, minX, minY, maxX, maxY, width, height int) bool {
+func isInsideRailLoop(rail *Grid, x, y, minX int) bool {
 	// Ray casting algorithm: count intersections going left
 	intersections := 0
 	for checkX := x - 1; checkX >= minX; checkX-- {
-		if railLayer[y][checkX] == 1 {
+		if rail.Has(checkX, y) {
 			intersections++
 		}
 	}
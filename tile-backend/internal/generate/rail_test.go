@@ -1,7 +1,11 @@
 package generate
 
 import (
+	"context"
+	"math/rand"
 	"testing"
+
+	"tile-backend/internal/model"
 )
 
 func TestGenerateRailLayer_EmptyGround(t *testing.T) {
@@ -10,7 +14,7 @@ func TestGenerateRailLayer_EmptyGround(t *testing.T) {
 	bridge := createEmptyLayer(width, height)
 	railLayer := createEmptyLayer(width, height)
 
-	debug := GenerateRailLayer(railLayer, ground, bridge, width, height)
+	debug := GenerateRailLayer(rand.New(rand.NewSource(1)), railLayer, ground, bridge, width, height)
 
 	if !debug.Skipped {
 		t.Errorf("Expected rail generation to be skipped with empty ground")
@@ -30,7 +34,7 @@ func TestGenerateRailLayer_SmallPlatform(t *testing.T) {
 		}
 	}
 
-	debug := GenerateRailLayer(railLayer, ground, bridge, width, height)
+	debug := GenerateRailLayer(rand.New(rand.NewSource(1)), railLayer, ground, bridge, width, height)
 
 	// Should either skip or find no platforms large enough
 	if debug.PlatformsFound > 0 && len(debug.RailLoops) > 0 {
@@ -61,7 +65,7 @@ func TestGenerateRailLayer_LargePlatform(t *testing.T) {
 		}
 	}
 
-	debug := GenerateRailLayer(railLayer, ground, bridge, width, height)
+	debug := GenerateRailLayer(rand.New(rand.NewSource(1)), railLayer, ground, bridge, width, height)
 
 	t.Logf("Platforms found: %d", debug.PlatformsFound)
 	t.Logf("Rail loops placed: %d", len(debug.RailLoops))
@@ -121,7 +125,7 @@ func TestGenerateRailLayer_RailOnBridge(t *testing.T) {
 		}
 	}
 
-	debug := GenerateRailLayer(railLayer, ground, bridge, width, height)
+	debug := GenerateRailLayer(rand.New(rand.NewSource(1)), railLayer, ground, bridge, width, height)
 
 	t.Logf("Platforms found: %d", debug.PlatformsFound)
 
@@ -150,7 +154,7 @@ func TestGenerateRailLayer_EdgeDistance(t *testing.T) {
 		}
 	}
 
-	debug := GenerateRailLayer(railLayer, ground, bridge, width, height)
+	debug := GenerateRailLayer(rand.New(rand.NewSource(1)), railLayer, ground, bridge, width, height)
 
 	// Verify rail respects edge distance
 	for y := 0; y < height; y++ {
@@ -220,7 +224,7 @@ func TestGetRailIndentCells(t *testing.T) {
 		railLayer[y][15] = 1
 	}
 
-	indentCells := GetRailIndentCells(railLayer, width, height)
+	indentCells := GetRailIndentCells(GridFromLayer(railLayer, width, height))
 
 	t.Logf("Found %d indent cells", len(indentCells))
 
@@ -231,3 +235,23 @@ func TestGetRailIndentCells(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateRooms_RailLines(t *testing.T) {
+	for i := int64(0); i < 10; i++ {
+		seed := i
+		resp, err := GeneratePlatformRoom(context.Background(), PlatformGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight}, RailEnabled: true, Seed: &seed,
+		})
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+
+		hasRail := countCells(resp.Payload.Rail) > 0
+		if hasRail != (len(resp.Payload.RailLines) > 0) {
+			t.Errorf("seed %d: rail cells present = %v but %d rail lines", seed, hasRail, len(resp.Payload.RailLines))
+		}
+		if err := model.CheckLineSegments(resp.Payload.Rail, resp.Payload.RailLines); err != nil {
+			t.Errorf("seed %d: rail lines do not match the rail layer: %v", seed, err)
+		}
+	}
+}
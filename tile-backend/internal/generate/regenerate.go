@@ -0,0 +1,418 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"tile-backend/internal/model"
+	"tile-backend/internal/validate"
+)
+
+// regenerateMaxAttempts bounds how often the unlocked steps are re-rolled when the
+// result breaks a validation rule against the locked layers
+const regenerateMaxAttempts = 5
+
+// Layers that can be locked during regeneration. Ground is never regenerated and
+// the main path is always recomputed, so neither needs to be listed.
+var regenerableLayers = []string{"softEdge", "bridge", "rail", "static", "zoner", "chaser", "dps", "mobAir", "pickup"}
+
+// RegenerateRequest represents a request to re-run the unlocked steps of the pipeline on an existing template
+type RegenerateRequest struct {
+	Payload        model.TemplatePayload `json:"payload"`        // Existing template; ground is always kept
+	LockedLayers   []string              `json:"lockedLayers"`   // Layers to keep as-is: softEdge, bridge, rail, static, zoner, chaser, dps, mobAir, pickup (ground is always locked)
+	SoftEdgeCount  int                   `json:"softEdgeCount"`  // Suggested number of soft edges to place (optional)
+	RailEnabled    bool                  `json:"railEnabled"`    // Whether to generate rail layer (optional)
+	StaticCount    int                   `json:"staticCount"`    // Suggested number of statics to place (optional)
+	ChaserCount    int                   `json:"chaserCount"`    // Suggested number of chasers to place (optional)
+	ZonerCount     int                   `json:"zonerCount"`     // Suggested number of zoners to place (optional)
+	DPSCount       int                   `json:"dpsCount"`       // Suggested number of DPS to place (optional)
+	MobAirCount    int                   `json:"mobAirCount"`    // Suggested number of mob air (fly) to place (optional)
+	PickupCount    int                   `json:"pickupCount"`    // Suggested number of pickups (chests, health) to place (optional)
+	StageType      string                `json:"stageType"`      // Room stage type (optional, overrides enemy counts)
+	Seed           *int64                `json:"seed,omitempty"` // Random seed for reproducible output (optional, random if omitted)
+	StageOverrides model.StageOverrides  `json:"-"`              // Project stage overrides, applied on top of the stage config (set from project_id, not read from the body)
+}
+
+// RegenerateResponse represents the regenerated template
+type RegenerateResponse struct {
+	Payload     model.TemplatePayload `json:"payload"`
+	DebugInfo   *GenerateDebugInfo    `json:"debugInfo,omitempty"`
+	Difficulty  *DifficultyScore      `json:"difficulty,omitempty"`
+	Seed        int64                 `json:"seed"`        // Seed used for this generation
+	Regenerated []string              `json:"regenerated"` // Layers that were re-rolled
+	Attempts    int                   `json:"attempts"`    // Re-rolls needed to satisfy validation
+}
+
+// RegenerateTemplate keeps the locked layers of an existing template and re-runs the
+// remaining pipeline steps around them. The result always passes strict validation.
+// It stops with a *StepError naming the interrupted step once ctx is canceled or
+// its deadline passes.
+func RegenerateTemplate(ctx context.Context, req RegenerateRequest) (*RegenerateResponse, error) {
+	payload := req.Payload
+	if result := validate.ValidateTemplate(&payload, false); !result.Valid {
+		return nil, fmt.Errorf("invalid payload: %s", firstValidationError(result))
+	}
+
+	locked := make(map[string]bool)
+	for _, name := range req.LockedLayers {
+		if name == "ground" {
+			continue
+		}
+		if !isRegenerableLayer(name) {
+			return nil, fmt.Errorf("unknown layer: %s (allowed: ground, %s)", name, strings.Join(regenerableLayers, ", "))
+		}
+		locked[name] = true
+	}
+	// Only bridge rooms place bridges; other shapes keep the bridge cells they have
+	if payload.RoomShape == nil || *payload.RoomShape != "bridge" {
+		locked["bridge"] = true
+	}
+
+	var regenerated []string
+	for _, name := range regenerableLayers {
+		if !locked[name] {
+			regenerated = append(regenerated, name)
+		}
+	}
+
+	rng, seed := newRequestRand(req.Seed)
+
+	var lastErr string
+	for attempt := 1; attempt <= regenerateMaxAttempts; attempt++ {
+		resp, err := regenerateOnce(ctx, rng, req, locked)
+		if err != nil {
+			return nil, err
+		}
+		result := validate.ValidateTemplate(&resp.Payload, true)
+		if result.Valid {
+			resp.Seed = seed
+			resp.Regenerated = regenerated
+			resp.Attempts = attempt
+			return resp, nil
+		}
+		lastErr = firstValidationError(result)
+	}
+
+	return nil, fmt.Errorf("could not regenerate a valid template in %d attempts: %s", regenerateMaxAttempts, lastErr)
+}
+
+// regenerateOnce runs one pass of the unlocked steps
+func regenerateOnce(ctx context.Context, rng *rand.Rand, req RegenerateRequest, locked map[string]bool) (*RegenerateResponse, error) {
+	src := req.Payload
+	width, height := src.Meta.Width, src.Meta.Height
+	ground := copyLayer(src.Ground)
+
+	doors := []DoorPosition{}
+	if mask := model.ComputeOpenDoors(src.Doors); mask != nil {
+		doors = bitmaskToDoors(*mask)
+	}
+	// Payloads with door descriptors keep their exact openings
+	doorPositions, sides, _, err := resolveDoors(doors, src.DoorDescriptors, width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep returns a copy of the source layer (or an empty one when it is absent)
+	keep := func(layer model.Layer) [][]int {
+		if layer == nil {
+			return createEmptyLayer(width, height)
+		}
+		return copyLayer(layer)
+	}
+
+	debugInfo := &GenerateDebugInfo{}
+	steps := newStepTracker(ctx, "regenerate")
+
+	// Step 1: Soft edge
+	softEdgeLayer := keep(src.SoftEdge)
+	if !locked["softEdge"] {
+		softEdgeLayer = createEmptyLayer(width, height)
+		if req.SoftEdgeCount > 0 {
+			debugInfo.SoftEdge = generateSoftEdgeLayerWithDebug(rng, softEdgeLayer, ground, doorPositions, width, height, req.SoftEdgeCount)
+			// A locked bridge keeps its cells; soft edge must not cover them
+			if locked["bridge"] {
+				clearOverlap(softEdgeLayer, keep(src.Bridge))
+			}
+		} else {
+			debugInfo.SoftEdge = &SoftEdgeDebugInfo{Skipped: true, SkipReason: "softEdgeCount is 0 or not specified"}
+		}
+	}
+	if err := steps.done("soft edge"); err != nil {
+		return nil, err
+	}
+
+	// Step 2: Bridge
+	bridgeLayer := keep(src.Bridge)
+	if !locked["bridge"] {
+		bridgeLayer = createEmptyLayer(width, height)
+		debugInfo.BridgeLayer = generateBridgeLayerWithDebug(bridgeLayer, ground, softEdgeLayer, width, height)
+		// A locked rail running over a bridge keeps the bridge cells it needs
+		if locked["rail"] && src.Bridge != nil {
+			rail := keep(src.Rail)
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					if rail[y][x] == 1 && ground[y][x] == 0 && src.Bridge[y][x] == 1 {
+						bridgeLayer[y][x] = 1
+					}
+				}
+			}
+		}
+	}
+
+	// Step 3: Rail
+	railLayer := keep(src.Rail)
+	if !locked["rail"] {
+		railLayer = createEmptyLayer(width, height)
+		if req.RailEnabled {
+			// Locked statics and pickups, and hazards, are carved out of the ground so loops route around them
+			railGround := ground
+			if locked["static"] || locked["pickup"] || src.Hazard != nil {
+				railGround = copyLayer(ground)
+			}
+			if src.Hazard != nil {
+				clearOverlap(railGround, src.Hazard)
+			}
+			if locked["static"] {
+				clearOverlap(railGround, keep(src.Static))
+			}
+			if locked["pickup"] {
+				clearOverlap(railGround, keep(src.Pickup))
+			}
+			debugInfo.Rail = GenerateRailLayer(rng, railLayer, railGround, bridgeLayer, width, height)
+		} else {
+			debugInfo.Rail = &RailDebugInfo{Skipped: true, SkipReason: "railEnabled is false or not specified"}
+		}
+	}
+	if err := steps.done("rail"); err != nil {
+		return nil, err
+	}
+
+	// Statics and enemies are placed on grids seeded with the locked layers, and
+	// read back as layers once placement is done
+	lc := newLayerContext(ground, softEdgeLayer, bridgeLayer, railLayer, doorPositions, width, height)
+
+	// Locked enemies are obstacles for any newly placed static or enemy
+	lockedEnemies := NewGrid(width, height)
+	for _, l := range []struct {
+		name  string
+		layer model.Layer
+		grid  *Grid
+	}{{"static", src.Static, lc.Static}, {"zoner", src.Zoner, lc.Zoner}, {"chaser", src.Chaser, lc.Chaser}, {"dps", src.DPS, lc.DPS}, {"mobAir", src.MobAir, lc.MobAir}} {
+		if locked[l.name] {
+			l.grid.Or(GridFromLayer(l.layer, width, height))
+			if l.name != "static" {
+				lockedEnemies.Or(l.grid)
+			}
+		}
+	}
+
+	// The pipeline and hazards are never regenerated; statics and enemies keep off them
+	blockedMasks := (&ConstraintMasks{}).withBlocked(src.Pipeline, src.Hazard)
+
+	// Step 4: Static
+	if !locked["static"] {
+		if req.StaticCount > 0 {
+			// Locked enemies and pickups are carved out of the ground statics read
+			staticLC := *lc
+			staticLC.Ground = lc.Ground.Clone()
+			staticLC.Ground.AndNot(lockedEnemies)
+			if locked["pickup"] {
+				staticLC.Ground.AndNot(GridFromLayer(src.Pickup, width, height))
+			}
+			debugInfo.Static = generateStaticLayerWithDebugAndRail(ctx, rng, &staticLC, blockedMasks, req.StaticCount)
+		} else {
+			debugInfo.Static = &StaticDebugInfo{Skipped: true, SkipReason: "staticCount is 0 or not specified"}
+		}
+	}
+	if err := steps.done("static"); err != nil {
+		return nil, err
+	}
+
+	// Apply stage rules
+	stageType := req.StageType
+	roomType := ""
+	if src.RoomShape != nil {
+		roomType = *src.RoomShape
+		if roomType == "all" {
+			roomType = "full"
+		}
+	}
+	stageResult, err := ValidateAndApplyStage(rng, stageType, roomType, req.StageOverrides, sides, ground, width, height)
+	if err != nil {
+		return nil, err
+	}
+	if stageResult != nil && stageResult.Valid && stageType != "" {
+		req.ChaserCount = stageResult.ChaserCount
+		req.ZonerCount = stageResult.ZonerCount
+		req.DPSCount = stageResult.DPSCount
+		req.MobAirCount = stageResult.MobAirCount
+	}
+
+	// Step 5: Main path (always recomputed from ground and bridge)
+	mainPathData, mainPathDebug := ComputeMainPath(ground, bridgeLayer, src.Hazard, doorPositions, width, height)
+	debugInfo.MainPath = mainPathDebug
+	if err := steps.done("main path"); err != nil {
+		return nil, err
+	}
+	lc.MainPath = mainPathData
+
+	// Ground enemies treat statics and locked enemies alike as occupied cells
+	groundLC := *lc
+	groundLC.Static = lc.Static.Clone()
+	groundLC.Static.Or(lockedEnemies)
+
+	// Step 6: Zoner
+	if !locked["zoner"] {
+		if req.ZonerCount > 0 {
+			debugInfo.Zoner = GenerateZonerLayer(ctx, rng, &groundLC, blockedMasks, req.ZonerCount)
+		} else {
+			debugInfo.Zoner = &EnemyLayerDebugInfo{Skipped: true, SkipReason: "zonerCount is 0 or not specified"}
+		}
+	}
+
+	// Step 7: Chaser
+	if !locked["chaser"] {
+		if req.ChaserCount > 0 {
+			chaserDebug := GenerateChaserLayer(ctx, rng, &groundLC, blockedMasks, req.ChaserCount)
+			if remaining := req.ChaserCount - chaserDebug.PlacedCount; remaining > 0 {
+				GenerateChaserLayerRelaxed(ctx, rng, &groundLC, blockedMasks, remaining)
+			}
+			debugInfo.Chaser = chaserDebug
+		} else {
+			debugInfo.Chaser = &EnemyLayerDebugInfo{Skipped: true, SkipReason: "chaserCount is 0 or not specified"}
+		}
+	}
+
+	// Step 8: DPS
+	if !locked["dps"] {
+		if req.DPSCount > 0 {
+			dpsDebug := GenerateDPSLayer(ctx, rng, &groundLC, blockedMasks, req.DPSCount)
+			if remaining := req.DPSCount - dpsDebug.PlacedCount; remaining > 0 {
+				GenerateDPSLayerRelaxed(ctx, rng, &groundLC, blockedMasks, remaining)
+			}
+			debugInfo.DPS = dpsDebug
+		} else {
+			debugInfo.DPS = &EnemyLayerDebugInfo{Skipped: true, SkipReason: "dpsCount is 0 or not specified"}
+		}
+	}
+
+	// Step 9: Mob air
+	if !locked["mobAir"] {
+		if req.MobAirCount > 0 {
+			debugInfo.MobAir = GenerateMobAirLayerNew(ctx, lc, blockedMasks, req.MobAirCount)
+		} else {
+			debugInfo.MobAir = &MobAirDebugInfo{Skipped: true, SkipReason: "mobAirCount is 0 or not specified"}
+		}
+	}
+
+	// Locked layers are returned as they came; the rest are read back from the grids
+	readBack := func(name string, layer model.Layer, g *Grid) [][]int {
+		if locked[name] {
+			return keep(layer)
+		}
+		return g.Layer()
+	}
+	staticLayer := readBack("static", src.Static, lc.Static)
+	zonerLayer := readBack("zoner", src.Zoner, lc.Zoner)
+	chaserLayer := readBack("chaser", src.Chaser, lc.Chaser)
+	dpsLayer := readBack("dps", src.DPS, lc.DPS)
+	mobAirLayer := readBack("mobAir", src.MobAir, lc.MobAir)
+
+	if err := steps.done("enemies"); err != nil {
+		return nil, err
+	}
+
+	// Step 10: Pickup
+	pickupLayer := src.Pickup
+	if !locked["pickup"] {
+		pickupLayer = nil
+		if req.PickupCount > 0 {
+			pickupLayer = createEmptyLayer(width, height)
+			debugInfo.Pickup = GeneratePickupLayer(rng, pickupLayer, ground, bridgeLayer, railLayer, keep(src.Pipeline), keep(src.Hazard), staticLayer, chaserLayer, zonerLayer, dpsLayer, doorPositions, mainPathData, width, height, req.PickupCount)
+		} else {
+			debugInfo.Pickup = &PickupDebugInfo{Skipped: true, SkipReason: "pickupCount is 0 or not specified"}
+		}
+	}
+	if err := steps.done("pickup"); err != nil {
+		return nil, err
+	}
+
+	// Build main path layer for output
+	mainPathLayer := createEmptyLayer(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if mainPathData.OnMainPath[y][x] {
+				mainPathLayer[y][x] = 1
+			}
+		}
+	}
+
+	payload := src
+	payload.Ground = ground
+	payload.SoftEdge = softEdgeLayer
+	payload.Bridge = bridgeLayer
+	payload.Rail = railLayer
+	payload.Static = staticLayer
+	payload.Zoner = zonerLayer
+	payload.Chaser = chaserLayer
+	payload.DPS = dpsLayer
+	payload.MobAir = mobAirLayer
+	payload.Pickup = pickupLayer
+	payload.MainPath = mainPathLayer
+	payload.OpenDoors = model.ComputeOpenDoors(src.Doors)
+	if !locked["rail"] {
+		// Rail line segments described the old rail layer
+		payload.RailLines = model.ExtractLineSegments(railLayer)
+	}
+	if req.StageType != "" {
+		payload.StageType = &req.StageType
+	}
+
+	// Patrol routes always follow the final chasers, statics and hazards
+	payload.ChaserPatrols, debugInfo.Patrol = GenerateChaserPatrols(ctx, rng, SymmetryNone, chaserLayer, ground, staticLayer, src.Hazard, doorPositions, width, height)
+	if err := steps.done("patrols"); err != nil {
+		return nil, err
+	}
+
+	// Spawn waves follow the stage; without one, waves of re-rolled enemies are stale
+	if hints := stageResult.PlacementHints; hints != nil && hints.WaveCount > 0 {
+		payload.Waves, debugInfo.Waves = assignWaves(hints, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, width, height)
+	} else if !locked["chaser"] || !locked["zoner"] || !locked["dps"] || !locked["mobAir"] {
+		payload.Waves = nil
+	}
+
+	difficulty := ComputeDifficulty(ground, softEdgeLayer, src.Hazard, staticLayer, chaserLayer, zonerLayer, dpsLayer, mobAirLayer, mainPathData, width, height)
+
+	return &RegenerateResponse{Payload: payload, DebugInfo: debugInfo, Difficulty: difficulty}, nil
+}
+
+// isRegenerableLayer reports whether name is a layer the regenerate pipeline knows
+func isRegenerableLayer(name string) bool {
+	for _, l := range regenerableLayers {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
+
+// clearOverlap zeroes every cell of dst that is set in mask
+func clearOverlap(dst, mask [][]int) {
+	for y := range dst {
+		for x := range dst[y] {
+			if mask[y][x] == 1 {
+				dst[y][x] = 0
+			}
+		}
+	}
+}
+
+// firstValidationError formats the first error of a failed validation result
+func firstValidationError(result *model.ValidationResult) string {
+	if len(result.Errors) == 0 {
+		return "validation failed"
+	}
+	e := result.Errors[0]
+	return fmt.Sprintf("%s at (%d,%d): %s", e.Layer, e.X, e.Y, e.Reason)
+}
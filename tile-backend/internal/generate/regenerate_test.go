@@ -0,0 +1,137 @@
+package generate
+
+import (
+	"context"
+	"testing"
+	"tile-backend/internal/validate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seededFullRoom(t *testing.T, seed int64) *FullRoomGenerateResponse {
+	t.Helper()
+	resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width:         20,
+		Height:        12,
+		Doors:         []DoorPosition{DoorTop, DoorRight, DoorBottom, DoorLeft},
+		SoftEdgeCount: 3,
+		RailEnabled:   true,
+		StaticCount:   4,
+		StageType:     "pressure",
+		Seed:          &seed,
+	})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestRegenerateTemplate_KeepsLockedTerrain(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		src := seededFullRoom(t, seed)
+
+		newSeed := seed + 1000
+		resp, err := RegenerateTemplate(context.Background(), RegenerateRequest{
+			Payload:      src.Payload,
+			LockedLayers: []string{"ground", "softEdge", "bridge", "rail", "static"},
+			StageType:    "pressure",
+			Seed:         &newSeed,
+		})
+		require.NoError(t, err, "seed %d", seed)
+
+		assert.Equal(t, src.Payload.Ground, resp.Payload.Ground)
+		assert.Equal(t, src.Payload.SoftEdge, resp.Payload.SoftEdge)
+		assert.Equal(t, src.Payload.Bridge, resp.Payload.Bridge)
+		assert.Equal(t, src.Payload.Rail, resp.Payload.Rail)
+		assert.Equal(t, src.Payload.Static, resp.Payload.Static)
+		assert.Equal(t, []string{"zoner", "chaser", "dps", "mobAir", "pickup"}, resp.Regenerated)
+		assert.Greater(t, countCells(resp.Payload.Chaser)+countCells(resp.Payload.Zoner)+countCells(resp.Payload.DPS), 0)
+
+		result := validate.ValidateTemplate(&resp.Payload, true)
+		assert.True(t, result.Valid, "seed %d: %v", seed, result.Errors)
+	}
+}
+
+func TestRegenerateTemplate_KeepsLockedEnemies(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		src := seededFullRoom(t, seed)
+
+		resp, err := RegenerateTemplate(context.Background(), RegenerateRequest{
+			Payload:      src.Payload,
+			LockedLayers: []string{"chaser", "zoner", "dps", "mobAir"},
+			StaticCount:  6,
+			RailEnabled:  true,
+		})
+		require.NoError(t, err, "seed %d", seed)
+
+		assert.Equal(t, src.Payload.Chaser, resp.Payload.Chaser)
+		assert.Equal(t, src.Payload.Zoner, resp.Payload.Zoner)
+		assert.Equal(t, src.Payload.DPS, resp.Payload.DPS)
+		assert.Equal(t, src.Payload.MobAir, resp.Payload.MobAir)
+		assert.Equal(t, src.Payload.Ground, resp.Payload.Ground)
+
+		// New statics never land on a locked ground enemy
+		for y := 0; y < 12; y++ {
+			for x := 0; x < 20; x++ {
+				if resp.Payload.Static[y][x] == 1 {
+					assert.Zero(t, resp.Payload.Chaser[y][x]+resp.Payload.Zoner[y][x]+resp.Payload.DPS[y][x],
+						"seed %d: static on enemy at (%d,%d)", seed, x, y)
+				}
+			}
+		}
+
+		result := validate.ValidateTemplate(&resp.Payload, true)
+		assert.True(t, result.Valid, "seed %d: %v", seed, result.Errors)
+	}
+}
+
+func TestRegenerateTemplate_KeepsBridgeOutOfOtherShapes(t *testing.T) {
+	for seed := int64(1); seed <= 40; seed++ {
+		src, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+			Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, StaticCount: 4, Seed: &seed,
+		})
+		require.NoError(t, err)
+		require.Zero(t, countCells(src.Payload.Bridge), "seed %d: full rooms have no bridge", seed)
+
+		newSeed := seed + 1000
+		resp, err := RegenerateTemplate(context.Background(), RegenerateRequest{
+			Payload:      src.Payload,
+			LockedLayers: []string{"static"},
+			Seed:         &newSeed,
+		})
+		require.NoError(t, err, "seed %d", seed)
+		assert.Zero(t, countCells(resp.Payload.Bridge), "seed %d", seed)
+		assert.NotContains(t, resp.Regenerated, "bridge")
+	}
+}
+
+func TestRegenerateTemplate_Reproducible(t *testing.T) {
+	src := seededFullRoom(t, 3)
+	seed := int64(99)
+	req := RegenerateRequest{
+		Payload:      src.Payload,
+		LockedLayers: []string{"static"},
+		StageType:    "building",
+		RailEnabled:  true,
+		Seed:         &seed,
+	}
+
+	first, err := RegenerateTemplate(context.Background(), req)
+	require.NoError(t, err)
+	second, err := RegenerateTemplate(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, seed, first.Seed)
+	assert.Equal(t, first.Payload, second.Payload)
+}
+
+func TestRegenerateTemplate_InvalidInput(t *testing.T) {
+	src := seededFullRoom(t, 4)
+
+	_, err := RegenerateTemplate(context.Background(), RegenerateRequest{Payload: src.Payload, LockedLayers: []string{"lava"}})
+	assert.Error(t, err)
+
+	broken := src.Payload
+	broken.Ground = broken.Ground[:3]
+	_, err = RegenerateTemplate(context.Background(), RegenerateRequest{Payload: broken})
+	assert.Error(t, err)
+}
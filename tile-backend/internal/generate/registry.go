@@ -0,0 +1,338 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"tile-backend/internal/model"
+)
+
+// ShapeInfo describes a registered room shape
+type ShapeInfo struct {
+	Name         string         `json:"name"`             // Registry key, used by /generate/{shape} and batch requests
+	RoomType     string         `json:"roomType"`         // Room type used by stage rules and project stats ("full" for fullroom)
+	PayloadShape string         `json:"payloadShape"`     // roomShape written into generated payloads
+	Stages       []string       `json:"stages,omitempty"` // Stage types the shape supports (empty = any the stage rules allow)
+	Doors        []DoorPosition `json:"doors"`            // Sides that may hold a door
+	MinDoors     int            `json:"minDoors"`         // Fewest doors a request may ask for
+	MinWidth     int            `json:"minWidth"`         // Smallest room width
+	MinHeight    int            `json:"minHeight"`        // Smallest room height
+}
+
+// allowsStage reports whether the shape itself restricts the stage type
+func (info ShapeInfo) allowsStage(stageType string) bool {
+	if len(info.Stages) == 0 {
+		return true
+	}
+	for _, s := range info.Stages {
+		if s == stageType {
+			return true
+		}
+	}
+	return false
+}
+
+// RoomParams are the shape-independent inputs AutoFill generates rooms from
+type RoomParams struct {
+	Width     int
+	Height    int
+	Doors     []DoorPosition
+	StageType string
+}
+
+// RoomResult is a generator's output: the shape-specific response plus the
+// parts every caller needs
+type RoomResult struct {
+	Response   interface{}            // Shape-specific response, as served by /generate/{shape}
+	Payload    *model.TemplatePayload // Points into Response
+	Difficulty *DifficultyScore
+	Validation *ValidationDebugInfo // Strict self-validation outcome (see generateValidated)
+}
+
+// GenerateOptions are caller-supplied settings applied on top of a request
+type GenerateOptions struct {
+	Seed           *int64               // Replaces the request's seed when non-nil
+	StageOverrides model.StageOverrides // Project stage overrides (see ValidateAndApplyStage)
+	Trace          bool                 // Record step-by-step frames (see Trace)
+}
+
+// Generator builds rooms of one shape
+type Generator interface {
+	// Info describes the shape
+	Info() ShapeInfo
+	// NewRequest returns a pointer to an empty request, ready for JSON decoding
+	NewRequest() interface{}
+	// RequestFor builds a request from the shared AutoFill parameters
+	RequestFor(params RoomParams) interface{}
+	// Generate runs a request from NewRequest or RequestFor with the options
+	// applied; the caller's request is not modified. It returns a *StepError
+	// once ctx is canceled or its deadline passes.
+	Generate(ctx context.Context, req interface{}, opts GenerateOptions) (*RoomResult, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []Generator // in registration order
+)
+
+// The built-in shapes register in a fixed order, which is also the order AutoFill
+// falls back through when no shape has a deficit
+func init() {
+	for _, g := range []Generator{fullRoomGenerator, bridgeGenerator, platformGenerator, caveGenerator} {
+		Register(g)
+	}
+}
+
+// Register adds a generator to the registry. It panics on an empty or duplicate
+// name, like other init-time registries.
+func Register(g Generator) {
+	info := g.Info()
+	if info.Name == "" || info.RoomType == "" {
+		panic("generate: Register needs a shape name and room type")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, existing := range registry {
+		if existing.Info().Name == info.Name {
+			panic(fmt.Sprintf("generate: shape %q registered twice", info.Name))
+		}
+	}
+	registry = append(registry, g)
+}
+
+// LookupGenerator returns the generator registered under a shape name
+func LookupGenerator(name string) (Generator, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, g := range registry {
+		if g.Info().Name == name {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+// generatorForRoomType returns the generator for a stage/project room type
+func generatorForRoomType(roomType string) (Generator, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, g := range registry {
+		if g.Info().RoomType == roomType {
+			return g, true
+		}
+	}
+	return nil, false
+}
+
+// Generators returns every registered generator, in registration order
+func Generators() []Generator {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return append([]Generator(nil), registry...)
+}
+
+// ShapeNames returns the registered shape names, in registration order
+func ShapeNames() []string {
+	var names []string
+	for _, g := range Generators() {
+		names = append(names, g.Info().Name)
+	}
+	return names
+}
+
+// ShapeInfos returns the metadata of every registered shape. Stages lists the
+// stage types the shape can actually be generated for.
+func ShapeInfos() []ShapeInfo {
+	var infos []ShapeInfo
+	for _, g := range Generators() {
+		info := g.Info()
+		var stages []string
+		for _, cfg := range GetAllStageConfigs() {
+			if stageShapeCompat(cfg.StageType, info.RoomType) {
+				stages = append(stages, cfg.StageType)
+			}
+		}
+		info.Stages = stages
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// Combo is one shape × door mask × stage type combination
+type Combo struct {
+	Shape     string         // Registry shape name
+	DoorMask  int            // Bitmask: Top=1, Right=2, Bottom=4, Left=8
+	Doors     []DoorPosition // The doors of DoorMask
+	StageType string
+}
+
+// Combos lists every combination the registered shapes can be generated for:
+// each stage type from ShapeInfos, with each door mask on the shape's door
+// sides that has enough doors and passes the stage's door rules. Hidden stages
+// are left out, as they are from ShapeInfos.
+func Combos() []Combo {
+	var combos []Combo
+	for _, info := range ShapeInfos() {
+		for _, stage := range info.Stages {
+			for mask := 1; mask < 16; mask++ {
+				doors := bitmaskToDoors(mask)
+				if len(doors) < info.MinDoors || !info.allowsDoors(doors) || !stageDoorCompat(stage, mask) {
+					continue
+				}
+				combos = append(combos, Combo{Shape: info.Name, DoorMask: mask, Doors: doors, StageType: stage})
+			}
+		}
+	}
+	return combos
+}
+
+// allowsDoors reports whether every door is on one of the shape's door sides
+func (info ShapeInfo) allowsDoors(doors []DoorPosition) bool {
+	for _, d := range doors {
+		if !slices.Contains(info.Doors, d) {
+			return false
+		}
+	}
+	return true
+}
+
+// unknownShapeError lists the registered shapes
+func unknownShapeError(shape string) error {
+	return fmt.Errorf("unknown shape: %s (allowed: %s)", shape, strings.Join(ShapeNames(), ", "))
+}
+
+// apply copies the set options into a request's seed, overrides and trace fields
+func (opts GenerateOptions) apply(seed **int64, overrides *model.StageOverrides, trace *bool) {
+	if opts.Seed != nil {
+		*seed = opts.Seed
+	}
+	if opts.StageOverrides != nil {
+		*overrides = opts.StageOverrides
+	}
+	if opts.Trace {
+		*trace = true
+	}
+}
+
+// allDoorSides lists every side, for shapes that accept doors anywhere
+var allDoorSides = []DoorPosition{DoorTop, DoorRight, DoorBottom, DoorLeft}
+
+// shapeGenerator adapts a typed generate function to Generator, so each shape
+// only supplies its metadata and request plumbing
+type shapeGenerator[Req any, Resp any] struct {
+	info       ShapeInfo
+	generate   func(context.Context, Req) (*Resp, error)
+	setOptions func(req *Req, opts GenerateOptions)
+	fromParams func(params RoomParams) Req
+	result     func(resp *Resp) (*model.TemplatePayload, *DifficultyScore, *ValidationDebugInfo)
+}
+
+func (g *shapeGenerator[Req, Resp]) Info() ShapeInfo {
+	return g.info
+}
+
+func (g *shapeGenerator[Req, Resp]) NewRequest() interface{} {
+	return new(Req)
+}
+
+func (g *shapeGenerator[Req, Resp]) RequestFor(params RoomParams) interface{} {
+	req := g.fromParams(params)
+	return &req
+}
+
+func (g *shapeGenerator[Req, Resp]) Generate(ctx context.Context, req interface{}, opts GenerateOptions) (*RoomResult, error) {
+	typed, ok := req.(*Req)
+	if !ok {
+		return nil, fmt.Errorf("%s generator got a %T request", g.info.Name, req)
+	}
+	r := *typed
+	g.setOptions(&r, opts)
+	resp, err := g.generate(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	payload, difficulty, validation := g.result(resp)
+	return &RoomResult{Response: resp, Payload: payload, Difficulty: difficulty, Validation: validation}, nil
+}
+
+// sampledRequest points at the request fields generateSampled reads and rewrites
+type sampledRequest struct {
+	target                **DifficultyTarget
+	seed                  **int64
+	maxValidationAttempts int
+}
+
+// sampledResponse points at the response fields generateSampled fills in
+type sampledResponse struct {
+	payload    *model.TemplatePayload
+	difficulty *DifficultyScore
+	seed       *int64
+	target     **DifficultyTargetResult
+	validation **ValidationDebugInfo
+}
+
+// roomRequest is a pointer to a shape request that generateSampled can resample
+type roomRequest[Req any] interface {
+	*Req
+	sampling() sampledRequest
+}
+
+// roomResponse is a pointer to a shape response that generateSampled can fill in
+type roomResponse[Resp any] interface {
+	*Resp
+	sampling() sampledResponse
+}
+
+// generateSampled runs a shape's single-seed generate function under the request's
+// difficulty target and self-validation. It stops with a *StepError naming the
+// interrupted step once ctx is canceled or its deadline passes. With a difficulty
+// target, whole rooms are resampled and the closest one is kept. Rooms failing
+// strict validation are regenerated from fresh seeds, up to maxValidationAttempts
+// times; debugInfo.validation reports the outcome.
+func generateSampled[Req any, Resp any, PReq roomRequest[Req], PResp roomResponse[Resp]](
+	ctx context.Context, req Req, generate func(context.Context, Req) (*Resp, error)) (*Resp, error) {
+
+	fields := PReq(&req).sampling()
+
+	// Difficulty target: resample whole rooms and keep the closest one
+	if *fields.target != nil {
+		resp, result, seed, err := generateToTarget(*fields.target, *fields.seed, func(seed int64) (*Resp, *DifficultyScore, error) {
+			attempt := req
+			attemptFields := PReq(&attempt).sampling()
+			*attemptFields.target = nil
+			*attemptFields.seed = &seed
+			resp, err := generateSampled[Req, Resp, PReq, PResp](ctx, attempt, generate)
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp, PResp(resp).sampling().difficulty, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		out := PResp(resp).sampling()
+		*out.seed = seed
+		*out.target = result
+		return resp, nil
+	}
+
+	// Self-validation: regenerate from fresh seeds until the room passes strict validation
+	resp, validation, seed, err := generateValidated(fields.maxValidationAttempts, *fields.seed, func(seed int64) (*Resp, *model.TemplatePayload, error) {
+		attempt := req
+		*PReq(&attempt).sampling().seed = &seed
+		resp, err := generate(ctx, attempt)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp, PResp(resp).sampling().payload, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := PResp(resp).sampling()
+	*out.seed = seed
+	*out.validation = validation
+	return resp, nil
+}
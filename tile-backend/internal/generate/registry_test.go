@@ -0,0 +1,151 @@
+package generate
+
+import (
+	"context"
+	"testing"
+
+	"tile-backend/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_BuiltinShapes(t *testing.T) {
+	assert.Equal(t, []string{"fullroom", "bridge", "platform", "cave"}, ShapeNames())
+
+	g, ok := LookupGenerator("fullroom")
+	require.True(t, ok)
+	assert.Equal(t, "full", g.Info().RoomType)
+	assert.Equal(t, "all", g.Info().PayloadShape)
+
+	g, ok = generatorForRoomType("full")
+	require.True(t, ok)
+	assert.Equal(t, "fullroom", g.Info().Name)
+
+	_, ok = LookupGenerator("tunnel")
+	assert.False(t, ok)
+}
+
+func TestRegistry_GenerateMatchesDirectCall(t *testing.T) {
+	seed := int64(11)
+	direct, err := GenerateCave(context.Background(), CaveGenerateRequest{Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, Seed: &seed})
+	require.NoError(t, err)
+
+	g, _ := LookupGenerator("cave")
+	result, err := g.Generate(context.Background(), g.RequestFor(RoomParams{Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}}), GenerateOptions{Seed: &seed})
+	require.NoError(t, err)
+	assert.Equal(t, direct.Payload.Ground, result.Payload.Ground)
+	assert.Equal(t, direct.Difficulty, result.Difficulty)
+
+	// The response is the shape's own type, as served by /generate/{shape}
+	_, ok := result.Response.(*CaveGenerateResponse)
+	assert.True(t, ok)
+
+	_, err = g.Generate(context.Background(), &BridgeGenerateRequest{}, GenerateOptions{})
+	assert.Error(t, err)
+}
+
+func TestRegistry_MinDoorsMatchesGenerate(t *testing.T) {
+	// Each shape accepts exactly MinDoors doors and rejects one fewer
+	sides := []DoorPosition{DoorLeft, DoorRight, DoorTop, DoorBottom}
+	for _, name := range []string{"fullroom", "bridge", "platform", "cave"} {
+		g, _ := LookupGenerator(name)
+		seed := int64(3)
+		minDoors := g.Info().MinDoors
+		_, err := g.Generate(context.Background(), g.RequestFor(RoomParams{Width: 20, Height: 12, Doors: sides[:minDoors]}), GenerateOptions{Seed: &seed})
+		assert.NoError(t, err, name)
+		if minDoors > 0 {
+			_, err = g.Generate(context.Background(), g.RequestFor(RoomParams{Width: 20, Height: 12, Doors: sides[:minDoors-1]}), GenerateOptions{Seed: &seed})
+			assert.Error(t, err, name)
+		}
+	}
+}
+
+// stubGenerator is a minimal shape used to check that registration alone makes
+// a shape reachable
+type stubGenerator struct {
+	info    ShapeInfo
+	invalid bool // Leave out a required layer, so the room fails validation
+}
+
+func (s *stubGenerator) Info() ShapeInfo                     { return s.info }
+func (s *stubGenerator) NewRequest() interface{}             { return &RoomParams{} }
+func (s *stubGenerator) RequestFor(p RoomParams) interface{} { return &p }
+func (s *stubGenerator) Generate(ctx context.Context, req interface{}, opts GenerateOptions) (*RoomResult, error) {
+	p := req.(*RoomParams)
+	w, h := p.Width, p.Height
+	payload := &model.TemplatePayload{
+		Ground: createEmptyLayer(w, h), Chaser: createEmptyLayer(w, h), Zoner: createEmptyLayer(w, h),
+		DPS: createEmptyLayer(w, h), MobAir: createEmptyLayer(w, h),
+	}
+	if !s.invalid {
+		payload.Static = createEmptyLayer(w, h)
+	}
+	payload.Meta.Width, payload.Meta.Height = p.Width, p.Height
+	return &RoomResult{Response: payload, Payload: payload}, nil
+}
+
+func TestRegistry_RegisterShape(t *testing.T) {
+	stub := &stubGenerator{info: ShapeInfo{Name: "stub", RoomType: "stub", PayloadShape: "stub", Stages: []string{"start"}}}
+	Register(stub)
+	defer func() {
+		registryMu.Lock()
+		registry = registry[:len(registry)-1]
+		registryMu.Unlock()
+	}()
+
+	g, ok := LookupGenerator("stub")
+	require.True(t, ok)
+	assert.Same(t, stub, g)
+
+	// Stage compatibility follows the shape's own stage list
+	assert.True(t, stageShapeCompat("start", "stub"))
+	assert.False(t, stageShapeCompat("teaching", "stub"))
+
+	payload, err := generateRoom(context.Background(), workItem{shape: "stub", doorMask: 1, stageType: "start"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 20, payload.Meta.Width)
+
+	// Generators that do not validate their rooms are checked before saving
+	stub.invalid = true
+	_, err = generateRoom(context.Background(), workItem{shape: "stub", doorMask: 1, stageType: "start"}, nil)
+	assert.ErrorContains(t, err, "static layer is missing")
+	stub.invalid = false
+
+	assert.Panics(t, func() { Register(stub) })
+	assert.Panics(t, func() { Register(&stubGenerator{}) })
+}
+
+func TestShapeInfos_Stages(t *testing.T) {
+	stages := make(map[string][]string)
+	for _, info := range ShapeInfos() {
+		stages[info.Name] = info.Stages
+	}
+	assert.NotContains(t, stages["bridge"], "peak")
+	assert.Contains(t, stages["fullroom"], "peak")
+	assert.Contains(t, stages["cave"], "pressure")
+	assert.NotContains(t, stages["cave"], "boss")
+}
+
+func TestStageShapeCompat_UnregisteredShape(t *testing.T) {
+	assert.False(t, stageShapeCompat("start", "tunnel"))
+}
+
+func TestCombos(t *testing.T) {
+	combos := Combos()
+	require.NotEmpty(t, combos)
+
+	seen := make(map[string]bool)
+	for _, c := range combos {
+		g, ok := LookupGenerator(c.Shape)
+		require.True(t, ok, c.Shape)
+		assert.True(t, stageShapeCompat(c.StageType, g.Info().RoomType), "%s/%s", c.Shape, c.StageType)
+		assert.True(t, stageDoorCompat(c.StageType, c.DoorMask), "%s/%d", c.StageType, c.DoorMask)
+		assert.Equal(t, bitmaskToDoors(c.DoorMask), c.Doors)
+		assert.GreaterOrEqual(t, len(c.Doors), g.Info().MinDoors)
+		seen[c.Shape+"/"+c.StageType] = true
+	}
+	assert.True(t, seen["fullroom/peak"])
+	assert.False(t, seen["bridge/peak"], "bridge rooms are never peak rooms")
+	assert.False(t, seen["fullroom/start"], "hidden stages are left out")
+}
@@ -0,0 +1,332 @@
+package generate
+
+import (
+	"fmt"
+
+	"tile-backend/internal/model"
+)
+
+// RouteRequest represents a request to analyze the door-to-door routes of a template
+type RouteRequest struct {
+	Payload model.TemplatePayload `json:"payload"`
+}
+
+// RouteAnalysis describes how the doors of a room connect. Routes run over
+// ground and bridge cells, hazards included, like the main path.
+type RouteAnalysis struct {
+	Width            int                    `json:"width"`
+	Height           int                    `json:"height"`
+	Doors            []model.DoorDescriptor `json:"doors"`            // Doors in canonical order (by side, then offset)
+	Routes           []DoorRoute            `json:"routes"`           // One per door pair
+	ChokeCells       []model.Point          `json:"chokeCells"`       // Cells every route between some pair of doors has to cross
+	MainPath         []CorridorCell         `json:"mainPath"`         // Main path cells with the corridor width at each
+	MinCorridorWidth int                    `json:"minCorridorWidth"` // Narrowest corridor along the main path; 0 without a main path
+	Overlays         RouteOverlays          `json:"overlays"`
+}
+
+// DoorRoute describes the routes between two doors
+type DoorRoute struct {
+	From           model.DoorDescriptor `json:"from"`
+	To             model.DoorDescriptor `json:"to"`
+	Connected      bool                 `json:"connected"`
+	Length         int                  `json:"length"`         // Steps on the shortest path between the door centers
+	Path           []model.Point        `json:"path,omitempty"` // Shortest path, both ends included
+	DisjointRoutes int                  `json:"disjointRoutes"` // Routes between the openings that share no step
+}
+
+// CorridorCell is a main path cell and the corridor width there
+type CorridorCell struct {
+	X     int `json:"x"`
+	Y     int `json:"y"`
+	Width int `json:"width"`
+}
+
+// RouteOverlays are the analysis as layers the same size as the room, ready to
+// draw over it
+type RouteOverlays struct {
+	Routes        model.Layer `json:"routes"`        // Cells on a door-to-door shortest path
+	Chokes        model.Layer `json:"chokes"`        // Choke cells
+	MainPath      model.Layer `json:"mainPath"`      // Main path cells
+	CorridorWidth [][]int     `json:"corridorWidth"` // Corridor width on main path cells, 0 elsewhere
+}
+
+// routeGrid is the walkable room and its doors, read from a payload
+type routeGrid struct {
+	ground, bridge, hazard [][]int
+	walkable               *Grid
+	sites                  []DoorSite
+	doors                  []model.DoorDescriptor
+	width, height          int
+}
+
+// newRouteGrid reads the walkable cells and doors of a payload. Layers that are
+// missing or short read as empty.
+func newRouteGrid(payload *model.TemplatePayload, width, height int) (*routeGrid, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("payload has no size")
+	}
+	sites, _, doors, err := resolveDoors(nil, payload.DoorList(width, height), width, height)
+	if err != nil {
+		return nil, err
+	}
+	g := &routeGrid{
+		ground: GridFromLayer(payload.Ground, width, height).Layer(),
+		bridge: GridFromLayer(payload.Bridge, width, height).Layer(),
+		hazard: GridFromLayer(payload.Hazard, width, height).Layer(),
+		sites:  sites,
+		doors:  doors,
+		width:  width,
+		height: height,
+	}
+	g.walkable = GridFromLayer(g.ground, width, height)
+	g.walkable.Or(GridFromLayer(g.bridge, width, height))
+	return g, nil
+}
+
+// AnalyzeRoutes finds the shortest path and the number of disjoint routes
+// between every pair of doors, the choke cells, and the corridor width along
+// the main path
+func AnalyzeRoutes(payload *model.TemplatePayload) (*RouteAnalysis, error) {
+	width, height := payload.Meta.Width, payload.Meta.Height
+	g, err := newRouteGrid(payload, width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &RouteAnalysis{
+		Width:      width,
+		Height:     height,
+		Doors:      g.doors,
+		Routes:     []DoorRoute{},
+		ChokeCells: []model.Point{},
+		MainPath:   []CorridorCell{},
+		Overlays: RouteOverlays{
+			Routes:        createEmptyLayer(width, height),
+			Chokes:        createEmptyLayer(width, height),
+			MainPath:      createEmptyLayer(width, height),
+			CorridorWidth: createEmptyLayer(width, height),
+		},
+	}
+
+	// Door-to-door shortest paths and disjoint routes
+	centers := make([]Point, len(g.sites))
+	for i, site := range g.sites {
+		centers[i] = g.walkable.Nearest(site.Point)
+	}
+	for i := 0; i < len(g.sites); i++ {
+		for j := i + 1; j < len(g.sites); j++ {
+			route := DoorRoute{From: g.doors[i], To: g.doors[j]}
+			if path := g.shortestPath(centers[i], centers[j]); path != nil {
+				route.Connected = true
+				route.Length = len(path) - 1
+				for _, p := range path {
+					route.Path = append(route.Path, model.Point{X: p.X, Y: p.Y})
+					analysis.Overlays.Routes[p.Y][p.X] = 1
+				}
+				route.DisjointRoutes = g.disjointRoutes(g.openingCells(g.sites[i]), g.openingCells(g.sites[j]))
+			}
+			analysis.Routes = append(analysis.Routes, route)
+		}
+	}
+
+	// Choke cells: removing one cuts a door off from the first door
+	if len(centers) >= 2 && g.walkable.Has(centers[0].X, centers[0].Y) {
+		cv := findCutVertices(g.walkable, centers[0])
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if cv.separates(Point{x, y}, centers[1:]) {
+					analysis.ChokeCells = append(analysis.ChokeCells, model.Point{X: x, Y: y})
+					analysis.Overlays.Chokes[y][x] = 1
+				}
+			}
+		}
+	}
+
+	// Corridor width along the main path
+	for _, c := range g.mainPathCorridor() {
+		analysis.MainPath = append(analysis.MainPath, c)
+		analysis.Overlays.MainPath[c.Y][c.X] = 1
+		analysis.Overlays.CorridorWidth[c.Y][c.X] = c.Width
+		if analysis.MinCorridorWidth == 0 || c.Width < analysis.MinCorridorWidth {
+			analysis.MinCorridorWidth = c.Width
+		}
+	}
+
+	return analysis, nil
+}
+
+// MinCorridorWidth returns the narrowest corridor along the main path of a
+// template, or false when the template has no main path (fewer than two doors,
+// or doors that do not connect)
+func MinCorridorWidth(payload *model.TemplatePayload, width, height int) (int, bool) {
+	g, err := newRouteGrid(payload, width, height)
+	if err != nil {
+		return 0, false
+	}
+	narrowest := 0
+	for _, c := range g.mainPathCorridor() {
+		if narrowest == 0 || c.Width < narrowest {
+			narrowest = c.Width
+		}
+	}
+	return narrowest, narrowest > 0
+}
+
+// mainPathCorridor computes the main path and returns its cells in row-major
+// order with the corridor width at each. Cells in a door opening are left out:
+// their width is the door's.
+func (g *routeGrid) mainPathCorridor() []CorridorCell {
+	mainPath, _ := ComputeMainPath(g.ground, g.bridge, g.hazard, g.sites, g.width, g.height)
+	inDoor := NewGrid(g.width, g.height)
+	for _, site := range g.sites {
+		for _, c := range site.Cells {
+			inDoor.Set(c.X, c.Y)
+		}
+	}
+
+	var cells []CorridorCell
+	for y := 0; y < g.height; y++ {
+		for x := 0; x < g.width; x++ {
+			if mainPath.OnMainPath[y][x] && !inDoor.Has(x, y) {
+				cells = append(cells, CorridorCell{X: x, Y: y, Width: g.corridorWidth(x, y)})
+			}
+		}
+	}
+	return cells
+}
+
+// corridorWidth returns the width of the corridor through a walkable cell: the
+// shorter of the horizontal and vertical walkable runs it lies on
+func (g *routeGrid) corridorWidth(x, y int) int {
+	run := func(dx, dy int) int {
+		n := 1
+		for cx, cy := x-dx, y-dy; g.walkable.Has(cx, cy); cx, cy = cx-dx, cy-dy {
+			n++
+		}
+		for cx, cy := x+dx, y+dy; g.walkable.Has(cx, cy); cx, cy = cx+dx, cy+dy {
+			n++
+		}
+		return n
+	}
+	return min(run(1, 0), run(0, 1))
+}
+
+// shortestPath returns a shortest 4-connected walk from start to end over
+// walkable cells, both ends included, or nil when there is none
+func (g *routeGrid) shortestPath(start, end Point) []Point {
+	if !g.walkable.Has(start.X, start.Y) || !g.walkable.Has(end.X, end.Y) {
+		return nil
+	}
+	prev := make(map[Point]Point)
+	prev[start] = start
+	queue := []Point{start}
+	var buf []Point
+	for head := 0; head < len(queue); head++ {
+		p := queue[head]
+		if p == end {
+			break
+		}
+		buf = g.walkable.Neighbors(p, buf[:0])
+		for _, n := range buf {
+			if _, seen := prev[n]; !seen {
+				prev[n] = p
+				queue = append(queue, n)
+			}
+		}
+	}
+	if _, ok := prev[end]; !ok {
+		return nil
+	}
+
+	var path []Point
+	for p := end; p != start; p = prev[p] {
+		path = append(path, p)
+	}
+	path = append(path, start)
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// openingCells returns the walkable cells of a door opening, or the walkable
+// cell nearest its center when the opening is closed
+func (g *routeGrid) openingCells(site DoorSite) []Point {
+	var cells []Point
+	for _, c := range site.Cells {
+		if g.walkable.Has(c.X, c.Y) {
+			cells = append(cells, c)
+		}
+	}
+	if len(cells) == 0 {
+		if p := g.walkable.Nearest(site.Point); g.walkable.Has(p.X, p.Y) {
+			cells = append(cells, p)
+		}
+	}
+	return cells
+}
+
+// disjointRoutes counts the routes from one set of cells to another that share
+// no step between two cells: the maximum flow when every step between
+// neighbouring walkable cells carries one unit each way
+func (g *routeGrid) disjointRoutes(from, to []Point) int {
+	w, n := g.width, g.width*g.height
+	dirs := [4]Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+	flow := make([]int8, n*4) // Net flow out of cell i in direction d, at i*4+d
+	sink := make([]bool, n)
+	for _, p := range to {
+		sink[p.Y*w+p.X] = true
+	}
+
+	const unvisited = -2
+	via := make([]int, n) // Direction each cell was reached by; -1 for sources
+	queue := make([]int, 0, n)
+	routes := 0
+	for {
+		for i := range via {
+			via[i] = unvisited
+		}
+		queue = queue[:0]
+		for _, p := range from {
+			if i := p.Y*w + p.X; !sink[i] && via[i] == unvisited {
+				via[i] = -1
+				queue = append(queue, i)
+			}
+		}
+
+		// Find a path with spare capacity on every step
+		end := -1
+		for head := 0; head < len(queue) && end < 0; head++ {
+			u := queue[head]
+			for d, dir := range dirs {
+				x, y := u%w+dir.X, u/w+dir.Y
+				if !g.walkable.Has(x, y) {
+					continue
+				}
+				v := y*w + x
+				if via[v] != unvisited || flow[u*4+d] >= 1 {
+					continue
+				}
+				via[v] = d
+				if sink[v] {
+					end = v
+					break
+				}
+				queue = append(queue, v)
+			}
+		}
+		if end < 0 {
+			return routes
+		}
+
+		// Push one unit along it
+		for v := end; via[v] >= 0; {
+			d := via[v]
+			u := (v/w-dirs[d].Y)*w + v%w - dirs[d].X
+			flow[u*4+d]++
+			flow[v*4+(d+2)%4]--
+			v = u
+		}
+		routes++
+	}
+}
@@ -0,0 +1,130 @@
+package generate
+
+import (
+	"context"
+	"testing"
+
+	"tile-backend/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// routesPayload returns a 12x7 all-ground room with one-cell doors halfway down
+// the left and right sides
+func routesPayload() *model.TemplatePayload {
+	p := heatmapPayload(12, 7)
+	p.DoorDescriptors = []model.DoorDescriptor{
+		{Side: "right", Offset: 3, Width: 1},
+		{Side: "left", Offset: 3, Width: 1},
+	}
+	return p
+}
+
+// walledPayload returns the routesPayload room split by a wall at x=6 with gaps
+// at the given rows
+func walledPayload(gaps ...int) *model.TemplatePayload {
+	p := routesPayload()
+	for y := 0; y < 7; y++ {
+		p.Ground[y][6] = 0
+	}
+	for _, y := range gaps {
+		p.Ground[y][6] = 1
+	}
+	return p
+}
+
+func TestAnalyzeRoutes_OpenRoom(t *testing.T) {
+	a, err := AnalyzeRoutes(routesPayload())
+	require.NoError(t, err)
+
+	require.Len(t, a.Doors, 2)
+	assert.Equal(t, "right", a.Doors[0].Side, "doors are in canonical order")
+	require.Len(t, a.Routes, 1)
+	r := a.Routes[0]
+	assert.True(t, r.Connected)
+	assert.Equal(t, 11, r.Length)
+	assert.Equal(t, model.Point{X: 11, Y: 3}, r.Path[0])
+	assert.Equal(t, model.Point{X: 0, Y: 3}, r.Path[len(r.Path)-1])
+	assert.Equal(t, 3, r.DisjointRoutes, "a one-cell door on an edge has three steps out")
+	assert.Empty(t, a.ChokeCells)
+
+	// The main path runs along row 3; the room is 7 cells tall
+	assert.Equal(t, 7, a.MinCorridorWidth)
+	assert.Len(t, a.MainPath, 10, "door cells are left out")
+	assert.Equal(t, 7, a.Overlays.CorridorWidth[3][5])
+	assert.Equal(t, 1, a.Overlays.MainPath[3][5])
+	assert.Equal(t, 1, a.Overlays.Routes[3][0])
+}
+
+func TestAnalyzeRoutes_Choke(t *testing.T) {
+	a, err := AnalyzeRoutes(walledPayload(3))
+	require.NoError(t, err)
+
+	require.Len(t, a.Routes, 1)
+	assert.Equal(t, 1, a.Routes[0].DisjointRoutes)
+	assert.Equal(t, []model.Point{{X: 5, Y: 3}, {X: 6, Y: 3}, {X: 7, Y: 3}}, a.ChokeCells)
+	assert.Equal(t, 1, a.Overlays.Chokes[3][6])
+	assert.Equal(t, 1, a.MinCorridorWidth, "the gap is one cell wide")
+
+	width, ok := MinCorridorWidth(walledPayload(3), 12, 7)
+	assert.True(t, ok)
+	assert.Equal(t, 1, width)
+
+	// Two gaps give two routes and no choke
+	a, err = AnalyzeRoutes(walledPayload(1, 5))
+	require.NoError(t, err)
+	assert.Equal(t, 2, a.Routes[0].DisjointRoutes)
+	assert.Empty(t, a.ChokeCells)
+}
+
+func TestAnalyzeRoutes_Disconnected(t *testing.T) {
+	a, err := AnalyzeRoutes(walledPayload())
+	require.NoError(t, err)
+
+	require.Len(t, a.Routes, 1)
+	assert.False(t, a.Routes[0].Connected)
+	assert.Empty(t, a.Routes[0].Path)
+	assert.Zero(t, a.Routes[0].DisjointRoutes)
+	assert.Zero(t, a.MinCorridorWidth)
+
+	_, ok := MinCorridorWidth(walledPayload(), 12, 7)
+	assert.False(t, ok)
+}
+
+func TestAnalyzeRoutes_Errors(t *testing.T) {
+	p := routesPayload()
+	p.DoorDescriptors = []model.DoorDescriptor{{Side: "left", Offset: 6, Width: 3}}
+	_, err := AnalyzeRoutes(p)
+	assert.Error(t, err, "the door runs past the side")
+
+	p = routesPayload()
+	p.Meta.Height = 0
+	_, err = AnalyzeRoutes(p)
+	assert.Error(t, err)
+}
+
+func TestAnalyzeRoutes_GeneratedRooms(t *testing.T) {
+	seed := int64(5)
+	for _, g := range Generators() {
+		t.Run(g.Info().Name, func(t *testing.T) {
+			req := g.RequestFor(RoomParams{Width: 24, Height: 14, Doors: []DoorPosition{DoorTop, DoorRight, DoorLeft}, StageType: "teaching"})
+			result, err := g.Generate(context.Background(), req, GenerateOptions{Seed: &seed})
+			require.NoError(t, err)
+
+			a, err := AnalyzeRoutes(result.Payload)
+			require.NoError(t, err)
+			require.Len(t, a.Routes, 3)
+			for _, r := range a.Routes {
+				assert.True(t, r.Connected, "%s-%s", r.From.Side, r.To.Side)
+				assert.GreaterOrEqual(t, r.DisjointRoutes, 1)
+			}
+			assert.Greater(t, a.MinCorridorWidth, 0)
+			assert.Len(t, a.Overlays.Chokes, 14)
+
+			width, ok := MinCorridorWidth(result.Payload, 24, 14)
+			assert.True(t, ok)
+			assert.Equal(t, a.MinCorridorWidth, width)
+		})
+	}
+}
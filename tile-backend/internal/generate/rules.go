@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 )
@@ -67,56 +68,55 @@ const (
 // Static validation
 // ============================================================================
 
-// isValidStaticPosition checks if a 2x2 static can be placed at the given top-left corner
-func isValidStaticPosition(pos Point, ground, softEdge, bridge, staticLayer [][]int, forbiddenCells map[Point]bool, width, height int) bool {
-	// Check all 4 cells of the 2x2 area
+// isValidStaticPosition checks if a 2x2 static can be placed at the given top-left corner.
+// blocked holds the cells no static may cover beyond the layers: the door area and
+// the designer's noStatic mask (see staticBlockedCells).
+func isValidStaticPosition(pos Point, lc *LayerContext, blocked *Grid) bool {
+	if !staticFits(pos, lc, blocked) {
+		return false
+	}
+
+	// Check that the static doesn't touch any existing static (including diagonals)
+	return !touchesExistingStatic(pos, lc.Static)
+}
+
+// staticFits checks that all 4 cells of the 2x2 area at pos are in bounds, on
+// ground, and clear of soft edge, bridge, rail, existing statics and blocked cells
+func staticFits(pos Point, lc *LayerContext, blocked *Grid) bool {
 	for dy := 0; dy < staticSize; dy++ {
 		for dx := 0; dx < staticSize; dx++ {
 			x := pos.X + dx
 			y := pos.Y + dy
 
-			// Check bounds
-			if x >= width || y >= height {
-				return false
-			}
-
-			// Check ground layer (must be 1)
-			if ground[y][x] != 1 {
-				return false
-			}
-
-			// Check soft edge (must be 0)
-			if softEdge[y][x] != 0 {
-				return false
-			}
-
-			// Check bridge (must be 0)
-			if bridge[y][x] != 0 {
+			// Must be on ground (out-of-bounds cells are never ground)
+			if !lc.Ground.Has(x, y) {
 				return false
 			}
 
-			// Check existing static (must be 0)
-			if staticLayer[y][x] != 0 {
+			if lc.SoftEdge.Has(x, y) || lc.Bridge.Has(x, y) || lc.Rail.Has(x, y) || lc.Static.Has(x, y) {
 				return false
 			}
 
-			// Check forbidden cells (door area)
-			if forbiddenCells[Point{X: x, Y: y}] {
+			if blocked.Has(x, y) {
 				return false
 			}
 		}
 	}
+	return true
+}
 
-	// Check that the static doesn't touch any existing static (including diagonals)
-	if touchesExistingStatic(pos, staticLayer, width, height) {
-		return false
+// staticBlockedCells returns the cells a static may not cover whatever the
+// layers hold: cells within doorForbiddenRadius of a door and designer noStatic cells
+func staticBlockedCells(doorPositions []DoorSite, masks *ConstraintMasks, width, height int) *Grid {
+	blocked := gridFromPoints(getDoorForbiddenCells(doorPositions, width, height), width, height)
+	if masks != nil {
+		blocked.Or(GridFromLayer(masks.NoStatic, width, height))
 	}
-
-	return true
+	return blocked
 }
 
 // touchesExistingStatic checks if placing a 2x2 static at pos would touch any existing static
-func touchesExistingStatic(pos Point, staticLayer [][]int, width, height int) bool {
+func touchesExistingStatic(pos Point, staticLayer *Grid) bool {
 	// Check a 4x4 area around the 2x2 placement (1 cell buffer on each side)
 	for dy := -1; dy <= staticSize; dy++ {
 		for dx := -1; dx <= staticSize; dx++ {
@@ -125,13 +125,8 @@ func touchesExistingStatic(pos Point, staticLayer [][]int, width, height int) bo
 				continue
 			}
 
-			x := pos.X + dx
-			y := pos.Y + dy
-
-			if x >= 0 && x < width && y >= 0 && y < height {
-				if staticLayer[y][x] != 0 {
-					return true
-				}
+			if staticLayer.Has(pos.X+dx, pos.Y+dy) {
+				return true
 			}
 		}
 	}
@@ -153,126 +148,15 @@ func wouldTouch(pos1, pos2 Point) bool {
 	return xOverlap && yOverlap
 }
 
-// checkConnectivityAfterPlacement checks if all doors remain connected after placing a static
-func checkConnectivityAfterPlacement(ground, staticLayer [][]int, doorPositions map[DoorPosition]Point, newStaticPos Point, width, height int) bool {
-	if len(doorPositions) < 2 {
-		return true
-	}
-
-	// Create a temporary walkable map: ground=1 and static=0 means walkable
-	// Temporarily mark the new static position as blocked
-	walkable := make([][]bool, height)
-	for y := 0; y < height; y++ {
-		walkable[y] = make([]bool, width)
-		for x := 0; x < width; x++ {
-			walkable[y][x] = ground[y][x] == 1 && staticLayer[y][x] == 0
-		}
-	}
-
-	// Block the new static position
-	for dy := 0; dy < staticSize; dy++ {
-		for dx := 0; dx < staticSize; dx++ {
-			x := newStaticPos.X + dx
-			y := newStaticPos.Y + dy
-			if x < width && y < height {
-				walkable[y][x] = false
-			}
-		}
-	}
-
-	// Get door positions as a slice
-	doors := make([]Point, 0, len(doorPositions))
-	for _, pos := range doorPositions {
-		doors = append(doors, pos)
-	}
-
-	// Check if all doors are connected using BFS from the first door
-	startDoor := doors[0]
-	visited := bfsConnectivity(walkable, startDoor, width, height)
-
-	// Check if all other doors are reachable
-	for _, door := range doors[1:] {
-		if !visited[door.Y][door.X] {
-			return false
-		}
-	}
-
-	return true
-}
-
-// bfsConnectivity performs BFS to find all connected cells from a starting point
-func bfsConnectivity(walkable [][]bool, start Point, width, height int) [][]bool {
-	visited := make([][]bool, height)
-	for y := 0; y < height; y++ {
-		visited[y] = make([]bool, width)
-	}
-
-	// Find the nearest walkable cell to the start point (door might be on edge)
-	startCell := findNearestWalkable(walkable, start, width, height)
-	if startCell.X < 0 {
-		return visited // No walkable cell found
-	}
-
-	queue := []Point{startCell}
-	visited[startCell.Y][startCell.X] = true
-
-	// 4-directional movement
-	dxArr := []int{0, 1, 0, -1}
-	dyArr := []int{-1, 0, 1, 0}
-
-	for len(queue) > 0 {
-		curr := queue[0]
-		queue = queue[1:]
-
-		for i := 0; i < 4; i++ {
-			nx := curr.X + dxArr[i]
-			ny := curr.Y + dyArr[i]
-
-			if nx >= 0 && nx < width && ny >= 0 && ny < height && !visited[ny][nx] && walkable[ny][nx] {
-				visited[ny][nx] = true
-				queue = append(queue, Point{X: nx, Y: ny})
-			}
-		}
-	}
-
-	return visited
-}
-
-// findNearestWalkable finds the nearest walkable cell to the given point
-func findNearestWalkable(walkable [][]bool, pos Point, width, height int) Point {
-	// Check the point itself first
-	if pos.X >= 0 && pos.X < width && pos.Y >= 0 && pos.Y < height && walkable[pos.Y][pos.X] {
-		return pos
-	}
-
-	// Search in expanding squares
-	for radius := 1; radius < max(width, height); radius++ {
-		for dy := -radius; dy <= radius; dy++ {
-			for dx := -radius; dx <= radius; dx++ {
-				if abs(dx) != radius && abs(dy) != radius {
-					continue // Only check the perimeter
-				}
-				nx := pos.X + dx
-				ny := pos.Y + dy
-				if nx >= 0 && nx < width && ny >= 0 && ny < height && walkable[ny][nx] {
-					return Point{X: nx, Y: ny}
-				}
-			}
-		}
-	}
-
-	return Point{X: -1, Y: -1} // Not found
-}
-
 // findValidStaticPositions finds all valid top-left corners for 2x2 static placement
-func findValidStaticPositions(ground, softEdge, bridge, staticLayer [][]int, forbiddenCells map[Point]bool, width, height int) []Point {
+func findValidStaticPositions(lc *LayerContext, blocked *Grid) []Point {
 	var positions []Point
 
 	// Iterate through all possible top-left corners for 2x2 placement
-	for y := 0; y <= height-staticSize; y++ {
-		for x := 0; x <= width-staticSize; x++ {
+	for y := 0; y <= lc.Height-staticSize; y++ {
+		for x := 0; x <= lc.Width-staticSize; x++ {
 			pos := Point{X: x, Y: y}
-			if isValidStaticPosition(pos, ground, softEdge, bridge, staticLayer, forbiddenCells, width, height) {
+			if isValidStaticPosition(pos, lc, blocked) {
 				positions = append(positions, pos)
 			}
 		}
@@ -286,7 +170,7 @@ func findValidStaticPositions(ground, softEdge, bridge, staticLayer [][]int, for
 // ============================================================================
 
 // isValidTurretPosition checks if a turret can be placed at the given position
-func isValidTurretPosition(pos Point, ground, softEdge, bridge, staticLayer, turretLayer [][]int, doorPositions map[DoorPosition]Point, width, height int) bool {
+func isValidTurretPosition(pos Point, ground, softEdge, bridge, staticLayer, turretLayer [][]int, doorPositions []DoorSite, masks *ConstraintMasks, width, height int) bool {
 	x, y := pos.X, pos.Y
 
 	// Check bounds
@@ -319,9 +203,14 @@ func isValidTurretPosition(pos Point, ground, softEdge, bridge, staticLayer, tur
 		return false
 	}
 
+	// Must not be on a designer noEnemy cell
+	if masks.noEnemyAt(x, y) {
+		return false
+	}
+
 	// Check minimum distance from doors (at least 4 cells)
 	for _, doorPos := range doorPositions {
-		dist := manhattanDistance(pos, doorPos)
+		dist := distanceToDoor(pos, doorPos)
 		if dist < turretMinDoorDistance {
 			return false
 		}
@@ -356,45 +245,6 @@ func tooCloseToExistingTurret(pos Point, turretLayer [][]int, width, height int)
 	return false
 }
 
-// checkTurretConnectivityAfterPlacement checks if all doors remain connected after placing a turret
-func checkTurretConnectivityAfterPlacement(ground, staticLayer, turretLayer [][]int, doorPositions map[DoorPosition]Point, newTurretPos Point, width, height int) bool {
-	if len(doorPositions) < 2 {
-		return true
-	}
-
-	// Create a temporary walkable map
-	walkable := make([][]bool, height)
-	for y := 0; y < height; y++ {
-		walkable[y] = make([]bool, width)
-		for x := 0; x < width; x++ {
-			// Walkable if ground=1 and not blocked by static or turret
-			walkable[y][x] = ground[y][x] == 1 && staticLayer[y][x] == 0 && turretLayer[y][x] == 0
-		}
-	}
-
-	// Block the new turret position
-	walkable[newTurretPos.Y][newTurretPos.X] = false
-
-	// Get door positions as a slice
-	doors := make([]Point, 0, len(doorPositions))
-	for _, pos := range doorPositions {
-		doors = append(doors, pos)
-	}
-
-	// Check if all doors are connected using BFS from the first door
-	startDoor := doors[0]
-	visited := bfsConnectivity(walkable, startDoor, width, height)
-
-	// Check if all other doors are reachable
-	for _, door := range doors[1:] {
-		if !visited[door.Y][door.X] {
-			return false
-		}
-	}
-
-	return true
-}
-
 // CornerType represents the type of corner a ground tile forms
 type CornerType int
 
@@ -445,13 +295,13 @@ func getGroundCornerType(pos Point, ground [][]int, width, height int) CornerTyp
 }
 
 // findValidTurretPositions finds all valid positions for turret placement
-func findValidTurretPositions(ground, softEdge, bridge, staticLayer, turretLayer [][]int, doorPositions map[DoorPosition]Point, width, height int) []Point {
+func findValidTurretPositions(ground, softEdge, bridge, staticLayer, turretLayer [][]int, doorPositions []DoorSite, masks *ConstraintMasks, width, height int) []Point {
 	var positions []Point
 
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			pos := Point{X: x, Y: y}
-			if isValidTurretPosition(pos, ground, softEdge, bridge, staticLayer, turretLayer, doorPositions, width, height) {
+			if isValidTurretPosition(pos, ground, softEdge, bridge, staticLayer, turretLayer, doorPositions, masks, width, height) {
 				positions = append(positions, pos)
 			}
 		}
@@ -466,7 +316,7 @@ func findValidTurretPositions(ground, softEdge, bridge, staticLayer, turretLayer
 
 // isValidMobGroundPosition checks if a single cell is valid for mob ground
 func isValidMobGroundPosition(pos Point, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer [][]int,
-	doorPositions map[DoorPosition]Point, width, height int) bool {
+	doorPositions []DoorSite, masks *ConstraintMasks, width, height int) bool {
 
 	x, y := pos.X, pos.Y
 
@@ -490,9 +340,14 @@ func isValidMobGroundPosition(pos Point, ground, softEdge, bridge, staticLayer,
 		return false
 	}
 
+	// Must not be on a designer noEnemy cell
+	if masks.noEnemyAt(x, y) {
+		return false
+	}
+
 	// Must be at least 2 cells away from doors
 	for _, doorPos := range doorPositions {
-		if manhattanDistance(pos, doorPos) < mobGroundMinDoorDistance {
+		if distanceToDoor(pos, doorPos) < mobGroundMinDoorDistance {
 			return false
 		}
 	}
@@ -526,13 +381,13 @@ func touchesExistingMobGround(pos Point, mobGroundLayer [][]int, width, height i
 
 // canPlace2x2MobGround checks if a 2x2 mob ground can be placed at the given top-left corner
 func canPlace2x2MobGround(pos Point, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer [][]int,
-	doorPositions map[DoorPosition]Point, width, height int) bool {
+	doorPositions []DoorSite, masks *ConstraintMasks, width, height int) bool {
 
 	// Check all 4 cells
 	for dy := 0; dy < 2; dy++ {
 		for dx := 0; dx < 2; dx++ {
 			checkPos := Point{X: pos.X + dx, Y: pos.Y + dy}
-			if !isValidMobGroundPosition(checkPos, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer, doorPositions, width, height) {
+			if !isValidMobGroundPosition(checkPos, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer, doorPositions, masks, width, height) {
 				return false
 			}
 		}
@@ -568,20 +423,20 @@ func touches2x2ExistingMobGround(pos Point, mobGroundLayer [][]int, width, heigh
 
 // canPlace1x1MobGround checks if a 1x1 mob ground can be placed
 func canPlace1x1MobGround(pos Point, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer [][]int,
-	doorPositions map[DoorPosition]Point, width, height int) bool {
-	return isValidMobGroundPosition(pos, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer, doorPositions, width, height)
+	doorPositions []DoorSite, masks *ConstraintMasks, width, height int) bool {
+	return isValidMobGroundPosition(pos, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer, doorPositions, masks, width, height)
 }
 
 // findValidMobGroundPositions finds all valid positions for mob ground placement
 func findValidMobGroundPositions(ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer [][]int,
-	doorPositions map[DoorPosition]Point, width, height int) []Point {
+	doorPositions []DoorSite, masks *ConstraintMasks, width, height int) []Point {
 
 	var positions []Point
 
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			pos := Point{X: x, Y: y}
-			if isValidMobGroundPosition(pos, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer, doorPositions, width, height) {
+			if isValidMobGroundPosition(pos, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer, doorPositions, masks, width, height) {
 				positions = append(positions, pos)
 			}
 		}
@@ -606,7 +461,7 @@ func isWalkableForMobGround(pos Point, ground, softEdge, bridge, staticLayer, tu
 // isValidMobAirPosition checks if a single cell is valid for mob air
 // Note: Mob Air (flying mobs) do NOT require ground=1, they can spawn anywhere
 func isValidMobAirPosition(pos Point, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer, mobAirLayer [][]int,
-	doorPositions map[DoorPosition]Point, width, height int) bool {
+	doorPositions []DoorSite, masks *ConstraintMasks, width, height int) bool {
 
 	x, y := pos.X, pos.Y
 
@@ -634,9 +489,14 @@ func isValidMobAirPosition(pos Point, ground, softEdge, bridge, staticLayer, tur
 		return false
 	}
 
+	// Must not be on a designer noEnemy cell
+	if masks.noEnemyAt(x, y) {
+		return false
+	}
+
 	// Must be at least 4 cells away from doors
 	for _, doorPos := range doorPositions {
-		if manhattanDistance(pos, doorPos) < mobAirMinDoorDistance {
+		if distanceToDoor(pos, doorPos) < mobAirMinDoorDistance {
 			return false
 		}
 	}
@@ -670,7 +530,7 @@ func touchesExistingMobAir(pos Point, mobAirLayer [][]int, width, height int) bo
 
 // findValidMobAirPositions finds all valid positions for mob air placement
 func findValidMobAirPositions(ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer, mobAirLayer [][]int,
-	doorPositions map[DoorPosition]Point, width, height int) []Point {
+	doorPositions []DoorSite, masks *ConstraintMasks, width, height int) []Point {
 
 	var positions []Point
 
@@ -678,7 +538,7 @@ func findValidMobAirPositions(ground, softEdge, bridge, staticLayer, turretLayer
 		for x := 0; x < width; x++ {
 			pos := Point{X: x, Y: y}
 			if isValidMobAirPosition(pos, ground, softEdge, bridge, staticLayer, turretLayer, mobGroundLayer, mobAirLayer,
-				doorPositions, width, height) {
+				doorPositions, masks, width, height) {
 				positions = append(positions, pos)
 			}
 		}
@@ -691,11 +551,11 @@ func findValidMobAirPositions(ground, softEdge, bridge, staticLayer, turretLayer
 // Soft edge / Island validation
 // ============================================================================
 
-// isFarEnoughFromDoors checks if a position is far enough from all doors
-func isFarEnoughFromDoors(x, y int, doorPositions map[DoorPosition]Point, minDistance int) bool {
+// isFarEnoughFromDoors checks if a position is far enough from every door cell
+func isFarEnoughFromDoors(x, y int, doorPositions []DoorSite, minDistance int) bool {
 	pos := Point{X: x, Y: y}
 	for _, doorPos := range doorPositions {
-		if manhattanDistance(pos, doorPos) < minDistance {
+		if distanceToDoor(pos, doorPos) < minDistance {
 			return false
 		}
 	}
@@ -722,7 +582,7 @@ func canPlaceSoftEdge(placement SoftEdgePlacement, softEdgeLayer [][]int, width,
 }
 
 // isValidIslandPosition checks if an island can be placed at (x, y) with exactly minIslandGroundDistance from existing ground
-func isValidIslandPosition(ground [][]int, x, y, islandWidth, islandHeight, gridWidth, gridHeight int) bool {
+func isValidIslandPosition(ground [][]int, x, y, islandWidth, islandHeight int, masks *ConstraintMasks, gridWidth, gridHeight int) bool {
 	// Check that the island area and its surrounding margin are all void
 	// Margin is minIslandGroundDistance on each side
 	checkStartX := x - minIslandGroundDistance
@@ -744,6 +604,10 @@ func isValidIslandPosition(ground [][]int, x, y, islandWidth, islandHeight, grid
 				if ground[cy][cx] != 0 {
 					return false
 				}
+				// Islands cannot cover designer forceVoid cells
+				if masks.forceVoidAt(cx, cy) {
+					return false
+				}
 			} else {
 				// The margin area must be void (no existing ground within minIslandGroundDistance)
 				if ground[cy][cx] != 0 {
@@ -790,23 +654,27 @@ func isValidIslandPosition(ground [][]int, x, y, islandWidth, islandHeight, grid
 // Platform connectivity (used by platform.go eraser validation)
 // ============================================================================
 
-// areAllDoorsConnected checks if all doors are connected via walkable ground
-func areAllDoorsConnected(ground [][]int, width, height int, doors []DoorPosition) bool {
+// areAllDoorsConnected checks if all doors are connected via walkable ground.
+// The search starts from every cell of the first door; another door counts as
+// reached when any of its cells, or a cell next to one, was visited.
+func areAllDoorsConnected(ground [][]int, width, height int, doors []DoorSite) bool {
 	if len(doors) < 2 {
 		return true
 	}
 
-	// Get first door position
-	startX, startY := getDoorPosition(doors[0], width, height)
-
-	// BFS from first door
+	// BFS from the first door
 	visited := make([][]bool, height)
 	for y := 0; y < height; y++ {
 		visited[y] = make([]bool, width)
 	}
 
-	queue := []Point{{X: startX, Y: startY}}
-	visited[startY][startX] = true
+	var queue []Point
+	for _, c := range doors[0].Cells {
+		if c.X >= 0 && c.X < width && c.Y >= 0 && c.Y < height && !visited[c.Y][c.X] {
+			visited[c.Y][c.X] = true
+			queue = append(queue, c)
+		}
+	}
 
 	// Also mark adjacent ground as visited (door might be at edge)
 	for len(queue) > 0 {
@@ -828,24 +696,27 @@ func areAllDoorsConnected(ground [][]int, width, height int, doors []DoorPositio
 	}
 
 	// Check if all other doors are reachable
-	for i := 1; i < len(doors); i++ {
-		doorX, doorY := getDoorPosition(doors[i], width, height)
-
-		// Check if door position or any adjacent cell is visited
+	for _, door := range doors[1:] {
+		// Check if a door cell or any adjacent cell is visited
 		reachable := false
-		checkPositions := []Point{
-			{doorX, doorY},
-			{doorX - 1, doorY}, {doorX + 1, doorY},
-			{doorX, doorY - 1}, {doorX, doorY + 1},
-		}
+		for _, c := range door.Cells {
+			checkPositions := []Point{
+				{c.X, c.Y},
+				{c.X - 1, c.Y}, {c.X + 1, c.Y},
+				{c.X, c.Y - 1}, {c.X, c.Y + 1},
+			}
 
-		for _, pos := range checkPositions {
-			if pos.X >= 0 && pos.X < width && pos.Y >= 0 && pos.Y < height {
-				if visited[pos.Y][pos.X] {
-					reachable = true
-					break
+			for _, pos := range checkPositions {
+				if pos.X >= 0 && pos.X < width && pos.Y >= 0 && pos.Y < height {
+					if visited[pos.Y][pos.X] {
+						reachable = true
+						break
+					}
 				}
 			}
+			if reachable {
+				break
+			}
 		}
 
 		if !reachable {
@@ -1033,58 +904,19 @@ func bridgeTouchesExistingGround(bx, by, bw, bh int, excludeIsland Island, allIs
 // Rail-aware validation variants
 // ============================================================================
 
-func isValidStaticPositionWithRail(pos Point, ground, softEdge, bridge, rail, staticLayer [][]int, forbiddenCells map[Point]bool, width, height int) bool {
-	// Check all 4 cells of the 2x2 area
-	for dy := 0; dy < staticSize; dy++ {
-		for dx := 0; dx < staticSize; dx++ {
-			x := pos.X + dx
-			y := pos.Y + dy
-
-			// Check bounds
-			if x < 0 || x >= width || y < 0 || y >= height {
-				return false
-			}
-
-			// Must be on ground
-			if ground[y][x] != 1 {
-				return false
-			}
-
-			// Cannot be on bridge
-			if bridge[y][x] == 1 {
-				return false
-			}
-
-			// Cannot be on soft edge
-			if softEdge[y][x] == 1 {
-				return false
-			}
-
-			// Cannot be on rail
-			if rail[y][x] == 1 {
-				return false
-			}
-
-			// Cannot be on existing static
-			if staticLayer[y][x] == 1 {
-				return false
-			}
-
-			// Cannot be in forbidden zone (doors)
-			if forbiddenCells[Point{X: x, Y: y}] {
-				return false
-			}
-		}
-	}
-	return true
+// isValidStaticPositionWithRail checks the 2x2 area at pos like isValidStaticPosition,
+// but leaves touching statics to the caller, which filters them out of its
+// candidates after each placement
+func isValidStaticPositionWithRail(pos Point, lc *LayerContext, blocked *Grid) bool {
+	return staticFits(pos, lc, blocked)
 }
 
-func findValidStaticPositionsWithRail(ground, softEdge, bridge, rail, staticLayer [][]int, forbiddenCells map[Point]bool, width, height int) []Point {
+func findValidStaticPositionsWithRail(lc *LayerContext, blocked *Grid) []Point {
 	var positions []Point
-	for y := 0; y <= height-staticSize; y++ {
-		for x := 0; x <= width-staticSize; x++ {
+	for y := 0; y <= lc.Height-staticSize; y++ {
+		for x := 0; x <= lc.Width-staticSize; x++ {
 			pos := Point{X: x, Y: y}
-			if isValidStaticPositionWithRail(pos, ground, softEdge, bridge, rail, staticLayer, forbiddenCells, width, height) {
+			if isValidStaticPositionWithRail(pos, lc, blocked) {
 				positions = append(positions, pos)
 			}
 		}
@@ -1092,7 +924,7 @@ func findValidStaticPositionsWithRail(ground, softEdge, bridge, rail, staticLaye
 	return positions
 }
 
-func isValidTurretPositionWithRail(pos Point, ground, softEdge, bridge, rail, staticLayer, turretLayer [][]int, doorPositions map[DoorPosition]Point, width, height int) bool {
+func isValidTurretPositionWithRail(pos Point, ground, softEdge, bridge, rail, staticLayer, turretLayer [][]int, doorPositions []DoorSite, masks *ConstraintMasks, width, height int) bool {
 	x, y := pos.X, pos.Y
 
 	if x < 0 || x >= width || y < 0 || y >= height {
@@ -1129,9 +961,14 @@ func isValidTurretPositionWithRail(pos Point, ground, softEdge, bridge, rail, st
 		return false
 	}
 
+	// Must not be on a designer noEnemy cell
+	if masks.noEnemyAt(x, y) {
+		return false
+	}
+
 	// Must be at least turretMinDoorDistance cells away from doors
 	for _, doorPos := range doorPositions {
-		if manhattanDistance(pos, doorPos) < turretMinDoorDistance {
+		if distanceToDoor(pos, doorPos) < turretMinDoorDistance {
 			return false
 		}
 	}
@@ -1139,14 +976,14 @@ func isValidTurretPositionWithRail(pos Point, ground, softEdge, bridge, rail, st
 	return true
 }
 
-func findValidTurretPositionsWithRail(ground, softEdge, bridge, rail, staticLayer, turretLayer [][]int, doorPositions map[DoorPosition]Point, width, height int) []Point {
+func findValidTurretPositionsWithRail(ground, softEdge, bridge, rail, staticLayer, turretLayer [][]int, doorPositions []DoorSite, masks *ConstraintMasks, width, height int) []Point {
 	var positions []Point
 	forbiddenCells := getDoorForbiddenCells(doorPositions, width, height)
 
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			pos := Point{X: x, Y: y}
-			if isValidTurretPositionWithRail(pos, ground, softEdge, bridge, rail, staticLayer, turretLayer, doorPositions, width, height) && !forbiddenCells[pos] {
+			if isValidTurretPositionWithRail(pos, ground, softEdge, bridge, rail, staticLayer, turretLayer, doorPositions, masks, width, height) && !forbiddenCells[pos] {
 				positions = append(positions, pos)
 			}
 		}
@@ -1154,7 +991,7 @@ func findValidTurretPositionsWithRail(ground, softEdge, bridge, rail, staticLaye
 	return positions
 }
 
-func isValidMobGroundPositionWithRail(pos Point, ground, softEdge, bridge, rail, staticLayer, turretLayer, mobGroundLayer [][]int, doorPositions map[DoorPosition]Point, width, height int) bool {
+func isValidMobGroundPositionWithRail(pos Point, ground, softEdge, bridge, rail, staticLayer, turretLayer, mobGroundLayer [][]int, doorPositions []DoorSite, masks *ConstraintMasks, width, height int) bool {
 	x, y := pos.X, pos.Y
 
 	if x < 0 || x >= width || y < 0 || y >= height {
@@ -1196,9 +1033,14 @@ func isValidMobGroundPositionWithRail(pos Point, ground, softEdge, bridge, rail,
 		return false
 	}
 
+	// Must not be on a designer noEnemy cell
+	if masks.noEnemyAt(x, y) {
+		return false
+	}
+
 	// Must be at least mobGroundMinDoorDistance cells away from doors
 	for _, doorPos := range doorPositions {
-		if manhattanDistance(pos, doorPos) < mobGroundMinDoorDistance {
+		if distanceToDoor(pos, doorPos) < mobGroundMinDoorDistance {
 			return false
 		}
 	}
@@ -1206,14 +1048,14 @@ func isValidMobGroundPositionWithRail(pos Point, ground, softEdge, bridge, rail,
 	return true
 }
 
-func findValidMobGroundPositionsWithRail(ground, softEdge, bridge, rail, staticLayer, turretLayer, mobGroundLayer [][]int, doorPositions map[DoorPosition]Point, width, height int) []Point {
+func findValidMobGroundPositionsWithRail(ground, softEdge, bridge, rail, staticLayer, turretLayer, mobGroundLayer [][]int, doorPositions []DoorSite, masks *ConstraintMasks, width, height int) []Point {
 	var positions []Point
 	forbiddenCells := getDoorForbiddenCells(doorPositions, width, height)
 
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			pos := Point{X: x, Y: y}
-			if isValidMobGroundPositionWithRail(pos, ground, softEdge, bridge, rail, staticLayer, turretLayer, mobGroundLayer, doorPositions, width, height) && !forbiddenCells[pos] {
+			if isValidMobGroundPositionWithRail(pos, ground, softEdge, bridge, rail, staticLayer, turretLayer, mobGroundLayer, doorPositions, masks, width, height) && !forbiddenCells[pos] {
 				positions = append(positions, pos)
 			}
 		}
@@ -1299,18 +1141,20 @@ func removeSinglePosition(positions []Point, toRemove Point) []Point {
 // Unified door forbidden zone (radius-based)
 // ============================================================================
 
-// getDoorForbiddenCellsRadius returns all cells within Manhattan distance `radius` of any door
-func getDoorForbiddenCellsRadius(doorPositions map[DoorPosition]Point, width, height, radius int) map[Point]bool {
+// getDoorForbiddenCellsRadius returns all cells within Manhattan distance `radius` of any door cell
+func getDoorForbiddenCellsRadius(doorPositions []DoorSite, width, height, radius int) map[Point]bool {
 	forbidden := make(map[Point]bool)
 	for _, doorPos := range doorPositions {
-		for dy := -radius; dy <= radius; dy++ {
-			for dx := -radius; dx <= radius; dx++ {
-				if abs(dx)+abs(dy) > radius {
-					continue
-				}
-				x, y := doorPos.X+dx, doorPos.Y+dy
-				if x >= 0 && x < width && y >= 0 && y < height {
-					forbidden[Point{X: x, Y: y}] = true
+		for _, cell := range doorPos.Cells {
+			for dy := -radius; dy <= radius; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					if abs(dx)+abs(dy) > radius {
+						continue
+					}
+					x, y := cell.X+dx, cell.Y+dy
+					if x >= 0 && x < width && y >= 0 && y < height {
+						forbidden[Point{X: x, Y: y}] = true
+					}
 				}
 			}
 		}
@@ -1322,26 +1166,24 @@ func getDoorForbiddenCellsRadius(doorPositions map[DoorPosition]Point, width, he
 // New enemy layer validation (Chaser / Zoner / DPS)
 // ============================================================================
 
-// isValidEnemyPosition checks if a cell is valid for enemy placement (Chaser/Zoner/DPS)
-func isValidEnemyPosition(pos Point, ground, softEdge, bridge, rail, staticLayer [][]int, forbidden map[Point]bool, width, height int) bool {
+// isValidEnemyPosition checks if a cell is valid for enemy placement (Chaser/Zoner/DPS).
+// forbidden holds the door forbidden zone.
+func isValidEnemyPosition(pos Point, lc *LayerContext, forbidden *Grid, masks *ConstraintMasks) bool {
 	x, y := pos.X, pos.Y
-	if x < 0 || x >= width || y < 0 || y >= height {
+	// Must be on ground (out-of-bounds cells are never ground)
+	if !lc.Ground.Has(x, y) {
 		return false
 	}
-	// Must be on ground
-	if ground[y][x] != 1 {
-		return false
-	}
-	// Cannot be on softEdge, bridge, static
-	if softEdge[y][x] != 0 || bridge[y][x] != 0 || staticLayer[y][x] != 0 {
+	// Cannot be on softEdge, bridge, static or rail
+	if lc.SoftEdge.Has(x, y) || lc.Bridge.Has(x, y) || lc.Static.Has(x, y) || lc.Rail.Has(x, y) {
 		return false
 	}
-	// Cannot be on rail (if exists)
-	if rail != nil && rail[y][x] != 0 {
+	// Cannot be in door forbidden zone
+	if forbidden.Has(x, y) {
 		return false
 	}
-	// Cannot be in door forbidden zone
-	if forbidden[pos] {
+	// Cannot be on a designer noEnemy cell
+	if masks.noEnemyAt(x, y) {
 		return false
 	}
 	return true
@@ -1350,7 +1192,7 @@ func isValidEnemyPosition(pos Point, ground, softEdge, bridge, rail, staticLayer
 // pickFromTopN randomly selects one candidate from the top fraction of a sorted slice.
 // fraction is 0.0-1.0, minN is the minimum pool size.
 // Returns the selected point and its index in the slice.
-func pickFromTopN(candidates []Point, fraction float64, minN int) (Point, int) {
+func pickFromTopN(rng *rand.Rand, candidates []Point, fraction float64, minN int) (Point, int) {
 	n := int(float64(len(candidates)) * fraction)
 	if n < minN {
 		n = minN
@@ -1358,7 +1200,7 @@ func pickFromTopN(candidates []Point, fraction float64, minN int) (Point, int) {
 	if n > len(candidates) {
 		n = len(candidates)
 	}
-	idx := rand.Intn(n)
+	idx := rng.Intn(n)
 	return candidates[idx], idx
 }
 
@@ -1399,51 +1241,51 @@ func touchesLayer(pos Point, layer [][]int, width, height int) bool {
 // ============================================================================
 
 // isValidMobAirPositionNew checks if a cell is valid for mob air with new enemy layers
-func isValidMobAirPositionNew(pos Point, ground, softEdge, bridge, staticLayer, zonerLayer, chaserLayer, dpsLayer, mobAirLayer [][]int,
-	doorPositions map[DoorPosition]Point, width, height int) bool {
-
+func isValidMobAirPositionNew(pos Point, lc *LayerContext, masks *ConstraintMasks) bool {
 	x, y := pos.X, pos.Y
-	if x < 0 || x >= width || y < 0 || y >= height {
-		return false
-	}
 
 	// Must be at least 2 cells away from room edges
-	if x < mobAirMinEdgeDistance || x >= width-mobAirMinEdgeDistance ||
-		y < mobAirMinEdgeDistance || y >= height-mobAirMinEdgeDistance {
+	if x < mobAirMinEdgeDistance || x >= lc.Width-mobAirMinEdgeDistance ||
+		y < mobAirMinEdgeDistance || y >= lc.Height-mobAirMinEdgeDistance {
 		return false
 	}
 
 	// No ground requirement - flying mobs can spawn anywhere
 
 	// Must not overlap with other layers
-	if softEdge[y][x] != 0 || bridge[y][x] != 0 || staticLayer[y][x] != 0 ||
-		zonerLayer[y][x] != 0 || chaserLayer[y][x] != 0 || dpsLayer[y][x] != 0 {
+	if lc.SoftEdge.Has(x, y) || lc.Bridge.Has(x, y) || lc.Static.Has(x, y) ||
+		lc.Zoner.Has(x, y) || lc.Chaser.Has(x, y) || lc.DPS.Has(x, y) {
 		return false
 	}
 
 	// Must not already have mob air
-	if mobAirLayer[y][x] != 0 {
+	if lc.MobAir.Has(x, y) {
+		return false
+	}
+
+	// Must not be on a designer noEnemy cell
+	if masks.noEnemyAt(x, y) {
 		return false
 	}
 
 	// Must be at least 4 cells away from doors
-	for _, doorPos := range doorPositions {
-		if manhattanDistance(pos, doorPos) < mobAirMinDoorDistance {
+	for _, doorPos := range lc.DoorPositions {
+		if distanceToDoor(pos, doorPos) < mobAirMinDoorDistance {
 			return false
 		}
 	}
 
 	// Must not touch existing mob air (distance >= 1 means no 8-directional adjacency)
-	if touchesLayer(pos, mobAirLayer, width, height) {
+	if lc.MobAir.Touches(pos) {
 		return false
 	}
 
 	return true
 }
 
-// GenerateMobAirLayerNew generates mob air layer using new enemy layers instead of turret/mobGround
-func GenerateMobAirLayerNew(mobAirLayer, ground, softEdge, bridge, staticLayer, zonerLayer, chaserLayer, dpsLayer [][]int,
-	doorPositions map[DoorPosition]Point, width, height, targetCount int, regionFilter ...*RegionFilter) *MobAirDebugInfo {
+// GenerateMobAirLayerNew places mob air into lc.MobAir using new enemy layers instead of turret/mobGround.
+// Ranking the candidates is quadratic in the room area, so it stops early once ctx is done.
+func GenerateMobAirLayerNew(ctx context.Context, lc *LayerContext, masks *ConstraintMasks, targetCount int, regionFilter ...*RegionFilter) *MobAirDebugInfo {
 
 	debug := &MobAirDebugInfo{
 		TargetCount: targetCount,
@@ -1452,13 +1294,14 @@ func GenerateMobAirLayerNew(mobAirLayer, ground, softEdge, bridge, staticLayer,
 		Misses:      []MissInfo{},
 	}
 
+	width, height := lc.Width, lc.Height
+
 	// Find valid positions
 	var candidates []Point
 	for y := 0; y < height; y++ {
 		for x := 0; x < width; x++ {
 			pos := Point{x, y}
-			if isValidMobAirPositionNew(pos, ground, softEdge, bridge, staticLayer, zonerLayer, chaserLayer, dpsLayer, mobAirLayer,
-				doorPositions, width, height) {
+			if isValidMobAirPositionNew(pos, lc, masks) {
 				candidates = append(candidates, pos)
 			}
 		}
@@ -1480,13 +1323,11 @@ func GenerateMobAirLayerNew(mobAirLayer, ground, softEdge, bridge, staticLayer,
 		for dy := -3; dy <= 3; dy++ {
 			for dx := -3; dx <= 3; dx++ {
 				nx, ny := pos.X+dx, pos.Y+dy
-				if nx >= 0 && nx < width && ny >= 0 && ny < height {
-					if zonerLayer[ny][nx] == 1 {
-						s += 2.0
-					}
-					if chaserLayer[ny][nx] == 1 {
-						s += 1.5
-					}
+				if lc.Zoner.Has(nx, ny) {
+					s += 2.0
+				}
+				if lc.Chaser.Has(nx, ny) {
+					s += 1.5
 				}
 			}
 		}
@@ -1495,6 +1336,9 @@ func GenerateMobAirLayerNew(mobAirLayer, ground, softEdge, bridge, staticLayer,
 
 	// Sort by score descending
 	for i := 0; i < len(scoredCandidates)-1; i++ {
+		if ctx.Err() != nil {
+			return debug
+		}
 		for j := i + 1; j < len(scoredCandidates); j++ {
 			if scoredCandidates[j].score > scoredCandidates[i].score {
 				scoredCandidates[i], scoredCandidates[j] = scoredCandidates[j], scoredCandidates[i]
@@ -1509,16 +1353,15 @@ func GenerateMobAirLayerNew(mobAirLayer, ground, softEdge, bridge, staticLayer,
 		}
 		pos := sc.pos
 		// Re-validate (previous placements may have invalidated)
-		if !isValidMobAirPositionNew(pos, ground, softEdge, bridge, staticLayer, zonerLayer, chaserLayer, dpsLayer, mobAirLayer,
-			doorPositions, width, height) {
+		if !isValidMobAirPositionNew(pos, lc, masks) {
 			continue
 		}
-		mobAirLayer[pos.Y][pos.X] = 1
+		lc.MobAir.Set(pos.X, pos.Y)
 		remaining--
 		debug.PlacedCount++
 
 		reason := "density_based placement"
-		if ground[pos.Y][pos.X] == 0 {
+		if !lc.Ground.Has(pos.X, pos.Y) {
 			reason += " (on void, flying)"
 		}
 		debug.Placements = append(debug.Placements, PlaceInfo{
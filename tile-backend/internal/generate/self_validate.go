@@ -0,0 +1,98 @@
+package generate
+
+import (
+	"fmt"
+	"tile-backend/internal/model"
+	"tile-backend/internal/validate"
+)
+
+const (
+	defaultValidationAttempts = 3  // attempt budget when maxValidationAttempts is not set
+	maxValidationAttempts     = 20 // hard cap on the attempt budget
+)
+
+// ValidationDebugInfo reports the strict validation every generated room goes through
+type ValidationDebugInfo struct {
+	Valid       bool                    `json:"valid"`                // Whether the returned room passed strict validation
+	Attempts    int                     `json:"attempts"`             // Rooms generated, the returned one included
+	MaxAttempts int                     `json:"maxAttempts"`          // Attempt budget that applied
+	AttemptSeed int64                   `json:"attemptSeed"`          // Seed of the returned attempt; equals the request seed when the first attempt passed
+	Violations  []model.ValidationError `json:"violations,omitempty"` // Strict validation errors of the returned room
+}
+
+// validationAttemptBudget checks a request's maxValidationAttempts and returns the
+// budget that applies
+func validationAttemptBudget(maxAttempts int) (int, error) {
+	if maxAttempts < 0 || maxAttempts > maxValidationAttempts {
+		return 0, fmt.Errorf("maxValidationAttempts must be between 0 and %d (0 = default %d)", maxValidationAttempts, defaultValidationAttempts)
+	}
+	if maxAttempts == 0 {
+		return defaultValidationAttempts, nil
+	}
+	return maxAttempts, nil
+}
+
+// generateValidated runs attempt until its room passes strict validation or the
+// budget runs out, and returns the last attempt with the outcome. The first
+// attempt uses the request seed itself, so a room that passes stays the one the
+// seed always gave; later attempt seeds are drawn from it, so the whole search
+// replays from the request seed. Generator errors are returned as they are.
+func generateValidated[T any](maxAttempts int, seed *int64,
+	attempt func(seed int64) (T, *model.TemplatePayload, error)) (T, *ValidationDebugInfo, int64, error) {
+
+	var resp T
+	budget, err := validationAttemptBudget(maxAttempts)
+	if err != nil {
+		return resp, nil, 0, err
+	}
+
+	rng, masterSeed := newRequestRand(seed)
+	info := &ValidationDebugInfo{MaxAttempts: budget}
+	attemptSeed := masterSeed
+	for info.Attempts < budget {
+		if info.Attempts > 0 {
+			attemptSeed = rng.Int63()
+		}
+		var payload *model.TemplatePayload
+		resp, payload, err = attempt(attemptSeed)
+		if err != nil {
+			return resp, nil, 0, err
+		}
+		info.Attempts++
+		info.AttemptSeed = attemptSeed
+
+		result := validate.ValidateTemplate(payload, true)
+		info.Valid = result.Valid
+		info.Violations = result.Errors
+		if result.Valid {
+			info.Violations = nil
+			break
+		}
+	}
+	return resp, info, masterSeed, nil
+}
+
+// validationError returns an error describing a room that still failed strict
+// validation on its last attempt, or nil when it passed
+func (info *ValidationDebugInfo) validationError() error {
+	if info == nil || info.Valid {
+		return nil
+	}
+	reason := "validation failed"
+	if len(info.Violations) > 0 {
+		reason = firstValidationError(&model.ValidationResult{Errors: info.Violations})
+	}
+	return fmt.Errorf("room failed strict validation in %d attempts: %s", info.Attempts, reason)
+}
+
+// validationError returns an error when the room failed strict validation. Rooms
+// from generators that do not report a validation outcome are validated here.
+func (r *RoomResult) validationError() error {
+	if r.Validation != nil {
+		return r.Validation.validationError()
+	}
+	if result := validate.ValidateTemplate(r.Payload, true); !result.Valid {
+		return fmt.Errorf("room failed strict validation: %s", firstValidationError(result))
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"tile-backend/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// selfValidateRoom returns a generated room that passes strict validation, and
+// a copy missing its static layer that does not
+func selfValidateRoom(t *testing.T) (valid, invalid *model.TemplatePayload) {
+	t.Helper()
+	seed := int64(1)
+	resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, Seed: &seed,
+	})
+	require.NoError(t, err)
+	broken := resp.Payload
+	broken.Static = nil
+	return &resp.Payload, &broken
+}
+
+func TestGenerateValidated_RetriesUntilValid(t *testing.T) {
+	valid, invalid := selfValidateRoom(t)
+	seed := int64(42)
+
+	var seeds []int64
+	run := func() (*ValidationDebugInfo, int64) {
+		seeds = seeds[:0]
+		_, info, masterSeed, err := generateValidated(0, &seed, func(s int64) (int, *model.TemplatePayload, error) {
+			seeds = append(seeds, s)
+			if len(seeds) < 3 {
+				return len(seeds), invalid, nil
+			}
+			return len(seeds), valid, nil
+		})
+		require.NoError(t, err)
+		return info, masterSeed
+	}
+
+	info, masterSeed := run()
+	assert.Equal(t, seed, masterSeed)
+	assert.True(t, info.Valid)
+	assert.Equal(t, 3, info.Attempts)
+	assert.Equal(t, defaultValidationAttempts, info.MaxAttempts)
+	assert.Empty(t, info.Violations)
+	require.Len(t, seeds, 3)
+	assert.Equal(t, seed, seeds[0], "the first attempt uses the request seed")
+	assert.NotEqual(t, seed, seeds[1])
+	assert.Equal(t, seeds[2], info.AttemptSeed)
+	assert.NoError(t, info.validationError())
+
+	// The search replays from the request seed
+	first := append([]int64(nil), seeds...)
+	run()
+	assert.Equal(t, first, seeds)
+}
+
+func TestGenerateValidated_BudgetExhausted(t *testing.T) {
+	_, invalid := selfValidateRoom(t)
+	seed := int64(42)
+	calls := 0
+	last, info, _, err := generateValidated(5, &seed, func(s int64) (int, *model.TemplatePayload, error) {
+		calls++
+		return calls, invalid, nil
+	})
+	require.NoError(t, err, "an invalid room is returned, not an error")
+	assert.Equal(t, 5, last, "the final attempt is returned")
+	assert.False(t, info.Valid)
+	assert.Equal(t, 5, info.Attempts)
+	require.NotEmpty(t, info.Violations)
+	assert.Equal(t, "static layer is missing", info.Violations[0].Reason)
+	assert.ErrorContains(t, info.validationError(), "failed strict validation in 5 attempts")
+}
+
+func TestGenerateValidated_Errors(t *testing.T) {
+	valid, _ := selfValidateRoom(t)
+	attempt := func(s int64) (int, *model.TemplatePayload, error) { return 0, valid, nil }
+
+	for _, budget := range []int{-1, maxValidationAttempts + 1} {
+		_, _, _, err := generateValidated(budget, nil, attempt)
+		assert.EqualError(t, err, "maxValidationAttempts must be between 0 and 20 (0 = default 3)", "budget %d", budget)
+	}
+
+	// Generator errors come from the request, so they are not retried
+	calls := 0
+	boom := errors.New("boom")
+	_, _, _, err := generateValidated(5, nil, func(s int64) (int, *model.TemplatePayload, error) {
+		calls++
+		return 0, nil, boom
+	})
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, 1, calls)
+}
+
+func TestGenerators_ReportValidation(t *testing.T) {
+	seed := int64(7)
+	for _, g := range Generators() {
+		t.Run(g.Info().Name, func(t *testing.T) {
+			req := g.RequestFor(RoomParams{Width: 20, Height: 14, Doors: []DoorPosition{DoorLeft, DoorRight}, StageType: "teaching"})
+			result, err := g.Generate(context.Background(), req, GenerateOptions{Seed: &seed})
+			require.NoError(t, err)
+			require.NotNil(t, result.Validation)
+			assert.True(t, result.Validation.Valid)
+			assert.Equal(t, 1, result.Validation.Attempts)
+			assert.Equal(t, seed, result.Validation.AttemptSeed)
+			assert.NoError(t, result.validationError())
+		})
+	}
+
+	// The budget is checked like any other request field
+	_, err := GenerateCave(context.Background(), CaveGenerateRequest{
+		Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, MaxValidationAttempts: maxValidationAttempts + 1,
+	})
+	assert.ErrorContains(t, err, "maxValidationAttempts")
+}
@@ -0,0 +1,278 @@
+package generate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"tile-backend/internal/model"
+)
+
+// StageConfigFile is the on-disk format of a stage config file
+type StageConfigFile struct {
+	Stages []StageConfig `json:"stages"` // Listing order of GET /stage-configs
+}
+
+// stageConfigSet is one loaded, validated set of stage configs. A set is never
+// modified after it is installed; reloads swap in a new one.
+type stageConfigSet struct {
+	order  []string
+	byType map[string]StageConfig
+}
+
+var (
+	stageConfigMu   sync.RWMutex
+	liveStageConfig = newStageConfigSet(defaultStageConfigs())
+)
+
+// placementRules lists the placement rule identifiers buildPlacementHints knows
+var placementRules = map[string]bool{
+	"": true, "start": true, "teaching": true, "building": true, "pressure": true, "peak": true, "boss": true,
+}
+
+// placementDefaults are the rule parameters used when a config leaves them zero
+var placementDefaults = map[string]PlacementParams{
+	"teaching": {DPSYRange: [2]int{5, 7}},
+	"pressure": {GroupRange: [2]int{2, 2}},
+	"peak":     {GroupRange: [2]int{2, 4}},
+}
+
+const (
+	defaultBossArenaSize         = 6
+	defaultBossArenaEdgeDistance = 3
+	maxPlacementGroups           = 4 // one group per quadrant
+	maxSpawnWaves                = 4
+)
+
+// currentStageConfigs returns the live config set
+func currentStageConfigs() *stageConfigSet {
+	stageConfigMu.RLock()
+	defer stageConfigMu.RUnlock()
+	return liveStageConfig
+}
+
+// placementParams returns the placement parameters with rule defaults filled in
+func (cfg *StageConfig) placementParams() PlacementParams {
+	params := cfg.Placement
+	defaults := placementDefaults[cfg.PlacementRule]
+	if params.DPSYRange == [2]int{} {
+		params.DPSYRange = defaults.DPSYRange
+	}
+	if params.GroupRange == [2]int{} {
+		params.GroupRange = defaults.GroupRange
+	}
+	return params
+}
+
+// bossArenaParams returns the arena size and edge distance with defaults filled in
+func (cfg *StageConfig) bossArenaParams() (size, edgeDistance int) {
+	size, edgeDistance = cfg.BossArenaSize, cfg.BossArenaEdgeDistance
+	if size == 0 {
+		size = defaultBossArenaSize
+	}
+	if edgeDistance == 0 {
+		edgeDistance = defaultBossArenaEdgeDistance
+	}
+	return size, edgeDistance
+}
+
+// ParseStageConfigs decodes and validates a stage config file. Unknown fields
+// are rejected so that typos do not silently fall back to defaults.
+func ParseStageConfigs(data []byte) ([]StageConfig, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var file StageConfigFile
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid stage config: %w", err)
+	}
+	if err := ValidateStageConfigs(file.Stages); err != nil {
+		return nil, err
+	}
+	return file.Stages, nil
+}
+
+// ValidateStageConfigs checks a full set of stage configs; returns the first problem found
+func ValidateStageConfigs(configs []StageConfig) error {
+	if len(configs) == 0 {
+		return fmt.Errorf("stage config: no stages defined")
+	}
+	seen := make(map[string]bool)
+	for i := range configs {
+		cfg := &configs[i]
+		if cfg.StageType == "" {
+			return fmt.Errorf("stage config: stage %d has no stageType", i)
+		}
+		if seen[cfg.StageType] {
+			return fmt.Errorf("stage config: duplicate stage %q", cfg.StageType)
+		}
+		seen[cfg.StageType] = true
+		if err := cfg.validate(); err != nil {
+			return fmt.Errorf("stage config: stage %q: %w", cfg.StageType, err)
+		}
+	}
+	return nil
+}
+
+// validate checks a single stage config
+func (cfg *StageConfig) validate() error {
+	ranges := []struct {
+		name string
+		r    [2]int
+	}{
+		{"chaserRange", cfg.ChaserRange},
+		{"zonerRange", cfg.ZonerRange},
+		{"dpsRange", cfg.DPSRange},
+		{"mobAirRange", cfg.MobAirRange},
+		{"staticRange", cfg.StaticRange},
+	}
+	for _, r := range ranges {
+		if r.r[0] < 0 || r.r[0] > r.r[1] {
+			return fmt.Errorf("%s [%d, %d] must satisfy 0 <= min <= max", r.name, r.r[0], r.r[1])
+		}
+	}
+
+	for _, rt := range cfg.AllowedRoomTypes {
+		if _, ok := generatorForRoomType(rt); !ok {
+			return fmt.Errorf("allowedRoomTypes: unknown room type %q", rt)
+		}
+	}
+
+	if r := cfg.DoorRestrictions; r != nil {
+		if r.MaxDoors < 0 {
+			return fmt.Errorf("doorRestrictions.maxDoors must not be negative")
+		}
+		if r.ForbidCornerPair && r.OnlyCornerPair {
+			return fmt.Errorf("doorRestrictions: forbidCornerPair and onlyCornerPair are mutually exclusive")
+		}
+		for _, d := range r.AllowedDoors {
+			if sideIndex(d) == len(doorOrder) {
+				return fmt.Errorf("doorRestrictions.allowedDoors: invalid door %q", d)
+			}
+		}
+	}
+
+	if cfg.BossArenaSize < 0 || cfg.BossArenaEdgeDistance < 0 {
+		return fmt.Errorf("bossArenaSize and bossArenaEdgeDistance must not be negative")
+	}
+
+	if !placementRules[cfg.PlacementRule] {
+		return fmt.Errorf("unknown placementRule %q", cfg.PlacementRule)
+	}
+	params := cfg.placementParams()
+	if y := params.DPSYRange; y[0] < 0 || y[0] > y[1] {
+		return fmt.Errorf("placement.dpsYRange [%d, %d] must satisfy 0 <= min <= max", y[0], y[1])
+	}
+	if g := params.GroupRange; g != [2]int{} && (g[0] < 1 || g[0] > g[1] || g[1] > maxPlacementGroups) {
+		return fmt.Errorf("placement.groupRange [%d, %d] must satisfy 1 <= min <= max <= %d", g[0], g[1], maxPlacementGroups)
+	}
+
+	if cfg.WaveCount < 0 || cfg.WaveCount > maxSpawnWaves {
+		return fmt.Errorf("waveCount %d must be between 0 and %d", cfg.WaveCount, maxSpawnWaves)
+	}
+	if len(cfg.WaveBudgets) != cfg.WaveCount {
+		return fmt.Errorf("waveBudgets must have one entry per wave (%d), got %d", cfg.WaveCount, len(cfg.WaveBudgets))
+	}
+	for i, b := range cfg.WaveBudgets {
+		if b < 1 {
+			return fmt.Errorf("waveBudgets[%d] must be at least 1, got %d", i, b)
+		}
+	}
+	return nil
+}
+
+// newStageConfigSet indexes validated configs
+func newStageConfigSet(configs []StageConfig) *stageConfigSet {
+	set := &stageConfigSet{byType: make(map[string]StageConfig, len(configs))}
+	for _, cfg := range configs {
+		set.order = append(set.order, cfg.StageType)
+		set.byType[cfg.StageType] = cfg
+	}
+	return set
+}
+
+// SetStageConfigs validates configs and makes them the live stage configs.
+// On error the live configs are left unchanged.
+func SetStageConfigs(configs []StageConfig) error {
+	if err := ValidateStageConfigs(configs); err != nil {
+		return err
+	}
+	set := newStageConfigSet(configs)
+	stageConfigMu.Lock()
+	liveStageConfig = set
+	stageConfigMu.Unlock()
+	return nil
+}
+
+// LoadStageConfigFile reads, validates and installs a stage config file. It is
+// used at startup and on every reload; on error the live configs are left
+// unchanged. Returns the number of stages loaded.
+func LoadStageConfigFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read stage config: %w", err)
+	}
+	configs, err := ParseStageConfigs(data)
+	if err != nil {
+		return 0, err
+	}
+	if err := SetStageConfigs(configs); err != nil {
+		return 0, err
+	}
+	return len(configs), nil
+}
+
+// resolveStageConfig returns the live config for a stage with the project's
+// override for it applied, or nil if the stage is unknown
+func resolveStageConfig(stageType string, overrides model.StageOverrides) *StageConfig {
+	cfg := GetStageConfig(stageType)
+	if cfg == nil {
+		return nil
+	}
+	if o, ok := overrides[stageType]; ok {
+		applyStageOverride(cfg, o)
+	}
+	return cfg
+}
+
+// applyStageOverride replaces the config values the override sets
+func applyStageOverride(cfg *StageConfig, o model.StageOverride) {
+	if o.ChaserRange != nil {
+		cfg.ChaserRange = *o.ChaserRange
+	}
+	if o.ZonerRange != nil {
+		cfg.ZonerRange = *o.ZonerRange
+	}
+	if o.DPSRange != nil {
+		cfg.DPSRange = *o.DPSRange
+	}
+	if o.MobAirRange != nil {
+		cfg.MobAirRange = *o.MobAirRange
+	}
+	if o.StaticRange != nil {
+		cfg.StaticRange = *o.StaticRange
+	}
+}
+
+// ValidateStageOverrides checks project overrides against the live stage
+// configs: every stage must exist and the overridden config must stay valid.
+func ValidateStageOverrides(overrides model.StageOverrides) error {
+	for stageType, o := range overrides {
+		cfg := GetStageConfig(stageType)
+		if cfg == nil {
+			return fmt.Errorf("unknown stage type: %s", stageType)
+		}
+		applyStageOverride(cfg, o)
+		if err := cfg.validate(); err != nil {
+			return fmt.Errorf("stage %s: %w", stageType, err)
+		}
+	}
+	return nil
+}
+
+// ResetStageConfigs restores the built-in stage configs
+func ResetStageConfigs() {
+	stageConfigMu.Lock()
+	liveStageConfig = newStageConfigSet(defaultStageConfigs())
+	stageConfigMu.Unlock()
+}
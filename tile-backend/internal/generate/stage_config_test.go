@@ -0,0 +1,137 @@
+package generate
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tile-backend/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStageConfigFile_MatchesDefaults(t *testing.T) {
+	data, err := os.ReadFile("../../config/stages.json")
+	require.NoError(t, err)
+
+	configs, err := ParseStageConfigs(data)
+	require.NoError(t, err)
+	assert.Equal(t, defaultStageConfigs(), configs)
+	assert.NoError(t, ValidateStageConfigs(defaultStageConfigs()))
+}
+
+func TestParseStageConfigs_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not json", `{"stages": [`},
+		{"unknown field", `{"stages": [{"stageType": "a", "dpsRang": [1, 2]}]}`},
+		{"no stages", `{"stages": []}`},
+		{"missing stage type", `{"stages": [{"dpsRange": [1, 2]}]}`},
+		{"duplicate stage", `{"stages": [{"stageType": "a"}, {"stageType": "a"}]}`},
+		{"inverted range", `{"stages": [{"stageType": "a", "chaserRange": [3, 1]}]}`},
+		{"negative range", `{"stages": [{"stageType": "a", "dpsRange": [-1, 1]}]}`},
+		{"unknown room type", `{"stages": [{"stageType": "a", "allowedRoomTypes": ["tunnel"]}]}`},
+		{"bad door", `{"stages": [{"stageType": "a", "doorRestrictions": {"allowedDoors": ["middle"]}}]}`},
+		{"conflicting corner rules", `{"stages": [{"stageType": "a", "doorRestrictions": {"forbidCornerPair": true, "onlyCornerPair": true}}]}`},
+		{"unknown placement rule", `{"stages": [{"stageType": "a", "placementRule": "swarm"}]}`},
+		{"too many groups", `{"stages": [{"stageType": "a", "placementRule": "peak", "placement": {"groupRange": [2, 5]}}]}`},
+		{"too many waves", `{"stages": [{"stageType": "a", "waveCount": 5, "waveBudgets": [1, 1, 1, 1, 1]}]}`},
+		{"missing wave budget", `{"stages": [{"stageType": "a", "waveCount": 2, "waveBudgets": [8]}]}`},
+		{"budgets without waves", `{"stages": [{"stageType": "a", "waveBudgets": [8]}]}`},
+		{"empty wave budget", `{"stages": [{"stageType": "a", "waveCount": 2, "waveBudgets": [8, 0]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseStageConfigs([]byte(tt.data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSetStageConfigs_LiveReload(t *testing.T) {
+	defer ResetStageConfigs()
+
+	configs := defaultStageConfigs()
+	for i := range configs {
+		if configs[i].StageType == "teaching" {
+			configs[i].DPSRange = [2]int{1, 1}
+		}
+	}
+	configs = append(configs, StageConfig{StageType: "gauntlet", AllowedRoomTypes: []string{"full"}, ChaserRange: [2]int{3, 3}, PlacementRule: "peak"})
+	require.NoError(t, SetStageConfigs(configs))
+
+	assert.Equal(t, [2]int{1, 1}, GetStageConfig("teaching").DPSRange)
+	listed := GetAllStageConfigs()
+	assert.Equal(t, "gauntlet", listed[len(listed)-1].StageType)
+	for _, cfg := range listed {
+		assert.NotEqual(t, "start", cfg.StageType, "hidden stages are not listed")
+	}
+
+	// A new stage is usable right away, with the rule's default parameters
+	seed := int64(3)
+	resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, StageType: "gauntlet", Seed: &seed,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, countCells(resp.Payload.Chaser))
+	assert.False(t, stageShapeCompat("gauntlet", "bridge"))
+
+	// An invalid config leaves the live one in place
+	assert.Error(t, SetStageConfigs([]StageConfig{{StageType: "teaching", DPSRange: [2]int{2, 1}}}))
+	assert.NotNil(t, GetStageConfig("gauntlet"))
+}
+
+func TestLoadStageConfigFile(t *testing.T) {
+	defer ResetStageConfigs()
+
+	path := filepath.Join(t.TempDir(), "stages.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"stages": [{"stageType": "solo", "dpsRange": [1, 2]}]}`), 0o644))
+	n, err := LoadStageConfigFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Nil(t, GetStageConfig("teaching"))
+	assert.Equal(t, [2]int{1, 2}, GetStageConfig("solo").DPSRange)
+
+	// A broken file keeps the previous configs
+	require.NoError(t, os.WriteFile(path, []byte(`{"stages": [{"stageType": "solo", "dpsRange": [2, 1]}]}`), 0o644))
+	_, err = LoadStageConfigFile(path)
+	assert.Error(t, err)
+	assert.NotNil(t, GetStageConfig("solo"))
+
+	_, err = LoadStageConfigFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestValidateAndApplyStage_ProjectOverrides(t *testing.T) {
+	dps := [2]int{9, 9}
+	overrides := model.StageOverrides{"pressure": {DPSRange: &dps}}
+	ground := createEmptyLayer(20, 12)
+
+	result, err := ValidateAndApplyStage(rand.New(rand.NewSource(1)), "pressure", "full", overrides, []DoorPosition{DoorLeft, DoorRight}, ground, 20, 12)
+	require.NoError(t, err)
+	assert.Equal(t, 9, result.DPSCount)
+	assert.Equal(t, 1, result.ZonerCount, "values without an override keep the global config")
+
+	// The global config is untouched
+	assert.Equal(t, [2]int{4, 6}, GetStageConfig("pressure").DPSRange)
+
+	// Overrides for other stages do not apply
+	result, err = ValidateAndApplyStage(rand.New(rand.NewSource(1)), "teaching", "full", overrides, []DoorPosition{DoorLeft, DoorRight}, ground, 20, 12)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, result.DPSCount, 3)
+}
+
+func TestValidateStageOverrides(t *testing.T) {
+	good := [2]int{6, 8}
+	bad := [2]int{5, 2}
+	assert.NoError(t, ValidateStageOverrides(model.StageOverrides{"pressure": {DPSRange: &good}}))
+	assert.NoError(t, ValidateStageOverrides(nil))
+	assert.Error(t, ValidateStageOverrides(model.StageOverrides{"pressure": {ChaserRange: &bad}}))
+	assert.Error(t, ValidateStageOverrides(model.StageOverrides{"chapter3": {DPSRange: &good}}))
+}
@@ -8,24 +8,37 @@ import (
 
 // StageConfig defines enemy count ranges and constraints for a stage type
 type StageConfig struct {
-	StageType        string
-	AllowedRoomTypes []string // empty = all allowed
-	DoorRestrictions *DoorRestriction
-	ChaserRange      [2]int // [min, max]
-	ZonerRange       [2]int
-	DPSRange         [2]int
-	MobAirRange      [2]int
-	StaticRange      [2]int // [0,0] means use request value
-	BossArena        bool   // requires 6x6 clear center area
-	PlacementRule    string // placement rule identifier
+	StageType             string           `json:"stageType"`
+	AllowedRoomTypes      []string         `json:"allowedRoomTypes"` // empty = all allowed
+	DoorRestrictions      *DoorRestriction `json:"doorRestrictions,omitempty"`
+	ChaserRange           [2]int           `json:"chaserRange"` // [min, max]
+	ZonerRange            [2]int           `json:"zonerRange"`
+	DPSRange              [2]int           `json:"dpsRange"`
+	MobAirRange           [2]int           `json:"mobAirRange"`
+	StaticRange           [2]int           `json:"staticRange"`                     // [0,0] means use request value
+	BossArena             bool             `json:"bossArena"`                       // requires a clear square area in the center
+	BossArenaSize         int              `json:"bossArenaSize,omitempty"`         // side of the arena square (default 6)
+	BossArenaEdgeDistance int              `json:"bossArenaEdgeDistance,omitempty"` // min distance of the arena from the edges (default 3)
+	PlacementRule         string           `json:"placementRule"`                   // placement rule identifier
+	Placement             PlacementParams  `json:"placement"`                       // placement rule parameters
+	WaveCount             int              `json:"waveCount,omitempty"`             // number of spawn waves (0 = all enemies spawn at once)
+	WaveBudgets           []int            `json:"waveBudgets,omitempty"`           // max enemies per wave, one entry per wave
+	Hidden                bool             `json:"hidden,omitempty"`                // usable, but not listed for the frontend
+}
+
+// PlacementParams tunes a stage's placement rule. Zero values take the rule's
+// defaults (see placementDefaults).
+type PlacementParams struct {
+	DPSYRange  [2]int `json:"dpsYRange"`  // teaching: rows DPS may use [min, max]
+	GroupRange [2]int `json:"groupRange"` // pressure, peak: number of enemy groups [min, max], 1-4
 }
 
 // DoorRestriction defines door open constraints for a stage
 type DoorRestriction struct {
-	ForbidCornerPair bool           // forbid 2-door diagonal combos (left+top, left+bottom, etc.)
-	OnlyCornerPair   bool           // only allow 2-door diagonal combos or 1-door
-	MaxDoors         int            // 0 = no limit
-	AllowedDoors     []DoorPosition // if non-empty, only these doors are allowed
+	ForbidCornerPair bool           `json:"forbidCornerPair,omitempty"` // forbid 2-door diagonal combos (left+top, left+bottom, etc.)
+	OnlyCornerPair   bool           `json:"onlyCornerPair,omitempty"`   // only allow 2-door diagonal combos or 1-door
+	MaxDoors         int            `json:"maxDoors,omitempty"`         // 0 = no limit
+	AllowedDoors     []DoorPosition `json:"allowedDoors,omitempty"`     // if non-empty, only these doors are allowed
 }
 
 // StagePlacementHints tells generators how to place enemies for a specific stage
@@ -43,6 +56,10 @@ type StagePlacementHints struct {
 	// Grouping
 	GroupCount int              // 0 = no grouping, just use default placement
 	Groups     []PlacementGroup // if GroupCount > 0, defines how enemies are split per group
+
+	// Spawn waves
+	WaveCount   int   // 0 = no waves
+	WaveBudgets []int // max enemies per wave
 }
 
 // PlacementGroup defines enemy allocation for one spatial group
@@ -69,6 +86,17 @@ const (
 	RegionBottomRight                    // bottom-right quadrant
 )
 
+// regionNames are the debug names of the group regions
+var regionNames = map[GroupRegion]string{
+	RegionFull: "full", RegionTop: "top", RegionBottom: "bottom", RegionLeft: "left", RegionRight: "right",
+	RegionTopLeft: "topLeft", RegionTopRight: "topRight", RegionBottomLeft: "bottomLeft", RegionBottomRight: "bottomRight",
+}
+
+// String returns the region's debug name
+func (r GroupRegion) String() string {
+	return regionNames[r]
+}
+
 // GetRegionBounds returns the y and x bounds [minY, maxY, minX, maxX] for a region
 func GetRegionBounds(region GroupRegion, width, height int) (minY, maxY, minX, maxX int) {
 	midY := height / 2
@@ -113,121 +141,118 @@ type BossArenaInfo struct {
 	Width, Height int
 }
 
-// StageConfigJSON is the JSON-friendly version for the frontend API
-type StageConfigJSON struct {
-	StageType        string   `json:"stageType"`
-	AllowedRoomTypes []string `json:"allowedRoomTypes"`
-	ChaserRange      [2]int   `json:"chaserRange"`
-	ZonerRange       [2]int   `json:"zonerRange"`
-	DPSRange         [2]int   `json:"dpsRange"`
-	MobAirRange      [2]int   `json:"mobAirRange"`
-	BossArena        bool     `json:"bossArena"`
-}
-
-var stageConfigs = map[string]StageConfig{
-	model.StageStart: {
-		StageType:        model.StageStart,
-		DoorRestrictions: &DoorRestriction{MaxDoors: 1, AllowedDoors: []DoorPosition{DoorRight}},
-		DPSRange:         [2]int{0, 0},
-		ChaserRange:      [2]int{0, 0},
-		ZonerRange:       [2]int{0, 0},
-		MobAirRange:      [2]int{0, 0},
-		PlacementRule:    "start",
-	},
-	model.StageTeaching: {
-		StageType:     model.StageTeaching,
-		DPSRange:      [2]int{2, 3},
-		ChaserRange:   [2]int{0, 0},
-		ZonerRange:    [2]int{0, 0},
-		MobAirRange:   [2]int{0, 0},
-		PlacementRule: "teaching",
-	},
-	model.StageBuilding: {
-		StageType:     model.StageBuilding,
-		DPSRange:      [2]int{2, 3},
-		ChaserRange:   [2]int{2, 3},
-		ZonerRange:    [2]int{0, 0},
-		MobAirRange:   [2]int{0, 0},
-		PlacementRule: "building",
-	},
-	model.StagePressure: {
-		StageType:        model.StagePressure,
-		AllowedRoomTypes: []string{"full", "platform"}, // not bridge
-		DPSRange:         [2]int{4, 6},
-		ChaserRange:      [2]int{6, 8},
-		ZonerRange:       [2]int{1, 1},
-		MobAirRange:      [2]int{2, 4},
-		PlacementRule:    "pressure",
-	},
-	model.StagePeak: {
-		StageType:        model.StagePeak,
-		AllowedRoomTypes: []string{"full"}, // only full
-		DoorRestrictions: &DoorRestriction{ForbidCornerPair: true},
-		DPSRange:         [2]int{6, 12},
-		ChaserRange:      [2]int{6, 8},
-		ZonerRange:       [2]int{2, 3},
-		MobAirRange:      [2]int{2, 4},
-		PlacementRule:    "peak",
-	},
-	model.StageRelease: {
-		StageType:     model.StageRelease,
-		DPSRange:      [2]int{0, 2},
-		ChaserRange:   [2]int{0, 0},
-		ZonerRange:    [2]int{0, 0},
-		MobAirRange:   [2]int{0, 0},
-		PlacementRule: "teaching", // same as teaching
-	},
-	model.StageBoss: {
-		StageType:        model.StageBoss,
-		AllowedRoomTypes: []string{"full", "platform"}, // not bridge
-		DoorRestrictions: &DoorRestriction{OnlyCornerPair: true, MaxDoors: 2},
-		DPSRange:         [2]int{0, 0},
-		ChaserRange:      [2]int{0, 0},
-		ZonerRange:       [2]int{0, 0},
-		MobAirRange:      [2]int{0, 0},
-		BossArena:        true,
-		PlacementRule:    "boss",
-	},
+// defaultStageConfigs returns the built-in stage configs, used until a config
+// file is loaded. config/stages.json holds the same values.
+func defaultStageConfigs() []StageConfig {
+	return []StageConfig{
+		{
+			StageType:        model.StageStart,
+			DoorRestrictions: &DoorRestriction{MaxDoors: 1, AllowedDoors: []DoorPosition{DoorRight}},
+			DPSRange:         [2]int{0, 0},
+			ChaserRange:      [2]int{0, 0},
+			ZonerRange:       [2]int{0, 0},
+			MobAirRange:      [2]int{0, 0},
+			PlacementRule:    "start",
+			Hidden:           true,
+		},
+		{
+			StageType:     model.StageTeaching,
+			DPSRange:      [2]int{2, 3},
+			ChaserRange:   [2]int{0, 0},
+			ZonerRange:    [2]int{0, 0},
+			MobAirRange:   [2]int{0, 0},
+			PlacementRule: "teaching",
+			Placement:     PlacementParams{DPSYRange: [2]int{5, 7}},
+		},
+		{
+			StageType:     model.StageBuilding,
+			DPSRange:      [2]int{2, 3},
+			ChaserRange:   [2]int{2, 3},
+			ZonerRange:    [2]int{0, 0},
+			MobAirRange:   [2]int{0, 0},
+			PlacementRule: "building",
+		},
+		{
+			StageType:        model.StagePressure,
+			AllowedRoomTypes: []string{"full", "platform", "cave"}, // not bridge
+			DPSRange:         [2]int{4, 6},
+			ChaserRange:      [2]int{6, 8},
+			ZonerRange:       [2]int{1, 1},
+			MobAirRange:      [2]int{2, 4},
+			PlacementRule:    "pressure",
+			Placement:        PlacementParams{GroupRange: [2]int{2, 2}},
+			WaveCount:        2,
+			WaveBudgets:      []int{10, 10},
+		},
+		{
+			StageType:        model.StagePeak,
+			AllowedRoomTypes: []string{"full"}, // only full
+			DoorRestrictions: &DoorRestriction{ForbidCornerPair: true},
+			DPSRange:         [2]int{6, 12},
+			ChaserRange:      [2]int{6, 8},
+			ZonerRange:       [2]int{2, 3},
+			MobAirRange:      [2]int{2, 4},
+			PlacementRule:    "peak",
+			Placement:        PlacementParams{GroupRange: [2]int{2, 4}},
+			WaveCount:        3,
+			WaveBudgets:      []int{10, 10, 10},
+		},
+		{
+			StageType:     model.StageRelease,
+			DPSRange:      [2]int{0, 2},
+			ChaserRange:   [2]int{0, 0},
+			ZonerRange:    [2]int{0, 0},
+			MobAirRange:   [2]int{0, 0},
+			PlacementRule: "teaching", // same as teaching
+			Placement:     PlacementParams{DPSYRange: [2]int{5, 7}},
+		},
+		{
+			StageType:             model.StageBoss,
+			AllowedRoomTypes:      []string{"full", "platform"}, // not bridge
+			DoorRestrictions:      &DoorRestriction{OnlyCornerPair: true, MaxDoors: 2},
+			DPSRange:              [2]int{0, 0},
+			ChaserRange:           [2]int{0, 0},
+			ZonerRange:            [2]int{0, 0},
+			MobAirRange:           [2]int{0, 0},
+			BossArena:             true,
+			BossArenaSize:         6,
+			BossArenaEdgeDistance: 3,
+			PlacementRule:         "boss",
+		},
+	}
 }
 
-// GetStageConfig returns the config for a stage type, or nil if not found
+// GetStageConfig returns the live config for a stage type, or nil if not found
 func GetStageConfig(stageType string) *StageConfig {
-	cfg, ok := stageConfigs[stageType]
+	set := currentStageConfigs()
+	cfg, ok := set.byType[stageType]
 	if !ok {
 		return nil
 	}
 	return &cfg
 }
 
-// GetAllStageConfigs returns all stage configs as JSON-friendly structs for the frontend
-func GetAllStageConfigs() []StageConfigJSON {
-	order := []string{
-		model.StageTeaching, model.StageBuilding, model.StagePressure,
-		model.StagePeak, model.StageRelease, model.StageBoss,
-	}
-	var result []StageConfigJSON
-	for _, st := range order {
-		cfg := stageConfigs[st]
-		result = append(result, StageConfigJSON{
-			StageType:        cfg.StageType,
-			AllowedRoomTypes: cfg.AllowedRoomTypes,
-			ChaserRange:      cfg.ChaserRange,
-			ZonerRange:       cfg.ZonerRange,
-			DPSRange:         cfg.DPSRange,
-			MobAirRange:      cfg.MobAirRange,
-			BossArena:        cfg.BossArena,
-		})
+// GetAllStageConfigs returns the live configs of the listed (non-hidden) stages,
+// in file order, for the frontend
+func GetAllStageConfigs() []StageConfig {
+	set := currentStageConfigs()
+	result := make([]StageConfig, 0, len(set.order))
+	for _, st := range set.order {
+		if cfg := set.byType[st]; !cfg.Hidden {
+			result = append(result, cfg)
+		}
 	}
 	return result
 }
 
 // ValidateAndApplyStage validates stage constraints and returns adjusted enemy counts + placement hints.
-func ValidateAndApplyStage(stageType, roomType string, doors []DoorPosition, ground [][]int, width, height int) (*StageValidationResult, error) {
+// Project overrides for the stage, if any, replace the matching config values.
+func ValidateAndApplyStage(rng *rand.Rand, stageType, roomType string, overrides model.StageOverrides, doors []DoorPosition, ground [][]int, width, height int) (*StageValidationResult, error) {
 	if stageType == "" {
 		return &StageValidationResult{Valid: true}, nil
 	}
 
-	cfg := GetStageConfig(stageType)
+	cfg := resolveStageConfig(stageType, overrides)
 	if cfg == nil {
 		return nil, fmt.Errorf("unknown stage type: %s", stageType)
 	}
@@ -254,10 +279,10 @@ func ValidateAndApplyStage(stageType, roomType string, doors []DoorPosition, gro
 	}
 
 	// Generate random counts within ranges
-	chaserCount := randRange(cfg.ChaserRange[0], cfg.ChaserRange[1])
-	zonerCount := randRange(cfg.ZonerRange[0], cfg.ZonerRange[1])
-	dpsCount := randRange(cfg.DPSRange[0], cfg.DPSRange[1])
-	mobAirCount := randRange(cfg.MobAirRange[0], cfg.MobAirRange[1])
+	chaserCount := randRange(rng, cfg.ChaserRange[0], cfg.ChaserRange[1])
+	zonerCount := randRange(rng, cfg.ZonerRange[0], cfg.ZonerRange[1])
+	dpsCount := randRange(rng, cfg.DPSRange[0], cfg.DPSRange[1])
+	mobAirCount := randRange(rng, cfg.MobAirRange[0], cfg.MobAirRange[1])
 
 	result := &StageValidationResult{
 		Valid:       true,
@@ -268,13 +293,14 @@ func ValidateAndApplyStage(stageType, roomType string, doors []DoorPosition, gro
 	}
 
 	// Build placement hints based on stage
-	result.PlacementHints = buildPlacementHints(cfg, chaserCount, zonerCount, dpsCount, mobAirCount, width, height)
+	result.PlacementHints = buildPlacementHints(rng, cfg, chaserCount, zonerCount, dpsCount, mobAirCount, width, height)
 
 	// Boss arena check
 	if cfg.BossArena {
-		arena := findBossArena(ground, width, height)
+		size, edge := cfg.bossArenaParams()
+		arena := findBossArena(ground, width, height, size, edge)
 		if arena == nil {
-			return nil, fmt.Errorf("stage %s requires a 6x6 clear area in center (distance > 3 from edges), not found", stageType)
+			return nil, fmt.Errorf("stage %s requires a %dx%d clear area in center (distance > %d from edges), not found", stageType, size, size, edge)
 		}
 		result.BossArena = arena
 	}
@@ -283,13 +309,14 @@ func ValidateAndApplyStage(stageType, roomType string, doors []DoorPosition, gro
 }
 
 // buildPlacementHints creates stage-specific placement hints
-func buildPlacementHints(cfg *StageConfig, chaserCount, zonerCount, dpsCount, mobAirCount, width, height int) *StagePlacementHints {
-	hints := &StagePlacementHints{}
+func buildPlacementHints(rng *rand.Rand, cfg *StageConfig, chaserCount, zonerCount, dpsCount, mobAirCount, width, height int) *StagePlacementHints {
+	hints := &StagePlacementHints{WaveCount: cfg.WaveCount, WaveBudgets: cfg.WaveBudgets}
+	params := cfg.placementParams()
 
 	switch cfg.PlacementRule {
 	case "teaching":
-		// DPS only, restricted to y ∈ [5,7]
-		hints.DPSYRange = [2]int{5, 7}
+		// DPS only, restricted to the configured rows (default y ∈ [5,7])
+		hints.DPSYRange = params.DPSYRange
 
 	case "building":
 		// Chaser: symmetric left-right, center area
@@ -298,16 +325,17 @@ func buildPlacementHints(cfg *StageConfig, chaserCount, zonerCount, dpsCount, mo
 		// DPS: default placement (no special constraint)
 
 	case "pressure":
-		// Zoner central, split into 2 groups
+		// Zoner central, split into groups (default 2)
 		hints.ZonerCentral = true
-		hints.GroupCount = 2
-		// Split enemies into 2 groups, pick top/bottom or left/right randomly
-		groupDPS := splitCount(dpsCount, 2)
-		groupChaser := splitCount(chaserCount, 2)
-		groupZoner := splitCount(zonerCount, 2)
-		groupMobAir := splitCount(mobAirCount, 2)
-		regions := pickHalves()
-		for i := 0; i < 2; i++ {
+		groupCount := randRange(rng, params.GroupRange[0], params.GroupRange[1])
+		hints.GroupCount = groupCount
+		// Split enemies into groups; 2 groups pick top/bottom or left/right randomly
+		groupDPS := splitCount(dpsCount, groupCount)
+		groupChaser := splitCount(chaserCount, groupCount)
+		groupZoner := splitCount(zonerCount, groupCount)
+		groupMobAir := splitCount(mobAirCount, groupCount)
+		regions := groupRegions(rng, groupCount)
+		for i := 0; i < groupCount && i < len(regions); i++ {
 			hints.Groups = append(hints.Groups, PlacementGroup{
 				Region:      regions[i],
 				DPSCount:    groupDPS[i],
@@ -318,8 +346,8 @@ func buildPlacementHints(cfg *StageConfig, chaserCount, zonerCount, dpsCount, mo
 		}
 
 	case "peak":
-		// Split into 2-4 groups
-		groupCount := randRange(2, 4)
+		// Split into groups (default 2-4)
+		groupCount := randRange(rng, params.GroupRange[0], params.GroupRange[1])
 		hints.GroupCount = groupCount
 
 		groupDPS := splitCount(dpsCount, groupCount)
@@ -327,12 +355,7 @@ func buildPlacementHints(cfg *StageConfig, chaserCount, zonerCount, dpsCount, mo
 		groupZoner := splitCount(zonerCount, groupCount)
 		groupMobAir := splitCount(mobAirCount, groupCount)
 
-		var regions []GroupRegion
-		if groupCount == 2 {
-			regions = pickHalves()
-		} else {
-			regions = []GroupRegion{RegionTopLeft, RegionTopRight, RegionBottomLeft, RegionBottomRight}
-		}
+		regions := groupRegions(rng, groupCount)
 
 		for i := 0; i < groupCount && i < len(regions); i++ {
 			hints.Groups = append(hints.Groups, PlacementGroup{
@@ -354,9 +377,21 @@ func buildPlacementHints(cfg *StageConfig, chaserCount, zonerCount, dpsCount, mo
 	return hints
 }
 
+// groupRegions returns the regions for n enemy groups: the whole room for one
+// group, a random pair of halves for two, and quadrants otherwise
+func groupRegions(rng *rand.Rand, n int) []GroupRegion {
+	switch n {
+	case 1:
+		return []GroupRegion{RegionFull}
+	case 2:
+		return pickHalves(rng)
+	}
+	return []GroupRegion{RegionTopLeft, RegionTopRight, RegionBottomLeft, RegionBottomRight}
+}
+
 // pickHalves randomly returns top/bottom or left/right region pair
-func pickHalves() []GroupRegion {
-	if rand.Float64() < 0.5 {
+func pickHalves(rng *rand.Rand) []GroupRegion {
+	if rng.Float64() < 0.5 {
 		return []GroupRegion{RegionTop, RegionBottom}
 	}
 	return []GroupRegion{RegionLeft, RegionRight}
@@ -434,11 +469,9 @@ func isCornerDoorPair(doors []DoorPosition) bool {
 	return false
 }
 
-// findBossArena finds a 6x6 clear area in the center of the room
-func findBossArena(ground [][]int, width, height int) *BossArenaInfo {
-	const arenaSize = 6
-	const minEdgeDist = 3
-
+// findBossArena finds an arenaSize x arenaSize clear area closest to the center
+// of the room, at least minEdgeDist cells from every edge
+func findBossArena(ground [][]int, width, height, arenaSize, minEdgeDist int) *BossArenaInfo {
 	centerX, centerY := width/2, height/2
 	bestDist := width + height
 	var best *BossArenaInfo
@@ -468,9 +501,9 @@ func findBossArena(ground [][]int, width, height int) *BossArenaInfo {
 }
 
 // randRange returns a random int in [min, max] inclusive
-func randRange(min, max int) int {
+func randRange(rng *rand.Rand, min, max int) int {
 	if min >= max {
 		return min
 	}
-	return min + rand.Intn(max-min+1)
+	return min + rng.Intn(max-min+1)
 }
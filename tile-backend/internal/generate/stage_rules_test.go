@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -61,7 +62,7 @@ func TestReleaseStage_DPSCountInRange(t *testing.T) {
 			RailEnabled:   true,
 		}
 
-		resp, err := GenerateFullRoom(req)
+		resp, err := GenerateFullRoom(context.Background(), req)
 		if err != nil {
 			continue
 		}
@@ -115,7 +116,7 @@ func TestPressureStage_ChaserCountInRange(t *testing.T) {
 			StaticCount:   5,
 			RailEnabled:   trial%2 == 0,
 		}
-		resp, err := GenerateFullRoom(req)
+		resp, err := GenerateFullRoom(context.Background(), req)
 		if err != nil {
 			continue
 		}
@@ -145,7 +146,7 @@ func TestReleaseStage_OnlyDPSEnemies(t *testing.T) {
 			StageType: "release",
 		}
 
-		resp, err := GenerateFullRoom(req)
+		resp, err := GenerateFullRoom(context.Background(), req)
 		if err != nil {
 			continue
 		}
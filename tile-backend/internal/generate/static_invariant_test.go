@@ -1,6 +1,7 @@
 package generate
 
 import (
+	"context"
 	"testing"
 )
 
@@ -42,7 +43,7 @@ func TestStaticOnGroundInvariant_Bridge(t *testing.T) {
 							RailEnabled:   railEnabled,
 						}
 
-						resp, err := GenerateBridgeRoom(req)
+						resp, err := GenerateBridgeRoom(context.Background(), req)
 						if err != nil {
 							continue
 						}
@@ -87,7 +88,7 @@ func TestStaticOnGroundInvariant_B4(t *testing.T) {
 			RailEnabled:   true,
 		}
 
-		resp, err := GenerateBridgeRoom(req)
+		resp, err := GenerateBridgeRoom(context.Background(), req)
 		if err != nil {
 			continue
 		}
@@ -139,7 +140,7 @@ func TestStaticOnGroundInvariant_FullRoom(t *testing.T) {
 							RailEnabled:   railEnabled,
 						}
 
-						resp, err := GenerateFullRoom(req)
+						resp, err := GenerateFullRoom(context.Background(), req)
 						if err != nil {
 							continue
 						}
@@ -201,7 +202,7 @@ func TestStaticOnGroundInvariant_Platform(t *testing.T) {
 							RailEnabled:   railEnabled,
 						}
 
-						resp, err := GeneratePlatformRoom(req)
+						resp, err := GeneratePlatformRoom(context.Background(), req)
 						if err != nil {
 							continue
 						}
@@ -248,7 +249,7 @@ func TestStaticPlacementFallback_P4(t *testing.T) {
 			RailEnabled:   false,
 		}
 
-		resp, err := GeneratePlatformRoom(req)
+		resp, err := GeneratePlatformRoom(context.Background(), req)
 		if err != nil {
 			// Generation error is not the bug we're testing; skip trial
 			continue
@@ -298,7 +299,7 @@ func TestDPSMinimum_BridgeBuilding(t *testing.T) {
 				RailEnabled:   true,
 			}
 
-			resp, err := GenerateBridgeRoom(req)
+			resp, err := GenerateBridgeRoom(context.Background(), req)
 			if err != nil {
 				continue
 			}
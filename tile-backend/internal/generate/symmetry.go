@@ -0,0 +1,426 @@
+package generate
+
+import (
+	"fmt"
+	"sort"
+	"tile-backend/internal/model"
+	"tile-backend/internal/validate"
+)
+
+// Symmetry is a whole-room symmetry mode
+type Symmetry string
+
+const (
+	SymmetryNone          Symmetry = "none"
+	SymmetryHorizontal    Symmetry = "horizontal"     // Left half mirrors the right half (across the vertical center line)
+	SymmetryVertical      Symmetry = "vertical"       // Top half mirrors the bottom half (across the horizontal center line)
+	SymmetryBoth          Symmetry = "both"           // Horizontal and vertical at once; one quadrant defines the room
+	SymmetryRotational180 Symmetry = "rotational-180" // Rotating the room half a turn leaves it unchanged
+)
+
+// symmetryRepairRounds bounds the validate-and-clear passes after mirroring
+const symmetryRepairRounds = 10
+
+// SymmetryDebugInfo reports how the room was made symmetric
+type SymmetryDebugInfo struct {
+	Mode             string `json:"mode"`
+	AddedDoors       int    `json:"addedDoors"`       // Doors added or widened to mirror the requested ones
+	GroundCellsAdded int    `json:"groundCellsAdded"` // Ground cells added by mirroring and reconnecting
+	MirroredCells    int    `json:"mirroredCells"`    // Cells changed in the other layers when copying the source region
+	RemovedCells     int    `json:"removedCells"`     // Cells cleared because the mirrored copy broke a rule
+	RailDropped      bool   `json:"railDropped"`      // Rail was removed because the mirrored loop was invalid
+	HazardDropped    bool   `json:"hazardDropped"`    // Hazards were removed because the mirrored strips cut every safe route
+	Valid            bool   `json:"valid"`            // Strict validation and symmetry checks passed
+}
+
+// ValidateSymmetry checks if the symmetry mode is valid; returns error if not
+func ValidateSymmetry(s Symmetry) error {
+	switch s {
+	case "", SymmetryNone, SymmetryHorizontal, SymmetryVertical, SymmetryBoth, SymmetryRotational180:
+		return nil
+	}
+	return fmt.Errorf("invalid symmetry %q: must be one of none, horizontal, vertical, both, rotational-180", s)
+}
+
+// enabled reports whether the mode mirrors anything
+func (s Symmetry) enabled() bool {
+	return s != "" && s != SymmetryNone
+}
+
+// flip is one mirror operation: flipX maps x to width-1-x, flipY maps y to height-1-y
+type flip struct {
+	flipX, flipY bool
+}
+
+// flips returns the non-identity operations of the mode
+func (s Symmetry) flips() []flip {
+	switch s {
+	case SymmetryHorizontal:
+		return []flip{{flipX: true}}
+	case SymmetryVertical:
+		return []flip{{flipY: true}}
+	case SymmetryBoth:
+		return []flip{{flipX: true}, {flipY: true}, {flipX: true, flipY: true}}
+	case SymmetryRotational180:
+		return []flip{{flipX: true, flipY: true}}
+	}
+	return nil
+}
+
+// apply maps a cell through the flip
+func (f flip) apply(p Point, width, height int) Point {
+	if f.flipX {
+		p.X = width - 1 - p.X
+	}
+	if f.flipY {
+		p.Y = height - 1 - p.Y
+	}
+	return p
+}
+
+// orbit returns p and every distinct image of p under the mode
+func (s Symmetry) orbit(p Point, width, height int) []Point {
+	points := []Point{p}
+	for _, f := range s.flips() {
+		q := f.apply(p, width, height)
+		seen := false
+		for _, o := range points {
+			if o == q {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			points = append(points, q)
+		}
+	}
+	return points
+}
+
+// source returns the cell whose value the rest of p's orbit copies: the first
+// cell of the orbit in row-major order
+func (s Symmetry) source(p Point, width, height int) Point {
+	best := p
+	for _, q := range s.orbit(p, width, height) {
+		if q.Y < best.Y || (q.Y == best.Y && q.X < best.X) {
+			best = q
+		}
+	}
+	return best
+}
+
+// isSymmetric reports whether every orbit of the layer holds a single value
+func (s Symmetry) isSymmetric(layer [][]int, width, height int) bool {
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			src := s.source(Point{X: x, Y: y}, width, height)
+			if layer[y][x] != layer[src.Y][src.X] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// checkMasks rejects designer masks that the mode would have to break
+func (s Symmetry) checkMasks(masks *ConstraintMasks, width, height int) error {
+	named := []struct {
+		name string
+		mask [][]int
+	}{
+		{"forceGround", masks.ForceGround},
+		{"forceVoid", masks.ForceVoid},
+		{"noEnemy", masks.NoEnemy},
+		{"noStatic", masks.NoStatic},
+	}
+	for _, m := range named {
+		if m.mask != nil && !s.isSymmetric(m.mask, width, height) {
+			return fmt.Errorf("%s mask is not %s symmetric", m.name, s)
+		}
+	}
+	return nil
+}
+
+// mirrorDoor returns the image of a door under the flip
+func (f flip) mirrorDoor(d model.DoorDescriptor, width, height int) model.DoorDescriptor {
+	switch d.Side {
+	case model.DoorSideTop, model.DoorSideBottom:
+		if f.flipX {
+			d.Offset = width - d.Offset - d.Span()
+		}
+		if f.flipY {
+			if d.Side == model.DoorSideTop {
+				d.Side = model.DoorSideBottom
+			} else {
+				d.Side = model.DoorSideTop
+			}
+		}
+	case model.DoorSideLeft, model.DoorSideRight:
+		if f.flipY {
+			d.Offset = height - d.Offset - d.Span()
+		}
+		if f.flipX {
+			if d.Side == model.DoorSideLeft {
+				d.Side = model.DoorSideRight
+			} else {
+				d.Side = model.DoorSideLeft
+			}
+		}
+	}
+	return d
+}
+
+// symmetricDoors adds the mirror image of every door and merges openings on the
+// same side that overlap or touch, so the door list matches the mirrored room.
+// Returns the resolved sites like resolveDoors.
+func symmetricDoors(s Symmetry, descriptors []model.DoorDescriptor, width, height int) ([]DoorSite, []DoorPosition, []model.DoorDescriptor, error) {
+	bySide := make(map[string][]model.DoorDescriptor)
+	for _, d := range descriptors {
+		bySide[d.Side] = append(bySide[d.Side], d)
+		for _, f := range s.flips() {
+			m := f.mirrorDoor(d, width, height)
+			bySide[m.Side] = append(bySide[m.Side], m)
+		}
+	}
+
+	var merged []model.DoorDescriptor
+	for _, side := range model.DoorSides {
+		doors := bySide[side]
+		sort.Slice(doors, func(i, j int) bool { return doors[i].Offset < doors[j].Offset })
+		var sideDoors []model.DoorDescriptor
+		for _, d := range doors {
+			d.Width = d.Span()
+			if n := len(sideDoors); n > 0 && d.Offset <= sideDoors[n-1].Offset+sideDoors[n-1].Width {
+				if end := d.Offset + d.Width; end > sideDoors[n-1].Offset+sideDoors[n-1].Width {
+					sideDoors[n-1].Width = end - sideDoors[n-1].Offset
+				}
+				continue
+			}
+			sideDoors = append(sideDoors, d)
+		}
+		if len(sideDoors) > model.MaxDoorsPerSide {
+			return nil, nil, nil, fmt.Errorf("%s symmetry needs %d doors on the %s side, at most %d allowed", s, len(sideDoors), side, model.MaxDoorsPerSide)
+		}
+		merged = append(merged, sideDoors...)
+	}
+	return resolveDoors(nil, merged, width, height)
+}
+
+// symmetrizeGround makes the ground symmetric by adding the mirror image of every
+// ground cell, reconnecting and mirroring again if that split the ground. The
+// union of two connected regions that share the (symmetric) doors stays
+// connected. forceVoid cells refilled here are recorded in reasons.
+func symmetrizeGround(s Symmetry, ground [][]int, masks *ConstraintMasks, reasons map[Point]string, width, height int) *SymmetryDebugInfo {
+	debug := &SymmetryDebugInfo{Mode: string(s)}
+	before := countCells(ground)
+
+	unionLayer(s, ground, width, height)
+	if len(findAllIslands(ground, width, height)) > 1 {
+		ensureGroundConnectivity(ground, width, height)
+		unionLayer(s, ground, width, height)
+	}
+	debug.GroundCellsAdded = countCells(ground) - before
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := Point{X: x, Y: y}
+			if masks.forceVoidAt(x, y) && ground[y][x] == 1 && reasons[p] == "" {
+				reasons[p] = "re-filled to keep the room symmetric"
+			}
+		}
+	}
+	return debug
+}
+
+// unionLayer sets every cell of an orbit when any cell of it is set
+func unionLayer(s Symmetry, layer [][]int, width, height int) {
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if layer[y][x] == 0 {
+				continue
+			}
+			for _, q := range s.orbit(Point{X: x, Y: y}, width, height) {
+				layer[q.Y][q.X] = 1
+			}
+		}
+	}
+}
+
+// mirrorLayers copies the source region of each layer onto the rest of the room
+// and returns how many cells changed. Every layer uses the same mapping, so
+// rules that only look at one cell across layers keep holding.
+func mirrorLayers(s Symmetry, width, height int, layers ...[][]int) int {
+	changed := 0
+	for _, layer := range layers {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				src := s.source(Point{X: x, Y: y}, width, height)
+				if layer[y][x] != layer[src.Y][src.X] {
+					layer[y][x] = layer[src.Y][src.X]
+					changed++
+				}
+			}
+		}
+	}
+	return changed
+}
+
+// dropCuttingStatics clears mirrored statics until the doors reach each other
+// over ground=1, static=0 cells again: mirroring can copy a static into the one
+// passage its source region left open. Each round frees the orbit of the first
+// static cell, in row-major order, next to the area the first door reaches.
+// Returns the number of cells removed.
+func dropCuttingStatics(s Symmetry, staticLayer, ground [][]int, doorPositions []DoorSite, width, height int) int {
+	if len(doorPositions) < 2 {
+		return 0
+	}
+	removed := 0
+	reached := NewGrid(width, height)
+	var buf []Point
+	for {
+		walkable := GridFromLayer(ground, width, height)
+		walkable.AndNot(GridFromLayer(staticLayer, width, height))
+		if areAllDoorsConnected(walkable.Layer(), width, height, doorPositions) {
+			return removed
+		}
+
+		// Flood from the first door like areAllDoorsConnected
+		reached.Reset()
+		var queue []Point
+		for _, c := range doorPositions[0].Cells {
+			if reached.InBounds(c.X, c.Y) && !reached.Has(c.X, c.Y) {
+				reached.Set(c.X, c.Y)
+				queue = append(queue, c)
+			}
+		}
+		for head := 0; head < len(queue); head++ {
+			buf = walkable.Neighbors(queue[head], buf[:0])
+			for _, n := range buf {
+				if !reached.Has(n.X, n.Y) {
+					reached.Set(n.X, n.Y)
+					queue = append(queue, n)
+				}
+			}
+		}
+
+		blocker := Point{X: -1, Y: -1}
+		for y := 0; y < height && blocker.X < 0; y++ {
+			for x := 0; x < width; x++ {
+				if staticLayer[y][x] == 0 || ground[y][x] != 1 {
+					continue
+				}
+				if reached.Has(x-1, y) || reached.Has(x+1, y) || reached.Has(x, y-1) || reached.Has(x, y+1) {
+					blocker = Point{X: x, Y: y}
+					break
+				}
+			}
+		}
+		if blocker.X < 0 {
+			// The ground itself is split; statics are not the cause
+			return removed
+		}
+		for _, q := range s.orbit(blocker, width, height) {
+			if staticLayer[q.Y][q.X] != 0 {
+				staticLayer[q.Y][q.X] = 0
+				removed++
+			}
+		}
+	}
+}
+
+// finishSymmetricPayload validates a mirrored payload strictly. Cells that break a
+// rule are cleared together with their mirror images; a broken rail drops the
+// whole rail layer, since half a loop is never valid. Ground must already be
+// symmetric and valid, so errors there fail the request.
+func finishSymmetricPayload(s Symmetry, payload *model.TemplatePayload, debug *SymmetryDebugInfo) error {
+	width, height := payload.Meta.Width, payload.Meta.Height
+	layers := map[string]model.Layer{
+		"softEdge": payload.SoftEdge,
+		"bridge":   payload.Bridge,
+		"static":   payload.Static,
+		"chaser":   payload.Chaser,
+		"zoner":    payload.Zoner,
+		"dps":      payload.DPS,
+		"mobAir":   payload.MobAir,
+	}
+	if payload.Pipeline != nil {
+		layers["pipeline"] = payload.Pipeline
+	}
+	if payload.Pickup != nil {
+		layers["pickup"] = payload.Pickup
+	}
+	if payload.Hazard != nil {
+		layers["hazard"] = payload.Hazard
+	}
+
+	for round := 0; round < symmetryRepairRounds; round++ {
+		// Cleared rail or pipeline cells change the segments
+		payload.SetLineSegments()
+		result := validate.ValidateTemplate(payload, true)
+		if result.Valid {
+			break
+		}
+		for _, e := range result.Errors {
+			if e.Layer == "rail" {
+				if n := countCells(payload.Rail); n > 0 {
+					clearLayer(payload.Rail)
+					debug.RemovedCells += n
+					debug.RailDropped = true
+				}
+				continue
+			}
+			layer, ok := layers[e.Layer]
+			if !ok {
+				return fmt.Errorf("symmetric room failed validation: %s at (%d,%d): %s", e.Layer, e.X, e.Y, e.Reason)
+			}
+			for _, q := range s.orbit(Point{X: e.X, Y: e.Y}, width, height) {
+				if layer[q.Y][q.X] != 0 {
+					layer[q.Y][q.X] = 0
+					debug.RemovedCells++
+				}
+			}
+		}
+	}
+
+	payload.SetLineSegments()
+	if result := validate.ValidateTemplate(payload, true); !result.Valid {
+		return fmt.Errorf("symmetric room failed validation: %s", firstValidationError(result))
+	}
+	for name, layer := range layers {
+		if !s.isSymmetric(layer, width, height) {
+			return fmt.Errorf("symmetric room failed validation: %s layer is not %s symmetric", name, s)
+		}
+	}
+	if !s.isSymmetric(payload.Ground, width, height) || !s.isSymmetric(payload.Rail, width, height) {
+		return fmt.Errorf("symmetric room failed validation: ground or rail is not %s symmetric", s)
+	}
+	debug.Valid = true
+	return nil
+}
+
+// clearLayer sets every cell of the layer to 0
+func clearLayer(layer [][]int) {
+	for y := range layer {
+		for x := range layer[y] {
+			layer[y][x] = 0
+		}
+	}
+}
+
+// countAddedDoors counts resolved doors that were not requested as-is
+func countAddedDoors(requested, resolved []model.DoorDescriptor) int {
+	added := 0
+	for _, d := range resolved {
+		found := false
+		for _, r := range requested {
+			if r.Side == d.Side && r.Offset == d.Offset && r.Span() == d.Span() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			added++
+		}
+	}
+	return added
+}
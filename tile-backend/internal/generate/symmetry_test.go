@@ -0,0 +1,221 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"tile-backend/internal/model"
+	"tile-backend/internal/validate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var symmetryModes = []Symmetry{SymmetryHorizontal, SymmetryVertical, SymmetryBoth, SymmetryRotational180}
+
+// assertSymmetricPayload checks every layer against the mode and runs strict validation
+func assertSymmetricPayload(t *testing.T, s Symmetry, payload model.TemplatePayload, msg string) {
+	w, h := payload.Meta.Width, payload.Meta.Height
+	layers := map[string]model.Layer{
+		"ground": payload.Ground, "softEdge": payload.SoftEdge, "bridge": payload.Bridge, "rail": payload.Rail,
+		"static": payload.Static, "chaser": payload.Chaser, "zoner": payload.Zoner, "dps": payload.DPS, "mobAir": payload.MobAir,
+	}
+	for name, layer := range layers {
+		assert.True(t, s.isSymmetric(layer, w, h), "%s: %s layer not symmetric", msg, name)
+	}
+	result := validate.ValidateTemplate(&payload, true)
+	assert.True(t, result.Valid, "%s: %v", msg, result.Errors)
+
+	sites, _, _, err := resolveDoors(nil, payload.DoorDescriptors, w, h)
+	require.NoError(t, err)
+	assert.True(t, areAllDoorsConnected(payload.Ground, w, h, sites), msg)
+
+	// Mirrored statics must not cut a door off
+	walkable := GridFromLayer(payload.Ground, w, h)
+	walkable.AndNot(GridFromLayer(payload.Static, w, h))
+	assert.True(t, areAllDoorsConnected(walkable.Layer(), w, h, sites), "%s: statics cut the doors off", msg)
+	assert.Len(t, findAllIslands(payload.Ground, w, h), 1, msg)
+
+	// Each patrol's mirror image is the patrol of the mirrored chaser
+	routes := make(map[Point]map[Point]bool)
+	for _, patrol := range payload.ChaserPatrols {
+		cells, err := patrol.PatrolCells()
+		require.NoError(t, err, msg)
+		route := make(map[Point]bool)
+		for _, c := range cells {
+			route[Point{X: c.X, Y: c.Y}] = true
+		}
+		routes[Point{X: patrol.Chaser.X, Y: patrol.Chaser.Y}] = route
+	}
+	for chaser, route := range routes {
+		for _, f := range s.flips() {
+			image, ok := routes[f.apply(chaser, w, h)]
+			require.True(t, ok, "%s: patrol of (%d,%d) not mirrored", msg, chaser.X, chaser.Y)
+			assert.Len(t, image, len(route), msg)
+			for c := range route {
+				assert.True(t, image[f.apply(c, w, h)], "%s: patrol of (%d,%d) not mirrored at (%d,%d)", msg, chaser.X, chaser.Y, c.X, c.Y)
+			}
+		}
+	}
+}
+
+func TestSymmetry_Orbit(t *testing.T) {
+	assert.Equal(t, []Point{{X: 1, Y: 2}, {X: 8, Y: 2}}, SymmetryHorizontal.orbit(Point{X: 1, Y: 2}, 10, 6))
+	assert.Equal(t, []Point{{X: 1, Y: 2}, {X: 1, Y: 3}}, SymmetryVertical.orbit(Point{X: 1, Y: 2}, 10, 6))
+	assert.Len(t, SymmetryBoth.orbit(Point{X: 1, Y: 2}, 10, 6), 4)
+	assert.Equal(t, []Point{{X: 1, Y: 2}, {X: 8, Y: 3}}, SymmetryRotational180.orbit(Point{X: 1, Y: 2}, 10, 6))
+
+	// The center column of an odd-width room is its own mirror
+	assert.Equal(t, []Point{{X: 4, Y: 0}}, SymmetryHorizontal.orbit(Point{X: 4, Y: 0}, 9, 6))
+	assert.Equal(t, Point{X: 1, Y: 2}, SymmetryHorizontal.source(Point{X: 8, Y: 2}, 10, 6))
+}
+
+func TestSymmetricDoors(t *testing.T) {
+	// A single left door gains a right door; the top door merges with its mirror
+	_, sides, descriptors, err := symmetricDoors(SymmetryHorizontal, []model.DoorDescriptor{
+		{Side: "left", Offset: 2, Width: 2},
+		{Side: "top", Offset: 10, Width: 1},
+	}, 20, 12)
+	require.NoError(t, err)
+	assert.Equal(t, []DoorPosition{DoorTop, DoorRight, DoorLeft}, sides)
+	assert.Equal(t, []model.DoorDescriptor{
+		{Side: "top", Offset: 9, Width: 2},
+		{Side: "right", Offset: 2, Width: 2},
+		{Side: "left", Offset: 2, Width: 2},
+	}, descriptors)
+
+	// Rotation maps top onto bottom with the offset flipped
+	_, _, descriptors, err = symmetricDoors(SymmetryRotational180, []model.DoorDescriptor{{Side: "top", Offset: 3, Width: 2}}, 20, 12)
+	require.NoError(t, err)
+	assert.Contains(t, descriptors, model.DoorDescriptor{Side: "bottom", Offset: 15, Width: 2})
+
+	// Two off-center doors on a side mirror into four
+	_, _, _, err = symmetricDoors(SymmetryHorizontal, []model.DoorDescriptor{
+		{Side: "top", Offset: 1}, {Side: "top", Offset: 5},
+	}, 20, 12)
+	assert.Error(t, err)
+}
+
+func TestGenerateRooms_Symmetry(t *testing.T) {
+	for _, mode := range symmetryModes {
+		for i := int64(0); i < 5; i++ {
+			seed := i
+
+			bridge, err := GenerateBridgeRoom(context.Background(), BridgeGenerateRequest{
+				Width: 20, Height: 14, Doors: []DoorPosition{DoorLeft, DoorTop},
+				SoftEdgeCount: 3, StaticCount: 4, ChaserCount: 3, ZonerCount: 2, DPSCount: 2, MobAirCount: 2,
+				RailEnabled: true, Symmetry: mode, Seed: &seed,
+			})
+			require.NoError(t, err, "bridge %s seed %d", mode, seed)
+			assertSymmetricPayload(t, mode, bridge.Payload, string(mode)+" bridge")
+			require.NotNil(t, bridge.DebugInfo.Symmetry)
+			assert.True(t, bridge.DebugInfo.Symmetry.Valid)
+
+			platform, err := GeneratePlatformRoom(context.Background(), PlatformGenerateRequest{
+				Width: 20, Height: 14, Doors: []DoorPosition{DoorLeft, DoorRight},
+				SoftEdgeCount: 3, StaticCount: 4, StageType: "pressure", RailEnabled: true, Symmetry: mode, Seed: &seed,
+			})
+			require.NoError(t, err, "platform %s seed %d", mode, seed)
+			assertSymmetricPayload(t, mode, platform.Payload, string(mode)+" platform")
+
+			full, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+				Width: 21, Height: 13, Doors: []DoorPosition{DoorTop, DoorBottom},
+				StaticCount: 4, StageType: "building", Symmetry: mode, Seed: &seed,
+			})
+			require.NoError(t, err, "full %s seed %d", mode, seed)
+			assertSymmetricPayload(t, mode, full.Payload, string(mode)+" full")
+
+			cave, err := GenerateCave(context.Background(), CaveGenerateRequest{
+				Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight},
+				StaticCount: 3, ChaserCount: 2, Symmetry: mode, Seed: &seed,
+			})
+			require.NoError(t, err, "cave %s seed %d", mode, seed)
+			assertSymmetricPayload(t, mode, cave.Payload, string(mode)+" cave")
+		}
+	}
+}
+
+func TestGenerateRooms_SymmetryStaticsKeepDoorsConnected(t *testing.T) {
+	// These seeds mirror a static into the only passage to a door
+	for _, seed := range []int64{11, 50} {
+		s := seed
+		resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+			Width: 20, Height: 12, Doors: []DoorPosition{DoorTop, DoorLeft, DoorBottom},
+			StaticCount: 12, Symmetry: SymmetryHorizontal, Seed: &s,
+		})
+		require.NoError(t, err, "seed %d", seed)
+		assertSymmetricPayload(t, SymmetryHorizontal, resp.Payload, fmt.Sprintf("seed %d", seed))
+		assert.Positive(t, resp.DebugInfo.Symmetry.RemovedCells, "seed %d", seed)
+	}
+}
+
+func TestDropCuttingStatics(t *testing.T) {
+	// A wall of statics at x=1 and its mirror at x=6 shut the left and right doors in
+	ground := filledLayer(8, 5, 1)
+	static := filledLayer(8, 5, 0)
+	for y := 0; y < 5; y++ {
+		static[y][1] = 1
+		static[y][6] = 1
+	}
+	sites, _, _, err := resolveDoors(nil, []model.DoorDescriptor{
+		{Side: "left", Offset: 2, Width: 1},
+		{Side: "right", Offset: 2, Width: 1},
+	}, 8, 5)
+	require.NoError(t, err)
+
+	removed := dropCuttingStatics(SymmetryHorizontal, static, ground, sites, 8, 5)
+	assert.Equal(t, 2, removed, "the first blocking cell and its mirror open both walls")
+	assert.Zero(t, static[0][1])
+	assert.Zero(t, static[0][6])
+	assert.True(t, SymmetryHorizontal.isSymmetric(static, 8, 5))
+	walkable := GridFromLayer(ground, 8, 5)
+	walkable.AndNot(GridFromLayer(static, 8, 5))
+	assert.True(t, areAllDoorsConnected(walkable.Layer(), 8, 5, sites))
+
+	// Connected doors leave the statics alone
+	assert.Zero(t, dropCuttingStatics(SymmetryHorizontal, static, ground, sites, 8, 5))
+}
+
+func TestGenerateRooms_SymmetryMirrorsDoors(t *testing.T) {
+	seed := int64(5)
+	resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorTop}, Symmetry: SymmetryBoth, Seed: &seed,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, model.DoorStates{Top: 1, Right: 1, Bottom: 1, Left: 1}, *resp.Payload.Doors)
+	require.NotNil(t, resp.DebugInfo.Symmetry)
+	assert.Equal(t, "both", resp.DebugInfo.Symmetry.Mode)
+	assert.Equal(t, 4, resp.DebugInfo.Symmetry.AddedDoors)
+}
+
+func TestGenerateRooms_SymmetryInvalidInput(t *testing.T) {
+	_, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+		Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, Symmetry: "diagonal",
+	})
+	assert.Error(t, err)
+
+	// An asymmetric mask cannot be honored in a symmetric room
+	_, err = GeneratePlatformRoom(context.Background(), PlatformGenerateRequest{
+		Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, Symmetry: SymmetryHorizontal,
+		ConstraintMasks: ConstraintMasks{ForceVoid: maskRect(20, 12, 0, 0, 3, 3)},
+	})
+	assert.Error(t, err)
+
+	// A symmetric mask is fine
+	_, err = GeneratePlatformRoom(context.Background(), PlatformGenerateRequest{
+		Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, Symmetry: SymmetryHorizontal,
+		ConstraintMasks: ConstraintMasks{NoEnemy: maskRect(20, 12, 8, 0, 12, 12)},
+	})
+	assert.NoError(t, err)
+}
+
+func TestGenerateRooms_NoSymmetryNoDebug(t *testing.T) {
+	seed := int64(1)
+	resp, err := GenerateBridgeRoom(context.Background(), BridgeGenerateRequest{
+		Width: 20, Height: 12, Doors: []DoorPosition{DoorLeft, DoorRight}, Symmetry: SymmetryNone, Seed: &seed,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, resp.DebugInfo.Symmetry)
+}
@@ -0,0 +1,128 @@
+package generate
+
+import (
+	"slices"
+
+	"tile-backend/internal/model"
+)
+
+// TraceFrame is one recorded point of a generation run. Layers holds the layers
+// the step touched, as they are once the step is done; a layer keeps its last
+// recorded state until a later frame lists it again, so the editor can rebuild
+// the room at any frame by replaying the frames before it.
+type TraceFrame struct {
+	Index     int                    `json:"index"`
+	Step      string                 `json:"step"`                // Pipeline step, e.g. "corner erase" or "chaser"
+	Rollback  bool                   `json:"rollback,omitempty"`  // The step's mutation was rejected and undone
+	Reason    string                 `json:"reason,omitempty"`    // Why the mutation was rejected (rollback frames only)
+	Layers    map[string]model.Layer `json:"layers"`              // Layer state after the frame
+	Attempted map[string]model.Layer `json:"attempted,omitempty"` // Rejected layer state before the rollback (rollback frames only)
+
+	// The final frame also carries what the last steps add besides layers
+	ChaserPatrols []model.ChaserPatrol `json:"chaserPatrols,omitempty"`
+	Waves         []model.EnemyWave    `json:"waves,omitempty"`
+}
+
+// Trace records layer snapshots while a room is generated. A nil *Trace records
+// nothing, so the generators call it unconditionally.
+type Trace struct {
+	frames []TraceFrame
+}
+
+// newTrace returns a trace when tracing was requested, nil otherwise
+func newTrace(enabled bool) *Trace {
+	if !enabled {
+		return nil
+	}
+	return &Trace{frames: []TraceFrame{}}
+}
+
+// Frames returns the recorded frames in order
+func (t *Trace) Frames() []TraceFrame {
+	if t == nil {
+		return nil
+	}
+	return t.frames
+}
+
+// snapshot records the named layers after a step
+func (t *Trace) snapshot(step string, layers map[string][][]int) {
+	if t == nil {
+		return
+	}
+	t.frames = append(t.frames, TraceFrame{
+		Index:  len(t.frames),
+		Step:   step,
+		Layers: copyTraceLayers(layers),
+	})
+}
+
+// snapshotGrids records the named placement grids after a step, read back as
+// layers only when tracing
+func (t *Trace) snapshotGrids(step string, grids map[string]*Grid) {
+	if t == nil {
+		return
+	}
+	layers := make(map[string][][]int, len(grids))
+	for name, g := range grids {
+		layers[name] = g.Layer()
+	}
+	t.snapshot(step, layers)
+}
+
+// snapshotPayload records every layer of a payload after a step, with its
+// chaser patrols and spawn waves
+func (t *Trace) snapshotPayload(step string, p *model.TemplatePayload) {
+	if t == nil {
+		return
+	}
+	layers := make(map[string][][]int)
+	for name, layer := range map[string]model.Layer{
+		"ground": p.Ground, "softEdge": p.SoftEdge, "bridge": p.Bridge, "rail": p.Rail, "pipeline": p.Pipeline,
+		"hazard": p.Hazard, "static": p.Static, "chaser": p.Chaser, "zoner": p.Zoner, "dps": p.DPS,
+		"mobAir": p.MobAir, "pickup": p.Pickup, "mainPath": p.MainPath,
+	} {
+		if layer != nil {
+			layers[name] = layer
+		}
+	}
+	t.snapshot(step, layers)
+	frame := &t.frames[len(t.frames)-1]
+	frame.ChaserPatrols = slices.Clone(p.ChaserPatrols)
+	frame.Waves = slices.Clone(p.Waves)
+}
+
+// tryMutate runs TryMutateWithRollback on one named layer and records a rollback
+// frame, holding both the rejected and the restored layer, when validate fails
+func (t *Trace) tryMutate(step, name, reason string, layer [][]int, mutate func(), validate func() bool) bool {
+	var attempted [][]int
+	ok := TryMutateWithRollback(layer, mutate, func() bool {
+		if validate() {
+			return true
+		}
+		if t != nil {
+			attempted = copyLayer(layer)
+		}
+		return false
+	})
+	if !ok && t != nil {
+		t.frames = append(t.frames, TraceFrame{
+			Index:     len(t.frames),
+			Step:      step,
+			Rollback:  true,
+			Reason:    reason,
+			Layers:    copyTraceLayers(map[string][][]int{name: layer}),
+			Attempted: map[string]model.Layer{name: attempted},
+		})
+	}
+	return ok
+}
+
+// copyTraceLayers deep-copies layers so later steps do not change a recorded frame
+func copyTraceLayers(layers map[string][][]int) map[string]model.Layer {
+	copied := make(map[string]model.Layer, len(layers))
+	for name, layer := range layers {
+		copied[name] = copyLayer(layer)
+	}
+	return copied
+}
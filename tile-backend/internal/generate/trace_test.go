@@ -0,0 +1,158 @@
+package generate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replayTrace rebuilds the layers recorded up to and including frame n
+func replayTrace(frames []TraceFrame, n int) map[string][][]int {
+	layers := make(map[string][][]int)
+	for _, f := range frames[:n+1] {
+		for name, layer := range f.Layers {
+			layers[name] = layer
+		}
+	}
+	return layers
+}
+
+func TestTryMutateWithRollback_Trace(t *testing.T) {
+	layer := createEmptyLayer(3, 3)
+	trace := newTrace(true)
+
+	ok := trace.tryMutate("paint", "ground", "too many cells", layer, func() {
+		layer[0][0], layer[1][1] = 1, 1
+	}, func() bool { return countCells(layer) < 2 })
+	assert.False(t, ok)
+	assert.Zero(t, countCells(layer))
+
+	ok = trace.tryMutate("paint", "ground", "too many cells", layer, func() {
+		layer[2][2] = 1
+	}, func() bool { return countCells(layer) < 2 })
+	assert.True(t, ok)
+
+	// Only the rejected mutation leaves a frame
+	frames := trace.Frames()
+	require.Len(t, frames, 1)
+	assert.True(t, frames[0].Rollback)
+	assert.Equal(t, "too many cells", frames[0].Reason)
+	assert.Equal(t, 2, countCells(frames[0].Attempted["ground"]))
+	assert.Zero(t, countCells(frames[0].Layers["ground"]))
+
+	// A nil trace still rolls back
+	var off *Trace
+	assert.False(t, off.tryMutate("paint", "ground", "", layer, func() { layer[0][0] = 1 }, func() bool { return false }))
+	assert.Equal(t, 1, countCells(layer))
+	assert.Nil(t, off.Frames())
+}
+
+func TestGenerateFullRoom_Trace(t *testing.T) {
+	for i := int64(0); i < 10; i++ {
+		seed := i
+		req := FullRoomGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight, DoorTop},
+			StaticCount: 4, ChaserCount: 3, RailEnabled: true, PickupCount: 2, Seed: &seed,
+		}
+		plain, err := GenerateFullRoom(context.Background(), req)
+		require.NoError(t, err)
+		assert.Nil(t, plain.Trace)
+
+		req.Trace = true
+		traced, err := GenerateFullRoom(context.Background(), req)
+		require.NoError(t, err)
+
+		// Tracing does not change the room
+		assert.Equal(t, plain.Payload, traced.Payload)
+
+		frames := traced.Trace
+		require.NotEmpty(t, frames)
+		var steps []string
+		for n, f := range frames {
+			assert.Equal(t, n, f.Index)
+			if !f.Rollback {
+				steps = append(steps, f.Step)
+			}
+		}
+		assert.Equal(t, []string{
+			"ground fill", "corner erase", "center pits", "connectivity repair",
+			"rail", "static", "zoner", "chaser", "dps", "mobAir", "pickup", "final",
+		}, steps)
+
+		// Replaying every frame gives the final room
+		final := replayTrace(frames, len(frames)-1)
+		assert.Equal(t, [][]int(traced.Payload.Ground), final["ground"])
+		assert.Equal(t, [][]int(traced.Payload.Chaser), final["chaser"])
+
+		// The final frame is taken after patrols and waves
+		last := frames[len(frames)-1]
+		assert.NotEmpty(t, last.ChaserPatrols)
+		assert.Equal(t, traced.Payload.ChaserPatrols, last.ChaserPatrols)
+		assert.Equal(t, traced.Payload.Waves, last.Waves)
+	}
+}
+
+func TestGenerateFullRoom_TraceRollbacks(t *testing.T) {
+	// Narrow rooms break door connectivity often enough to record rollbacks
+	found := false
+	for i := int64(0); i < 200 && !found; i++ {
+		seed := i
+		resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+			Width: 8, Height: 6, Doors: []DoorPosition{DoorLeft, DoorRight, DoorTop, DoorBottom}, Seed: &seed, Trace: true,
+		})
+		require.NoError(t, err)
+		for n, f := range resp.Trace {
+			if !f.Rollback {
+				continue
+			}
+			found = true
+			assert.Contains(t, []string{"corner erase", "center pits"}, f.Step)
+			assert.NotEmpty(t, f.Reason)
+			// The rejected erase carved ground that the rollback put back
+			attempted, restored := f.Attempted["ground"], f.Layers["ground"]
+			assert.Less(t, countCells(attempted), countCells(restored), "seed %d frame %d", i, n)
+			for y := range attempted {
+				for x := range attempted[y] {
+					if attempted[y][x] == 1 {
+						assert.Equal(t, 1, restored[y][x], "seed %d frame %d (%d,%d)", i, n, x, y)
+					}
+				}
+			}
+		}
+	}
+	assert.True(t, found, "no rollback recorded")
+}
+
+func TestGenerateRooms_Trace(t *testing.T) {
+	seed := int64(3)
+	bridge, err := GenerateBridgeRoom(context.Background(), BridgeGenerateRequest{
+		Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight}, StaticCount: 4, Seed: &seed, Trace: true,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, bridge.Trace)
+	assert.Equal(t, "connect doors", bridge.Trace[0].Step)
+
+	platform, err := GeneratePlatformRoom(context.Background(), PlatformGenerateRequest{
+		Width: 24, Height: 16, Doors: []DoorPosition{DoorTop, DoorBottom}, StaticCount: 4, Seed: &seed, Trace: true,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, platform.Trace)
+	assert.Equal(t, "platform ground", platform.Trace[0].Step)
+
+	cave, err := GenerateCave(context.Background(), CaveGenerateRequest{
+		Width: 30, Height: 20, Doors: []DoorPosition{DoorLeft, DoorRight}, Seed: &seed, Trace: true,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, cave.Trace)
+	assert.Equal(t, "cave ground", cave.Trace[0].Step)
+	assert.Equal(t, "final", cave.Trace[len(cave.Trace)-1].Step)
+
+	// The option reaches the request through the registry
+	g, ok := LookupGenerator("cave")
+	require.True(t, ok)
+	result, err := g.Generate(context.Background(), &CaveGenerateRequest{Width: 30, Height: 20, Doors: []DoorPosition{DoorLeft, DoorRight}}, GenerateOptions{Seed: &seed, Trace: true})
+	require.NoError(t, err)
+	assert.Equal(t, cave.Trace, result.Response.(*CaveGenerateResponse).Trace)
+}
@@ -14,25 +14,40 @@ const (
 
 // BridgeGenerateRequest represents the request for generating a bridge room
 type BridgeGenerateRequest struct {
-	Width         int            `json:"width"`
-	Height        int            `json:"height"`
-	Doors         []DoorPosition `json:"doors"`         // At least 2 doors required
-	SoftEdgeCount int            `json:"softEdgeCount"` // Suggested number of soft edges to place (optional)
-	RailEnabled   bool           `json:"railEnabled"`   // Whether to generate rail layer (optional)
-	StaticCount   int            `json:"staticCount"`   // Suggested number of statics to place (optional)
-	ChaserCount   int            `json:"chaserCount"`   // Suggested number of chasers to place (optional)
-	ZonerCount    int            `json:"zonerCount"`    // Suggested number of zoners to place (optional)
-	DPSCount      int            `json:"dpsCount"`      // Suggested number of DPS to place (optional)
-	MobAirCount   int            `json:"mobAirCount"`   // Suggested number of mob air (fly) to place (optional)
-	StageType     string         `json:"stageType"`     // Room stage type (optional)
-	RoomCategory  string         `json:"roomCategory"`  // Room category: normal, basement, test, cave (optional, default: normal)
+	Width                 int                    `json:"width"`
+	Height                int                    `json:"height"`
+	Doors                 []DoorPosition         `json:"doors"`                           // At least 2 doors required
+	DoorDescriptors       []model.DoorDescriptor `json:"doorDescriptors,omitempty"`       // Door openings with side, offset and width; replaces doors when set (optional)
+	Symmetry              Symmetry               `json:"symmetry,omitempty"`              // Whole-room symmetry: none, horizontal, vertical, both, rotational-180 (optional, default none)
+	SoftEdgeCount         int                    `json:"softEdgeCount"`                   // Suggested number of soft edges to place (optional)
+	RailEnabled           bool                   `json:"railEnabled"`                     // Whether to generate rail layer (optional)
+	PipelineEnabled       bool                   `json:"pipelineEnabled"`                 // Whether to generate pipeline layer (optional)
+	PipelineCount         int                    `json:"pipelineCount"`                   // Suggested number of pipeline runs to lay (optional, default 2)
+	HazardCount           int                    `json:"hazardCount"`                     // Suggested number of hazard strips (spikes, lava) to lay (optional)
+	StaticCount           int                    `json:"staticCount"`                     // Suggested number of statics to place (optional)
+	ChaserCount           int                    `json:"chaserCount"`                     // Suggested number of chasers to place (optional)
+	ZonerCount            int                    `json:"zonerCount"`                      // Suggested number of zoners to place (optional)
+	DPSCount              int                    `json:"dpsCount"`                        // Suggested number of DPS to place (optional)
+	MobAirCount           int                    `json:"mobAirCount"`                     // Suggested number of mob air (fly) to place (optional)
+	PickupCount           int                    `json:"pickupCount"`                     // Suggested number of pickups (chests, health) to place (optional)
+	StageType             string                 `json:"stageType"`                       // Room stage type (optional)
+	StageOverrides        model.StageOverrides   `json:"-"`                               // Project stage overrides, applied on top of the stage config (set from project_id, not read from the body)
+	RoomCategory          string                 `json:"roomCategory"`                    // Room category: normal, basement, test, cave (optional, default: normal)
+	Seed                  *int64                 `json:"seed,omitempty"`                  // Random seed for reproducible output (optional, random if omitted)
+	Trace                 bool                   `json:"-"`                               // Record a frame per pipeline step and rollback (set from the trace query parameter, not read from the body)
+	DifficultyTarget      *DifficultyTarget      `json:"difficultyTarget,omitempty"`      // Resample until the difficulty score is in range (optional)
+	MaxValidationAttempts int                    `json:"maxValidationAttempts,omitempty"` // Rooms to try until one passes strict validation (optional, default 3, max 20)
+	ConstraintMasks                              // Designer masks forceGround, forceVoid, noEnemy, noStatic (optional)
 }
 
 // BridgeGenerateResponse represents the generated template
 type BridgeGenerateResponse struct {
-	Payload    model.TemplatePayload `json:"payload"`
-	DebugInfo  *GenerateDebugInfo    `json:"debugInfo,omitempty"`
-	Difficulty *DifficultyScore      `json:"difficulty,omitempty"`
+	Payload          model.TemplatePayload   `json:"payload"`
+	DebugInfo        *GenerateDebugInfo      `json:"debugInfo,omitempty"`
+	Difficulty       *DifficultyScore        `json:"difficulty,omitempty"`
+	Seed             int64                   `json:"seed"`                       // Seed used for this generation
+	DifficultyTarget *DifficultyTargetResult `json:"difficultyTarget,omitempty"` // Target search outcome (only when difficultyTarget was requested)
+	Trace            []TraceFrame            `json:"trace,omitempty"`            // Step-by-step layer frames (only when trace was requested)
 }
 
 // GenerateDebugInfo contains debug information about the generation process
@@ -41,12 +56,20 @@ type GenerateDebugInfo struct {
 	SoftEdge    *SoftEdgeDebugInfo    `json:"softEdge,omitempty"`
 	BridgeLayer *BridgeLayerDebugInfo `json:"bridgeLayer,omitempty"`
 	Rail        *RailDebugInfo        `json:"rail,omitempty"`
+	Pipeline    *PipelineDebugInfo    `json:"pipeline,omitempty"`
+	Hazard      *HazardDebugInfo      `json:"hazard,omitempty"`
 	MainPath    *MainPathDebugInfo    `json:"mainPath,omitempty"`
 	Static      *StaticDebugInfo      `json:"static,omitempty"`
 	Chaser      *EnemyLayerDebugInfo  `json:"chaser,omitempty"`
 	Zoner       *EnemyLayerDebugInfo  `json:"zoner,omitempty"`
 	DPS         *EnemyLayerDebugInfo  `json:"dps,omitempty"`
 	MobAir      *MobAirDebugInfo      `json:"mobAir,omitempty"`
+	Pickup      *PickupDebugInfo      `json:"pickup,omitempty"`
+	Waves       *WaveDebugInfo        `json:"waves,omitempty"`
+	Patrol      *PatrolDebugInfo      `json:"patrol,omitempty"`
+	Constraints *ConstraintDebugInfo  `json:"constraints,omitempty"`
+	Symmetry    *SymmetryDebugInfo    `json:"symmetry,omitempty"`
+	Validation  *ValidationDebugInfo  `json:"validation,omitempty"`
 }
 
 // BridgeLayerDebugInfo contains debug info for bridge layer generation
@@ -140,6 +163,7 @@ type EnemyLayerDebugInfo struct {
 type MainPathDebugInfo struct {
 	PathCellCount int      `json:"pathCellCount"`
 	PathSegments  []string `json:"pathSegments,omitempty"`
+	HazardCells   int      `json:"hazardCells,omitempty"` // Path cells on hazards that no route could avoid
 	Misses        []string `json:"misses,omitempty"`
 }
 
@@ -222,18 +246,41 @@ type MainPathData struct {
 	SquishyScore    [][]float64 // walking_distance / direct_distance (higher = better for ranged)
 }
 
-// LayerContext holds all layer state to reduce parameter passing
+// LayerContext holds the layers static and enemy placement read and write, as
+// grids, so the rules test bits instead of indexing [][]int layers. A pipeline
+// builds it once its terrain is done, runs every placement step on it, and
+// reads the placed layers back with Grid.Layer once, for the payload; layers
+// stay [][]int in requests and responses.
 type LayerContext struct {
 	Width, Height int
-	Ground        [][]int
-	SoftEdge      [][]int
-	Bridge        [][]int
-	Rail          [][]int // nil when rail not enabled
-	Static        [][]int
-	Chaser        [][]int
-	Zoner         [][]int
-	DPS           [][]int
-	MobAir        [][]int
-	MainPath      *MainPathData
-	DoorPositions map[DoorPosition]Point
+	Ground        *Grid
+	SoftEdge      *Grid
+	Bridge        *Grid
+	Rail          *Grid // empty when rail not enabled
+	Static        *Grid
+	Chaser        *Grid
+	Zoner         *Grid
+	DPS           *Grid
+	MobAir        *Grid
+	MainPath      *MainPathData // set once the main path is computed; static placement does not read it
+	DoorPositions []DoorSite
+}
+
+// newLayerContext builds the placement context over the finished terrain.
+// Nil layers read as empty, and the static and enemy grids start empty.
+func newLayerContext(ground, softEdge, bridge, rail [][]int, doorPositions []DoorSite, width, height int) *LayerContext {
+	return &LayerContext{
+		Width:         width,
+		Height:        height,
+		Ground:        GridFromLayer(ground, width, height),
+		SoftEdge:      GridFromLayer(softEdge, width, height),
+		Bridge:        GridFromLayer(bridge, width, height),
+		Rail:          GridFromLayer(rail, width, height),
+		Static:        NewGrid(width, height),
+		Chaser:        NewGrid(width, height),
+		Zoner:         NewGrid(width, height),
+		DPS:           NewGrid(width, height),
+		MobAir:        NewGrid(width, height),
+		DoorPositions: doorPositions,
+	}
 }
@@ -0,0 +1,145 @@
+package generate
+
+import (
+	"fmt"
+
+	"tile-backend/internal/model"
+)
+
+// WaveDebugInfo contains debug info for spawn wave assignment
+type WaveDebugInfo struct {
+	Skipped    bool       `json:"skipped"`
+	SkipReason string     `json:"skipReason,omitempty"`
+	WaveCount  int        `json:"waveCount"` // Waves that got at least one enemy
+	Waves      []WaveInfo `json:"waves"`
+	Misses     []MissInfo `json:"misses,omitempty"`
+}
+
+// WaveInfo describes one spawn wave
+type WaveInfo struct {
+	Wave       int      `json:"wave"`
+	Regions    []string `json:"regions"` // Placement group regions the wave draws from
+	Budget     int      `json:"budget"`
+	EnemyCount int      `json:"enemyCount"`
+}
+
+// waveEntry is one enemy on its way into a wave
+type waveEntry struct {
+	layer string
+	pos   Point
+}
+
+// assignWaves splits the placed enemies into the stage's spawn waves. Enemies
+// are grouped by the placement group region they stand in, and the groups feed
+// the waves in order, so with two halves the second wave comes from the
+// opposite half of the room. A wave over its budget passes its excess on to
+// the next wave; the last wave keeps whatever is left. Waves that end up empty
+// are dropped and the rest renumbered from 1.
+func assignWaves(hints *StagePlacementHints, chaserLayer, zonerLayer, dpsLayer, mobAirLayer [][]int, width, height int) ([]model.EnemyWave, *WaveDebugInfo) {
+	if hints == nil || hints.WaveCount == 0 {
+		return nil, &WaveDebugInfo{Skipped: true, SkipReason: "stage has no spawn waves"}
+	}
+	debug := &WaveDebugInfo{Waves: []WaveInfo{}}
+
+	groups := hints.Groups
+	if len(groups) == 0 {
+		groups = []PlacementGroup{{Region: RegionFull}}
+	}
+	waveCount := hints.WaveCount
+
+	// Group g feeds waves [g*W/G, (g+1)*W/G); with more groups than waves,
+	// neighbouring groups share a wave
+	waveSpan := func(g int) (first, last int) {
+		first = g * waveCount / len(groups)
+		last = (g+1)*waveCount/len(groups) - 1
+		if last < first {
+			last = first
+		}
+		return first, last
+	}
+
+	perGroup := make([][]waveEntry, len(groups))
+	for _, l := range []struct {
+		name  string
+		layer [][]int
+	}{{"zoner", zonerLayer}, {"chaser", chaserLayer}, {"dps", dpsLayer}, {"mobAir", mobAirLayer}} {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if l.layer[y][x] != 1 {
+					continue
+				}
+				pos := Point{X: x, Y: y}
+				g := groupAt(groups, pos, width, height)
+				perGroup[g] = append(perGroup[g], waveEntry{layer: l.name, pos: pos})
+			}
+		}
+	}
+
+	// A group feeding several waves deals its enemies out in turn
+	waves := make([][]waveEntry, waveCount)
+	regions := make([][]string, waveCount)
+	for g, entries := range perGroup {
+		first, last := waveSpan(g)
+		for w := first; w <= last; w++ {
+			regions[w] = append(regions[w], groups[g].Region.String())
+		}
+		for i, e := range entries {
+			w := first + i%(last-first+1)
+			waves[w] = append(waves[w], e)
+		}
+	}
+
+	spilled := 0
+	for w := 0; w < waveCount-1; w++ {
+		if excess := len(waves[w]) - hints.WaveBudgets[w]; excess > 0 {
+			keep := len(waves[w]) - excess
+			waves[w+1] = append(waves[w+1], waves[w][keep:]...)
+			waves[w] = waves[w][:keep]
+			spilled += excess
+		}
+	}
+	if spilled > 0 {
+		debug.Misses = append(debug.Misses, MissInfo{Reason: "enemies over a wave budget moved to the next wave", Count: spilled})
+	}
+	if excess := len(waves[waveCount-1]) - hints.WaveBudgets[waveCount-1]; excess > 0 {
+		debug.Misses = append(debug.Misses, MissInfo{Reason: "last wave is over budget", Count: excess})
+	}
+
+	var result []model.EnemyWave
+	for w, entries := range waves {
+		if len(entries) == 0 {
+			debug.Misses = append(debug.Misses, MissInfo{Reason: fmt.Sprintf("wave %d got no enemies and was dropped", w+1)})
+			continue
+		}
+		debug.WaveCount++
+		debug.Waves = append(debug.Waves, WaveInfo{
+			Wave:       debug.WaveCount,
+			Regions:    regions[w],
+			Budget:     hints.WaveBudgets[w],
+			EnemyCount: len(entries),
+		})
+		for _, e := range entries {
+			result = append(result, model.EnemyWave{Layer: e.layer, X: e.pos.X, Y: e.pos.Y, Wave: debug.WaveCount})
+		}
+	}
+
+	return result, debug
+}
+
+// groupAt returns the index of the first group whose region contains pos, or
+// the group with the nearest region center when none does (fallback placement
+// may put enemies outside every group region)
+func groupAt(groups []PlacementGroup, pos Point, width, height int) int {
+	best, bestDist := 0, -1
+	for i, g := range groups {
+		minY, maxY, minX, maxX := GetRegionBounds(g.Region, width, height)
+		if pos.X >= minX && pos.X < maxX && pos.Y >= minY && pos.Y < maxY {
+			return i
+		}
+		dist := abs(pos.X-(minX+maxX)/2) + abs(pos.Y-(minY+maxY)/2)
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
@@ -0,0 +1,103 @@
+package generate
+
+import (
+	"context"
+	"testing"
+
+	"tile-backend/internal/model"
+	"tile-backend/internal/validate"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignWaves_OppositeHalves(t *testing.T) {
+	width, height := 12, 8
+	chaser, zoner, dps, mobAir := createEmptyLayer(width, height), createEmptyLayer(width, height), createEmptyLayer(width, height), createEmptyLayer(width, height)
+	chaser[2][1], chaser[5][3], dps[4][8], dps[6][10], mobAir[1][9] = 1, 1, 1, 1, 1
+
+	hints := &StagePlacementHints{
+		GroupCount:  2,
+		Groups:      []PlacementGroup{{Region: RegionLeft}, {Region: RegionRight}},
+		WaveCount:   2,
+		WaveBudgets: []int{10, 10},
+	}
+	waves, debug := assignWaves(hints, chaser, zoner, dps, mobAir, width, height)
+	require.Len(t, waves, 5)
+	assert.Equal(t, 2, debug.WaveCount)
+	assert.Empty(t, debug.Misses)
+	for _, w := range waves {
+		if w.Wave == 1 {
+			assert.Less(t, w.X, width/2, "wave 1 %s at (%d,%d)", w.Layer, w.X, w.Y)
+		} else {
+			assert.GreaterOrEqual(t, w.X, width/2, "wave 2 %s at (%d,%d)", w.Layer, w.X, w.Y)
+		}
+	}
+	assert.Equal(t, []string{"left"}, debug.Waves[0].Regions)
+	assert.Equal(t, []string{"right"}, debug.Waves[1].Regions)
+}
+
+func TestAssignWaves_Budgets(t *testing.T) {
+	width, height := 10, 6
+	chaser, empty := createEmptyLayer(width, height), createEmptyLayer(width, height)
+	for x := 0; x < 5; x++ {
+		chaser[2][x*2] = 1
+	}
+
+	// One group dealt over two waves (3 + 2); the first wave only takes 2
+	hints := &StagePlacementHints{WaveCount: 2, WaveBudgets: []int{2, 10}}
+	waves, debug := assignWaves(hints, chaser, empty, empty, empty, width, height)
+	require.Len(t, waves, 5)
+	require.Len(t, debug.Waves, 2)
+	assert.Equal(t, 2, debug.Waves[0].EnemyCount)
+	assert.Equal(t, 3, debug.Waves[1].EnemyCount)
+	assert.Equal(t, []MissInfo{{Reason: "enemies over a wave budget moved to the next wave", Count: 1}}, debug.Misses)
+
+	// Waves without enemies are dropped and the rest renumbered
+	single := createEmptyLayer(width, height)
+	single[3][3] = 1
+	hints = &StagePlacementHints{WaveCount: 3, WaveBudgets: []int{4, 4, 4}}
+	waves, debug = assignWaves(hints, single, empty, empty, empty, width, height)
+	assert.Equal(t, []model.EnemyWave{{Layer: "chaser", X: 3, Y: 3, Wave: 1}}, waves)
+	assert.Equal(t, 1, debug.WaveCount)
+	assert.Len(t, debug.Misses, 2)
+
+	waves, debug = assignWaves(&StagePlacementHints{}, chaser, empty, empty, empty, width, height)
+	assert.Nil(t, waves)
+	assert.True(t, debug.Skipped)
+}
+
+func TestGenerateRooms_Waves(t *testing.T) {
+	for i := int64(0); i < 10; i++ {
+		seed := i
+		for _, stage := range []string{"pressure", "peak"} {
+			resp, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+				Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight, DoorTop},
+				StaticCount: 4, StageType: stage, Seed: &seed,
+			})
+			require.NoError(t, err)
+			cfg := GetStageConfig(stage)
+			require.NotEmpty(t, resp.Payload.Waves, stage)
+			assert.LessOrEqual(t, resp.Payload.WaveCount(), cfg.WaveCount, stage)
+			assert.Equal(t, resp.DebugInfo.Waves.WaveCount, resp.Payload.WaveCount(), stage)
+			result := validate.ValidateTemplate(&resp.Payload, true)
+			assert.True(t, result.Valid, "%s: %v", stage, result.Errors)
+		}
+
+		cave, err := GenerateCave(context.Background(), CaveGenerateRequest{
+			Width: 30, Height: 20, Doors: []DoorPosition{DoorLeft, DoorRight}, StageType: "pressure", Seed: &seed,
+		})
+		require.NoError(t, err)
+		assert.NotEmpty(t, cave.Payload.Waves)
+		result := validate.ValidateTemplate(&cave.Payload, true)
+		assert.True(t, result.Valid, "cave: %v", result.Errors)
+
+		// Stages without waves leave the payload alone
+		building, err := GenerateFullRoom(context.Background(), FullRoomGenerateRequest{
+			Width: 24, Height: 16, Doors: []DoorPosition{DoorLeft, DoorRight}, StageType: "building", Seed: &seed,
+		})
+		require.NoError(t, err)
+		assert.Nil(t, building.Payload.Waves)
+		assert.True(t, building.DebugInfo.Waves.Skipped)
+	}
+}
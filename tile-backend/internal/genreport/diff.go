@@ -0,0 +1,162 @@
+package genreport
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+)
+
+// Thresholds decide which differences between two reports are reported
+type Thresholds struct {
+	FailureRate   float64 // Rise in failure rate that is a regression
+	FillRate      float64 // Drop in a layer's fill rate that is a regression
+	Slowdown      float64 // Ratio of mean times that is a regression...
+	SlowdownMs    float64 // ...when the mean also grows by this many milliseconds
+	Difficulty    float64 // Shift of mean difficulty, either way, worth a look
+	WalkableRatio float64 // Shift of mean walkable ratio, either way, worth a look
+}
+
+// DefaultThresholds are the thresholds the genreport command starts from
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		FailureRate:   0.05,
+		FillRate:      0.05,
+		Slowdown:      1.5,
+		SlowdownMs:    1,
+		Difficulty:    0.1,
+		WalkableRatio: 0.1,
+	}
+}
+
+// Change is one difference between two reports
+type Change struct {
+	Combo      string  `json:"combo"`  // ComboStats.Key
+	Metric     string  `json:"metric"` // e.g. "failureRate" or "fillRate:chaser"
+	Old        float64 `json:"old"`
+	New        float64 `json:"new"`
+	Regression bool    `json:"regression"` // False for shifts that are only worth a look
+}
+
+// Diff lists the differences between two reports
+type Diff struct {
+	Notes   []string `json:"notes,omitempty"`   // Option differences that make the comparison uneven
+	Added   []string `json:"added,omitempty"`   // Combinations only in the new report
+	Removed []string `json:"removed,omitempty"` // Combinations only in the old report
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// Regressions counts the changes that are regressions
+func (d *Diff) Regressions() int {
+	n := 0
+	for _, c := range d.Changes {
+		if c.Regression {
+			n++
+		}
+	}
+	return n
+}
+
+// Compare lists the differences from the old report to the current one that
+// pass the thresholds. Combinations are matched by shape, door mask and stage type.
+func Compare(old, cur *Report, th Thresholds) *Diff {
+	d := &Diff{}
+	o, n := old.Options, cur.Options
+	if o.Width != n.Width || o.Height != n.Height {
+		d.Notes = append(d.Notes, fmt.Sprintf("room size differs: %dx%d vs %dx%d", o.Width, o.Height, n.Width, n.Height))
+	}
+	if o.Seed != n.Seed || o.Runs != n.Runs {
+		d.Notes = append(d.Notes, fmt.Sprintf("seeds differ: %d runs from %d vs %d runs from %d", o.Runs, o.Seed, n.Runs, n.Seed))
+	}
+
+	oldCombos := make(map[string]*ComboStats)
+	for i := range old.Combos {
+		oldCombos[old.Combos[i].Key()] = &old.Combos[i]
+	}
+	seen := make(map[string]bool)
+	for i := range cur.Combos {
+		nc := &cur.Combos[i]
+		key := nc.Key()
+		seen[key] = true
+		oc, ok := oldCombos[key]
+		if !ok {
+			d.Added = append(d.Added, key)
+			continue
+		}
+		d.Changes = append(d.Changes, compareCombo(key, oc, nc, th)...)
+	}
+	for i := range old.Combos {
+		if key := old.Combos[i].Key(); !seen[key] {
+			d.Removed = append(d.Removed, key)
+		}
+	}
+	return d
+}
+
+// compareCombo lists the differences of one combination
+func compareCombo(key string, old, cur *ComboStats, th Thresholds) []Change {
+	var changes []Change
+	add := func(metric string, o, n float64, regression bool) {
+		changes = append(changes, Change{Combo: key, Metric: metric, Old: o, New: n, Regression: regression})
+	}
+
+	if cur.FailureRate-old.FailureRate >= th.FailureRate && cur.FailureRate > old.FailureRate {
+		add("failureRate", old.FailureRate, cur.FailureRate, true)
+	}
+	for _, name := range sortedKeys(old.Layers) {
+		ol, nl := old.Layers[name], cur.Layers[name]
+		if nl == nil {
+			continue // The layer was not generated in any successful run
+		}
+		if ol.FillRate-nl.FillRate >= th.FillRate && nl.FillRate < ol.FillRate {
+			add("fillRate:"+name, ol.FillRate, nl.FillRate, true)
+		}
+	}
+	if om, nm := old.Millis.Mean, cur.Millis.Mean; om > 0 && nm/om >= th.Slowdown && nm-om >= th.SlowdownMs {
+		add("millis", om, nm, true)
+	}
+	if math.Abs(cur.Difficulty.Mean-old.Difficulty.Mean) >= th.Difficulty && old.Difficulty.Count > 0 && cur.Difficulty.Count > 0 {
+		add("difficulty", old.Difficulty.Mean, cur.Difficulty.Mean, false)
+	}
+	if math.Abs(cur.WalkableRatio.Mean-old.WalkableRatio.Mean) >= th.WalkableRatio && old.WalkableRatio.Count > 0 && cur.WalkableRatio.Count > 0 {
+		add("walkableRatio", old.WalkableRatio.Mean, cur.WalkableRatio.Mean, false)
+	}
+	return changes
+}
+
+// WriteDiff writes the differences as text, regressions first
+func WriteDiff(w io.Writer, d *Diff) error {
+	for _, note := range d.Notes {
+		fmt.Fprintf(w, "note: %s\n", note)
+	}
+	for _, key := range d.Added {
+		fmt.Fprintf(w, "added: %s\n", key)
+	}
+	for _, key := range d.Removed {
+		fmt.Fprintf(w, "removed: %s\n", key)
+	}
+	if len(d.Changes) == 0 {
+		fmt.Fprintln(w, "no changes past the thresholds")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\tCOMBO\tMETRIC\tOLD\tNEW")
+	for _, regression := range []bool{true, false} {
+		for _, c := range d.Changes {
+			if c.Regression != regression {
+				continue
+			}
+			mark := "shift"
+			if c.Regression {
+				mark = "REGRESSION"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%.3f\t%.3f\n", mark, c.Combo, c.Metric, c.Old, c.New)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%d regressions, %d shifts\n", d.Regressions(), len(d.Changes)-d.Regressions())
+	return nil
+}
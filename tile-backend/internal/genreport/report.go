@@ -0,0 +1,336 @@
+// Package genreport runs the room generators over every shape × door mask ×
+// stage combination and collects quality statistics, so generator changes can
+// be compared across hundreds of rooms rather than a handful of seeds.
+package genreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"tile-backend/internal/generate"
+	"tile-backend/internal/model"
+)
+
+// Options control a report run
+type Options struct {
+	Runs    int           `json:"runs"`              // Generations per combination
+	Seed    int64         `json:"seed"`              // Run i of every combination uses seed Seed+i
+	Width   int           `json:"width"`             // Room width
+	Height  int           `json:"height"`            // Room height
+	Shapes  []string      `json:"shapes,omitempty"`  // Shape names to run (empty = all)
+	Stages  []string      `json:"stages,omitempty"`  // Stage types to run (empty = all)
+	Timeout time.Duration `json:"timeout,omitempty"` // Deadline per generation (0 = none), nanoseconds in JSON
+}
+
+// DefaultOptions are the settings the genreport command starts from
+func DefaultOptions() Options {
+	return Options{Runs: 20, Seed: 1, Width: 20, Height: 12}
+}
+
+// Report is the result of a run
+type Report struct {
+	Options Options       `json:"options"`
+	Shapes  []ShapeTotals `json:"shapes"`
+	Combos  []ComboStats  `json:"combos"`
+}
+
+// ShapeTotals sums a shape's combinations
+type ShapeTotals struct {
+	Shape          string  `json:"shape"`
+	Runs           int     `json:"runs"`
+	Failures       int     `json:"failures"`
+	FailureRate    float64 `json:"failureRate"`
+	MeanDifficulty float64 `json:"meanDifficulty"` // Over successful runs
+	MeanMillis     float64 `json:"meanMillis"`
+}
+
+// ComboStats are the statistics of one shape × door mask × stage combination
+type ComboStats struct {
+	Shape       string         `json:"shape"`
+	DoorMask    int            `json:"doorMask"` // Top=1, Right=2, Bottom=4, Left=8
+	StageType   string         `json:"stageType"`
+	Runs        int            `json:"runs"`
+	Failures    int            `json:"failures"`
+	FailureRate float64        `json:"failureRate"`
+	Errors      map[string]int `json:"errors,omitempty"` // Error message → count
+	Retries     int            `json:"retries"`          // Extra rooms generated to pass strict validation
+
+	Difficulty    Distribution `json:"difficulty"`    // Overall difficulty (0-1) of successful runs
+	WalkableRatio Distribution `json:"walkableRatio"` // Ground cells not covered by statics, over all cells, of successful runs
+	Millis        Distribution `json:"millis"`        // Generation time of every run, failed ones included
+
+	// Layers holds placed-vs-target counts for each debug info entry that
+	// reports them, keyed by its debugInfo field name
+	Layers map[string]*LayerStats `json:"layers,omitempty"`
+}
+
+// Key identifies the combination across reports
+func (c *ComboStats) Key() string {
+	return fmt.Sprintf("%s/%d/%s", c.Shape, c.DoorMask, c.StageType)
+}
+
+// Distribution summarizes a sample
+type Distribution struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	Max   float64 `json:"max"`
+}
+
+// LayerStats sums one layer's placement counts over successful runs
+type LayerStats struct {
+	Target    int     `json:"target"`
+	Placed    int     `json:"placed"`
+	FillRate  float64 `json:"fillRate"`  // Placed / Target, 1 when nothing was asked for
+	ShortRuns int     `json:"shortRuns"` // Runs that placed fewer than asked for
+}
+
+// Run generates Options.Runs rooms for every selected combination
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if opts.Runs <= 0 {
+		return nil, fmt.Errorf("runs must be positive, got %d", opts.Runs)
+	}
+	for _, shape := range opts.Shapes {
+		if _, ok := generate.LookupGenerator(shape); !ok {
+			return nil, fmt.Errorf("unknown shape: %s", shape)
+		}
+	}
+
+	report := &Report{Options: opts}
+	for _, combo := range generate.Combos() {
+		if len(opts.Shapes) > 0 && !slices.Contains(opts.Shapes, combo.Shape) {
+			continue
+		}
+		if len(opts.Stages) > 0 && !slices.Contains(opts.Stages, combo.StageType) {
+			continue
+		}
+		stats, err := runCombo(ctx, combo, opts)
+		if err != nil {
+			return nil, err
+		}
+		report.Combos = append(report.Combos, *stats)
+	}
+	if len(report.Combos) == 0 {
+		return nil, fmt.Errorf("no shape/stage combinations selected")
+	}
+	report.Shapes = shapeTotals(report.Combos)
+	return report, nil
+}
+
+// runCombo generates the runs of one combination. It only fails when ctx
+// itself is done; generation errors are counted.
+func runCombo(ctx context.Context, combo generate.Combo, opts Options) (*ComboStats, error) {
+	g, _ := generate.LookupGenerator(combo.Shape)
+	req := g.RequestFor(generate.RoomParams{
+		Width:     opts.Width,
+		Height:    opts.Height,
+		Doors:     combo.Doors,
+		StageType: combo.StageType,
+	})
+
+	stats := &ComboStats{
+		Shape:     combo.Shape,
+		DoorMask:  combo.DoorMask,
+		StageType: combo.StageType,
+		Runs:      opts.Runs,
+	}
+	var difficulty, walkable, millis []float64
+	for i := 0; i < opts.Runs; i++ {
+		seed := opts.Seed + int64(i)
+		result, elapsed, err := generateOnce(ctx, g, req, seed, opts.Timeout)
+		millis = append(millis, float64(elapsed.Microseconds())/1000)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr // The run was cut short, not failed
+		}
+
+		if err == nil && result.Validation != nil {
+			stats.Retries += result.Validation.Attempts - 1
+			if !result.Validation.Valid {
+				err = fmt.Errorf("failed strict validation in %d attempts", result.Validation.Attempts)
+				if v := result.Validation.Violations; len(v) > 0 {
+					// Leave out the cell, so the same violation groups across seeds
+					err = fmt.Errorf("%w: %s: %s", err, v[0].Layer, v[0].Reason)
+				}
+			}
+		}
+		if err != nil {
+			stats.Failures++
+			if stats.Errors == nil {
+				stats.Errors = make(map[string]int)
+			}
+			stats.Errors[err.Error()]++
+			continue
+		}
+		if result.Difficulty != nil {
+			difficulty = append(difficulty, result.Difficulty.Overall)
+		}
+		walkable = append(walkable, walkableRatio(result.Payload))
+		if err := addLayerCounts(stats, result.Response); err != nil {
+			return nil, fmt.Errorf("%s: reading debug info: %w", stats.Key(), err)
+		}
+	}
+
+	stats.FailureRate = float64(stats.Failures) / float64(stats.Runs)
+	stats.Difficulty = distribution(difficulty)
+	stats.WalkableRatio = distribution(walkable)
+	stats.Millis = distribution(millis)
+	for _, layer := range stats.Layers {
+		layer.FillRate = 1
+		if layer.Target > 0 {
+			layer.FillRate = float64(layer.Placed) / float64(layer.Target)
+		}
+	}
+	return stats, nil
+}
+
+// generateOnce runs one seeded generation with its own deadline and times it
+func generateOnce(ctx context.Context, g generate.Generator, req interface{}, seed int64, timeout time.Duration) (*generate.RoomResult, time.Duration, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	result, err := g.Generate(ctx, req, generate.GenerateOptions{Seed: &seed})
+	return result, time.Since(start), err
+}
+
+// walkableRatio is the share of cells that are ground without a static on them
+func walkableRatio(payload *model.TemplatePayload) float64 {
+	walkable, total := 0, 0
+	for y, row := range payload.Ground {
+		for x, cell := range row {
+			total++
+			if cell == 1 && !(y < len(payload.Static) && x < len(payload.Static[y]) && payload.Static[y][x] != 0) {
+				walkable++
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(walkable) / float64(total)
+}
+
+// placementCounts is the part of a layer's debug info the report reads; the
+// debug info types share the targetCount/placedCount field names
+type placementCounts struct {
+	TargetCount *int `json:"targetCount"`
+	PlacedCount *int `json:"placedCount"`
+}
+
+// addLayerCounts adds the placed and target counts of every debug info entry
+// in a shape response. The responses differ per shape, so they are read
+// through their JSON form.
+func addLayerCounts(stats *ComboStats, response interface{}) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		DebugInfo map[string]json.RawMessage `json:"debugInfo"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	for name, raw := range resp.DebugInfo {
+		var counts placementCounts
+		if json.Unmarshal(raw, &counts) != nil || counts.TargetCount == nil || counts.PlacedCount == nil {
+			continue // Not an object with placement counts
+		}
+		if stats.Layers == nil {
+			stats.Layers = make(map[string]*LayerStats)
+		}
+		layer := stats.Layers[name]
+		if layer == nil {
+			layer = &LayerStats{}
+			stats.Layers[name] = layer
+		}
+		layer.Target += *counts.TargetCount
+		layer.Placed += *counts.PlacedCount
+		if *counts.PlacedCount < *counts.TargetCount {
+			layer.ShortRuns++
+		}
+	}
+	return nil
+}
+
+// distribution summarizes a sample; percentiles use the nearest rank
+func distribution(sample []float64) Distribution {
+	if len(sample) == 0 {
+		return Distribution{}
+	}
+	sorted := append([]float64(nil), sample...)
+	sort.Float64s(sorted)
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return sorted[i]
+	}
+	return Distribution{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Mean:  sum / float64(len(sorted)),
+		P50:   rank(0.5),
+		P90:   rank(0.9),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// shapeTotals sums the combinations of each shape, in the order shapes first appear
+func shapeTotals(combos []ComboStats) []ShapeTotals {
+	var totals []ShapeTotals
+	index := make(map[string]int)
+	difficultySum := make(map[string]float64)
+	difficultyCount := make(map[string]int)
+	millisSum := make(map[string]float64)
+	for _, c := range combos {
+		i, ok := index[c.Shape]
+		if !ok {
+			i = len(totals)
+			index[c.Shape] = i
+			totals = append(totals, ShapeTotals{Shape: c.Shape})
+		}
+		totals[i].Runs += c.Runs
+		totals[i].Failures += c.Failures
+		difficultySum[c.Shape] += c.Difficulty.Mean * float64(c.Difficulty.Count)
+		difficultyCount[c.Shape] += c.Difficulty.Count
+		millisSum[c.Shape] += c.Millis.Mean * float64(c.Millis.Count)
+	}
+	for i := range totals {
+		t := &totals[i]
+		t.FailureRate = float64(t.Failures) / float64(t.Runs)
+		if n := difficultyCount[t.Shape]; n > 0 {
+			t.MeanDifficulty = difficultySum[t.Shape] / float64(n)
+		}
+		t.MeanMillis = millisSum[t.Shape] / float64(t.Runs)
+	}
+	return totals
+}
+
+// ReadReport loads a report written as JSON
+func ReadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,161 @@
+package genreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func smallOptions() Options {
+	opts := DefaultOptions()
+	opts.Runs = 3
+	opts.Shapes = []string{"fullroom"}
+	opts.Stages = []string{"peak"}
+	return opts
+}
+
+func TestRun(t *testing.T) {
+	report, err := Run(context.Background(), smallOptions())
+	require.NoError(t, err)
+	require.NotEmpty(t, report.Combos)
+	require.Len(t, report.Shapes, 1)
+
+	runs := 0
+	for _, c := range report.Combos {
+		assert.Equal(t, "fullroom", c.Shape)
+		assert.Equal(t, "peak", c.StageType)
+		assert.Equal(t, 3, c.Runs)
+		assert.Equal(t, 3, c.Millis.Count, "every run is timed")
+		assert.Equal(t, c.Runs-c.Failures, c.Difficulty.Count)
+		assert.Greater(t, c.WalkableRatio.Mean, 0.0)
+		assert.LessOrEqual(t, c.WalkableRatio.Max, 1.0)
+		require.Contains(t, c.Layers, "chaser", "peak rooms place chasers")
+		assert.Greater(t, c.Layers["chaser"].Target, 0)
+		runs += c.Runs
+	}
+	assert.Equal(t, runs, report.Shapes[0].Runs)
+
+	// The same seeds give the same rooms
+	again, err := Run(context.Background(), smallOptions())
+	require.NoError(t, err)
+	for i := range report.Combos {
+		assert.Equal(t, report.Combos[i].Difficulty, again.Combos[i].Difficulty)
+		assert.Equal(t, report.Combos[i].Layers, again.Combos[i].Layers)
+	}
+}
+
+func TestRun_InvalidOptions(t *testing.T) {
+	opts := smallOptions()
+	opts.Shapes = []string{"tunnel"}
+	_, err := Run(context.Background(), opts)
+	assert.ErrorContains(t, err, "unknown shape")
+
+	opts = smallOptions()
+	opts.Stages = []string{"nonexistent"}
+	_, err = Run(context.Background(), opts)
+	assert.ErrorContains(t, err, "no shape/stage combinations")
+
+	opts = smallOptions()
+	opts.Runs = 0
+	_, err = Run(context.Background(), opts)
+	assert.Error(t, err)
+}
+
+func TestRun_CountsFailures(t *testing.T) {
+	// Boss rooms need a clear center that a 10x10 platform room rarely has
+	opts := DefaultOptions()
+	opts.Runs = 10
+	opts.Width, opts.Height = 10, 10
+	opts.Shapes = []string{"platform"}
+	opts.Stages = []string{"boss"}
+	report, err := Run(context.Background(), opts)
+	require.NoError(t, err)
+	require.Greater(t, report.Shapes[0].Failures, 0)
+
+	for _, c := range report.Combos {
+		n := 0
+		for _, count := range c.Errors {
+			n += count
+		}
+		assert.Equal(t, c.Failures, n)
+		assert.InDelta(t, float64(c.Failures)/float64(c.Runs), c.FailureRate, 1e-9)
+	}
+}
+
+func TestDistribution(t *testing.T) {
+	d := distribution([]float64{5, 1, 4, 2, 3, 6, 7, 8, 9, 10})
+	assert.Equal(t, Distribution{Count: 10, Min: 1, Mean: 5.5, P50: 5, P90: 9, Max: 10}, d)
+	assert.Equal(t, Distribution{}, distribution(nil))
+}
+
+func TestCompare(t *testing.T) {
+	combo := func(failureRate, fill, millis, difficulty float64) ComboStats {
+		return ComboStats{
+			Shape: "fullroom", DoorMask: 5, StageType: "peak", Runs: 20,
+			FailureRate: failureRate,
+			Difficulty:  Distribution{Count: 20, Mean: difficulty},
+			Millis:      Distribution{Count: 20, Mean: millis},
+			Layers:      map[string]*LayerStats{"chaser": {FillRate: fill}},
+		}
+	}
+	old := &Report{Options: DefaultOptions(), Combos: []ComboStats{combo(0, 1, 2, 0.5)}}
+	th := DefaultThresholds()
+
+	// Small movements stay below the thresholds
+	same := &Report{Options: DefaultOptions(), Combos: []ComboStats{combo(0.01, 0.99, 2.5, 0.52)}}
+	d := Compare(old, same, th)
+	assert.Empty(t, d.Changes)
+	assert.Empty(t, d.Notes)
+
+	worse := &Report{Options: DefaultOptions(), Combos: []ComboStats{combo(0.2, 0.8, 4, 0.7)}}
+	d = Compare(old, worse, th)
+	metrics := make(map[string]bool)
+	for _, c := range d.Changes {
+		metrics[c.Metric] = c.Regression
+	}
+	assert.Equal(t, map[string]bool{"failureRate": true, "fillRate:chaser": true, "millis": true, "difficulty": false}, metrics)
+	assert.Equal(t, 3, d.Regressions())
+
+	// Improvements are not reported
+	d = Compare(worse, old, th)
+	assert.Equal(t, 0, d.Regressions())
+	assert.Len(t, d.Changes, 1, "only the difficulty shift remains")
+
+	// Unmatched combinations and uneven options are listed
+	other := combo(0, 1, 2, 0.5)
+	other.DoorMask = 10
+	opts := DefaultOptions()
+	opts.Seed = 7
+	d = Compare(old, &Report{Options: opts, Combos: []ComboStats{other}}, th)
+	assert.Equal(t, []string{"fullroom/10/peak"}, d.Added)
+	assert.Equal(t, []string{"fullroom/5/peak"}, d.Removed)
+	assert.Len(t, d.Notes, 1)
+}
+
+func TestWriteSummaryAndReadReport(t *testing.T) {
+	report, err := Run(context.Background(), smallOptions())
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSummary(&buf, report))
+	assert.Contains(t, buf.String(), "3 runs per combination")
+	assert.Contains(t, buf.String(), report.Combos[0].Key())
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	loaded, err := ReadReport(path)
+	require.NoError(t, err)
+	assert.Empty(t, Compare(report, loaded, DefaultThresholds()).Changes)
+
+	buf.Reset()
+	require.NoError(t, WriteDiff(&buf, Compare(report, loaded, DefaultThresholds())))
+	assert.Contains(t, buf.String(), "no changes past the thresholds")
+}
@@ -0,0 +1,74 @@
+package genreport
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteSummary writes the report as text: per-shape totals, one line per
+// combination, then the error messages of failed runs
+func WriteSummary(w io.Writer, r *Report) error {
+	opts := r.Options
+	fmt.Fprintf(w, "%d runs per combination, seeds %d-%d, %dx%d rooms, %d combinations\n\n",
+		opts.Runs, opts.Seed, opts.Seed+int64(opts.Runs)-1, opts.Width, opts.Height, len(r.Combos))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SHAPE\tRUNS\tFAILED\tDIFFICULTY\tMS")
+	for _, s := range r.Shapes {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%.2f\t%.2f\n", s.Shape, s.Runs, percent(s.FailureRate), s.MeanDifficulty, s.MeanMillis)
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "COMBO\tFAILED\tRETRIES\tDIFFICULTY p50/p90\tWALKABLE\tMS mean/p90\tUNDERFILLED")
+	for i := range r.Combos {
+		c := &r.Combos[i]
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f/%.2f\t%.2f\t%.2f/%.2f\t%s\n",
+			c.Key(), percent(c.FailureRate), c.Retries, c.Difficulty.P50, c.Difficulty.P90,
+			c.WalkableRatio.Mean, c.Millis.Mean, c.Millis.P90, underfilled(c))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var errorLines []string
+	for i := range r.Combos {
+		c := &r.Combos[i]
+		for _, msg := range sortedKeys(c.Errors) {
+			errorLines = append(errorLines, fmt.Sprintf("  %s: %d× %s", c.Key(), c.Errors[msg], msg))
+		}
+	}
+	if len(errorLines) > 0 {
+		fmt.Fprintf(w, "\nErrors:\n%s\n", strings.Join(errorLines, "\n"))
+	}
+	return nil
+}
+
+// underfilled lists the layers that placed fewer than asked for, with their fill rate
+func underfilled(c *ComboStats) string {
+	var parts []string
+	for _, name := range sortedKeys(c.Layers) {
+		if layer := c.Layers[name]; layer.FillRate < 1 {
+			parts = append(parts, fmt.Sprintf("%s %s", name, percent(layer.FillRate)))
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func percent(v float64) string {
+	return fmt.Sprintf("%.1f%%", v*100)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
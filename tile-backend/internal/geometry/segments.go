@@ -0,0 +1,177 @@
+// Package geometry describes grid layers as line segments. It mirrors the
+// frontend's lineExtractor.ts so both sides produce the same segments.
+package geometry
+
+import "fmt"
+
+// Point is a grid cell
+type Point struct {
+	X, Y int
+}
+
+// Segment is a horizontal (1×N) or vertical (N×1) run of cells, both ends inclusive
+type Segment struct {
+	Start, End Point
+}
+
+// candidate is a maximal run considered by the set cover
+type candidate struct {
+	seg   Segment
+	cells []Point
+}
+
+// ExtractSegments covers every set cell of grid with horizontal and vertical
+// segments, using as few as the greedy set cover finds: it repeatedly takes the
+// maximal run that covers the most uncovered cells, the longer run on a tie.
+// Runs may overlap where they cross, e.g. at the corner of an L.
+func ExtractSegments(grid [][]int) []Segment {
+	if len(grid) == 0 || len(grid[0]) == 0 {
+		return nil
+	}
+	height, width := len(grid), len(grid[0])
+	set := func(x, y int) bool {
+		return x < len(grid[y]) && grid[y][x] == 1
+	}
+
+	remaining := 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if set(x, y) {
+				remaining++
+			}
+		}
+	}
+	if remaining == 0 {
+		return nil
+	}
+
+	// All maximal runs: rows first, then columns
+	var candidates []candidate
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !set(x, y) {
+				continue
+			}
+			c := candidate{seg: Segment{Start: Point{X: x, Y: y}}}
+			for ; x < width && set(x, y); x++ {
+				c.cells = append(c.cells, Point{X: x, Y: y})
+			}
+			c.seg.End = Point{X: x - 1, Y: y}
+			candidates = append(candidates, c)
+		}
+	}
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			if !set(x, y) {
+				continue
+			}
+			c := candidate{seg: Segment{Start: Point{X: x, Y: y}}}
+			for ; y < height && set(x, y); y++ {
+				c.cells = append(c.cells, Point{X: x, Y: y})
+			}
+			c.seg.End = Point{X: x, Y: y - 1}
+			candidates = append(candidates, c)
+		}
+	}
+
+	covered := make(map[Point]bool, remaining)
+	var segments []Segment
+	for remaining > 0 {
+		best, bestNew := -1, 0
+		for i, c := range candidates {
+			n := 0
+			for _, p := range c.cells {
+				if !covered[p] {
+					n++
+				}
+			}
+			if n > bestNew || (n == bestNew && best >= 0 && len(c.cells) > len(candidates[best].cells)) {
+				best, bestNew = i, n
+			}
+		}
+		if best < 0 || bestNew == 0 {
+			break
+		}
+		for _, p := range candidates[best].cells {
+			covered[p] = true
+		}
+		remaining -= bestNew
+		segments = append(segments, candidates[best].seg)
+	}
+	return segments
+}
+
+// Cells returns the cells a segment covers, from Start to End. ok is false when
+// the segment is neither horizontal nor vertical.
+func (s Segment) Cells() (cells []Point, ok bool) {
+	if s.Start.X != s.End.X && s.Start.Y != s.End.Y {
+		return nil, false
+	}
+	dx, dy := sign(s.End.X-s.Start.X), sign(s.End.Y-s.Start.Y)
+	for p := s.Start; ; p = (Point{X: p.X + dx, Y: p.Y + dy}) {
+		cells = append(cells, p)
+		if p == s.End {
+			return cells, true
+		}
+	}
+}
+
+// MismatchError locates the first cell where segments and grid disagree
+type MismatchError struct {
+	X, Y   int
+	Reason string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("(%d,%d): %s", e.X, e.Y, e.Reason)
+}
+
+// CheckSegments verifies that segments cover exactly the set cells of grid:
+// every segment is straight, in bounds and on set cells, and every set cell is
+// covered. Segments may overlap and need not be minimal. Returns a
+// *MismatchError for the first problem found.
+func CheckSegments(grid [][]int, segments []Segment) error {
+	height := len(grid)
+	inBounds := func(p Point) bool {
+		return p.Y >= 0 && p.Y < height && p.X >= 0 && p.X < len(grid[p.Y])
+	}
+	covered := make(map[Point]bool)
+	for _, s := range segments {
+		for _, p := range []Point{s.Start, s.End} {
+			if !inBounds(p) {
+				return &MismatchError{X: p.X, Y: p.Y, Reason: "segment runs outside the grid"}
+			}
+		}
+		cells, ok := s.Cells()
+		if !ok {
+			return &MismatchError{X: s.Start.X, Y: s.Start.Y, Reason: fmt.Sprintf("segment to (%d,%d) is not horizontal or vertical", s.End.X, s.End.Y)}
+		}
+		for _, p := range cells {
+			if !inBounds(p) {
+				return &MismatchError{X: p.X, Y: p.Y, Reason: "segment runs outside the grid"}
+			}
+			if grid[p.Y][p.X] != 1 {
+				return &MismatchError{X: p.X, Y: p.Y, Reason: "segment covers an empty cell"}
+			}
+			covered[p] = true
+		}
+	}
+	for y := range grid {
+		for x, v := range grid[y] {
+			if v == 1 && !covered[Point{X: x, Y: y}] {
+				return &MismatchError{X: x, Y: y, Reason: "cell is not covered by any segment"}
+			}
+		}
+	}
+	return nil
+}
+
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
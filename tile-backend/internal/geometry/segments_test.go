@@ -0,0 +1,119 @@
+package geometry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// grid parses rows of '#' (set) and '.' (empty)
+func grid(rows ...string) [][]int {
+	g := make([][]int, len(rows))
+	for y, row := range rows {
+		g[y] = make([]int, len(row))
+		for x, c := range row {
+			if c == '#' {
+				g[y][x] = 1
+			}
+		}
+	}
+	return g
+}
+
+func seg(x1, y1, x2, y2 int) Segment {
+	return Segment{Start: Point{X: x1, Y: y1}, End: Point{X: x2, Y: y2}}
+}
+
+func TestExtractSegments(t *testing.T) {
+	tests := []struct {
+		name string
+		grid [][]int
+		want []Segment
+	}{
+		{"empty", grid("....", "...."), nil},
+		{"no rows", nil, nil},
+		{"single cell", grid("....", "..#."), []Segment{seg(2, 1, 2, 1)}},
+		{"horizontal", grid(".###.", "....."), []Segment{seg(1, 0, 3, 0)}},
+		{"vertical", grid(".#.", ".#.", ".#."), []Segment{seg(1, 0, 1, 2)}},
+		{
+			"L shares its corner",
+			grid(
+				".####.",
+				"....#.",
+				"....#.",
+			),
+			[]Segment{seg(1, 0, 4, 0), seg(4, 0, 4, 2)},
+		},
+		{
+			"rectangle loop",
+			grid(
+				"####",
+				"#..#",
+				"####",
+			),
+			[]Segment{seg(0, 0, 3, 0), seg(0, 2, 3, 2), seg(0, 0, 0, 2), seg(3, 0, 3, 2)},
+		},
+		{
+			// Greedy picks the long column first, then the leftover cells of the rows
+			"plus",
+			grid(
+				"..#..",
+				"..#..",
+				"#####",
+				"..#..",
+				"..#..",
+				"..#..",
+			),
+			[]Segment{seg(2, 0, 2, 5), seg(0, 2, 4, 2)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractSegments(tt.grid)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, CheckSegments(tt.grid, got))
+		})
+	}
+}
+
+func TestSegmentCells(t *testing.T) {
+	cells, ok := seg(3, 1, 1, 1).Cells()
+	require.True(t, ok)
+	assert.Equal(t, []Point{{X: 3, Y: 1}, {X: 2, Y: 1}, {X: 1, Y: 1}}, cells)
+
+	_, ok = seg(0, 0, 1, 1).Cells()
+	assert.False(t, ok)
+}
+
+func TestCheckSegments(t *testing.T) {
+	g := grid(
+		".###",
+		"...#",
+	)
+
+	assert.NoError(t, CheckSegments(g, []Segment{seg(1, 0, 3, 0), seg(3, 0, 3, 1)}))
+	// Overlapping and non-minimal segments are accepted
+	assert.NoError(t, CheckSegments(g, []Segment{seg(1, 0, 2, 0), seg(2, 0, 3, 0), seg(3, 1, 3, 0)}))
+
+	tests := []struct {
+		name     string
+		segments []Segment
+		x, y     int
+	}{
+		{"uncovered cell", []Segment{seg(1, 0, 3, 0)}, 3, 1},
+		{"empty cell", []Segment{seg(0, 0, 3, 0), seg(3, 0, 3, 1)}, 0, 0},
+		{"out of bounds", []Segment{seg(1, 0, 3, 0), seg(3, 0, 3, 4)}, 3, 4},
+		{"diagonal", []Segment{seg(1, 0, 3, 1)}, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSegments(g, tt.segments)
+			var mismatch *MismatchError
+			require.ErrorAs(t, err, &mismatch)
+			assert.Equal(t, tt.x, mismatch.X)
+			assert.Equal(t, tt.y, mismatch.Y)
+		})
+	}
+}
@@ -2,7 +2,10 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"tile-backend/internal/generate"
@@ -17,8 +20,9 @@ import (
 
 // TemplateHandler handles HTTP requests for templates
 type TemplateHandler struct {
-	store  store.TemplateStore
-	logger *zap.Logger
+	store    store.TemplateStore
+	projects store.ProjectStore // resolves project_id on generate requests; nil disables it
+	logger   *zap.Logger
 }
 
 // NewTemplateHandler creates a new template handler
@@ -71,6 +75,11 @@ func (h *TemplateHandler) CreateTemplate(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// The corridor width needs the main path, which the generator computes
+	if width, ok := generate.MinCorridorWidth(&template.Payload, template.Width, template.Height); ok {
+		template.MinCorridorWidth = &width
+	}
+
 	// Save to database
 	savedTemplate, err := h.store.Create(r.Context(), template)
 	if err != nil {
@@ -179,6 +188,36 @@ func (h *TemplateHandler) ListTemplates(w http.ResponseWriter, r *http.Request)
 			params.MaxMobAirCount = &i
 		}
 	}
+	if val := query.Get("min_pickup_count"); val != "" {
+		if i, err := strconv.Atoi(val); err == nil {
+			params.MinPickupCount = &i
+		}
+	}
+	if val := query.Get("max_pickup_count"); val != "" {
+		if i, err := strconv.Atoi(val); err == nil {
+			params.MaxPickupCount = &i
+		}
+	}
+	if val := query.Get("min_wave_count"); val != "" {
+		if i, err := strconv.Atoi(val); err == nil {
+			params.MinWaveCount = &i
+		}
+	}
+	if val := query.Get("max_wave_count"); val != "" {
+		if i, err := strconv.Atoi(val); err == nil {
+			params.MaxWaveCount = &i
+		}
+	}
+	if val := query.Get("min_corridor_width"); val != "" {
+		if i, err := strconv.Atoi(val); err == nil {
+			params.MinCorridorWidth = &i
+		}
+	}
+	if val := query.Get("max_corridor_width"); val != "" {
+		if i, err := strconv.Atoi(val); err == nil {
+			params.MaxCorridorWidth = &i
+		}
+	}
 
 	// Parse stage type filter
 	if val := query.Get("stage_type"); val != "" {
@@ -315,6 +354,126 @@ func (h *TemplateHandler) ValidateTemplate(w http.ResponseWriter, r *http.Reques
 	respondJSON(w, h.logger, http.StatusOK, validationResult)
 }
 
+// GetTemplateHeatmap handles GET /api/v1/templates/{id}/heatmap. The radius and
+// per-enemy weights come from the radius, chaser, zoner, dps and mobAir query
+// parameters.
+func (h *TemplateHandler) GetTemplateHeatmap(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(id); err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid UUID format", err.Error())
+		return
+	}
+
+	cfg, err := heatmapConfigFromQuery(r.URL.Query())
+	if err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid query parameters", err.Error())
+		return
+	}
+
+	template, err := h.store.Get(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			respondError(w, h.logger, http.StatusNotFound, "Template not found", "")
+			return
+		}
+		h.logger.Error("Failed to get template", zap.String("id", id), zap.Error(err))
+		respondError(w, h.logger, http.StatusInternalServerError, "Failed to get template", err.Error())
+		return
+	}
+
+	heatmap, err := generate.ComputeHeatmap(&template.Payload, cfg)
+	if err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Heatmap failed", err.Error())
+		return
+	}
+
+	respondJSON(w, h.logger, http.StatusOK, heatmap)
+}
+
+// AnalyzeHeatmap handles POST /api/v1/analyze/heatmap
+func (h *TemplateHandler) AnalyzeHeatmap(w http.ResponseWriter, r *http.Request) {
+	var req generate.HeatmapRequest
+
+	// Parse request body
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid JSON", err.Error())
+		return
+	}
+
+	// The payload only has to be well-formed, not playable
+	if result := validate.ValidateTemplate(&req.Payload, false); !result.Valid {
+		h.respondValidationError(w, result)
+		return
+	}
+
+	heatmap, err := generate.ComputeHeatmap(&req.Payload, req.HeatmapConfig)
+	if err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Heatmap failed", err.Error())
+		return
+	}
+
+	respondJSON(w, h.logger, http.StatusOK, heatmap)
+}
+
+// AnalyzeRoutes handles POST /api/v1/analyze/routes
+func (h *TemplateHandler) AnalyzeRoutes(w http.ResponseWriter, r *http.Request) {
+	var req generate.RouteRequest
+
+	// Parse request body
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid JSON", err.Error())
+		return
+	}
+
+	// The payload only has to be well-formed, not playable
+	if result := validate.ValidateTemplate(&req.Payload, false); !result.Valid {
+		h.respondValidationError(w, result)
+		return
+	}
+
+	analysis, err := generate.AnalyzeRoutes(&req.Payload)
+	if err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Route analysis failed", err.Error())
+		return
+	}
+
+	respondJSON(w, h.logger, http.StatusOK, analysis)
+}
+
+// heatmapConfigFromQuery reads a heatmap config from query parameters
+func heatmapConfigFromQuery(query url.Values) (generate.HeatmapConfig, error) {
+	var cfg generate.HeatmapConfig
+	if v := query.Get("radius"); v != "" {
+		radius, err := strconv.Atoi(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid radius: %s", v)
+		}
+		cfg.Radius = radius
+	}
+
+	weights := []struct {
+		name string
+		dst  **float64
+	}{
+		{"chaser", &cfg.Weights.Chaser},
+		{"zoner", &cfg.Weights.Zoner},
+		{"dps", &cfg.Weights.DPS},
+		{"mobAir", &cfg.Weights.MobAir},
+	}
+	for _, wt := range weights {
+		v := query.Get(wt.name)
+		if v == "" {
+			continue
+		}
+		weight, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid %s weight: %s", wt.name, v)
+		}
+		*wt.dst = &weight
+	}
+	return cfg, nil
+}
+
 // HealthCheck handles GET /health
 func (h *TemplateHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	// Check database connection
@@ -351,73 +510,156 @@ func (h *TemplateHandler) respondValidationError(w http.ResponseWriter, result *
 	respondJSON(w, h.logger, http.StatusBadRequest, response)
 }
 
-// GenerateBridge handles POST /api/v1/generate/bridge
-func (h *TemplateHandler) GenerateBridge(w http.ResponseWriter, r *http.Request) {
-	var req generate.BridgeGenerateRequest
+// GenerateRoom handles POST /api/v1/generate/{shape}
+func (h *TemplateHandler) GenerateRoom(w http.ResponseWriter, r *http.Request) {
+	shape := chi.URLParam(r, "shape")
+	g, ok := generate.LookupGenerator(shape)
+	if !ok {
+		respondError(w, h.logger, http.StatusNotFound, "Unknown shape",
+			"allowed: "+strings.Join(generate.ShapeNames(), ", "))
+		return
+	}
 
-	// Parse request body
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	// Parse request body into the shape's request type
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid JSON", err.Error())
+		return
+	}
+	req := g.NewRequest()
+	if err := json.Unmarshal(body, req); err != nil {
 		respondError(w, h.logger, http.StatusBadRequest, "Invalid JSON", err.Error())
 		return
 	}
 
-	// Validate doors
-	if len(req.Doors) < 2 {
-		respondError(w, h.logger, http.StatusBadRequest, "Invalid request", "at least 2 doors are required")
+	// Apply the project's stage overrides; trace=true records a frame per pipeline step
+	overrides, ok := h.bodyStageOverrides(w, r, body)
+	if !ok {
 		return
 	}
+	opts := generate.GenerateOptions{StageOverrides: overrides, Trace: r.URL.Query().Get("trace") == "true"}
 
-	// Generate bridge room
-	result, err := generate.GenerateBridgeRoom(req)
+	// Generate room
+	result, err := g.Generate(r.Context(), req, opts)
 	if err != nil {
-		respondError(w, h.logger, http.StatusBadRequest, "Generation failed", err.Error())
+		respondGenerateError(w, h.logger, "Generation failed", err)
 		return
 	}
 
-	respondJSON(w, h.logger, http.StatusOK, result)
+	respondJSON(w, h.logger, http.StatusOK, result.Response)
 }
 
-// GeneratePlatform handles POST /api/v1/generate/platform
-func (h *TemplateHandler) GeneratePlatform(w http.ResponseWriter, r *http.Request) {
-	var req generate.PlatformGenerateRequest
+// bodyStageOverrides loads the stage overrides of the project named by the
+// body's project_id, or returns nil overrides when it names none. It writes the
+// error response itself when it fails.
+func (h *TemplateHandler) bodyStageOverrides(w http.ResponseWriter, r *http.Request, body []byte) (model.StageOverrides, bool) {
+	var project struct {
+		ProjectID string `json:"project_id"`
+	}
+	if err := json.Unmarshal(body, &project); err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid JSON", err.Error())
+		return nil, false
+	}
+	if project.ProjectID == "" {
+		return nil, true
+	}
+	return h.projectStageOverrides(w, r, project.ProjectID)
+}
+
+// projectStageOverrides loads a project's stage overrides, writing the error
+// response itself when it fails
+func (h *TemplateHandler) projectStageOverrides(w http.ResponseWriter, r *http.Request, id string) (model.StageOverrides, bool) {
+	if _, err := uuid.Parse(id); err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid project_id", err.Error())
+		return nil, false
+	}
+	if h.projects == nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid project_id", "projects are not available")
+		return nil, false
+	}
+	project, err := h.projects.Get(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			respondError(w, h.logger, http.StatusNotFound, "Project not found", "")
+			return nil, false
+		}
+		h.logger.Error("Failed to get project", zap.String("id", id), zap.Error(err))
+		respondError(w, h.logger, http.StatusInternalServerError, "Failed to get project", err.Error())
+		return nil, false
+	}
+	return project.StageOverrides, true
+}
+
+// GetGenerateShapes handles GET /api/v1/generate/shapes
+func (h *TemplateHandler) GetGenerateShapes(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, h.logger, http.StatusOK, generate.ShapeInfos())
+}
+
+// GenerateBatch handles POST /api/v1/generate/batch
+func (h *TemplateHandler) GenerateBatch(w http.ResponseWriter, r *http.Request) {
+	var req generate.BatchGenerateRequest
 
 	// Parse request body
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid JSON", err.Error())
+		return
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
 		respondError(w, h.logger, http.StatusBadRequest, "Invalid JSON", err.Error())
 		return
 	}
 
-	// Generate platform room
-	result, err := generate.GeneratePlatformRoom(req)
+	// Every variant gets the project's stage overrides
+	overrides, ok := h.bodyStageOverrides(w, r, body)
+	if !ok {
+		return
+	}
+	req.StageOverrides = overrides
+
+	// Generate and rank variants
+	result, err := generate.GenerateBatch(r.Context(), req)
 	if err != nil {
-		respondError(w, h.logger, http.StatusBadRequest, "Generation failed", err.Error())
+		respondGenerateError(w, h.logger, "Generation failed", err)
 		return
 	}
 
 	respondJSON(w, h.logger, http.StatusOK, result)
 }
 
-// GenerateFullRoom handles POST /api/v1/generate/fullroom
-func (h *TemplateHandler) GenerateFullRoom(w http.ResponseWriter, r *http.Request) {
-	var req generate.FullRoomGenerateRequest
+// RegenerateTemplate handles POST /api/v1/generate/regenerate
+func (h *TemplateHandler) RegenerateTemplate(w http.ResponseWriter, r *http.Request) {
+	var req generate.RegenerateRequest
 
 	// Parse request body
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid JSON", err.Error())
+		return
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
 		respondError(w, h.logger, http.StatusBadRequest, "Invalid JSON", err.Error())
 		return
 	}
 
-	// Generate full room
-	result, err := generate.GenerateFullRoom(req)
+	// Apply the project's stage overrides
+	overrides, ok := h.bodyStageOverrides(w, r, body)
+	if !ok {
+		return
+	}
+	req.StageOverrides = overrides
+
+	// Re-run the unlocked steps
+	result, err := generate.RegenerateTemplate(r.Context(), req)
 	if err != nil {
-		respondError(w, h.logger, http.StatusBadRequest, "Generation failed", err.Error())
+		respondGenerateError(w, h.logger, "Generation failed", err)
 		return
 	}
 
 	respondJSON(w, h.logger, http.StatusOK, result)
 }
 
-// GetStageConfigs returns all stage type configurations for frontend use
+// GetStageConfigs returns the live stage type configurations for frontend use
 func (h *TemplateHandler) GetStageConfigs(w http.ResponseWriter, r *http.Request) {
 	configs := generate.GetAllStageConfigs()
 	respondJSON(w, h.logger, http.StatusOK, configs)
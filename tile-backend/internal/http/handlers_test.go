@@ -8,7 +8,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"tile-backend/internal/generate"
 	"tile-backend/internal/model"
+	"tile-backend/internal/store"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -48,6 +50,16 @@ func (m *MockTemplateStore) HealthCheck(ctx context.Context) error {
 	return args.Error(0)
 }
 
+func (m *MockTemplateStore) IncrementViewCount(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTemplateStore) ListByProject(ctx context.Context, projectID string, limit, offset int) ([]model.Template, int, error) {
+	args := m.Called(ctx, projectID, limit, offset)
+	return args.Get(0).([]model.Template), args.Get(1).(int), args.Error(2)
+}
+
 func createTestHandler() *TemplateHandler {
 	logger := zap.NewNop() // No-op logger for testing
 	mockStore := &MockTemplateStore{}
@@ -662,3 +674,423 @@ func TestTemplateHandler_CreateTemplate_NameFromMeta(t *testing.T) {
 
 	mockStore.AssertExpectations(t)
 }
+
+func TestTemplateHandler_CreateTemplate_MinCorridorWidth(t *testing.T) {
+	handler := createTestHandler()
+	mockStore := handler.store.(*MockTemplateStore)
+
+	// A 6x5 open room with left and right doors: the main path crosses it
+	// between walls five cells apart
+	layer := func(v int) [][]int {
+		rows := make([][]int, 5)
+		for y := range rows {
+			rows[y] = []int{v, v, v, v, v, v}
+		}
+		return rows
+	}
+	payload := model.TemplatePayload{
+		Ground: layer(1), Static: layer(0), Chaser: layer(0), Zoner: layer(0), DPS: layer(0), MobAir: layer(0),
+		Doors: &model.DoorStates{Left: 1, Right: 1},
+		Meta:  model.TemplateMeta{Name: "corridor", Version: 1, Width: 6, Height: 5},
+	}
+	saved := model.Template{ID: uuid.New(), Name: "corridor"}
+
+	mockStore.On("Create", mock.Anything, mock.MatchedBy(func(t model.Template) bool {
+		return t.MinCorridorWidth != nil && *t.MinCorridorWidth == 5
+	})).Return(&saved, nil).Once()
+
+	reqBody, _ := json.Marshal(model.CreateTemplateRequest{Payload: payload})
+	w := httptest.NewRecorder()
+	handler.CreateTemplate(w, httptest.NewRequest(http.MethodPost, "/api/v1/templates", bytes.NewReader(reqBody)))
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// Rooms without a main path leave the column empty
+	payload.Doors = &model.DoorStates{Left: 1}
+	mockStore.On("Create", mock.Anything, mock.MatchedBy(func(t model.Template) bool {
+		return t.MinCorridorWidth == nil
+	})).Return(&saved, nil).Once()
+
+	reqBody, _ = json.Marshal(model.CreateTemplateRequest{Payload: payload})
+	w = httptest.NewRecorder()
+	handler.CreateTemplate(w, httptest.NewRequest(http.MethodPost, "/api/v1/templates", bytes.NewReader(reqBody)))
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	mockStore.AssertExpectations(t)
+}
+
+func TestTemplateHandler_GenerateRoom_Success(t *testing.T) {
+	handler := createTestHandler()
+
+	body := `{"width": 20, "height": 12, "doors": ["left", "right"], "seed": 7}`
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/generate/cave", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("shape", "cave")
+	httpReq = httpReq.WithContext(context.WithValue(httpReq.Context(), chi.RouteCtxKey, rctx))
+
+	handler.GenerateRoom(w, httpReq)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Payload model.TemplatePayload `json:"payload"`
+		Seed    int64                 `json:"seed"`
+	}
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), response.Seed)
+	assert.Equal(t, 20, response.Payload.Meta.Width)
+}
+
+func TestTemplateHandler_GenerateRoom_Trace(t *testing.T) {
+	handler := createTestHandler()
+
+	body := `{"width": 20, "height": 12, "doors": ["left", "right"], "seed": 7}`
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/generate/fullroom?trace=true", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("shape", "fullroom")
+	httpReq = httpReq.WithContext(context.WithValue(httpReq.Context(), chi.RouteCtxKey, rctx))
+
+	handler.GenerateRoom(w, httpReq)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Trace []struct {
+			Step string `json:"step"`
+		} `json:"trace"`
+	}
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, response.Trace) {
+		assert.Equal(t, "ground fill", response.Trace[0].Step)
+		assert.Equal(t, "final", response.Trace[len(response.Trace)-1].Step)
+	}
+}
+
+func TestTemplateHandler_GenerateRoom_Canceled(t *testing.T) {
+	handler := createTestHandler()
+
+	body := `{"width": 20, "height": 12, "doors": ["left", "right"]}`
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/generate/fullroom", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("shape", "fullroom")
+	ctx, cancel := context.WithCancel(context.WithValue(httpReq.Context(), chi.RouteCtxKey, rctx))
+	cancel()
+	httpReq = httpReq.WithContext(ctx)
+
+	handler.GenerateRoom(w, httpReq)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var response model.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Generation canceled", response.Message)
+	assert.Equal(t, "ground", response.Details["step"])
+}
+
+func TestDeadlineMiddleware(t *testing.T) {
+	var deadline time.Time
+	var hasDeadline bool
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, hasDeadline = r.Context().Deadline()
+	})
+
+	start := time.Now()
+	DeadlineMiddleware(5*time.Second)(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, hasDeadline)
+	assert.WithinDuration(t, start.Add(5*time.Second), deadline, time.Second)
+
+	DeadlineMiddleware(0)(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.False(t, hasDeadline)
+}
+
+func TestTemplateHandler_GenerateRoom_UnknownShape(t *testing.T) {
+	handler := createTestHandler()
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/generate/tunnel", bytes.NewBufferString(`{}`))
+	w := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("shape", "tunnel")
+	httpReq = httpReq.WithContext(context.WithValue(httpReq.Context(), chi.RouteCtxKey, rctx))
+
+	handler.GenerateRoom(w, httpReq)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var response model.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Unknown shape", response.Message)
+}
+
+func TestTemplateHandler_GenerateRoom_InvalidProjectID(t *testing.T) {
+	handler := createTestHandler()
+
+	body := `{"width": 20, "height": 12, "doors": ["left", "right"], "project_id": "not-a-uuid"}`
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/generate/fullroom", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("shape", "fullroom")
+	httpReq = httpReq.WithContext(context.WithValue(httpReq.Context(), chi.RouteCtxKey, rctx))
+
+	handler.GenerateRoom(w, httpReq)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response model.ErrorResponse
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Invalid project_id", response.Message)
+}
+
+// MockProjectStore resolves project_id on generate requests; only Get is used
+type MockProjectStore struct {
+	store.ProjectStore
+	mock.Mock
+}
+
+func (m *MockProjectStore) Get(ctx context.Context, id string) (*model.Project, error) {
+	args := m.Called(ctx, id)
+	project, _ := args.Get(0).(*model.Project)
+	return project, args.Error(1)
+}
+
+// createProjectTestHandler returns a handler whose project resolves to overrides
+// that leave pressure rooms without DPS
+func createProjectTestHandler(projectID string) *TemplateHandler {
+	handler := createTestHandler()
+	none := [2]int{0, 0}
+	projects := &MockProjectStore{}
+	projects.On("Get", mock.Anything, projectID).Return(&model.Project{
+		StageOverrides: model.StageOverrides{"pressure": {DPSRange: &none}},
+	}, nil)
+	handler.projects = projects
+	return handler
+}
+
+func TestTemplateHandler_GenerateBatch_ProjectOverrides(t *testing.T) {
+	projectID := uuid.New().String()
+	handler := createProjectTestHandler(projectID)
+
+	generateBatch := func(body string) generate.BatchGenerateResponse {
+		w := httptest.NewRecorder()
+		handler.GenerateBatch(w, httptest.NewRequest(http.MethodPost, "/api/v1/generate/batch", bytes.NewBufferString(body)))
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp generate.BatchGenerateResponse
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp
+	}
+	request := `"shape": "fullroom", "count": 3, "request": {"width": 20, "height": 12, "doors": ["left", "right"], "stageType": "pressure", "seed": 4}`
+
+	dps := 0
+	for _, v := range generateBatch(`{` + request + `}`).Variants {
+		dps += countLayerCells(v.Payload.DPS)
+	}
+	assert.Greater(t, dps, 0, "pressure rooms have DPS by default")
+
+	resp := generateBatch(`{` + request + `, "project_id": "` + projectID + `"}`)
+	assert.Len(t, resp.Variants, 3)
+	for _, v := range resp.Variants {
+		assert.Zero(t, countLayerCells(v.Payload.DPS), "variant %d", v.Index)
+	}
+}
+
+func TestTemplateHandler_RegenerateTemplate_ProjectOverrides(t *testing.T) {
+	projectID := uuid.New().String()
+	handler := createProjectTestHandler(projectID)
+
+	seed := int64(4)
+	room, err := generate.GenerateFullRoom(context.Background(), generate.FullRoomGenerateRequest{
+		Width: 20, Height: 12, Doors: []generate.DoorPosition{generate.DoorLeft, generate.DoorRight}, StageType: "pressure", Seed: &seed,
+	})
+	assert.NoError(t, err)
+	assert.Greater(t, countLayerCells(room.Payload.DPS), 0)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"payload": room.Payload, "stageType": "pressure", "seed": 5, "project_id": projectID,
+	})
+	w := httptest.NewRecorder()
+	handler.RegenerateTemplate(w, httptest.NewRequest(http.MethodPost, "/api/v1/generate/regenerate", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp generate.RegenerateResponse
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Zero(t, countLayerCells(resp.Payload.DPS))
+}
+
+func TestTemplateHandler_GenerateBatch_InvalidProjectID(t *testing.T) {
+	handler := createTestHandler()
+
+	body := `{"shape": "fullroom", "count": 2, "request": {"width": 20, "height": 12, "doors": ["left", "right"]}, "project_id": "not-a-uuid"}`
+	w := httptest.NewRecorder()
+	handler.GenerateBatch(w, httptest.NewRequest(http.MethodPost, "/api/v1/generate/batch", bytes.NewBufferString(body)))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response model.ErrorResponse
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+	assert.Equal(t, "Invalid project_id", response.Message)
+}
+
+func countLayerCells(layer model.Layer) int {
+	n := 0
+	for _, row := range layer {
+		for _, v := range row {
+			if v != 0 {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func heatmapTestPayload() model.TemplatePayload {
+	empty := [][]int{{0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}}
+	return model.TemplatePayload{
+		Ground: [][]int{{1, 1, 1, 1}, {1, 1, 1, 1}, {1, 1, 1, 1}, {1, 1, 1, 1}},
+		Static: empty,
+		Chaser: empty,
+		Zoner:  empty,
+		DPS:    [][]int{{1, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}},
+		MobAir: empty,
+		Meta: model.TemplateMeta{
+			Name:    "test-template",
+			Version: 1,
+			Width:   4,
+			Height:  4,
+		},
+	}
+}
+
+func TestTemplateHandler_GetTemplateHeatmap_Success(t *testing.T) {
+	handler := createTestHandler()
+	mockStore := handler.store.(*MockTemplateStore)
+
+	templateID := uuid.New()
+	mockStore.On("Get", mock.Anything, templateID.String()).Return(&model.Template{
+		ID:      templateID,
+		Width:   4,
+		Height:  4,
+		Payload: heatmapTestPayload(),
+	}, nil)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/v1/templates/"+templateID.String()+"/heatmap?radius=2&dps=2", nil)
+	w := httptest.NewRecorder()
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", templateID.String())
+	httpReq = httpReq.WithContext(context.WithValue(httpReq.Context(), chi.RouteCtxKey, rctx))
+
+	handler.GetTemplateHeatmap(w, httpReq)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response generate.Heatmap
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, response.Radius)
+	assert.Equal(t, 2.0, response.Summary.PeakThreat)
+	assert.Equal(t, 1.0, response.Threat[0][0])
+	assert.Zero(t, response.Threat[3][3])
+	assert.Equal(t, &model.Point{X: 3, Y: 3}, response.Summary.SafestCell)
+
+	mockStore.AssertExpectations(t)
+}
+
+func TestTemplateHandler_GetTemplateHeatmap_InvalidQuery(t *testing.T) {
+	handler := createTestHandler()
+
+	for _, query := range []string{"radius=abc", "radius=50", "chaser=-1", "mobAir=x"} {
+		templateID := uuid.New()
+		mockStore := handler.store.(*MockTemplateStore)
+		mockStore.On("Get", mock.Anything, templateID.String()).Return(&model.Template{
+			ID:      templateID,
+			Payload: heatmapTestPayload(),
+		}, nil).Maybe()
+
+		httpReq := httptest.NewRequest(http.MethodGet, "/api/v1/templates/"+templateID.String()+"/heatmap?"+query, nil)
+		w := httptest.NewRecorder()
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", templateID.String())
+		httpReq = httpReq.WithContext(context.WithValue(httpReq.Context(), chi.RouteCtxKey, rctx))
+
+		handler.GetTemplateHeatmap(w, httpReq)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestTemplateHandler_AnalyzeHeatmap(t *testing.T) {
+	handler := createTestHandler()
+
+	reqBody, _ := json.Marshal(map[string]interface{}{
+		"payload": heatmapTestPayload(),
+		"radius":  3,
+		"weights": map[string]float64{"chaser": 0},
+	})
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/analyze/heatmap", bytes.NewReader(reqBody))
+	w := httptest.NewRecorder()
+
+	handler.AnalyzeHeatmap(w, httpReq)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response generate.Heatmap
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, response.Radius)
+	assert.Equal(t, 0.0, *response.Weights.Chaser)
+	assert.Equal(t, 1.0, *response.Weights.DPS)
+	assert.Len(t, response.Threat, 4)
+
+	// Malformed payloads are rejected like on create
+	payload := heatmapTestPayload()
+	payload.DPS = nil
+	reqBody, _ = json.Marshal(map[string]interface{}{"payload": payload})
+	httpReq = httptest.NewRequest(http.MethodPost, "/api/v1/analyze/heatmap", bytes.NewReader(reqBody))
+	w = httptest.NewRecorder()
+
+	handler.AnalyzeHeatmap(w, httpReq)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTemplateHandler_AnalyzeRoutes(t *testing.T) {
+	handler := createTestHandler()
+
+	payload := heatmapTestPayload()
+	payload.Doors = &model.DoorStates{Left: 1, Right: 1}
+	reqBody, _ := json.Marshal(map[string]interface{}{"payload": payload})
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/analyze/routes", bytes.NewReader(reqBody))
+	w := httptest.NewRecorder()
+
+	handler.AnalyzeRoutes(w, httpReq)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response generate.RouteAnalysis
+	err := json.NewDecoder(w.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Doors, 2)
+	if assert.Len(t, response.Routes, 1) {
+		assert.True(t, response.Routes[0].Connected)
+		assert.Equal(t, 3, response.Routes[0].Length)
+	}
+	assert.Empty(t, response.ChokeCells)
+	assert.Equal(t, 4, response.MinCorridorWidth)
+	assert.Len(t, response.Overlays.CorridorWidth, 4)
+
+	// Door descriptors are checked like on create
+	payload.DoorDescriptors = []model.DoorDescriptor{{Side: "left", Offset: 3, Width: 2}}
+	reqBody, _ = json.Marshal(map[string]interface{}{"payload": payload})
+	httpReq = httptest.NewRequest(http.MethodPost, "/api/v1/analyze/routes", bytes.NewReader(reqBody))
+	w = httptest.NewRecorder()
+
+	handler.AnalyzeRoutes(w, httpReq)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
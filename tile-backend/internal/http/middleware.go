@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -47,6 +48,22 @@ func RequestSizeLimitMiddleware(maxSize int64) func(http.Handler) http.Handler {
 	}
 }
 
+// DeadlineMiddleware gives every request a deadline, so work that honours the
+// request context (e.g. room generation) stops once it runs out. A timeout of
+// zero or less leaves requests without a deadline.
+func DeadlineMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 // RecoveryMiddleware recovers from panics and logs them
 func RecoveryMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
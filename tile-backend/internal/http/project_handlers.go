@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -57,6 +58,7 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 		ShapePctFull:     req.ShapePctFull,
 		ShapePctBridge:   req.ShapePctBridge,
 		ShapePctPlatform: req.ShapePctPlatform,
+		ShapePctCave:     req.ShapePctCave,
 		DoorDistribution: req.DoorDistribution,
 		StagePctStart:    req.StagePctStart,
 		StagePctTeaching: req.StagePctTeaching,
@@ -168,6 +170,7 @@ func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
 		ShapePctFull:     req.ShapePctFull,
 		ShapePctBridge:   req.ShapePctBridge,
 		ShapePctPlatform: req.ShapePctPlatform,
+		ShapePctCave:     req.ShapePctCave,
 		DoorDistribution: req.DoorDistribution,
 		StagePctStart:    req.StagePctStart,
 		StagePctTeaching: req.StagePctTeaching,
@@ -269,6 +272,15 @@ func (h *ProjectHandler) AutoFillProject(w http.ResponseWriter, r *http.Request)
 
 	// Run auto-fill
 	result, err := generate.AutoFill(r.Context(), project, stats, h.templateStore)
+	var stepErr *generate.StepError
+	if errors.As(err, &stepErr) {
+		// The rooms saved before the cut stay in the project
+		respondJSON(w, h.logger, http.StatusServiceUnavailable, model.AutoFillErrorResponse{
+			ErrorResponse: stepErrorResponse(h.logger, stepErr, err),
+			Result:        result,
+		})
+		return
+	}
 	if err != nil {
 		h.logger.Error("Auto-fill failed", zap.String("id", id), zap.Error(err))
 		respondError(w, h.logger, http.StatusInternalServerError, "Auto-fill failed", err.Error())
@@ -278,6 +290,68 @@ func (h *ProjectHandler) AutoFillProject(w http.ResponseWriter, r *http.Request)
 	respondJSON(w, h.logger, http.StatusOK, result)
 }
 
+// GetStageOverrides handles GET /api/v1/projects/{id}/stage-overrides
+func (h *ProjectHandler) GetStageOverrides(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if _, err := uuid.Parse(id); err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid UUID format", err.Error())
+		return
+	}
+
+	project, err := h.store.Get(r.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			respondError(w, h.logger, http.StatusNotFound, "Project not found", "")
+			return
+		}
+		h.logger.Error("Failed to get project", zap.String("id", id), zap.Error(err))
+		respondError(w, h.logger, http.StatusInternalServerError, "Failed to get project", err.Error())
+		return
+	}
+
+	overrides := project.StageOverrides
+	if overrides == nil {
+		overrides = model.StageOverrides{}
+	}
+	respondJSON(w, h.logger, http.StatusOK, overrides)
+}
+
+// UpdateStageOverrides handles PUT /api/v1/projects/{id}/stage-overrides (full replace)
+func (h *ProjectHandler) UpdateStageOverrides(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if _, err := uuid.Parse(id); err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid UUID format", err.Error())
+		return
+	}
+
+	var overrides model.StageOverrides
+	if err := json.NewDecoder(r.Body).Decode(&overrides); err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid JSON", err.Error())
+		return
+	}
+
+	// Overrides must name known stages and keep their configs valid
+	if err := generate.ValidateStageOverrides(overrides); err != nil {
+		respondError(w, h.logger, http.StatusBadRequest, "Invalid stage overrides", err.Error())
+		return
+	}
+
+	updated, err := h.store.UpdateStageOverrides(r.Context(), id, overrides)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			respondError(w, h.logger, http.StatusNotFound, "Project not found", "")
+			return
+		}
+		h.logger.Error("Failed to update stage overrides", zap.String("id", id), zap.Error(err))
+		respondError(w, h.logger, http.StatusInternalServerError, "Failed to update stage overrides", err.Error())
+		return
+	}
+
+	respondJSON(w, h.logger, http.StatusOK, updated)
+}
+
 // ListProjectTemplates handles GET /api/v1/projects/{id}/templates
 func (h *ProjectHandler) ListProjectTemplates(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
@@ -2,7 +2,10 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
+	"tile-backend/internal/generate"
 	"tile-backend/internal/model"
 
 	"go.uber.org/zap"
@@ -31,3 +34,34 @@ func respondError(w http.ResponseWriter, logger *zap.Logger, status int, message
 
 	respondJSON(w, logger, status, response)
 }
+
+// respondGenerateError sends a generation error. A generation cut short by the
+// request deadline or a client disconnect is reported with the step it stopped
+// in; any other error is a bad request.
+func respondGenerateError(w http.ResponseWriter, logger *zap.Logger, message string, err error) {
+	var stepErr *generate.StepError
+	if !errors.As(err, &stepErr) {
+		respondError(w, logger, http.StatusBadRequest, message, err.Error())
+		return
+	}
+	respondJSON(w, logger, http.StatusServiceUnavailable, stepErrorResponse(logger, stepErr, err))
+}
+
+// stepErrorResponse builds the 503 body for a generation cut short, naming the
+// step it stopped in and the steps already finished
+func stepErrorResponse(logger *zap.Logger, stepErr *generate.StepError, err error) model.ErrorResponse {
+	message := "Generation canceled"
+	if stepErr.TimedOut() {
+		message = "Generation timed out"
+	}
+	logger.Warn(message, zap.String("step", stepErr.Step), zap.Error(err))
+	return model.ErrorResponse{
+		Error:   http.StatusText(http.StatusServiceUnavailable),
+		Message: message,
+		Details: map[string]string{
+			"step":      stepErr.Step,
+			"completed": strings.Join(stepErr.Completed, ", "),
+			"details":   err.Error(),
+		},
+	}
+}
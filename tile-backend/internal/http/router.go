@@ -2,6 +2,7 @@ package http
 
 import (
 	"tile-backend/internal/store"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -9,8 +10,9 @@ import (
 	"go.uber.org/zap"
 )
 
-// SetupRouter creates and configures the HTTP router
-func SetupRouter(templateStore store.TemplateStore, projectStore store.ProjectStore, logger *zap.Logger, corsOrigins []string) *chi.Mux {
+// SetupRouter creates and configures the HTTP router. generateTimeout is the
+// per-request deadline for room generation and auto-fill (zero disables it).
+func SetupRouter(templateStore store.TemplateStore, projectStore store.ProjectStore, logger *zap.Logger, corsOrigins []string, generateTimeout time.Duration) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Add middleware
@@ -32,6 +34,7 @@ func SetupRouter(templateStore store.TemplateStore, projectStore store.ProjectSt
 
 	// Create handlers
 	templateHandler := NewTemplateHandler(templateStore, logger)
+	templateHandler.projects = projectStore
 	projectHandler := NewProjectHandler(projectStore, templateStore, logger)
 
 	// Health check endpoint
@@ -45,6 +48,7 @@ func SetupRouter(templateStore store.TemplateStore, projectStore store.ProjectSt
 			r.Get("/{id}", templateHandler.GetTemplate)
 			r.Delete("/{id}", templateHandler.DeleteTemplate)
 			r.Patch("/{id}/view", templateHandler.IncrementViewCount)
+			r.Get("/{id}/heatmap", templateHandler.GetTemplateHeatmap)
 			r.Post("/validate", templateHandler.ValidateTemplate)
 		})
 
@@ -54,17 +58,27 @@ func SetupRouter(templateStore store.TemplateStore, projectStore store.ProjectSt
 			r.Get("/", projectHandler.ListProjects)
 			r.Get("/{id}", projectHandler.GetProject)
 			r.Get("/{id}/stats", projectHandler.GetProjectStats)
-			r.Post("/{id}/autofill", projectHandler.AutoFillProject)
+			r.With(DeadlineMiddleware(generateTimeout)).Post("/{id}/autofill", projectHandler.AutoFillProject)
 			r.Get("/{id}/templates", projectHandler.ListProjectTemplates)
+			r.Get("/{id}/stage-overrides", projectHandler.GetStageOverrides)
+			r.Put("/{id}/stage-overrides", projectHandler.UpdateStageOverrides)
 			r.Put("/{id}", projectHandler.UpdateProject)
 			r.Delete("/{id}", projectHandler.DeleteProject)
 		})
 
 		// Generation endpoints
 		r.Route("/generate", func(r chi.Router) {
-			r.Post("/bridge", templateHandler.GenerateBridge)
-			r.Post("/platform", templateHandler.GeneratePlatform)
-			r.Post("/fullroom", templateHandler.GenerateFullRoom)
+			r.Use(DeadlineMiddleware(generateTimeout))
+			r.Get("/shapes", templateHandler.GetGenerateShapes)
+			r.Post("/batch", templateHandler.GenerateBatch)
+			r.Post("/regenerate", templateHandler.RegenerateTemplate)
+			r.Post("/{shape}", templateHandler.GenerateRoom) // Any registered shape: bridge, platform, fullroom, cave, ...
+		})
+
+		// Analysis endpoints
+		r.Route("/analyze", func(r chi.Router) {
+			r.Post("/heatmap", templateHandler.AnalyzeHeatmap)
+			r.Post("/routes", templateHandler.AnalyzeRoutes)
 		})
 
 		// Stage config endpoint
@@ -29,14 +29,17 @@ func ComputeTemplateStats(template *Template) {
 		template.RoomAttributes = template.Payload.Attributes
 	}
 
+	// Describe rail and pipeline as line segments when the client did not
+	template.Payload.FillLineSegments()
+
 	// Compute open doors bitmask
 	template.OpenDoors = ComputeOpenDoors(template.Payload.Doors)
 
 	// Calculate door connectivity
-	if template.Payload.Doors != nil {
+	if template.Payload.Doors != nil || len(template.Payload.DoorDescriptors) > 0 {
 		doorsConnected := CalculateDoorsConnected(
 			template.Payload.Ground,
-			template.Payload.Doors,
+			template.Payload.DoorList(template.Width, template.Height),
 			template.Width,
 			template.Height,
 		)
@@ -59,6 +62,12 @@ func ComputeTemplateStats(template *Template) {
 	mobAirCount := CountLayerTiles(template.Payload.MobAir)
 	template.MobAirCount = &mobAirCount
 
+	pickupCount := CountLayerTiles(template.Payload.Pickup)
+	template.PickupCount = &pickupCount
+
+	waveCount := template.Payload.WaveCount()
+	template.WaveCount = &waveCount
+
 	if template.Payload.StageType != nil {
 		template.StageType = template.Payload.StageType
 	}
@@ -97,41 +106,32 @@ func CountLayerTiles(layer Layer) int {
 	return count
 }
 
-// CalculateDoorsConnected checks if each door connects to walkable areas
-func CalculateDoorsConnected(ground Layer, doors *DoorStates, width, height int) DoorsConnected {
+// CalculateDoorsConnected checks if each door connects to walkable areas. A side
+// counts as connected when every cell of every door on it is walkable; use
+// TemplatePayload.DoorList to read legacy door states as descriptors.
+func CalculateDoorsConnected(ground Layer, doors []DoorDescriptor, width, height int) DoorsConnected {
 	result := DoorsConnected{}
 
-	if doors == nil || len(ground) == 0 {
+	if len(doors) == 0 || len(ground) == 0 {
 		return result
 	}
 
-	midWidth := width / 2
-	midHeight := height / 2
-
-	// Check top door (y=0, middle two cells)
-	if doors.Top == 1 && height > 0 {
-		result.Top = isDoorConnected(ground, midWidth-1, 0, width, height) &&
-			isDoorConnected(ground, midWidth, 0, width, height)
-	}
-
-	// Check bottom door (y=height-1, middle two cells)
-	if doors.Bottom == 1 && height > 0 {
-		result.Bottom = isDoorConnected(ground, midWidth-1, height-1, width, height) &&
-			isDoorConnected(ground, midWidth, height-1, width, height)
-	}
-
-	// Check left door (x=0, middle two cells)
-	if doors.Left == 1 && width > 0 {
-		result.Left = isDoorConnected(ground, 0, midHeight-1, width, height) &&
-			isDoorConnected(ground, 0, midHeight, width, height)
-	}
-
-	// Check right door (x=width-1, middle two cells)
-	if doors.Right == 1 && width > 0 {
-		result.Right = isDoorConnected(ground, width-1, midHeight-1, width, height) &&
-			isDoorConnected(ground, width-1, midHeight, width, height)
+	connected := map[string]bool{}
+	for _, door := range doors {
+		ok := true
+		for _, c := range door.Cells(width, height) {
+			ok = ok && isDoorConnected(ground, c.X, c.Y, width, height)
+		}
+		if prev, seen := connected[door.Side]; seen {
+			ok = ok && prev
+		}
+		connected[door.Side] = ok
 	}
 
+	result.Top = connected[DoorSideTop]
+	result.Right = connected[DoorSideRight]
+	result.Bottom = connected[DoorSideBottom]
+	result.Left = connected[DoorSideLeft]
 	return result
 }
 
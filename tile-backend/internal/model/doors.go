@@ -0,0 +1,143 @@
+package model
+
+import "fmt"
+
+// Door sides, in canonical order
+const (
+	DoorSideTop    = "top"
+	DoorSideRight  = "right"
+	DoorSideBottom = "bottom"
+	DoorSideLeft   = "left"
+)
+
+// DoorSides lists the door sides in canonical order
+var DoorSides = []string{DoorSideTop, DoorSideRight, DoorSideBottom, DoorSideLeft}
+
+// MaxDoorsPerSide is the most doors a single side may hold
+const MaxDoorsPerSide = 2
+
+// DoorDescriptor places one door opening on a side of the room
+type DoorDescriptor struct {
+	Side   string `json:"side"`            // top, right, bottom, left
+	Offset int    `json:"offset"`          // First cell of the opening along the side (x for top/bottom, y for left/right)
+	Width  int    `json:"width,omitempty"` // Cells the opening spans (optional, default 1)
+}
+
+// Span returns the number of cells the opening covers
+func (d DoorDescriptor) Span() int {
+	if d.Width <= 0 {
+		return 1
+	}
+	return d.Width
+}
+
+// Cells returns the cells of the opening, in order along the side
+func (d DoorDescriptor) Cells(width, height int) []Point {
+	cells := make([]Point, 0, d.Span())
+	for i := d.Offset; i < d.Offset+d.Span(); i++ {
+		switch d.Side {
+		case DoorSideTop:
+			cells = append(cells, Point{X: i, Y: 0})
+		case DoorSideBottom:
+			cells = append(cells, Point{X: i, Y: height - 1})
+		case DoorSideLeft:
+			cells = append(cells, Point{X: 0, Y: i})
+		case DoorSideRight:
+			cells = append(cells, Point{X: width - 1, Y: i})
+		}
+	}
+	return cells
+}
+
+// sideLength returns how many cells run along a side
+func sideLength(side string, width, height int) int {
+	if side == DoorSideLeft || side == DoorSideRight {
+		return height
+	}
+	return width
+}
+
+// ValidateDoorDescriptors checks sides, bounds and per-side limits. Doors on the
+// same side may not overlap or touch, otherwise they would read as one opening.
+func ValidateDoorDescriptors(doors []DoorDescriptor, width, height int) error {
+	bySide := make(map[string][]DoorDescriptor)
+	for i, d := range doors {
+		length := 0
+		switch d.Side {
+		case DoorSideTop, DoorSideRight, DoorSideBottom, DoorSideLeft:
+			length = sideLength(d.Side, width, height)
+		default:
+			return fmt.Errorf("door %d: invalid side %q: must be one of top, right, bottom, left", i, d.Side)
+		}
+		if d.Width < 0 {
+			return fmt.Errorf("door %d: width must not be negative", i)
+		}
+		if d.Offset < 0 || d.Offset+d.Span() > length {
+			return fmt.Errorf("door %d: %s door at offset %d width %d does not fit a side of %d cells", i, d.Side, d.Offset, d.Span(), length)
+		}
+		for _, other := range bySide[d.Side] {
+			if d.Offset <= other.Offset+other.Span() && other.Offset <= d.Offset+d.Span() {
+				return fmt.Errorf("door %d: overlaps or touches another %s door", i, d.Side)
+			}
+		}
+		bySide[d.Side] = append(bySide[d.Side], d)
+		if len(bySide[d.Side]) > MaxDoorsPerSide {
+			return fmt.Errorf("at most %d doors per side, got more on %s", MaxDoorsPerSide, d.Side)
+		}
+	}
+	return nil
+}
+
+// DoorStatesFromDescriptors marks every side that has at least one door
+func DoorStatesFromDescriptors(doors []DoorDescriptor) *DoorStates {
+	states := &DoorStates{}
+	for _, d := range doors {
+		switch d.Side {
+		case DoorSideTop:
+			states.Top = 1
+		case DoorSideRight:
+			states.Right = 1
+		case DoorSideBottom:
+			states.Bottom = 1
+		case DoorSideLeft:
+			states.Left = 1
+		}
+	}
+	return states
+}
+
+// LegacyDoorDescriptor is the door a bitmask-style payload has on side: one
+// cell at the middle of the side, where generated rooms have always put it
+func LegacyDoorDescriptor(side string, width, height int) DoorDescriptor {
+	return DoorDescriptor{Side: side, Offset: sideLength(side, width, height) / 2, Width: 1}
+}
+
+// LegacyDoorDescriptors converts bitmask-style door states into descriptors,
+// one LegacyDoorDescriptor per open side
+func LegacyDoorDescriptors(doors *DoorStates, width, height int) []DoorDescriptor {
+	if doors == nil {
+		return nil
+	}
+	open := map[string]bool{
+		DoorSideTop:    doors.Top == 1,
+		DoorSideRight:  doors.Right == 1,
+		DoorSideBottom: doors.Bottom == 1,
+		DoorSideLeft:   doors.Left == 1,
+	}
+	var descriptors []DoorDescriptor
+	for _, side := range DoorSides {
+		if open[side] {
+			descriptors = append(descriptors, LegacyDoorDescriptor(side, width, height))
+		}
+	}
+	return descriptors
+}
+
+// DoorList returns the payload's doors as descriptors: the explicit list when
+// present, otherwise the legacy door states converted
+func (tp *TemplatePayload) DoorList(width, height int) []DoorDescriptor {
+	if len(tp.DoorDescriptors) > 0 {
+		return tp.DoorDescriptors
+	}
+	return LegacyDoorDescriptors(tp.Doors, width, height)
+}
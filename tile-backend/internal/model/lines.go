@@ -0,0 +1,46 @@
+package model
+
+import "tile-backend/internal/geometry"
+
+// ExtractLineSegments describes a rail or pipeline layer as line segments; nil
+// when the layer has no cells
+func ExtractLineSegments(layer Layer) []LineSegment {
+	var lines []LineSegment
+	for _, s := range geometry.ExtractSegments(layer) {
+		lines = append(lines, LineSegment{
+			Start: Point{X: s.Start.X, Y: s.Start.Y},
+			End:   Point{X: s.End.X, Y: s.End.Y},
+		})
+	}
+	return lines
+}
+
+// CheckLineSegments reports whether lines cover exactly the cells of layer.
+// The error is a *geometry.MismatchError locating the first difference.
+func CheckLineSegments(layer Layer, lines []LineSegment) error {
+	segments := make([]geometry.Segment, len(lines))
+	for i, l := range lines {
+		segments[i] = geometry.Segment{
+			Start: geometry.Point{X: l.Start.X, Y: l.Start.Y},
+			End:   geometry.Point{X: l.End.X, Y: l.End.Y},
+		}
+	}
+	return geometry.CheckSegments(layer, segments)
+}
+
+// SetLineSegments recomputes RailLines and PipelineLines from their layers
+func (p *TemplatePayload) SetLineSegments() {
+	p.RailLines = ExtractLineSegments(p.Rail)
+	p.PipelineLines = ExtractLineSegments(p.Pipeline)
+}
+
+// FillLineSegments computes RailLines and PipelineLines for layers that have
+// cells but no segments yet; segments the client sent are kept
+func (p *TemplatePayload) FillLineSegments() {
+	if len(p.RailLines) == 0 {
+		p.RailLines = ExtractLineSegments(p.Rail)
+	}
+	if len(p.PipelineLines) == 0 {
+		p.PipelineLines = ExtractLineSegments(p.Pipeline)
+	}
+}
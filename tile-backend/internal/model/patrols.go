@@ -0,0 +1,46 @@
+package model
+
+import (
+	"fmt"
+
+	"tile-backend/internal/geometry"
+)
+
+// PatrolCells returns the cells a patrol route walks, in order, each cell once
+// per visit; the start of every segment after the first is not repeated. The
+// error is a *geometry.MismatchError when a segment is not horizontal or
+// vertical or does not start where the previous one ended.
+func (p ChaserPatrol) PatrolCells() ([]Point, error) {
+	if len(p.Lines) == 0 {
+		return nil, &geometry.MismatchError{X: p.Chaser.X, Y: p.Chaser.Y, Reason: "patrol has no segments"}
+	}
+	if p.Lines[0].Start != p.Chaser {
+		return nil, &geometry.MismatchError{X: p.Chaser.X, Y: p.Chaser.Y, Reason: "patrol must start at its chaser"}
+	}
+	var cells []Point
+	for i, l := range p.Lines {
+		if i > 0 && l.Start != p.Lines[i-1].End {
+			return nil, &geometry.MismatchError{X: l.Start.X, Y: l.Start.Y, Reason: "patrol segment does not start where the previous one ended"}
+		}
+		seg := geometry.Segment{
+			Start: geometry.Point{X: l.Start.X, Y: l.Start.Y},
+			End:   geometry.Point{X: l.End.X, Y: l.End.Y},
+		}
+		segCells, ok := seg.Cells()
+		if !ok {
+			return nil, &geometry.MismatchError{X: l.Start.X, Y: l.Start.Y, Reason: fmt.Sprintf("patrol segment to (%d,%d) is not horizontal or vertical", l.End.X, l.End.Y)}
+		}
+		if i > 0 {
+			segCells = segCells[1:]
+		}
+		for _, c := range segCells {
+			cells = append(cells, Point{X: c.X, Y: c.Y})
+		}
+	}
+	return cells, nil
+}
+
+// IsLoop reports whether the route ends where it starts
+func (p ChaserPatrol) IsLoop() bool {
+	return len(p.Lines) > 1 && p.Lines[len(p.Lines)-1].End == p.Lines[0].Start
+}
@@ -19,6 +19,7 @@ type Project struct {
 	ShapePctFull     int              `json:"shape_pct_full"`
 	ShapePctBridge   int              `json:"shape_pct_bridge"`
 	ShapePctPlatform int              `json:"shape_pct_platform"`
+	ShapePctCave     int              `json:"shape_pct_cave"`
 	DoorDistribution DoorDistribution `json:"door_distribution"`
 	StagePctStart    int              `json:"stage_pct_start"`
 	StagePctTeaching int              `json:"stage_pct_teaching"`
@@ -27,10 +28,24 @@ type Project struct {
 	StagePctPeak     int              `json:"stage_pct_peak"`
 	StagePctRelease  int              `json:"stage_pct_release"`
 	StagePctBoss     int              `json:"stage_pct_boss"`
+	StageOverrides   StageOverrides   `json:"stage_overrides"`
 	CreatedAt        time.Time        `json:"created_at"`
 	UpdatedAt        time.Time        `json:"updated_at"`
 }
 
+// StageOverride replaces some of a stage's global config values for one
+// project. Nil fields keep the global value.
+type StageOverride struct {
+	ChaserRange *[2]int `json:"chaser_range,omitempty"`
+	ZonerRange  *[2]int `json:"zoner_range,omitempty"`
+	DPSRange    *[2]int `json:"dps_range,omitempty"`
+	MobAirRange *[2]int `json:"mob_air_range,omitempty"`
+	StaticRange *[2]int `json:"static_range,omitempty"`
+}
+
+// StageOverrides maps stage type names to the project's override for that stage.
+type StageOverrides map[string]StageOverride
+
 // ProjectSummary is used for list responses, includes computed template_count
 type ProjectSummary struct {
 	ID               uuid.UUID        `json:"id"`
@@ -39,6 +54,7 @@ type ProjectSummary struct {
 	ShapePctFull     int              `json:"shape_pct_full"`
 	ShapePctBridge   int              `json:"shape_pct_bridge"`
 	ShapePctPlatform int              `json:"shape_pct_platform"`
+	ShapePctCave     int              `json:"shape_pct_cave"`
 	DoorDistribution DoorDistribution `json:"door_distribution"`
 	StagePctStart    int              `json:"stage_pct_start"`
 	StagePctTeaching int              `json:"stage_pct_teaching"`
@@ -59,6 +75,7 @@ type CreateProjectRequest struct {
 	ShapePctFull     int              `json:"shape_pct_full"`
 	ShapePctBridge   int              `json:"shape_pct_bridge"`
 	ShapePctPlatform int              `json:"shape_pct_platform"`
+	ShapePctCave     int              `json:"shape_pct_cave"`
 	DoorDistribution DoorDistribution `json:"door_distribution"`
 	StagePctStart    int              `json:"stage_pct_start"`
 	StagePctTeaching int              `json:"stage_pct_teaching"`
@@ -94,11 +111,11 @@ type DimensionStat struct {
 
 // ProjectStats contains statistics for all three distribution dimensions
 type ProjectStats struct {
-	TotalRooms    int                        `json:"total_rooms"`
-	TemplateCount int                        `json:"template_count"`
-	Shape         map[string]DimensionStat   `json:"shape"`          // "full", "bridge", "platform"
-	Door          map[string]DimensionStat   `json:"door"`           // bitmask keys "0"-"15"
-	Stage         map[string]DimensionStat   `json:"stage"`          // stage type names
+	TotalRooms    int                      `json:"total_rooms"`
+	TemplateCount int                      `json:"template_count"`
+	Shape         map[string]DimensionStat `json:"shape"` // "full", "bridge", "platform", "cave"
+	Door          map[string]DimensionStat `json:"door"`  // bitmask keys "0"-"15"
+	Stage         map[string]DimensionStat `json:"stage"` // stage type names
 }
 
 // AutoFillResult represents the result of an auto-fill operation
@@ -108,6 +125,13 @@ type AutoFillResult struct {
 	Items          []AutoFillItem `json:"items"`
 }
 
+// AutoFillErrorResponse is the body of an auto-fill cut short: the error, plus
+// the rooms saved before it stopped
+type AutoFillErrorResponse struct {
+	ErrorResponse
+	Result *AutoFillResult `json:"result"`
+}
+
 // AutoFillItem represents one generated (or failed) room in an auto-fill batch
 type AutoFillItem struct {
 	Shape      string     `json:"shape"`
@@ -130,10 +154,10 @@ func ValidateProjectRequest(req *CreateProjectRequest) map[string]string {
 	}
 
 	// Shape percentages must be non-negative and sum to 100
-	if req.ShapePctFull < 0 || req.ShapePctBridge < 0 || req.ShapePctPlatform < 0 {
+	if req.ShapePctFull < 0 || req.ShapePctBridge < 0 || req.ShapePctPlatform < 0 || req.ShapePctCave < 0 {
 		errors["shape_pct"] = "shape percentages must be non-negative"
 	}
-	shapeSum := req.ShapePctFull + req.ShapePctBridge + req.ShapePctPlatform
+	shapeSum := req.ShapePctFull + req.ShapePctBridge + req.ShapePctPlatform + req.ShapePctCave
 	if shapeSum != 100 {
 		errors["shape_pct_sum"] = fmt.Sprintf("shape percentages must sum to 100, got %d", shapeSum)
 	}
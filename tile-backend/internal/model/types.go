@@ -22,6 +22,23 @@ type LineSegment struct {
 	End   Point `json:"end"`
 }
 
+// ChaserPatrol is the patrol route of one chaser: a polyline starting at the
+// chaser's cell, each segment starting where the previous one ended. A route
+// whose last segment ends at its start is a loop; otherwise the chaser walks
+// it back and forth.
+type ChaserPatrol struct {
+	Chaser Point         `json:"chaser"`
+	Lines  []LineSegment `json:"lines"`
+}
+
+// EnemyWave assigns one enemy cell to a spawn wave
+type EnemyWave struct {
+	Layer string `json:"layer"` // "chaser", "zoner", "dps" or "mobAir"
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	Wave  int    `json:"wave"` // 1-based; waves spawn in order
+}
+
 // TemplateMeta represents the metadata for a template
 type TemplateMeta struct {
 	Name    string `json:"name"`
@@ -71,78 +88,89 @@ type DoorsConnected struct {
 
 // TemplatePayload represents the complete template data as received from frontend
 type TemplatePayload struct {
-	Ground        Layer           `json:"ground"`
-	SoftEdge      Layer           `json:"softEdge,omitempty"`      // Optional for backward compatibility
-	Bridge        Layer           `json:"bridge,omitempty"`        // Optional for backward compatibility
-	Pipeline      Layer           `json:"pipeline,omitempty"`      // Optional for backward compatibility
-	PipelineLines []LineSegment   `json:"pipelineLines,omitempty"` // Line segments describing pipeline paths
-	Rail          Layer           `json:"rail,omitempty"`          // Optional for backward compatibility
-	RailLines     []LineSegment   `json:"railLines,omitempty"`     // Line segments describing rail paths
-	Static        Layer           `json:"static"`
-	Chaser        Layer           `json:"chaser,omitempty"`
-	Zoner         Layer           `json:"zoner,omitempty"`
-	DPS           Layer           `json:"dps,omitempty"`
-	MobAir        Layer           `json:"mobAir"`
-	MainPath      Layer           `json:"mainPath,omitempty"` // Main path through room center
-	Doors         *DoorStates     `json:"doors,omitempty"`
-	Attributes    *RoomAttributes `json:"attributes,omitempty"` // Deprecated
-	StageType    *string         `json:"stageType,omitempty"`    // none, start, teaching, building, pressure, peak, release, boss
-	RoomShape    *string         `json:"roomShape,omitempty"`    // "all", "bridge", or "platform"
-	RoomCategory *string         `json:"roomCategory,omitempty"` // "normal", "basement", "test", "cave"
-	OpenDoors    *int            `json:"openDoors,omitempty"`    // Bitmask: Top=1, Right=2, Bottom=4, Left=8
-	Meta          TemplateMeta    `json:"meta"`
+	Ground          Layer            `json:"ground"`
+	SoftEdge        Layer            `json:"softEdge,omitempty"`      // Optional for backward compatibility
+	Bridge          Layer            `json:"bridge,omitempty"`        // Optional for backward compatibility
+	Pipeline        Layer            `json:"pipeline,omitempty"`      // Optional for backward compatibility
+	PipelineLines   []LineSegment    `json:"pipelineLines,omitempty"` // Line segments describing pipeline paths
+	Rail            Layer            `json:"rail,omitempty"`          // Optional for backward compatibility
+	RailLines       []LineSegment    `json:"railLines,omitempty"`     // Line segments describing rail paths
+	Static          Layer            `json:"static"`
+	Chaser          Layer            `json:"chaser,omitempty"`
+	Zoner           Layer            `json:"zoner,omitempty"`
+	DPS             Layer            `json:"dps,omitempty"`
+	MobAir          Layer            `json:"mobAir"`
+	Hazard          Layer            `json:"hazard,omitempty"`        // Damaging floor (spikes, lava): walkable but costly; optional for backward compatibility
+	Pickup          Layer            `json:"pickup,omitempty"`        // Chests and health pickups; optional for backward compatibility
+	MainPath        Layer            `json:"mainPath,omitempty"`      // Main path through room center
+	Waves           []EnemyWave      `json:"waves,omitempty"`         // Spawn wave of every enemy; absent when all enemies spawn at once
+	ChaserPatrols   []ChaserPatrol   `json:"chaserPatrols,omitempty"` // Patrol route of each chaser
+	Doors           *DoorStates      `json:"doors,omitempty"`
+	DoorDescriptors []DoorDescriptor `json:"doorDescriptors,omitempty"` // Door openings with side/offset/width; when absent, doors are read from Doors
+	Attributes      *RoomAttributes  `json:"attributes,omitempty"`      // Deprecated
+	StageType       *string          `json:"stageType,omitempty"`       // none, start, teaching, building, pressure, peak, release, boss
+	RoomShape       *string          `json:"roomShape,omitempty"`       // "all", "bridge", "platform", or "cave"
+	RoomCategory    *string          `json:"roomCategory,omitempty"`    // "normal", "basement", "test", "cave"
+	OpenDoors       *int             `json:"openDoors,omitempty"`       // Bitmask: Top=1, Right=2, Bottom=4, Left=8
+	Meta            TemplateMeta     `json:"meta"`
 }
 
 // Template represents a complete template record
 type Template struct {
-	ID             uuid.UUID       `json:"id"`
-	Name           string          `json:"name"`
-	Version        int             `json:"version"`
-	Width          int             `json:"width"`
-	Height         int             `json:"height"`
-	Payload        TemplatePayload `json:"payload"`
-	Thumbnail      *string         `json:"thumbnail,omitempty"` // Base64 encoded PNG
-	WalkableRatio  *float64        `json:"walkable_ratio,omitempty"`
-	RoomType       *string         `json:"room_type,omitempty"`
-	RoomCategory   *string         `json:"room_category,omitempty"`
-	RoomAttributes *RoomAttributes `json:"room_attributes,omitempty"`
-	DoorsConnected *DoorsConnected `json:"doors_connected,omitempty"`
-	OpenDoors      *int            `json:"open_doors,omitempty"` // Bitmask: Top=1, Right=2, Bottom=4, Left=8
-	StaticCount    *int            `json:"static_count,omitempty"`
-	ChaserCount    *int            `json:"chaser_count,omitempty"`
-	ZonerCount     *int            `json:"zoner_count,omitempty"`
-	DPSCount       *int            `json:"dps_count,omitempty"`
-	MobAirCount    *int            `json:"mobair_count,omitempty"`
-	StageType      *string         `json:"stage_type,omitempty"`
-	ProjectID      *uuid.UUID      `json:"project_id,omitempty"`
-	ViewCount      int             `json:"view_count"`
-	CreatedAt      time.Time       `json:"created_at"`
-	UpdatedAt      time.Time       `json:"updated_at"`
+	ID               uuid.UUID       `json:"id"`
+	Name             string          `json:"name"`
+	Version          int             `json:"version"`
+	Width            int             `json:"width"`
+	Height           int             `json:"height"`
+	Payload          TemplatePayload `json:"payload"`
+	Thumbnail        *string         `json:"thumbnail,omitempty"` // Base64 encoded PNG
+	WalkableRatio    *float64        `json:"walkable_ratio,omitempty"`
+	RoomType         *string         `json:"room_type,omitempty"`
+	RoomCategory     *string         `json:"room_category,omitempty"`
+	RoomAttributes   *RoomAttributes `json:"room_attributes,omitempty"`
+	DoorsConnected   *DoorsConnected `json:"doors_connected,omitempty"`
+	OpenDoors        *int            `json:"open_doors,omitempty"` // Bitmask: Top=1, Right=2, Bottom=4, Left=8
+	StaticCount      *int            `json:"static_count,omitempty"`
+	ChaserCount      *int            `json:"chaser_count,omitempty"`
+	ZonerCount       *int            `json:"zoner_count,omitempty"`
+	DPSCount         *int            `json:"dps_count,omitempty"`
+	MobAirCount      *int            `json:"mobair_count,omitempty"`
+	PickupCount      *int            `json:"pickup_count,omitempty"`
+	WaveCount        *int            `json:"wave_count,omitempty"`
+	MinCorridorWidth *int            `json:"min_corridor_width,omitempty"`
+	StageType        *string         `json:"stage_type,omitempty"`
+	ProjectID        *uuid.UUID      `json:"project_id,omitempty"`
+	ViewCount        int             `json:"view_count"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
 }
 
 // TemplateSummary represents a template summary for list responses
 type TemplateSummary struct {
-	ID             uuid.UUID       `json:"id"`
-	Name           string          `json:"name"`
-	Version        int             `json:"version"`
-	Width          int             `json:"width"`
-	Height         int             `json:"height"`
-	Thumbnail      *string         `json:"thumbnail,omitempty"` // Base64 encoded PNG
-	WalkableRatio  *float64        `json:"walkable_ratio,omitempty"`
-	RoomType       *string         `json:"room_type,omitempty"`
-	RoomCategory   *string         `json:"room_category,omitempty"`
-	RoomAttributes *RoomAttributes `json:"room_attributes,omitempty"`
-	DoorsConnected *DoorsConnected `json:"doors_connected,omitempty"`
-	OpenDoors      *int            `json:"open_doors,omitempty"` // Bitmask: Top=1, Right=2, Bottom=4, Left=8
-	StaticCount    *int            `json:"static_count,omitempty"`
-	ChaserCount    *int            `json:"chaser_count,omitempty"`
-	ZonerCount     *int            `json:"zoner_count,omitempty"`
-	DPSCount       *int            `json:"dps_count,omitempty"`
-	MobAirCount    *int            `json:"mobair_count,omitempty"`
-	StageType      *string         `json:"stage_type,omitempty"`
-	ViewCount      int             `json:"view_count"`
-	CreatedAt      time.Time       `json:"created_at"`
-	UpdatedAt      time.Time       `json:"updated_at"`
+	ID               uuid.UUID       `json:"id"`
+	Name             string          `json:"name"`
+	Version          int             `json:"version"`
+	Width            int             `json:"width"`
+	Height           int             `json:"height"`
+	Thumbnail        *string         `json:"thumbnail,omitempty"` // Base64 encoded PNG
+	WalkableRatio    *float64        `json:"walkable_ratio,omitempty"`
+	RoomType         *string         `json:"room_type,omitempty"`
+	RoomCategory     *string         `json:"room_category,omitempty"`
+	RoomAttributes   *RoomAttributes `json:"room_attributes,omitempty"`
+	DoorsConnected   *DoorsConnected `json:"doors_connected,omitempty"`
+	OpenDoors        *int            `json:"open_doors,omitempty"` // Bitmask: Top=1, Right=2, Bottom=4, Left=8
+	StaticCount      *int            `json:"static_count,omitempty"`
+	ChaserCount      *int            `json:"chaser_count,omitempty"`
+	ZonerCount       *int            `json:"zoner_count,omitempty"`
+	DPSCount         *int            `json:"dps_count,omitempty"`
+	MobAirCount      *int            `json:"mobair_count,omitempty"`
+	PickupCount      *int            `json:"pickup_count,omitempty"`
+	WaveCount        *int            `json:"wave_count,omitempty"`
+	MinCorridorWidth *int            `json:"min_corridor_width,omitempty"`
+	StageType        *string         `json:"stage_type,omitempty"`
+	ViewCount        int             `json:"view_count"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
 }
 
 // ListTemplatesQueryParams represents query parameters for listing templates
@@ -163,6 +191,12 @@ type ListTemplatesQueryParams struct {
 	MaxDPSCount      *int
 	MinMobAirCount   *int
 	MaxMobAirCount   *int
+	MinPickupCount   *int
+	MaxPickupCount   *int
+	MinWaveCount     *int
+	MaxWaveCount     *int
+	MinCorridorWidth *int
+	MaxCorridorWidth *int
 	StageType        string
 	// Door connectivity filters
 	TopDoorConnected    *bool
@@ -255,6 +289,11 @@ func (tp *TemplatePayload) UnmarshalJSON(data []byte) error {
 		tp.RoomShape = &shape
 	}
 
+	// Payloads that only list door descriptors still get per-side door states
+	if tp.Doors == nil && len(tp.DoorDescriptors) > 0 {
+		tp.Doors = DoorStatesFromDescriptors(tp.DoorDescriptors)
+	}
+
 	// Compute openDoors from doors if not already set
 	if tp.OpenDoors == nil && tp.Doors != nil {
 		bitmask := tp.Doors.Top*1 + tp.Doors.Right*2 + tp.Doors.Bottom*4 + tp.Doors.Left*8
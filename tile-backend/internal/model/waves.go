@@ -0,0 +1,32 @@
+package model
+
+// WaveLayers lists the enemy layers a wave entry may refer to
+var WaveLayers = []string{"chaser", "zoner", "dps", "mobAir"}
+
+// EnemyLayer returns the enemy layer with the given name, or nil for an
+// unknown name or an absent layer
+func (p *TemplatePayload) EnemyLayer(name string) Layer {
+	switch name {
+	case "chaser":
+		return p.Chaser
+	case "zoner":
+		return p.Zoner
+	case "dps":
+		return p.DPS
+	case "mobAir":
+		return p.MobAir
+	}
+	return nil
+}
+
+// WaveCount returns the number of spawn waves, i.e. the highest wave index;
+// 0 when the payload has no waves
+func (p *TemplatePayload) WaveCount() int {
+	count := 0
+	for _, w := range p.Waves {
+		if w.Wave > count {
+			count = w.Wave
+		}
+	}
+	return count
+}
@@ -20,6 +20,7 @@ type ProjectStore interface {
 	Update(ctx context.Context, id string, project model.Project) (*model.Project, error)
 	Delete(ctx context.Context, id string) error
 	Stats(ctx context.Context, id string) (*model.ProjectStats, error)
+	UpdateStageOverrides(ctx context.Context, id string, overrides model.StageOverrides) (model.StageOverrides, error)
 }
 
 // PostgreSQLProjectStore implements ProjectStore using PostgreSQL
@@ -47,17 +48,18 @@ func (s *PostgreSQLProjectStore) Create(ctx context.Context, project model.Proje
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal door_distribution: %w", err)
 	}
+	var overridesJSON []byte
 
 	query := `
 		INSERT INTO room_projects (
 			id, name, total_rooms,
-			shape_pct_full, shape_pct_bridge, shape_pct_platform,
+			shape_pct_full, shape_pct_bridge, shape_pct_platform, shape_pct_cave,
 			door_distribution,
 			stage_pct_start, stage_pct_teaching, stage_pct_building,
 			stage_pct_pressure, stage_pct_peak, stage_pct_release, stage_pct_boss
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
-		RETURNING created_at, updated_at`
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING stage_overrides, created_at, updated_at`
 
 	err = s.db.QueryRow(ctx, query,
 		project.ID,
@@ -66,6 +68,7 @@ func (s *PostgreSQLProjectStore) Create(ctx context.Context, project model.Proje
 		project.ShapePctFull,
 		project.ShapePctBridge,
 		project.ShapePctPlatform,
+		project.ShapePctCave,
 		doorJSON,
 		project.StagePctStart,
 		project.StagePctTeaching,
@@ -74,11 +77,14 @@ func (s *PostgreSQLProjectStore) Create(ctx context.Context, project model.Proje
 		project.StagePctPeak,
 		project.StagePctRelease,
 		project.StagePctBoss,
-	).Scan(&project.CreatedAt, &project.UpdatedAt)
+	).Scan(&overridesJSON, &project.CreatedAt, &project.UpdatedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert project: %w", err)
 	}
+	if err := json.Unmarshal(overridesJSON, &project.StageOverrides); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal stage_overrides: %w", err)
+	}
 
 	return &project, nil
 }
@@ -115,7 +121,7 @@ func (s *PostgreSQLProjectStore) List(ctx context.Context, params model.ListProj
 	listQuery := fmt.Sprintf(`
 		SELECT
 			p.id, p.name, p.total_rooms,
-			p.shape_pct_full, p.shape_pct_bridge, p.shape_pct_platform,
+			p.shape_pct_full, p.shape_pct_bridge, p.shape_pct_platform, p.shape_pct_cave,
 			p.door_distribution,
 			p.stage_pct_start, p.stage_pct_teaching, p.stage_pct_building,
 			p.stage_pct_pressure, p.stage_pct_peak, p.stage_pct_release, p.stage_pct_boss,
@@ -148,7 +154,7 @@ func (s *PostgreSQLProjectStore) List(ctx context.Context, params model.ListProj
 
 		err := rows.Scan(
 			&p.ID, &p.Name, &p.TotalRooms,
-			&p.ShapePctFull, &p.ShapePctBridge, &p.ShapePctPlatform,
+			&p.ShapePctFull, &p.ShapePctBridge, &p.ShapePctPlatform, &p.ShapePctCave,
 			&doorJSON,
 			&p.StagePctStart, &p.StagePctTeaching, &p.StagePctBuilding,
 			&p.StagePctPressure, &p.StagePctPeak, &p.StagePctRelease, &p.StagePctBoss,
@@ -185,23 +191,25 @@ func (s *PostgreSQLProjectStore) Get(ctx context.Context, id string) (*model.Pro
 	query := `
 		SELECT
 			id, name, total_rooms,
-			shape_pct_full, shape_pct_bridge, shape_pct_platform,
+			shape_pct_full, shape_pct_bridge, shape_pct_platform, shape_pct_cave,
 			door_distribution,
 			stage_pct_start, stage_pct_teaching, stage_pct_building,
 			stage_pct_pressure, stage_pct_peak, stage_pct_release, stage_pct_boss,
+			stage_overrides,
 			created_at, updated_at
 		FROM room_projects
 		WHERE id = $1`
 
 	var p model.Project
-	var doorJSON []byte
+	var doorJSON, overridesJSON []byte
 
 	err = s.db.QueryRow(ctx, query, projectID).Scan(
 		&p.ID, &p.Name, &p.TotalRooms,
-		&p.ShapePctFull, &p.ShapePctBridge, &p.ShapePctPlatform,
+		&p.ShapePctFull, &p.ShapePctBridge, &p.ShapePctPlatform, &p.ShapePctCave,
 		&doorJSON,
 		&p.StagePctStart, &p.StagePctTeaching, &p.StagePctBuilding,
 		&p.StagePctPressure, &p.StagePctPeak, &p.StagePctRelease, &p.StagePctBoss,
+		&overridesJSON,
 		&p.CreatedAt, &p.UpdatedAt,
 	)
 	if err != nil {
@@ -217,6 +225,12 @@ func (s *PostgreSQLProjectStore) Get(ctx context.Context, id string) (*model.Pro
 		}
 	}
 
+	if overridesJSON != nil {
+		if err := json.Unmarshal(overridesJSON, &p.StageOverrides); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal stage_overrides: %w", err)
+		}
+	}
+
 	return &p, nil
 }
 
@@ -231,6 +245,7 @@ func (s *PostgreSQLProjectStore) Update(ctx context.Context, id string, project
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal door_distribution: %w", err)
 	}
+	var overridesJSON []byte
 
 	query := `
 		UPDATE room_projects SET
@@ -239,16 +254,17 @@ func (s *PostgreSQLProjectStore) Update(ctx context.Context, id string, project
 			shape_pct_full = $4,
 			shape_pct_bridge = $5,
 			shape_pct_platform = $6,
-			door_distribution = $7,
-			stage_pct_start = $8,
-			stage_pct_teaching = $9,
-			stage_pct_building = $10,
-			stage_pct_pressure = $11,
-			stage_pct_peak = $12,
-			stage_pct_release = $13,
-			stage_pct_boss = $14
+			shape_pct_cave = $7,
+			door_distribution = $8,
+			stage_pct_start = $9,
+			stage_pct_teaching = $10,
+			stage_pct_building = $11,
+			stage_pct_pressure = $12,
+			stage_pct_peak = $13,
+			stage_pct_release = $14,
+			stage_pct_boss = $15
 		WHERE id = $1
-		RETURNING created_at, updated_at`
+		RETURNING stage_overrides, created_at, updated_at`
 
 	project.ID = projectID
 	err = s.db.QueryRow(ctx, query,
@@ -258,6 +274,7 @@ func (s *PostgreSQLProjectStore) Update(ctx context.Context, id string, project
 		project.ShapePctFull,
 		project.ShapePctBridge,
 		project.ShapePctPlatform,
+		project.ShapePctCave,
 		doorJSON,
 		project.StagePctStart,
 		project.StagePctTeaching,
@@ -266,7 +283,7 @@ func (s *PostgreSQLProjectStore) Update(ctx context.Context, id string, project
 		project.StagePctPeak,
 		project.StagePctRelease,
 		project.StagePctBoss,
-	).Scan(&project.CreatedAt, &project.UpdatedAt)
+	).Scan(&overridesJSON, &project.CreatedAt, &project.UpdatedAt)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -274,10 +291,47 @@ func (s *PostgreSQLProjectStore) Update(ctx context.Context, id string, project
 		}
 		return nil, fmt.Errorf("failed to update project: %w", err)
 	}
+	if err := json.Unmarshal(overridesJSON, &project.StageOverrides); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal stage_overrides: %w", err)
+	}
 
 	return &project, nil
 }
 
+// UpdateStageOverrides replaces a project's stage config overrides
+func (s *PostgreSQLProjectStore) UpdateStageOverrides(ctx context.Context, id string, overrides model.StageOverrides) (model.StageOverrides, error) {
+	projectID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID format: %w", err)
+	}
+
+	if overrides == nil {
+		overrides = model.StageOverrides{}
+	}
+	overridesJSON, err := json.Marshal(overrides)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal stage_overrides: %w", err)
+	}
+
+	var stored []byte
+	err = s.db.QueryRow(ctx,
+		"UPDATE room_projects SET stage_overrides = $2 WHERE id = $1 RETURNING stage_overrides",
+		projectID, overridesJSON,
+	).Scan(&stored)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("project not found")
+		}
+		return nil, fmt.Errorf("failed to update stage overrides: %w", err)
+	}
+
+	var result model.StageOverrides
+	if err := json.Unmarshal(stored, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal stage_overrides: %w", err)
+	}
+	return result, nil
+}
+
 // Delete removes a project by ID
 func (s *PostgreSQLProjectStore) Delete(ctx context.Context, id string) error {
 	projectID, err := uuid.Parse(id)
@@ -319,6 +373,7 @@ func (s *PostgreSQLProjectStore) Stats(ctx context.Context, id string) (*model.P
 		"full":     project.TotalRooms * project.ShapePctFull / 100,
 		"bridge":   project.TotalRooms * project.ShapePctBridge / 100,
 		"platform": project.TotalRooms * project.ShapePctPlatform / 100,
+		"cave":     project.TotalRooms * project.ShapePctCave / 100,
 	}
 	stageRequired := map[string]int{
 		"start":    project.TotalRooms * project.StagePctStart / 100,
@@ -83,9 +83,9 @@ func (s *PostgreSQLTemplateStore) Create(ctx context.Context, template model.Tem
 		INSERT INTO room_templates (
 			id, name, version, width, height, payload, thumbnail,
 			walkable_ratio, room_type, room_category, room_attributes, doors_connected, open_doors,
-			static_count, chaser_count, zoner_count, dps_count, mobair_count, stage_type, project_id
+			static_count, chaser_count, zoner_count, dps_count, mobair_count, pickup_count, wave_count, min_corridor_width, stage_type, project_id
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
 		RETURNING created_at, updated_at`
 
 	err = s.db.QueryRow(ctx, query,
@@ -107,6 +107,9 @@ func (s *PostgreSQLTemplateStore) Create(ctx context.Context, template model.Tem
 		template.ZonerCount,
 		template.DPSCount,
 		template.MobAirCount,
+		template.PickupCount,
+		template.WaveCount,
+		template.MinCorridorWidth,
 		template.StageType,
 		template.ProjectID,
 	).Scan(&template.CreatedAt, &template.UpdatedAt)
@@ -209,6 +212,42 @@ func (s *PostgreSQLTemplateStore) List(ctx context.Context, params model.ListTem
 		argIndex++
 	}
 
+	// Pickup count filters
+	if params.MinPickupCount != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf("pickup_count >= $%d", argIndex))
+		args = append(args, *params.MinPickupCount)
+		argIndex++
+	}
+	if params.MaxPickupCount != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf("pickup_count <= $%d", argIndex))
+		args = append(args, *params.MaxPickupCount)
+		argIndex++
+	}
+
+	// Wave count filters
+	if params.MinWaveCount != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf("wave_count >= $%d", argIndex))
+		args = append(args, *params.MinWaveCount)
+		argIndex++
+	}
+	if params.MaxWaveCount != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf("wave_count <= $%d", argIndex))
+		args = append(args, *params.MaxWaveCount)
+		argIndex++
+	}
+
+	// Corridor width filters
+	if params.MinCorridorWidth != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf("min_corridor_width >= $%d", argIndex))
+		args = append(args, *params.MinCorridorWidth)
+		argIndex++
+	}
+	if params.MaxCorridorWidth != nil {
+		whereClauses = append(whereClauses, fmt.Sprintf("min_corridor_width <= $%d", argIndex))
+		args = append(args, *params.MaxCorridorWidth)
+		argIndex++
+	}
+
 	// Stage type filter
 	if params.StageType != "" {
 		whereClauses = append(whereClauses, fmt.Sprintf("stage_type = $%d", argIndex))
@@ -260,7 +299,7 @@ func (s *PostgreSQLTemplateStore) List(ctx context.Context, params model.ListTem
 		SELECT
 			id, name, version, width, height, thumbnail,
 			walkable_ratio, room_type, room_category, room_attributes, doors_connected, open_doors,
-			static_count, chaser_count, zoner_count, dps_count, mobair_count, stage_type,
+			static_count, chaser_count, zoner_count, dps_count, mobair_count, pickup_count, wave_count, min_corridor_width, stage_type,
 			view_count, created_at, updated_at
 		FROM room_templates %s
 		ORDER BY created_at DESC
@@ -299,6 +338,9 @@ func (s *PostgreSQLTemplateStore) List(ctx context.Context, params model.ListTem
 			&template.ZonerCount,
 			&template.DPSCount,
 			&template.MobAirCount,
+			&template.PickupCount,
+			&template.WaveCount,
+			&template.MinCorridorWidth,
 			&template.StageType,
 			&template.ViewCount,
 			&template.CreatedAt,
@@ -347,7 +389,7 @@ func (s *PostgreSQLTemplateStore) Get(ctx context.Context, id string) (*model.Te
 		SELECT
 			id, name, version, width, height, payload, thumbnail,
 			walkable_ratio, room_type, room_category, room_attributes, doors_connected, open_doors,
-			static_count, chaser_count, zoner_count, dps_count, mobair_count, stage_type,
+			static_count, chaser_count, zoner_count, dps_count, mobair_count, pickup_count, wave_count, min_corridor_width, stage_type,
 			view_count, created_at, updated_at
 		FROM room_templates
 		WHERE id = $1`
@@ -376,6 +418,9 @@ func (s *PostgreSQLTemplateStore) Get(ctx context.Context, id string) (*model.Te
 		&template.ZonerCount,
 		&template.DPSCount,
 		&template.MobAirCount,
+		&template.PickupCount,
+		&template.WaveCount,
+		&template.MinCorridorWidth,
 		&template.StageType,
 		&template.ViewCount,
 		&template.CreatedAt,
@@ -494,7 +539,7 @@ func (s *PostgreSQLTemplateStore) ListByProject(ctx context.Context, projectID s
 		SELECT
 			id, name, version, width, height, payload, thumbnail,
 			walkable_ratio, room_type, room_category, room_attributes, doors_connected, open_doors,
-			static_count, chaser_count, zoner_count, dps_count, mobair_count, stage_type,
+			static_count, chaser_count, zoner_count, dps_count, mobair_count, pickup_count, wave_count, min_corridor_width, stage_type,
 			view_count, created_at, updated_at
 		FROM room_templates
 		WHERE project_id = $1
@@ -519,8 +564,8 @@ func (s *PostgreSQLTemplateStore) ListByProject(ctx context.Context, projectID s
 			&payloadJSON, &t.Thumbnail,
 			&t.WalkableRatio, &t.RoomType, &t.RoomCategory,
 			&roomAttributesJSON, &doorsConnectedJSON, &t.OpenDoors,
-			&t.StaticCount, &t.ChaserCount, &t.ZonerCount, &t.DPSCount, &t.MobAirCount,
-			&t.StageType, &t.ViewCount, &t.CreatedAt, &t.UpdatedAt,
+			&t.StaticCount, &t.ChaserCount, &t.ZonerCount, &t.DPSCount, &t.MobAirCount, &t.PickupCount, &t.WaveCount,
+			&t.MinCorridorWidth, &t.StageType, &t.ViewCount, &t.CreatedAt, &t.UpdatedAt,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan: %w", err)
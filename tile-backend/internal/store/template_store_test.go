@@ -43,7 +43,7 @@ func TestPostgreSQLTemplateStore_Create(t *testing.T) {
 
 	now := time.Now()
 
-	// Mock the INSERT query (19 args total)
+	// Mock the INSERT query (21 args total)
 	mock.ExpectQuery(`INSERT INTO room_templates`).
 		WithArgs(
 			template.ID, template.Name, template.Version, template.Width, template.Height,
@@ -60,7 +60,11 @@ func TestPostgreSQLTemplateStore_Create(t *testing.T) {
 			pgxmock.AnyArg(), // zoner_count
 			pgxmock.AnyArg(), // dps_count
 			pgxmock.AnyArg(), // mobair_count
+			pgxmock.AnyArg(), // pickup_count
+			pgxmock.AnyArg(), // wave_count
+			pgxmock.AnyArg(), // min_corridor_width
 			pgxmock.AnyArg(), // stage_type
+			pgxmock.AnyArg(), // project_id
 		).
 		WillReturnRows(pgxmock.NewRows([]string{"created_at", "updated_at"}).
 			AddRow(now, now))
@@ -95,13 +99,14 @@ func TestPostgreSQLTemplateStore_Create_Error(t *testing.T) {
 		},
 	}
 
-	// Mock a database error - use AnyArg for all params (19 args)
+	// Mock a database error - use AnyArg for all params (23 args)
 	mock.ExpectQuery(`INSERT INTO room_templates`).
 		WithArgs(
 			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
 			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
 			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
-			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
 		).
 		WillReturnError(assert.AnError)
 
@@ -127,8 +132,8 @@ func TestPostgreSQLTemplateStore_Get(t *testing.T) {
 	rows := pgxmock.NewRows([]string{
 		"id", "name", "version", "width", "height", "payload", "thumbnail",
 		"walkable_ratio", "room_type", "room_category", "room_attributes", "doors_connected", "open_doors",
-		"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "stage_type",
-		"created_at", "updated_at",
+		"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "pickup_count", "wave_count", "min_corridor_width", "stage_type",
+		"view_count", "created_at", "updated_at",
 	}).AddRow(
 		templateID, "test-template", 1, 10, 8,
 		[]byte(payloadJSON),
@@ -144,7 +149,11 @@ func TestPostgreSQLTemplateStore_Get(t *testing.T) {
 		(*int)(nil),     // zoner_count
 		(*int)(nil),     // dps_count
 		(*int)(nil),     // mobair_count
+		(*int)(nil),     // pickup_count
+		(*int)(nil),     // wave_count
+		(*int)(nil),     // min_corridor_width
 		(*string)(nil),  // stage_type
+		0,               // view_count
 		now, now,
 	)
 	mock.ExpectQuery(`SELECT`).
@@ -186,8 +195,8 @@ func TestPostgreSQLTemplateStore_Get_NotFound(t *testing.T) {
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "name", "version", "width", "height", "payload", "thumbnail",
 			"walkable_ratio", "room_type", "room_category", "room_attributes", "doors_connected", "open_doors",
-			"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "stage_type",
-			"created_at", "updated_at",
+			"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "pickup_count", "wave_count", "min_corridor_width", "stage_type",
+			"view_count", "created_at", "updated_at",
 		}))
 
 	_, err = store.Get(context.Background(), templateID)
@@ -214,20 +223,20 @@ func TestPostgreSQLTemplateStore_List(t *testing.T) {
 	listCols := []string{
 		"id", "name", "version", "width", "height", "thumbnail",
 		"walkable_ratio", "room_type", "room_category", "room_attributes", "doors_connected", "open_doors",
-		"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "stage_type",
-		"created_at", "updated_at",
+		"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "pickup_count", "wave_count", "min_corridor_width", "stage_type",
+		"view_count", "created_at", "updated_at",
 	}
 	mock.ExpectQuery(`SELECT`).
 		WithArgs(10, 0).
 		WillReturnRows(pgxmock.NewRows(listCols).
 			AddRow(uuid.New(), "template-1", 1, 10, 8, (*string)(nil),
 				(*float64)(nil), (*string)(nil), (*string)(nil), []byte(nil), []byte(nil), (*int)(nil),
-				(*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*string)(nil),
-				now, now).
+				(*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*string)(nil),
+				0, now, now).
 			AddRow(uuid.New(), "template-2", 2, 15, 12, (*string)(nil),
 				(*float64)(nil), (*string)(nil), (*string)(nil), []byte(nil), []byte(nil), (*int)(nil),
-				(*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*string)(nil),
-				now.Add(-time.Hour), now.Add(-time.Hour)))
+				(*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*string)(nil),
+				0, now.Add(-time.Hour), now.Add(-time.Hour)))
 
 	templates, total, err := store.List(context.Background(), model.ListTemplatesQueryParams{Limit: 10, Offset: 0})
 
@@ -259,16 +268,16 @@ func TestPostgreSQLTemplateStore_List_WithNameFilter(t *testing.T) {
 	listCols := []string{
 		"id", "name", "version", "width", "height", "thumbnail",
 		"walkable_ratio", "room_type", "room_category", "room_attributes", "doors_connected", "open_doors",
-		"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "stage_type",
-		"created_at", "updated_at",
+		"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "pickup_count", "wave_count", "min_corridor_width", "stage_type",
+		"view_count", "created_at", "updated_at",
 	}
 	mock.ExpectQuery(`SELECT`).
 		WithArgs("%test%", 20, 0).
 		WillReturnRows(pgxmock.NewRows(listCols).
 			AddRow(uuid.New(), "test-template", 1, 10, 8, (*string)(nil),
 				(*float64)(nil), (*string)(nil), (*string)(nil), []byte(nil), []byte(nil), (*int)(nil),
-				(*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*string)(nil),
-				now, now))
+				(*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*string)(nil),
+				0, now, now))
 
 	templates, total, err := store.List(context.Background(), model.ListTemplatesQueryParams{Limit: 20, Offset: 0, NameLike: nameFilter})
 
@@ -280,6 +289,128 @@ func TestPostgreSQLTemplateStore_List_WithNameFilter(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestPostgreSQLTemplateStore_List_WithPickupCountFilter(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := NewPostgreSQLTemplateStoreWithExecutor(mock)
+
+	minPickups, maxPickups := 1, 3
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM room_templates WHERE pickup_count >= \$1 AND pickup_count <= \$2`).
+		WithArgs(1, 3).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT`).
+		WithArgs(1, 3, 20, 0).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "name", "version", "width", "height", "thumbnail",
+			"walkable_ratio", "room_type", "room_category", "room_attributes", "doors_connected", "open_doors",
+			"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "pickup_count", "wave_count", "min_corridor_width", "stage_type",
+			"view_count", "created_at", "updated_at",
+		}))
+
+	_, total, err := store.List(context.Background(), model.ListTemplatesQueryParams{
+		Limit: 20, MinPickupCount: &minPickups, MaxPickupCount: &maxPickups,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgreSQLTemplateStore_List_WithWaveCountFilter(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := NewPostgreSQLTemplateStoreWithExecutor(mock)
+
+	minWaves := 2
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM room_templates WHERE wave_count >= \$1`).
+		WithArgs(2).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT`).
+		WithArgs(2, 20, 0).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "name", "version", "width", "height", "thumbnail",
+			"walkable_ratio", "room_type", "room_category", "room_attributes", "doors_connected", "open_doors",
+			"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "pickup_count", "wave_count", "min_corridor_width", "stage_type",
+			"view_count", "created_at", "updated_at",
+		}))
+
+	_, total, err := store.List(context.Background(), model.ListTemplatesQueryParams{Limit: 20, MinWaveCount: &minWaves})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgreSQLTemplateStore_List_WithCorridorWidthFilter(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := NewPostgreSQLTemplateStoreWithExecutor(mock)
+
+	minWidth, maxWidth := 2, 4
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM room_templates WHERE min_corridor_width >= \$1 AND min_corridor_width <= \$2`).
+		WithArgs(2, 4).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT`).
+		WithArgs(2, 4, 20, 0).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "name", "version", "width", "height", "thumbnail",
+			"walkable_ratio", "room_type", "room_category", "room_attributes", "doors_connected", "open_doors",
+			"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "pickup_count", "wave_count", "min_corridor_width", "stage_type",
+			"view_count", "created_at", "updated_at",
+		}))
+
+	_, total, err := store.List(context.Background(), model.ListTemplatesQueryParams{Limit: 20, MinCorridorWidth: &minWidth, MaxCorridorWidth: &maxWidth})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgreSQLTemplateStore_Create_MinCorridorWidth(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := NewPostgreSQLTemplateStoreWithExecutor(mock)
+
+	// The caller computes the corridor width; the store saves it as given
+	width := 5
+	template := model.Template{
+		Width:            6,
+		Height:           5,
+		MinCorridorWidth: &width,
+		Payload: model.TemplatePayload{
+			Meta: model.TemplateMeta{Name: "corridor", Version: 1, Width: 6, Height: 5},
+		},
+	}
+
+	args := make([]interface{}, 23)
+	for i := range args {
+		args[i] = pgxmock.AnyArg()
+	}
+	args[20] = &width
+	mock.ExpectQuery(`INSERT INTO room_templates`).
+		WithArgs(args...).
+		WillReturnRows(pgxmock.NewRows([]string{"created_at", "updated_at"}).
+			AddRow(time.Now(), time.Now()))
+
+	result, err := store.Create(context.Background(), template)
+
+	require.NoError(t, err)
+	require.NotNil(t, result.MinCorridorWidth)
+	assert.Equal(t, 5, *result.MinCorridorWidth)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestPostgreSQLTemplateStore_List_EmptyResult(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	require.NoError(t, err)
@@ -297,8 +428,8 @@ func TestPostgreSQLTemplateStore_List_EmptyResult(t *testing.T) {
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "name", "version", "width", "height", "thumbnail",
 			"walkable_ratio", "room_type", "room_category", "room_attributes", "doors_connected", "open_doors",
-			"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "stage_type",
-			"created_at", "updated_at",
+			"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "pickup_count", "wave_count", "min_corridor_width", "stage_type",
+			"view_count", "created_at", "updated_at",
 		}))
 
 	templates, total, err := store.List(context.Background(), model.ListTemplatesQueryParams{Limit: 20, Offset: 0})
@@ -368,13 +499,14 @@ func TestPostgreSQLTemplateStore_Create_JSONMarshalError(t *testing.T) {
 		},
 	}
 
-	// Mock the INSERT query to succeed (JSON marshaling happens before the query, 19 args)
+	// Mock the INSERT query to succeed (JSON marshaling happens before the query, 21 args)
 	mock.ExpectQuery(`INSERT INTO room_templates`).
 		WithArgs(
 			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
 			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
 			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
-			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
 		).
 		WillReturnRows(pgxmock.NewRows([]string{"created_at", "updated_at"}).
 			AddRow(time.Now(), time.Now()))
@@ -402,14 +534,14 @@ func TestPostgreSQLTemplateStore_Get_JSONUnmarshalError(t *testing.T) {
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "name", "version", "width", "height", "payload", "thumbnail",
 			"walkable_ratio", "room_type", "room_category", "room_attributes", "doors_connected", "open_doors",
-			"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "stage_type",
-			"created_at", "updated_at",
+			"static_count", "chaser_count", "zoner_count", "dps_count", "mobair_count", "pickup_count", "wave_count", "min_corridor_width", "stage_type",
+			"view_count", "created_at", "updated_at",
 		}).AddRow(
 			templateID, "test-template", 1, 10, 8,
 			[]byte(`{"invalid": json}`), // Invalid JSON
 			(*string)(nil), (*float64)(nil), (*string)(nil), (*string)(nil), []byte(nil), []byte(nil), (*int)(nil),
-			(*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*string)(nil),
-			now, now,
+			(*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*int)(nil), (*string)(nil),
+			0, now, now,
 		))
 
 	_, err = store.Get(context.Background(), templateID)
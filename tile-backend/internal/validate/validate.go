@@ -2,6 +2,7 @@ package validate
 
 import (
 	"fmt"
+	"tile-backend/internal/geometry"
 	"tile-backend/internal/model"
 )
 
@@ -130,11 +131,196 @@ func validateLayers(payload *model.TemplatePayload) []model.ValidationError {
 		layers["rail"] = payload.Rail
 	}
 
+	// Add hazard layer if present (optional for backward compatibility)
+	if payload.Hazard != nil {
+		layers["hazard"] = payload.Hazard
+	}
+
+	// Add pickup layer if present (optional for backward compatibility)
+	if payload.Pickup != nil {
+		layers["pickup"] = payload.Pickup
+	}
+
 	for layerName, layer := range layers {
 		layerErrors := validateSingleLayer(layerName, layer, width, height)
 		errors = append(errors, layerErrors...)
 	}
 
+	// Door descriptors are optional; bitmask-only payloads skip this check
+	if len(payload.DoorDescriptors) > 0 {
+		if err := model.ValidateDoorDescriptors(payload.DoorDescriptors, width, height); err != nil {
+			errors = append(errors, model.ValidationError{
+				Layer:  "doors",
+				X:      0,
+				Y:      0,
+				Reason: err.Error(),
+			})
+		}
+	}
+
+	// Line segments are optional; when present they must match their layer cell for cell
+	lineSets := []struct {
+		name  string
+		layer model.Layer
+		lines []model.LineSegment
+	}{
+		{"railLines", payload.Rail, payload.RailLines},
+		{"pipelineLines", payload.Pipeline, payload.PipelineLines},
+	}
+	for _, ls := range lineSets {
+		if len(ls.lines) == 0 {
+			continue
+		}
+		if ls.layer == nil {
+			errors = append(errors, model.ValidationError{
+				Layer:  ls.name,
+				Reason: "line segments given without their layer",
+			})
+			continue
+		}
+		if err := model.CheckLineSegments(ls.layer, ls.lines); err != nil {
+			lineErr := model.ValidationError{Layer: ls.name, Reason: err.Error()}
+			if mismatch, ok := err.(*geometry.MismatchError); ok {
+				lineErr.X, lineErr.Y, lineErr.Reason = mismatch.X, mismatch.Y, mismatch.Reason
+			}
+			errors = append(errors, lineErr)
+		}
+	}
+
+	errors = append(errors, validateWaves(payload)...)
+	errors = append(errors, validatePatrols(payload)...)
+
+	return errors
+}
+
+// validateWaves checks that spawn waves, when present, give every enemy exactly
+// one wave and that waves are numbered from 1 without gaps
+func validateWaves(payload *model.TemplatePayload) []model.ValidationError {
+	var errors []model.ValidationError
+	if len(payload.Waves) == 0 {
+		return nil
+	}
+
+	type enemyCell struct {
+		layer string
+		x, y  int
+	}
+	assigned := make(map[enemyCell]bool)
+	used := make(map[int]bool)
+	for _, w := range payload.Waves {
+		layer := payload.EnemyLayer(w.Layer)
+		switch {
+		case layer == nil:
+			errors = append(errors, model.ValidationError{Layer: "waves", X: w.X, Y: w.Y, Reason: fmt.Sprintf("unknown enemy layer %q", w.Layer)})
+		case w.Y < 0 || w.Y >= len(layer) || w.X < 0 || w.X >= len(layer[w.Y]) || layer[w.Y][w.X] != 1:
+			errors = append(errors, model.ValidationError{Layer: "waves", X: w.X, Y: w.Y, Reason: fmt.Sprintf("no %s enemy at this cell", w.Layer)})
+		case w.Wave < 1:
+			errors = append(errors, model.ValidationError{Layer: "waves", X: w.X, Y: w.Y, Reason: "wave must be at least 1"})
+		case assigned[enemyCell{w.Layer, w.X, w.Y}]:
+			errors = append(errors, model.ValidationError{Layer: "waves", X: w.X, Y: w.Y, Reason: fmt.Sprintf("%s enemy is assigned to more than one wave", w.Layer)})
+		default:
+			assigned[enemyCell{w.Layer, w.X, w.Y}] = true
+			used[w.Wave] = true
+		}
+	}
+
+	for _, name := range model.WaveLayers {
+		layer := payload.EnemyLayer(name)
+		for y := range layer {
+			for x, v := range layer[y] {
+				if v == 1 && !assigned[enemyCell{name, x, y}] {
+					errors = append(errors, model.ValidationError{Layer: "waves", X: x, Y: y, Reason: fmt.Sprintf("%s enemy has no wave", name)})
+				}
+			}
+		}
+	}
+
+	for wave := 1; wave < payload.WaveCount(); wave++ {
+		if !used[wave] {
+			errors = append(errors, model.ValidationError{Layer: "waves", Reason: fmt.Sprintf("wave %d has no enemies", wave)})
+		}
+	}
+
+	return errors
+}
+
+// validatePatrols checks that chaser patrols, when present, are connected
+// polylines inside the room, start at a chaser, one per chaser, and that no two
+// routes share a cell
+func validatePatrols(payload *model.TemplatePayload) []model.ValidationError {
+	var errors []model.ValidationError
+	if len(payload.ChaserPatrols) == 0 {
+		return nil
+	}
+	if payload.Chaser == nil {
+		return []model.ValidationError{{Layer: "chaserPatrols", Reason: "patrols given without the chaser layer"}}
+	}
+
+	width, height := payload.Meta.Width, payload.Meta.Height
+	routed := make(map[model.Point]bool)
+	owner := make(map[model.Point]model.Point)
+	for _, patrol := range payload.ChaserPatrols {
+		c := patrol.Chaser
+		if c.Y < 0 || c.Y >= len(payload.Chaser) || c.X < 0 || c.X >= len(payload.Chaser[c.Y]) || payload.Chaser[c.Y][c.X] != 1 {
+			errors = append(errors, model.ValidationError{Layer: "chaserPatrols", X: c.X, Y: c.Y, Reason: "no chaser at the start of this patrol"})
+			continue
+		}
+		if routed[c] {
+			errors = append(errors, model.ValidationError{Layer: "chaserPatrols", X: c.X, Y: c.Y, Reason: "chaser has more than one patrol"})
+			continue
+		}
+		routed[c] = true
+
+		cells, err := patrol.PatrolCells()
+		if err != nil {
+			patrolErr := model.ValidationError{Layer: "chaserPatrols", Reason: err.Error()}
+			if mismatch, ok := err.(*geometry.MismatchError); ok {
+				patrolErr.X, patrolErr.Y, patrolErr.Reason = mismatch.X, mismatch.Y, mismatch.Reason
+			}
+			errors = append(errors, patrolErr)
+			continue
+		}
+		for _, p := range cells {
+			if p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height {
+				errors = append(errors, model.ValidationError{Layer: "chaserPatrols", X: p.X, Y: p.Y, Reason: "patrol route leaves the room"})
+				break
+			}
+			if other, taken := owner[p]; taken && other != c {
+				errors = append(errors, model.ValidationError{Layer: "chaserPatrols", X: p.X, Y: p.Y, Reason: "patrol routes overlap"})
+				continue
+			}
+			owner[p] = c
+		}
+	}
+
+	return errors
+}
+
+// validatePatrolFooting checks that every patrol cell is walkable for a chaser:
+// on ground, clear of static items and hazards. Malformed routes are reported by
+// validatePatrols and skipped here.
+func validatePatrolFooting(payload *model.TemplatePayload) []model.ValidationError {
+	var errors []model.ValidationError
+	width, height := payload.Meta.Width, payload.Meta.Height
+	for _, patrol := range payload.ChaserPatrols {
+		cells, err := patrol.PatrolCells()
+		if err != nil {
+			continue
+		}
+		for _, p := range cells {
+			if p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height {
+				break
+			}
+			switch {
+			case payload.Ground[p.Y][p.X] != 1:
+				errors = append(errors, model.ValidationError{Layer: "chaserPatrols", X: p.X, Y: p.Y, Reason: "patrol route must stay on ground"})
+			case payload.Static[p.Y][p.X] == 1:
+				errors = append(errors, model.ValidationError{Layer: "chaserPatrols", X: p.X, Y: p.Y, Reason: "patrol route cannot cross static items"})
+			case len(payload.Hazard) > p.Y && len(payload.Hazard[p.Y]) > p.X && payload.Hazard[p.Y][p.X] == 1:
+				errors = append(errors, model.ValidationError{Layer: "chaserPatrols", X: p.X, Y: p.Y, Reason: "patrol route cannot cross hazards"})
+			}
+		}
+	}
 	return errors
 }
 
@@ -207,6 +393,10 @@ func validateLogicalRules(payload *model.TemplatePayload) []model.ValidationErro
 			if payload.Rail != nil && len(payload.Rail) > y && len(payload.Rail[y]) > x {
 				rail = payload.Rail[y][x]
 			}
+			var hazard int = 0
+			if payload.Hazard != nil && len(payload.Hazard) > y && len(payload.Hazard[y]) > x {
+				hazard = payload.Hazard[y][x]
+			}
 			static := payload.Static[y][x]
 			var chaser int = 0
 			if payload.Chaser != nil && len(payload.Chaser) > y && len(payload.Chaser[y]) > x {
@@ -220,6 +410,10 @@ func validateLogicalRules(payload *model.TemplatePayload) []model.ValidationErro
 			if payload.DPS != nil && len(payload.DPS) > y && len(payload.DPS[y]) > x {
 				dps = payload.DPS[y][x]
 			}
+			var pickup int = 0
+			if payload.Pickup != nil && len(payload.Pickup) > y && len(payload.Pickup[y]) > x {
+				pickup = payload.Pickup[y][x]
+			}
 
 			// SoftEdge validation rules
 			if softEdge == 1 {
@@ -289,6 +483,36 @@ func validateLogicalRules(payload *model.TemplatePayload) []model.ValidationErro
 				}
 			}
 
+			// Hazard validation rules
+			if hazard == 1 {
+				// Rule: hazard==1 => ground==1 (hazards are damaging ground, never void or bridge)
+				if ground == 0 {
+					errors = append(errors, model.ValidationError{
+						Layer:  "hazard",
+						X:      x,
+						Y:      y,
+						Reason: "hazards must be placed on ground",
+					})
+				}
+				// Rule: hazard cannot share a cell with a static item or rail
+				if static == 1 {
+					errors = append(errors, model.ValidationError{
+						Layer:  "hazard",
+						X:      x,
+						Y:      y,
+						Reason: "hazards cannot be placed under static items",
+					})
+				}
+				if rail == 1 {
+					errors = append(errors, model.ValidationError{
+						Layer:  "hazard",
+						X:      x,
+						Y:      y,
+						Reason: "hazards cannot be placed under rail",
+					})
+				}
+			}
+
 			// Rail validation rules
 			if rail == 1 {
 				// Rule: rail==1 => ground==1 || bridge==1 (rail must be on ground or bridge)
@@ -392,10 +616,40 @@ func validateLogicalRules(payload *model.TemplatePayload) []model.ValidationErro
 				}
 			}
 
+			// Rule: pickup==1 => ground==1 && static==0 && rail==0
+			if pickup == 1 {
+				if ground == 0 {
+					errors = append(errors, model.ValidationError{
+						Layer:  "pickup",
+						X:      x,
+						Y:      y,
+						Reason: "pickups require walkable ground",
+					})
+				}
+				if static == 1 {
+					errors = append(errors, model.ValidationError{
+						Layer:  "pickup",
+						X:      x,
+						Y:      y,
+						Reason: "pickups cannot be placed on static items",
+					})
+				}
+				if rail == 1 {
+					errors = append(errors, model.ValidationError{
+						Layer:  "pickup",
+						X:      x,
+						Y:      y,
+						Reason: "pickups cannot be placed on rail",
+					})
+				}
+			}
+
 			// Note: mobAir has no constraints, can be placed anywhere
 		}
 	}
 
+	errors = append(errors, validatePatrolFooting(payload)...)
+
 	return errors
 }
 
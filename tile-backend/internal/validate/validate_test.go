@@ -449,3 +449,390 @@ func TestValidateTemplate_LogicalRules_Fixed(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateTemplate_DoorDescriptors(t *testing.T) {
+	empty := func() model.Layer {
+		layer := make(model.Layer, 6)
+		for y := range layer {
+			layer[y] = make([]int, 8)
+		}
+		return layer
+	}
+	payload := func(doors []model.DoorDescriptor) *model.TemplatePayload {
+		return &model.TemplatePayload{
+			Ground:          empty(),
+			Static:          empty(),
+			Chaser:          empty(),
+			Zoner:           empty(),
+			DPS:             empty(),
+			MobAir:          empty(),
+			DoorDescriptors: doors,
+			Meta:            model.TemplateMeta{Name: "test", Version: 1, Width: 8, Height: 6},
+		}
+	}
+
+	result := ValidateTemplate(payload([]model.DoorDescriptor{{Side: "top", Offset: 1, Width: 2}, {Side: "top", Offset: 5}}), false)
+	assert.True(t, result.Valid)
+
+	result = ValidateTemplate(payload([]model.DoorDescriptor{{Side: "left", Offset: 5, Width: 2}}), false)
+	assert.False(t, result.Valid)
+	if assert.Len(t, result.Errors, 1) {
+		assert.Equal(t, "doors", result.Errors[0].Layer)
+	}
+}
+
+func TestValidateTemplate_LineSegments(t *testing.T) {
+	empty := func() model.Layer {
+		layer := make(model.Layer, 6)
+		for y := range layer {
+			layer[y] = make([]int, 8)
+		}
+		return layer
+	}
+	ground := empty()
+	for y := range ground {
+		for x := range ground[y] {
+			ground[y][x] = 1
+		}
+	}
+	pipeline := empty()
+	for x := 1; x <= 4; x++ {
+		pipeline[2][x] = 1
+	}
+	payload := func(lines []model.LineSegment) *model.TemplatePayload {
+		return &model.TemplatePayload{
+			Ground:        ground,
+			Pipeline:      pipeline,
+			PipelineLines: lines,
+			Static:        empty(),
+			Chaser:        empty(),
+			Zoner:         empty(),
+			DPS:           empty(),
+			MobAir:        empty(),
+			Meta:          model.TemplateMeta{Name: "test", Version: 1, Width: 8, Height: 6},
+		}
+	}
+	seg := func(x1, y1, x2, y2 int) model.LineSegment {
+		return model.LineSegment{Start: model.Point{X: x1, Y: y1}, End: model.Point{X: x2, Y: y2}}
+	}
+
+	// Missing segments are fine; matching ones too, in either direction
+	assert.True(t, ValidateTemplate(payload(nil), false).Valid)
+	assert.True(t, ValidateTemplate(payload([]model.LineSegment{seg(4, 2, 1, 2)}), false).Valid)
+	assert.True(t, ValidateTemplate(payload([]model.LineSegment{seg(1, 2, 2, 2), seg(2, 2, 4, 2)}), false).Valid)
+
+	tests := []struct {
+		name  string
+		lines []model.LineSegment
+		x, y  int
+	}{
+		{"too short", []model.LineSegment{seg(1, 2, 3, 2)}, 4, 2},
+		{"too long", []model.LineSegment{seg(1, 2, 5, 2)}, 5, 2},
+		{"diagonal", []model.LineSegment{seg(1, 2, 4, 3)}, 1, 2},
+		{"outside", []model.LineSegment{seg(1, 2, 4, 2), seg(1, 2, 1, 9)}, 1, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ValidateTemplate(payload(tt.lines), false)
+			assert.False(t, result.Valid)
+			if assert.Len(t, result.Errors, 1) {
+				assert.Equal(t, "pipelineLines", result.Errors[0].Layer)
+				assert.Equal(t, tt.x, result.Errors[0].X)
+				assert.Equal(t, tt.y, result.Errors[0].Y)
+			}
+		})
+	}
+
+	// Rail segments without a rail layer
+	p := payload(nil)
+	p.RailLines = []model.LineSegment{seg(0, 0, 1, 0)}
+	result := ValidateTemplate(p, false)
+	assert.False(t, result.Valid)
+	assert.Equal(t, "railLines", result.Errors[0].Layer)
+}
+
+func TestValidateTemplate_PickupRules(t *testing.T) {
+	empty := func() model.Layer {
+		layer := make(model.Layer, 4)
+		for y := range layer {
+			layer[y] = make([]int, 6)
+		}
+		return layer
+	}
+	payload := func() *model.TemplatePayload {
+		ground := empty()
+		for y := range ground {
+			for x := 1; x < 6; x++ {
+				ground[y][x] = 1
+			}
+		}
+		return &model.TemplatePayload{
+			Ground: ground,
+			Static: empty(),
+			Chaser: empty(),
+			Zoner:  empty(),
+			DPS:    empty(),
+			MobAir: empty(),
+			Pickup: empty(),
+			Meta:   model.TemplateMeta{Name: "test", Version: 1, Width: 6, Height: 4},
+		}
+	}
+
+	valid := payload()
+	valid.Pickup[1][2] = 1
+	assert.True(t, ValidateTemplate(valid, true).Valid)
+
+	tests := []struct {
+		name   string
+		setup  func(p *model.TemplatePayload)
+		reason string
+	}{
+		{"on void", func(p *model.TemplatePayload) {}, "pickups require walkable ground"},
+		{"on static", func(p *model.TemplatePayload) {
+			p.Ground[1][0] = 1
+			p.Static[1][0] = 1
+		}, "pickups cannot be placed on static items"},
+		{"on rail", func(p *model.TemplatePayload) {
+			p.Ground[1][0] = 1
+			p.Rail = empty()
+			p.Rail[1][0] = 1
+		}, "pickups cannot be placed on rail"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := payload()
+			p.Pickup[1][0] = 1
+			tt.setup(p)
+			result := ValidateTemplate(p, true)
+			assert.False(t, result.Valid)
+			var reasons []string
+			for _, e := range result.Errors {
+				if e.Layer == "pickup" {
+					reasons = append(reasons, e.Reason)
+				}
+			}
+			assert.Equal(t, []string{tt.reason}, reasons)
+		})
+	}
+
+	// Non-strict validation only checks the layer shape
+	loose := payload()
+	loose.Pickup[0][0] = 1
+	assert.True(t, ValidateTemplate(loose, false).Valid)
+	loose.Pickup = model.Layer{{0}}
+	assert.False(t, ValidateTemplate(loose, false).Valid)
+}
+
+func TestValidateTemplate_HazardRules(t *testing.T) {
+	empty := func() model.Layer {
+		layer := make(model.Layer, 4)
+		for y := range layer {
+			layer[y] = make([]int, 6)
+		}
+		return layer
+	}
+	payload := func() *model.TemplatePayload {
+		ground := empty()
+		for y := range ground {
+			for x := 1; x < 6; x++ {
+				ground[y][x] = 1
+			}
+		}
+		return &model.TemplatePayload{
+			Ground: ground,
+			Static: empty(),
+			Chaser: empty(),
+			Zoner:  empty(),
+			DPS:    empty(),
+			MobAir: empty(),
+			Hazard: empty(),
+			Meta:   model.TemplateMeta{Name: "test", Version: 1, Width: 6, Height: 4},
+		}
+	}
+
+	valid := payload()
+	valid.Hazard[1][2] = 1
+	valid.Hazard[1][3] = 1
+	assert.True(t, ValidateTemplate(valid, true).Valid)
+
+	tests := []struct {
+		name   string
+		setup  func(p *model.TemplatePayload)
+		reason string
+	}{
+		{"on void", func(p *model.TemplatePayload) {}, "hazards must be placed on ground"},
+		{"under static", func(p *model.TemplatePayload) {
+			p.Ground[1][0] = 1
+			p.Static[1][0] = 1
+		}, "hazards cannot be placed under static items"},
+		{"under rail", func(p *model.TemplatePayload) {
+			p.Ground[1][0] = 1
+			p.Rail = empty()
+			p.Rail[1][0] = 1
+		}, "hazards cannot be placed under rail"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := payload()
+			p.Hazard[1][0] = 1
+			tt.setup(p)
+			result := ValidateTemplate(p, true)
+			assert.False(t, result.Valid)
+			var reasons []string
+			for _, e := range result.Errors {
+				if e.Layer == "hazard" {
+					reasons = append(reasons, e.Reason)
+				}
+			}
+			assert.Equal(t, []string{tt.reason}, reasons)
+		})
+	}
+}
+
+func TestValidateTemplate_Waves(t *testing.T) {
+	empty := func() model.Layer {
+		layer := make(model.Layer, 4)
+		for y := range layer {
+			layer[y] = make([]int, 4)
+		}
+		return layer
+	}
+	payload := func() *model.TemplatePayload {
+		ground := empty()
+		for y := range ground {
+			for x := range ground[y] {
+				ground[y][x] = 1
+			}
+		}
+		p := &model.TemplatePayload{
+			Ground: ground,
+			Static: empty(),
+			Chaser: empty(),
+			Zoner:  empty(),
+			DPS:    empty(),
+			MobAir: empty(),
+			Meta:   model.TemplateMeta{Name: "test", Version: 1, Width: 4, Height: 4},
+		}
+		p.Chaser[0][0] = 1
+		p.DPS[2][3] = 1
+		p.Waves = []model.EnemyWave{{Layer: "chaser", X: 0, Y: 0, Wave: 1}, {Layer: "dps", X: 3, Y: 2, Wave: 2}}
+		return p
+	}
+
+	assert.True(t, ValidateTemplate(payload(), false).Valid)
+	assert.Equal(t, 2, payload().WaveCount())
+
+	tests := []struct {
+		name   string
+		setup  func(p *model.TemplatePayload)
+		reason string
+	}{
+		{"unknown layer", func(p *model.TemplatePayload) { p.Waves[1].Layer = "boss" }, `unknown enemy layer "boss"`},
+		{"no enemy", func(p *model.TemplatePayload) { p.Waves[1].X = 2 }, "no dps enemy at this cell"},
+		{"wave zero", func(p *model.TemplatePayload) { p.Waves[0].Wave = 0 }, "wave must be at least 1"},
+		{"assigned twice", func(p *model.TemplatePayload) {
+			p.Waves = append(p.Waves, model.EnemyWave{Layer: "dps", X: 3, Y: 2, Wave: 1})
+		}, "dps enemy is assigned to more than one wave"},
+		{"enemy without wave", func(p *model.TemplatePayload) { p.MobAir[1][1] = 1 }, "mobAir enemy has no wave"},
+		{"gap", func(p *model.TemplatePayload) { p.Waves[1].Wave = 3 }, "wave 2 has no enemies"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := payload()
+			tt.setup(p)
+			result := ValidateTemplate(p, false)
+			assert.False(t, result.Valid)
+			var reasons []string
+			for _, e := range result.Errors {
+				if e.Layer == "waves" {
+					reasons = append(reasons, e.Reason)
+				}
+			}
+			assert.Contains(t, reasons, tt.reason)
+		})
+	}
+}
+
+func TestValidateTemplate_ChaserPatrols(t *testing.T) {
+	empty := func() model.Layer {
+		layer := make(model.Layer, 5)
+		for y := range layer {
+			layer[y] = make([]int, 5)
+		}
+		return layer
+	}
+	pt := func(x, y int) model.Point { return model.Point{X: x, Y: y} }
+	payload := func() *model.TemplatePayload {
+		ground := empty()
+		for y := range ground {
+			for x := range ground[y] {
+				ground[y][x] = 1
+			}
+		}
+		p := &model.TemplatePayload{
+			Ground: ground,
+			Static: empty(),
+			Chaser: empty(),
+			Zoner:  empty(),
+			DPS:    empty(),
+			MobAir: empty(),
+			Meta:   model.TemplateMeta{Name: "test", Version: 1, Width: 5, Height: 5},
+		}
+		p.Chaser[0][0] = 1
+		p.Chaser[4][4] = 1
+		p.ChaserPatrols = []model.ChaserPatrol{
+			// A 3×3 loop and a back-and-forth line
+			{Chaser: pt(0, 0), Lines: []model.LineSegment{
+				{Start: pt(0, 0), End: pt(2, 0)}, {Start: pt(2, 0), End: pt(2, 2)},
+				{Start: pt(2, 2), End: pt(0, 2)}, {Start: pt(0, 2), End: pt(0, 0)},
+			}},
+			{Chaser: pt(4, 4), Lines: []model.LineSegment{{Start: pt(4, 4), End: pt(4, 1)}}},
+		}
+		return p
+	}
+
+	result := ValidateTemplate(payload(), true)
+	assert.True(t, result.Valid, "%v", result.Errors)
+	assert.True(t, payload().ChaserPatrols[0].IsLoop())
+	assert.False(t, payload().ChaserPatrols[1].IsLoop())
+
+	tests := []struct {
+		name   string
+		setup  func(p *model.TemplatePayload)
+		strict bool
+		reason string
+	}{
+		{"no chaser", func(p *model.TemplatePayload) { p.Chaser[4][4] = 0 }, false, "no chaser at the start of this patrol"},
+		{"two patrols", func(p *model.TemplatePayload) {
+			p.ChaserPatrols = append(p.ChaserPatrols, model.ChaserPatrol{Chaser: pt(4, 4), Lines: []model.LineSegment{{Start: pt(4, 4), End: pt(3, 4)}}})
+		}, false, "chaser has more than one patrol"},
+		{"wrong start", func(p *model.TemplatePayload) { p.ChaserPatrols[1].Lines[0].Start = pt(4, 3) }, false, "patrol must start at its chaser"},
+		{"broken", func(p *model.TemplatePayload) { p.ChaserPatrols[0].Lines[1].Start = pt(2, 1) }, false, "patrol segment does not start where the previous one ended"},
+		{"diagonal", func(p *model.TemplatePayload) { p.ChaserPatrols[1].Lines[0].End = pt(3, 3) }, false, "patrol segment to (3,3) is not horizontal or vertical"},
+		{"leaves room", func(p *model.TemplatePayload) { p.ChaserPatrols[1].Lines[0].End = pt(4, 6) }, false, "patrol route leaves the room"},
+		{"overlap", func(p *model.TemplatePayload) {
+			p.ChaserPatrols[1].Lines = append(p.ChaserPatrols[1].Lines, model.LineSegment{Start: pt(4, 1), End: pt(2, 1)})
+		}, false, "patrol routes overlap"},
+		{"void", func(p *model.TemplatePayload) { p.Ground[2][1] = 0 }, true, "patrol route must stay on ground"},
+		{"static", func(p *model.TemplatePayload) { p.Static[3][4] = 1 }, true, "patrol route cannot cross static items"},
+		{"hazard", func(p *model.TemplatePayload) {
+			p.Hazard = empty()
+			p.Hazard[0][1] = 1
+		}, true, "patrol route cannot cross hazards"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := payload()
+			tt.setup(p)
+			result := ValidateTemplate(p, tt.strict)
+			assert.False(t, result.Valid)
+			var reasons []string
+			for _, e := range result.Errors {
+				if e.Layer == "chaserPatrols" {
+					reasons = append(reasons, e.Reason)
+				}
+			}
+			assert.Contains(t, reasons, tt.reason)
+		})
+	}
+}
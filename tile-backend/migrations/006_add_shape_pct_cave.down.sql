@@ -0,0 +1,5 @@
+-- Restore the three-shape percentage check
+ALTER TABLE room_projects DROP CONSTRAINT IF EXISTS chk_shape_pct_sum;
+ALTER TABLE room_projects DROP COLUMN IF EXISTS shape_pct_cave;
+ALTER TABLE room_projects
+ADD CONSTRAINT chk_shape_pct_sum CHECK (shape_pct_full + shape_pct_bridge + shape_pct_platform = 100);
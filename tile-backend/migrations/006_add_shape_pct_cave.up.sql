@@ -0,0 +1,8 @@
+-- Add cave shape percentage to room_projects
+ALTER TABLE room_projects
+ADD COLUMN IF NOT EXISTS shape_pct_cave int NOT NULL DEFAULT 0 CHECK (shape_pct_cave >= 0 AND shape_pct_cave <= 100);
+
+-- Shape percentages now include cave
+ALTER TABLE room_projects DROP CONSTRAINT IF EXISTS chk_shape_pct_sum;
+ALTER TABLE room_projects
+ADD CONSTRAINT chk_shape_pct_sum CHECK (shape_pct_full + shape_pct_bridge + shape_pct_platform + shape_pct_cave = 100);
@@ -0,0 +1,2 @@
+-- Remove per-project stage config overrides
+ALTER TABLE room_projects DROP COLUMN IF EXISTS stage_overrides;
@@ -0,0 +1,4 @@
+-- Per-project stage config overrides, keyed by stage type:
+-- {"pressure": {"dps_range": [6, 8]}, ...}
+ALTER TABLE room_projects
+ADD COLUMN IF NOT EXISTS stage_overrides jsonb NOT NULL DEFAULT '{}'::jsonb;
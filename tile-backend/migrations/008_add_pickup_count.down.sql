@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_room_templates_pickup_count;
+ALTER TABLE room_templates DROP COLUMN IF EXISTS pickup_count;
@@ -0,0 +1,6 @@
+-- Add pickup_count computed column to room_templates
+ALTER TABLE room_templates ADD COLUMN IF NOT EXISTS pickup_count int;
+
+CREATE INDEX IF NOT EXISTS idx_room_templates_pickup_count ON room_templates (pickup_count);
+
+COMMENT ON COLUMN room_templates.pickup_count IS 'Number of pickup tiles (pickup layer cells with value 1)';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_room_templates_wave_count;
+ALTER TABLE room_templates DROP COLUMN IF EXISTS wave_count;
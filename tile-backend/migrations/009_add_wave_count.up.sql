@@ -0,0 +1,6 @@
+-- Add wave_count computed column to room_templates
+ALTER TABLE room_templates ADD COLUMN IF NOT EXISTS wave_count int;
+
+CREATE INDEX IF NOT EXISTS idx_room_templates_wave_count ON room_templates (wave_count);
+
+COMMENT ON COLUMN room_templates.wave_count IS 'Number of enemy spawn waves (highest wave index in payload.waves, 0 when absent)';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_room_templates_min_corridor_width;
+ALTER TABLE room_templates DROP COLUMN IF EXISTS min_corridor_width;
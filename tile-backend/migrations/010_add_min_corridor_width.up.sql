@@ -0,0 +1,6 @@
+-- Add min_corridor_width computed column to room_templates
+ALTER TABLE room_templates ADD COLUMN IF NOT EXISTS min_corridor_width int;
+
+CREATE INDEX IF NOT EXISTS idx_room_templates_min_corridor_width ON room_templates (min_corridor_width);
+
+COMMENT ON COLUMN room_templates.min_corridor_width IS 'Narrowest corridor along the main path between the doors, in cells (NULL without a main path, and for templates saved before this migration)';
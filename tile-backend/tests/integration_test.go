@@ -12,6 +12,7 @@ import (
 	httpHandler "tile-backend/internal/http"
 	"tile-backend/internal/model"
 	"tile-backend/internal/store"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
@@ -57,7 +58,7 @@ func (suite *IntegrationTestSuite) SetupSuite() {
 	projectStore := store.NewPostgreSQLProjectStore(suite.db)
 
 	// Setup HTTP server
-	router := httpHandler.SetupRouter(templateStore, projectStore, suite.logger, []string{})
+	router := httpHandler.SetupRouter(templateStore, projectStore, suite.logger, []string{}, 10*time.Second)
 	suite.server = httptest.NewServer(router)
 }
 